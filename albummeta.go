@@ -0,0 +1,257 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	albumNFOFilename  = "album.nfo"
+	artistNFOFilename = "artist.nfo"
+	tagReportFilename = "tags.txt"
+)
+
+// metadataOnly makes album downloads write the cover, album.nfo and a tag report instead
+// of the audio, set by --metadata-only
+var metadataOnly bool
+
+// albumNFO is a Kodi album NFO file, which Jellyfin reads as well
+type albumNFO struct {
+	XMLName        xml.Name          `xml:"album"`
+	Title          string            `xml:"title"`
+	MBID           string            `xml:"musicbrainzalbumid,omitempty"`
+	ReleaseGroupID string            `xml:"musicbrainzreleasegroupid,omitempty"`
+	Artist         string            `xml:"artist"`
+	ArtistCredits  []nfoArtistCredit `xml:"albumArtistCredits,omitempty"`
+	Genre          string            `xml:"genre,omitempty"`
+	Type           string            `xml:"releasetype,omitempty"`
+	Year           string            `xml:"year,omitempty"`
+	ReleaseDate    string            `xml:"releasedate,omitempty"`
+	Label          string            `xml:"label,omitempty"`
+	Barcode        string            `xml:"barcode,omitempty"`
+	Status         string            `xml:"releasestatus,omitempty"`
+	Thumb          string            `xml:"thumb,omitempty"`
+	Tracks         []nfoTrack        `xml:"track"`
+}
+
+type nfoArtistCredit struct {
+	Artist string `xml:"artist"`
+	MBID   string `xml:"musicBrainzArtistID,omitempty"`
+}
+
+type nfoTrack struct {
+	Disc     int    `xml:"disc,omitempty"`
+	Position int    `xml:"position"`
+	Title    string `xml:"title"`
+	Duration string `xml:"duration,omitempty"`
+}
+
+// artistNFO is a Kodi artist NFO file
+type artistNFO struct {
+	XMLName   xml.Name         `xml:"artist"`
+	Name      string           `xml:"name"`
+	MBID      string           `xml:"musicBrainzArtistID,omitempty"`
+	Country   string           `xml:"country,omitempty"`
+	Biography string           `xml:"biography,omitempty"`
+	Thumb     string           `xml:"thumb,omitempty"`
+	Albums    []nfoArtistAlbum `xml:"album"`
+}
+
+type nfoArtistAlbum struct {
+	Title string `xml:"title"`
+	Year  string `xml:"year,omitempty"`
+}
+
+// writeAlbumMetadata saves the cover, album.nfo and tags.txt of an album to albumDir
+// without downloading any audio. Existing tracks are left alone, the tag report lists
+// where their tags differ from what a download would write.
+func (api *DabAPI) writeAlbumMetadata(ctx context.Context, album *Album, albumDir string, coverData []byte, config *Config, warningCollector *WarningCollector) error {
+	var written []string
+	if coverData != nil {
+		if err := api.saveAlbumArt(ctx, album, albumDir, coverData, config); err != nil {
+			return fmt.Errorf("failed to save cover art: %w", err)
+		}
+		written = append(written, "cover")
+	} else {
+		colorWarning.Printf("⚠️ %s has no cover art\n", album.Title)
+	}
+
+	if err := os.WriteFile(filepath.Join(albumDir, albumNFOFilename), albumNFOData(album), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", albumNFOFilename, err)
+	}
+	written = append(written, albumNFOFilename)
+
+	report, differing := tagReport(album, albumDir, config, warningCollector)
+	if err := os.WriteFile(filepath.Join(albumDir, tagReportFilename), report, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", tagReportFilename, err)
+	}
+	written = append(written, tagReportFilename)
+
+	colorSuccess.Printf("🏷️ Wrote %s for %s to %s\n", strings.Join(written, ", "), album.Title, albumDir)
+	if differing > 0 {
+		colorWarning.Printf("⚠️ %d tracks on disk have different tags, see %s\n", differing, tagReportFilename)
+	}
+	return nil
+}
+
+// albumNFOData encodes the album as a Kodi album NFO, with the MusicBrainz IDs of the
+// release found while tagging it
+func albumNFOData(album *Album) []byte {
+	nfo := albumNFO{
+		Title:       album.Title,
+		Artist:      album.Artist,
+		Genre:       album.Genre,
+		Type:        album.Type,
+		Year:        album.Year,
+		ReleaseDate: album.ReleaseDate,
+		Barcode:     album.UPC,
+		MBID:        album.MusicBrainzID,
+		Thumb:       album.Cover,
+	}
+	if nfo.Year == "" && len(album.ReleaseDate) >= 4 {
+		nfo.Year = album.ReleaseDate[:4]
+	}
+	if label, ok := album.Label.(string); ok {
+		nfo.Label = label
+	}
+	if release := albumCache.GetCachedRelease(album.Artist, album.Title); release != nil {
+		nfo.MBID = release.ID
+		nfo.ReleaseGroupID = release.ReleaseGroup.ID
+		nfo.Status = release.Status
+		if nfo.Barcode == "" {
+			nfo.Barcode = release.Barcode
+		}
+		if nfo.Label == "" && len(release.LabelInfo) > 0 {
+			nfo.Label = release.LabelInfo[0].Label.Name
+		}
+		for _, credit := range release.ArtistCredit {
+			nfo.ArtistCredits = append(nfo.ArtistCredits, nfoArtistCredit{Artist: credit.Artist.Name, MBID: credit.Artist.ID})
+		}
+	}
+	for i, track := range album.Tracks {
+		entry := nfoTrack{Disc: track.DiscNumber, Position: track.TrackNumber, Title: track.Title}
+		if entry.Position == 0 {
+			entry.Position = i + 1
+		}
+		if track.Duration > 0 {
+			entry.Duration = fmt.Sprintf("%d:%02d", track.Duration/60, track.Duration%60)
+		}
+		nfo.Tracks = append(nfo.Tracks, entry)
+	}
+	return encodeNFO(nfo)
+}
+
+// artistNFOData encodes the artist and the given releases as a Kodi artist NFO. Releases
+// listed by the previous NFO are kept, so downloading more of a discography adds to it.
+// The MusicBrainz artist ID comes from the releases cached while tagging.
+func artistNFOData(artist *Artist, albums []Album, previous []byte) []byte {
+	nfo := artistNFO{Name: artist.Name, Country: artist.Country, Biography: artist.Bio, Thumb: artist.Picture}
+	var old artistNFO
+	if xml.Unmarshal(previous, &old) == nil {
+		nfo.MBID = old.MBID
+		listed := map[string]bool{}
+		for _, album := range albums {
+			listed[strings.ToLower(album.Title)] = true
+		}
+		for _, album := range old.Albums {
+			if !listed[strings.ToLower(album.Title)] {
+				nfo.Albums = append(nfo.Albums, album)
+			}
+		}
+	}
+	for _, album := range albums {
+		year := album.Year
+		if year == "" && len(album.ReleaseDate) >= 4 {
+			year = album.ReleaseDate[:4]
+		}
+		nfo.Albums = append(nfo.Albums, nfoArtistAlbum{Title: album.Title, Year: year})
+		if nfo.MBID != "" {
+			continue
+		}
+		if release := albumCache.GetCachedRelease(album.Artist, album.Title); release != nil {
+			for _, credit := range release.ArtistCredit {
+				if strings.EqualFold(credit.Artist.Name, artist.Name) {
+					nfo.MBID = credit.Artist.ID
+				}
+			}
+		}
+	}
+	return encodeNFO(nfo)
+}
+
+func encodeNFO(nfo interface{}) []byte {
+	data, _ := xml.MarshalIndent(nfo, "", "  ")
+	return append([]byte(xml.Header), append(data, '\n')...)
+}
+
+// writeNFO saves an NFO file, a failure only produces a warning
+func writeNFO(path string, data []byte) {
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		colorWarning.Printf("⚠️ Failed to write %s: %v\n", path, err)
+	}
+}
+
+// tagReport lists the file name and tags every track of the album gets when downloaded.
+// For FLACs already on disk, tags with other values are marked; the number of such
+// tracks is returned too.
+func tagReport(album *Album, albumDir string, config *Config, warningCollector *WarningCollector) ([]byte, int) {
+	var report bytes.Buffer
+	fmt.Fprintf(&report, "Tags of %s by %s (DAB album %s), %s\n", album.Title, album.Artist, album.ID, time.Now().Format(time.RFC3339))
+	fmt.Fprintf(&report, "Lines marked with ! differ from the file on disk.\n")
+
+	differing := 0
+	for i, track := range album.Tracks {
+		if track.TrackNumber == 0 {
+			track.TrackNumber = i + 1
+		}
+		file := trackFile(config, album, track, album.Artist)
+		comment := buildVorbisComment(track, album, len(album.Tracks), warningCollector)
+
+		existing, err := readVorbisTags(filepath.Join(albumDir, file))
+		onDisk := err == nil
+		status := "not downloaded"
+		if onDisk {
+			status = "on disk"
+		}
+		fmt.Fprintf(&report, "\n%s (%s)\n", file, status)
+
+		expected := map[string][]string{}
+		var names []string
+		for _, field := range comment.Comments {
+			name, value, _ := strings.Cut(field, "=")
+			name = strings.ToUpper(name)
+			if _, seen := expected[name]; !seen {
+				names = append(names, name)
+			}
+			expected[name] = append(expected[name], value)
+		}
+		sort.Strings(names)
+
+		changed := false
+		for _, name := range names {
+			value := strings.Join(expected[name], "; ")
+			current := strings.Join(existing[name], "; ")
+			if onDisk && current != value {
+				changed = true
+				if current == "" {
+					fmt.Fprintf(&report, "! %s=%s (missing)\n", name, value)
+				} else {
+					fmt.Fprintf(&report, "! %s=%s (on disk: %s)\n", name, value, current)
+				}
+				continue
+			}
+			fmt.Fprintf(&report, "  %s=%s\n", name, value)
+		}
+		if changed {
+			differing++
+		}
+	}
+	return report.Bytes(), differing
+}
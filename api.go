@@ -33,14 +33,107 @@ type DabAPI struct {
 	client         *http.Client
 	mu             sync.Mutex // Mutex to protect rate limiter
 	rateLimiter    *time.Ticker // Rate limiter for API requests
+	apiToken       string // Bearer token for authenticated DAB instances
+	apiCookie      string // Cookie header for cookie-authenticated DAB instances
+	extraHeaders   map[string]string // Extra headers for the DAB endpoint (e.g. Cloudflare Access tokens)
+	outageWait     time.Duration // How long to wait for an unreachable endpoint, 0 disables
+	outageMu       sync.Mutex
+	outageDone     chan struct{} // Closed when the current outage wait finishes
+	outageErr      error         // Result of the last outage wait
+	searchCache    *SearchCache  // Cached search results, nil disables caching
+	prefetchMu     sync.Mutex
+	prefetched     map[string]*albumPrefetch // Albums loaded ahead by PrefetchAlbums
+	daemon         *DaemonClient // Running daemon searches are sent to, nil searches directly
 }
 
-// Request makes HTTP requests to the API
+// SetAuth configures the bearer token and/or cookie sent with requests to the DAB endpoint
+func (api *DabAPI) SetAuth(token, cookie string) {
+	api.apiToken = token
+	api.apiCookie = cookie
+}
+
+// SetHeaders configures extra headers sent with requests to the DAB endpoint.
+// A "User-Agent" entry replaces the default user agent.
+func (api *DabAPI) SetHeaders(headers map[string]string) {
+	api.extraHeaders = headers
+}
+
+// mergeHeaderFlags adds headers given as "Name: Value" to the configured ones, replacing
+// configured headers of the same name
+func mergeHeaderFlags(headers map[string]string, flags []string) (map[string]string, error) {
+	if len(flags) == 0 {
+		return headers, nil
+	}
+	merged := make(map[string]string, len(headers)+len(flags))
+	for name, value := range headers {
+		merged[name] = value
+	}
+	for _, flag := range flags {
+		name, value, ok := strings.Cut(flag, ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" || strings.ContainsAny(name, " \t") {
+			return nil, fmt.Errorf("invalid header %q, use \"Name: Value\"", flag)
+		}
+		for existing := range merged {
+			if strings.EqualFold(existing, name) {
+				delete(merged, existing)
+			}
+		}
+		merged[name] = strings.TrimSpace(value)
+	}
+	return merged, nil
+}
+
+// setEndpointHeaders adds authentication and extra headers to requests for the DAB endpoint.
+// Requests to other hosts (e.g. stream CDNs or cover art) never receive them.
+func (api *DabAPI) setEndpointHeaders(req *http.Request) {
+	endpointURL, err := url.Parse(api.endpoint)
+	if err != nil || !strings.EqualFold(req.URL.Host, endpointURL.Host) {
+		return
+	}
+	for name, value := range api.extraHeaders {
+		req.Header.Set(name, value)
+	}
+	if api.apiToken != "" {
+		req.Header.Set("Authorization", "Bearer "+api.apiToken)
+	}
+	if api.apiCookie != "" {
+		req.Header.Set("Cookie", api.apiCookie)
+	}
+}
+
+// Request makes HTTP requests to the API. If the DAB endpoint is down, the request waits
+// for it to come back (see SetOutageWait) instead of failing straight away.
 func (api *DabAPI) Request(ctx context.Context, path string, isPathOnly bool, params []QueryParam) (*http.Response, error) {
-	api.mu.Lock()
-	<-api.rateLimiter.C // Wait for the rate limiter
-	api.mu.Unlock()
+	return api.requestWithHeaders(ctx, path, isPathOnly, params, nil)
+}
+
+// RequestRange requests a URL starting at offset bytes, for resuming partial downloads.
+// The response is 206 when the server honours the range, 200 when it sends the whole
+// file instead, or 416 when offset is past the end of the file.
+func (api *DabAPI) RequestRange(ctx context.Context, fullURL string, offset int64) (*http.Response, error) {
+	ctx = streamContext(ctx)
+	if offset <= 0 {
+		return api.Request(ctx, fullURL, false, nil)
+	}
+	return api.requestWithHeaders(ctx, fullURL, false, nil, map[string]string{"Range": fmt.Sprintf("bytes=%d-", offset)})
+}
 
+func (api *DabAPI) requestWithHeaders(ctx context.Context, path string, isPathOnly bool, params []QueryParam, headers map[string]string) (*http.Response, error) {
+	for resumes := 0; ; resumes++ {
+		resp, unreachable, err := api.request(ctx, path, isPathOnly, params, headers)
+		if err == nil || !unreachable || api.outageWait <= 0 || resumes >= maxOutageResumes || ctx.Err() != nil {
+			return resp, err
+		}
+		if waitErr := api.waitForEndpoint(ctx); waitErr != nil {
+			return nil, fmt.Errorf("%w (%v)", err, waitErr)
+		}
+	}
+}
+
+// request performs a single request with retries. unreachable reports whether the
+// last failure means the DAB endpoint itself is down.
+func (api *DabAPI) request(ctx context.Context, path string, isPathOnly bool, params []QueryParam, headers map[string]string) (*http.Response, bool, error) {
 	var fullURL string
 
 	if isPathOnly {
@@ -51,7 +144,23 @@ func (api *DabAPI) Request(ctx context.Context, path string, isPathOnly bool, pa
 
 	u, err := url.Parse(fullURL)
 	if err != nil {
-		return nil, fmt.Errorf("error parsing URL: %w", err)
+		return nil, false, fmt.Errorf("error parsing URL: %w", err)
+	}
+	endpointURL, _ := url.Parse(api.endpoint)
+	isEndpoint := endpointURL != nil && strings.EqualFold(u.Host, endpointURL.Host)
+
+	// Only the DAB API is rate limited and capped here; streams and covers to other
+	// hosts are capped by their callers, so they don't wait behind API calls
+	if isEndpoint {
+		api.mu.Lock()
+		<-api.rateLimiter.C // Wait for the rate limiter
+		api.mu.Unlock()
+
+		release, err := dabLimit.acquire(ctx)
+		defer release()
+		if err != nil {
+			return nil, false, err
+		}
 	}
 
 	if len(params) > 0 {
@@ -63,34 +172,56 @@ func (api *DabAPI) Request(ctx context.Context, path string, isPathOnly bool, pa
 	}
 
 	var resp *http.Response
+	unreachable := false
 	err = RetryWithBackoff(defaultMaxRetries, 1, func() error {
+		unreachable = false
 		req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
 		if err != nil {
 			return fmt.Errorf("error creating request: %w", err)
 		}
 		req.Header.Set("User-Agent", userAgent)
+		api.setEndpointHeaders(req)
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
 
+		start := time.Now()
 		resp, err = api.client.Do(req)
+		statusCode := 0
+		if resp != nil {
+			statusCode = resp.StatusCode
+		}
+		apiStats.Record(statsEndpointName(u.Path, isEndpoint, u.Host), time.Since(start), statusCode, err)
 		if err != nil {
+			unreachable = isEndpoint
 			return fmt.Errorf("error executing request: %w", err)
 		}
 
 		if resp.StatusCode == http.StatusTooManyRequests {
 			resp.Body.Close()
+			downloadThrottle.RecordRateLimit()
 			return fmt.Errorf("rate limit exceeded (429), retrying") // Return error to trigger retry
 		}
+		if headers["Range"] != "" && (resp.StatusCode == http.StatusPartialContent || resp.StatusCode == http.StatusRequestedRangeNotSatisfiable) {
+			return nil // Handled by the caller resuming the download
+		}
 		if resp.StatusCode != http.StatusOK {
 			resp.Body.Close()
-			return fmt.Errorf("request failed with status: %s", resp.Status)
+			unreachable = isEndpoint && isOutageStatus(resp.StatusCode)
+			message := "request failed"
+			if isEndpoint && (resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden) {
+				message = "the DAB instance requires authentication, check api_token, api_cookie and api_headers"
+			}
+			return &HTTPError{StatusCode: resp.StatusCode, Status: resp.Status, Message: message}
 		}
 		return nil
 	})
 
 	if err != nil {
-		return nil, err
+		return nil, unreachable, err
 	}
 
-	return resp, nil
+	return resp, false, nil
 }
 
 // GetAlbum retrieves album information
@@ -183,9 +314,8 @@ func (api *DabAPI) GetArtist(ctx context.Context, artistID string, config *Confi
 	}
 
 	if debug {
-		// Debug: Print the raw JSON response
+		// The body itself is printed at trace level, -vvv
 		fmt.Printf("DEBUG - Raw artist response body length: %d bytes\n", len(body))
-		fmt.Printf("DEBUG - Raw artist response: %s\n", string(body))
 	}
 
 	// The discography endpoint returns a different structure
@@ -227,6 +357,7 @@ func (api *DabAPI) GetArtist(ctx context.Context, artistID string, config *Confi
 		album := &artist.Albums[i] // Capture album for goroutine
 
 		go func(album *Album) {
+			defer handlePanic()
 			defer wg.Done()
 			if err := sem.Acquire(ctx, 1); err != nil {
 				colorError.Printf("Failed to acquire semaphore for album %s: %v\n", album.Title, err)
@@ -335,14 +466,23 @@ func (api *DabAPI) GetStreamURL(ctx context.Context, trackID string) (string, er
 	if err != nil {
 		return "", err
 	}
+	if streamURL.URL == "" {
+		return "", ErrTrackUnavailable
+	}
 
 	return streamURL.URL, nil
 }
 
 // DownloadCover downloads cover art
 func (api *DabAPI) DownloadCover(ctx context.Context, coverURL string) ([]byte, error) {
+	release, err := coverLimit.acquire(ctx)
+	defer release()
+	if err != nil {
+		return nil, err
+	}
+
 	var coverData []byte
-	err := RetryWithBackoff(defaultMaxRetries, 1, func() error {
+	err = RetryWithBackoff(defaultMaxRetries, 1, func() error {
 		resp, err := api.Request(ctx, coverURL, false, nil)
 		if err != nil {
 			return err
@@ -358,6 +498,25 @@ func (api *DabAPI) DownloadCover(ctx context.Context, coverURL string) ([]byte,
 
 // Search searches for artists, albums, or tracks.
 func (api *DabAPI) Search(ctx context.Context, query string, searchType string, limit int, debug bool) (*SearchResults, error) {
+	if api.daemon != nil {
+		results, err := api.daemon.Search(ctx, query, searchType, limit)
+		if err == nil {
+			return results, nil
+		}
+		if debug {
+			fmt.Printf("DEBUG - Daemon search failed, searching directly: %v\n", err)
+		}
+	}
+	cacheKey := searchCacheKey(query, searchType, limit)
+	if api.searchCache != nil {
+		if cached, ok := api.searchCache.Get(cacheKey); ok {
+			if debug {
+				fmt.Printf("DEBUG - Using cached search results for '%s'\n", query)
+			}
+			return cached, nil
+		}
+	}
+
 	results := &SearchResults{}
 	var wg sync.WaitGroup
 	var mu sync.Mutex
@@ -373,6 +532,7 @@ func (api *DabAPI) Search(ctx context.Context, query string, searchType string,
 	for _, t := range searchTypes {
 		wg.Add(1)
 		go func(t string) {
+			defer handlePanic()
 			defer wg.Done()
 			params := []QueryParam{
 				{Name: "q", Value: query},
@@ -395,12 +555,9 @@ func (api *DabAPI) Search(ctx context.Context, query string, searchType string,
 			mu.Lock()
 			defer mu.Unlock()
 
-			if debug {
-				fmt.Printf("DEBUG - Raw search response body: %s\n", string(body))
-			}
 			var data map[string]json.RawMessage
 			if err := json.Unmarshal(body, &data); err != nil {
-				fmt.Printf("ERROR: Failed to unmarshal JSON. Raw response body: %s\n", string(body))
+				fmt.Printf("ERROR: Failed to unmarshal %s search response: %v (run with -vvv to see it)\n", t, err)
 				errChan <- err
 				return
 			}
@@ -417,17 +574,12 @@ func (api *DabAPI) Search(ctx context.Context, query string, searchType string,
 						errChan <- err
 						return
 					}
-					uniqueArtists := make(map[string]Artist)
+					// Every track of an artist lists it again
+					var artists []Artist
 					for _, track := range tempTracks {
-						artist := Artist{
-							ID:   track.ArtistId,
-							Name: track.Artist,
-						}
-						uniqueArtists[fmt.Sprintf("%v", artist.ID)] = artist // Use artist ID as key for uniqueness
-					}
-					for _, artist := range uniqueArtists {
-						results.Artists = append(results.Artists, artist)
+						artists = append(artists, Artist{ID: track.ArtistId, Name: track.Artist})
 					}
+					results.Artists = dedupeArtists(artists)
 				} else if res, ok := data["results"]; ok {
 					if err := json.Unmarshal(res, &results.Artists); err != nil {
 						errChan <- err
@@ -467,5 +619,11 @@ func (api *DabAPI) Search(ctx context.Context, query string, searchType string,
 		}
 	}
 
+	if api.searchCache != nil {
+		if err := api.searchCache.Put(cacheKey, results); err != nil && debug {
+			fmt.Printf("DEBUG - Failed to write search cache: %v\n", err)
+		}
+	}
+
 	return results, nil
 }
\ No newline at end of file
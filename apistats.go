@@ -0,0 +1,148 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const maxLatencySamples = 500 // Latency samples kept per endpoint
+
+// EndpointStats holds the request statistics of one API endpoint
+type EndpointStats struct {
+	Requests    int       `json:"requests"`
+	Errors      int       `json:"errors"`
+	RateLimited int       `json:"rate_limited"`
+	LatenciesMs []float64 `json:"latencies_ms"`
+}
+
+// APIStats collects per-endpoint latency and error statistics for the session
+type APIStats struct {
+	Started   time.Time                 `json:"started"`
+	Endpoints map[string]*EndpointStats `json:"endpoints"`
+	mu        sync.Mutex
+}
+
+// apiStats holds the statistics of the current session
+var apiStats = &APIStats{Started: time.Now(), Endpoints: map[string]*EndpointStats{}}
+
+// apiStatsPath is where the last session's statistics are saved for `debug stats`
+var apiStatsPath = filepath.Join("config", "api_stats.json")
+
+// Record adds the outcome of one request attempt
+func (s *APIStats) Record(endpoint string, latency time.Duration, statusCode int, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats, ok := s.Endpoints[endpoint]
+	if !ok {
+		stats = &EndpointStats{}
+		s.Endpoints[endpoint] = stats
+	}
+	stats.Requests++
+	if err != nil || statusCode >= 400 {
+		stats.Errors++
+	}
+	if statusCode == http.StatusTooManyRequests {
+		stats.RateLimited++
+	}
+	stats.LatenciesMs = append(stats.LatenciesMs, float64(latency.Microseconds())/1000)
+	if len(stats.LatenciesMs) > maxLatencySamples {
+		stats.LatenciesMs = stats.LatenciesMs[len(stats.LatenciesMs)-maxLatencySamples:]
+	}
+}
+
+// statsEndpointName names an endpoint for statistics: the path for the DAB API, the host otherwise
+func statsEndpointName(path string, isEndpoint bool, host string) string {
+	if !isEndpoint {
+		return "external " + host
+	}
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return "/"
+	}
+	return path
+}
+
+// Save writes the statistics so `debug stats` can show them after the run
+func (s *APIStats) Save(path string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.Endpoints) == 0 {
+		return nil
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// LoadAPIStats reads statistics saved by a previous run
+func LoadAPIStats(path string) (*APIStats, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	stats := &APIStats{}
+	if err := json.Unmarshal(data, stats); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return stats, nil
+}
+
+// Print shows latency percentiles, error rates and rate-limit hits per endpoint
+func (s *APIStats) Print() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	names := make([]string, 0, len(s.Endpoints))
+	for name := range s.Endpoints {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	colorInfo.Printf("📊 API statistics for the session started %s\n\n", FormatDate(s.Started))
+	fmt.Printf("%-32s %8s %8s %8s %8s %8s %7s\n", "Endpoint", "Requests", "p50 ms", "p90 ms", "p99 ms", "Errors", "429s")
+	for _, name := range names {
+		stats := s.Endpoints[name]
+		errorRate := 0.0
+		if stats.Requests > 0 {
+			errorRate = float64(stats.Errors) / float64(stats.Requests) * 100
+		}
+		line := fmt.Sprintf("%-32s %8d %8.0f %8.0f %8.0f %7.1f%% %7d", TruncateString(name, 32), stats.Requests,
+			percentile(stats.LatenciesMs, 50), percentile(stats.LatenciesMs, 90), percentile(stats.LatenciesMs, 99), errorRate, stats.RateLimited)
+		if stats.Errors > 0 || stats.RateLimited > 0 {
+			colorWarning.Println(line)
+		} else {
+			fmt.Println(line)
+		}
+	}
+}
+
+// percentile returns the p-th percentile of values using the nearest-rank method
+func percentile(values []float64, p float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	rank := int(p/100*float64(len(sorted))+0.5) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+	return sorted[rank]
+}
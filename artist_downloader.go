@@ -3,11 +3,12 @@ package main
 import (
 	"context"
 	"fmt"
-	
+	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/cheggaaa/pb/v3"
 	"golang.org/x/sync/semaphore"
@@ -15,9 +16,11 @@ import (
 
 // DownloadArtistDiscography downloads an artist's complete discography
 func (api *DabAPI) DownloadArtistDiscography(ctx context.Context, artistID string, config *Config, debug bool, filter string, noConfirm bool) error {
-	// Create warning collector based on config
-	warningCollector := NewWarningCollector(config.WarningBehavior != "silent")
-	
+	releases, err := parseReleaseRange(fromYear, toYear, releasedAfter)
+	if err != nil {
+		return err
+	}
+
 	artist, err := api.GetArtist(ctx, artistID, config, debug)
 	if err != nil {
 		return fmt.Errorf("failed to get artist info: %w", err)
@@ -32,6 +35,29 @@ func (api *DabAPI) DownloadArtistDiscography(ctx context.Context, artistID strin
 
 	// Categorize albums by type
 	albums, eps, singles, other := api.categorizeAlbums(artist.Albums)
+	if releases.active() {
+		before := len(albums) + len(eps) + len(singles) + len(other)
+		albums = filterByReleaseDate(albums, releases)
+		eps = filterByReleaseDate(eps, releases)
+		singles = filterByReleaseDate(singles, releases)
+		other = filterByReleaseDate(other, releases)
+		excluded := before - len(albums) - len(eps) - len(singles) - len(other)
+		colorInfo.Printf("📅 Only items %s (%d excluded)\n", releases, excluded)
+	}
+	if len(titleExclusions) > 0 {
+		var excluded, n int
+		albums, n = excludeTitles(albums)
+		excluded += n
+		eps, n = excludeTitles(eps)
+		excluded += n
+		singles, n = excludeTitles(singles)
+		excluded += n
+		other, n = excludeTitles(other)
+		excluded += n
+		if excluded > 0 {
+			colorInfo.Printf("🚫 %d items excluded by title\n", excluded)
+		}
+	}
 
 	// Show categorized content
 	totalItems := len(albums) + len(eps) + len(singles) + len(other)
@@ -52,17 +78,7 @@ func (api *DabAPI) DownloadArtistDiscography(ctx context.Context, artistID strin
 
 	itemsToDownload := []Album{}
 	if filter != "all" {
-		filterParts := strings.Split(filter, ",")
-		for _, part := range filterParts {
-			switch strings.TrimSpace(part) {
-			case "albums":
-				itemsToDownload = append(itemsToDownload, albums...)
-			case "eps":
-				itemsToDownload = append(itemsToDownload, eps...)
-			case "singles":
-				itemsToDownload = append(itemsToDownload, singles...)
-			}
-		}
+		itemsToDownload = filterArtistItems(albums, eps, singles, filter)
 	} else {
 		// Menu for download selection
 		colorInfo.Println("\nWhat would you like to download?")
@@ -113,27 +129,107 @@ func (api *DabAPI) DownloadArtistDiscography(ctx context.Context, artistID strin
 		fmt.Printf("%d. [%s] %s (%s)\n", i+1, strings.ToUpper(item.Type), item.Title, item.ReleaseDate)
 	}
 
+	// Show the expected size of the job so the user can make an informed choice
+	trackCount, duration, size := estimateDownload(itemsToDownload, config)
+	colorInfo.Printf("\n📦 Estimated: %d tracks, %s of audio, ~%s (%s)\n", trackCount, formatDuration(duration), FormatBytes(size), strings.ToUpper(config.Format))
+
 	// Confirm download
 	if !noConfirm {
-		confirm := GetYesNoInput("Proceed with download? (y/N)", "n")
-		if !confirm {
+		if !Confirm("Proceed with download? (y/N)") {
 			colorWarning.Println("⚠️ Download cancelled.")
 			return nil
 		}
 	}
 
+	job := startArtistJob(artistID, artist, itemsToDownload, config)
+	return api.downloadArtistItems(ctx, artist, itemsToDownload, config, debug, job)
+}
+
+// filterArtistItems returns the categorized items matching a comma-separated filter (albums, eps, singles)
+func filterArtistItems(albums, eps, singles []Album, filter string) []Album {
+	items := []Album{}
+	for _, part := range strings.Split(filter, ",") {
+		switch strings.TrimSpace(part) {
+		case "albums":
+			items = append(items, albums...)
+		case "eps":
+			items = append(items, eps...)
+		case "singles":
+			items = append(items, singles...)
+		}
+	}
+	return items
+}
+
+// startArtistJob records the job so an interrupted download can be resumed with `jobs resume`.
+// Returns nil if the job could not be recorded.
+func startArtistJob(artistID string, artist *Artist, items []Album, config *Config) *Job {
+	job := &Job{Type: "artist", Target: artistID, Name: artist.Name, Format: config.Format, Bitrate: config.Bitrate}
+	for _, item := range items {
+		job.Items = append(job.Items, item.ID)
+	}
+	if err := jobStore.Start(job); err != nil {
+		colorWarning.Printf("⚠️ Failed to record job: %v\n", err)
+		return nil
+	}
+	return job
+}
+
+// ResumeArtistJob continues an artist job, downloading only the items not yet completed
+func (api *DabAPI) ResumeArtistJob(ctx context.Context, job *Job, config *Config, debug bool) error {
+	artist, err := api.GetArtist(ctx, job.Target, config, debug)
+	if err != nil {
+		return fmt.Errorf("failed to get artist info: %w", err)
+	}
+
+	pending := make(map[string]bool)
+	for _, id := range job.Pending() {
+		pending[id] = true
+	}
+	itemsToDownload := []Album{}
+	for _, album := range artist.Albums {
+		if pending[album.ID] {
+			itemsToDownload = append(itemsToDownload, album)
+			delete(pending, album.ID) // The artist endpoint may list an album twice
+		}
+	}
+
+	colorInfo.Printf("🔁 Resuming job %d [%s] for %s: %d of %d items remaining\n", job.ID, job.CorrelationID, artist.Name, len(itemsToDownload), len(job.Items))
+	job.Status = JobRunning
+	return api.downloadArtistItems(ctx, artist, itemsToDownload, config, debug, job)
+}
+
+// downloadArtistItems downloads the selected items of an artist, recording progress in job if set
+func (api *DabAPI) downloadArtistItems(ctx context.Context, artist *Artist, itemsToDownload []Album, config *Config, debug bool, job *Job) error {
+	if job != nil {
+		ctx = withCorrelationID(ctx, job.CorrelationID)
+	}
+	warningCollector := NewWarningCollector(config.WarningBehavior != "silent")
+	warningCollector.correlationID = correlationIDFrom(ctx)
+
 	// Setup for download
 	artistDir := filepath.Join(api.outputLocation, SanitizeFileName(artist.Name))
 	if err := CreateDirIfNotExists(artistDir); err != nil {
+		if job != nil {
+			jobStore.Finish(job, err)
+		}
 		return fmt.Errorf("failed to create artist directory: %w", err)
 	}
 
 	var wg sync.WaitGroup
-	sem := semaphore.NewWeighted(int64(config.Parallelism))
-	stats := &DownloadStats{}
+	albums := albumConcurrency(config)
+	if albums > len(itemsToDownload) {
+		albums = len(itemsToDownload)
+	}
+	sem := semaphore.NewWeighted(int64(albums))
+	// Tracks per album are reduced while several albums download at once
+	albumConfig := *config
+	albumConfig.MaxConcurrentTracks = nestedTrackConcurrency(config, albums)
+	stats := &DownloadStats{CorrelationID: correlationIDFrom(ctx)}
+	var statsMu sync.Mutex // Albums finish concurrently
 	errorChan := make(chan trackError, len(itemsToDownload))
 	var pool *pb.Pool
-	if isTTY() {
+	if progressBarsEnabled() {
 		var poolErr error
 		pool, poolErr = pb.StartPool()
 		if poolErr != nil {
@@ -142,7 +238,7 @@ func (api *DabAPI) DownloadArtistDiscography(ctx context.Context, artistID strin
 		}
 	} else {
 		if debug {
-			colorInfo.Println("DEBUG: isTTY() is false. Progress bars will not be displayed.")
+			colorInfo.Println("DEBUG: Progress bars are disabled (not a TTY or --plain). Progress bars will not be displayed.")
 		}
 	}
 
@@ -157,18 +253,23 @@ func (api *DabAPI) DownloadArtistDiscography(ctx context.Context, artistID strin
 		}
 
 		go func(idx int, item Album) {
+			defer handlePanic()
 			defer wg.Done()
 			defer sem.Release(1)
 
 			colorInfo.Printf("🎵 Downloading %s %d/%d: %s\n", strings.ToUpper(item.Type), idx+1, len(itemsToDownload), item.Title)
-			itemStats, err := api.DownloadAlbum(ctx, item.ID, config, debug, pool, warningCollector)
+			itemStats, err := api.DownloadAlbum(ctx, item.ID, &albumConfig, debug, pool, warningCollector)
 			if err != nil {
 				errorChan <- trackError{item.Title, fmt.Errorf("item %s: %w", item.Title, err)}
 			} else {
-				stats.SuccessCount += itemStats.SuccessCount
-				stats.SkippedCount += itemStats.SkippedCount
-				stats.FailedCount += itemStats.FailedCount
-				stats.FailedItems = append(stats.FailedItems, itemStats.FailedItems...)
+				if job != nil {
+					if err := jobStore.MarkItemDone(job, item.ID); err != nil && debug {
+						colorWarning.Printf("DEBUG: Failed to update job %d: %v\n", job.ID, err)
+					}
+				}
+				statsMu.Lock()
+				addStats(stats, itemStats)
+				statsMu.Unlock()
 			}
 		}(idx, item)
 	}
@@ -186,6 +287,17 @@ func (api *DabAPI) DownloadArtistDiscography(ctx context.Context, artistID strin
 		stats.FailedItems = append(stats.FailedItems, fmt.Sprintf("%s: %v", err.Title, err.Err))
 	}
 
+	if config.SaveArtistArt || config.WriteNFO {
+		if err := api.saveArtistArt(ctx, artist, itemsToDownload, artistDir, config, debug); err != nil {
+			warningCollector.AddCoverArtDownloadWarning(artist.Name, err.Error())
+		}
+	}
+	if config.WriteNFO {
+		path := filepath.Join(artistDir, artistNFOFilename)
+		previous, _ := os.ReadFile(path)
+		writeNFO(path, artistNFOData(artist, itemsToDownload, previous))
+	}
+
 	// Show warning summary first if configured
 	if config.WarningBehavior == "summary" {
 		warningCollector.PrintSummary()
@@ -193,27 +305,52 @@ func (api *DabAPI) DownloadArtistDiscography(ctx context.Context, artistID strin
 	
 	// Print download summary
 	api.printDownloadStats(artist.Name, stats)
-	
+	NotifySummary(config, EventBatchComplete, artist.Name, stats)
+
+	if job != nil {
+		var jobErr error
+		if pending := len(job.Pending()); pending > 0 {
+			jobErr = fmt.Errorf("%d items failed", pending)
+			colorInfo.Printf("💡 Run 'dab-downloader jobs resume %d' to retry the remaining items\n", job.ID)
+		}
+		if err := jobStore.Finish(job, jobErr); err != nil {
+			colorWarning.Printf("⚠️ Failed to update job %d: %v\n", job.ID, err)
+		}
+	}
+
 	return nil
 }
 
 // printDownloadStats prints the download statistics
 func (api *DabAPI) printDownloadStats(artistName string, stats *DownloadStats) {
-	colorInfo.Printf("\n📊 Download Summary for %s:\n", artistName)
-	colorSuccess.Printf("✅ Successfully downloaded: %d items\n", stats.SuccessCount)
+	printStatsCounts(artistName, stats)
+	colorSuccess.Println(T("stats.location", filepath.Join(api.outputLocation, SanitizeFileName(artistName))))
+	printThroughputGraph()
+	colorInfo.Println(T("stats.finished_at", FormatDate(time.Now())))
+}
+
+// printStatsCounts prints the success, skip and failure counts of a download
+func printStatsCounts(title string, stats *DownloadStats) {
+	colorInfo.Println("\n" + T("stats.header", title))
+	colorSuccess.Println(T("stats.success", stats.SuccessCount))
 
 	if stats.SkippedCount > 0 {
-		colorWarning.Printf("⭐ Skipped (already exist): %d items\n", stats.SkippedCount)
+		colorWarning.Println(T("stats.skipped", stats.SkippedCount))
+	}
+
+	if len(stats.UnavailableItems) > 0 {
+		colorWarning.Println(T("stats.unavailable", len(stats.UnavailableItems)))
+		for _, title := range stats.UnavailableItems {
+			colorWarning.Printf("   - %s\n", title)
+		}
 	}
 
 	if len(stats.FailedItems) > 0 {
-		colorError.Printf("❌ Failed to download: %d items\n", len(stats.FailedItems))
+		colorError.Println(T("stats.failed", len(stats.FailedItems)))
 		for _, msg := range stats.FailedItems {
 			colorError.Printf("   - %s\n", msg)
 		}
 	}
-
-	colorSuccess.Printf("🎉 Artist discography downloaded to: %s\n", filepath.Join(api.outputLocation, SanitizeFileName(artistName)))
 }
 
 // getCustomSelection handles user's custom selection of albums/EPs/singles
@@ -290,4 +427,46 @@ func (api *DabAPI) parseSelection(input string, allItems []Album) []Album {
 		}
 	}
 	return selected
-}
\ No newline at end of file
+}
+
+// estimateDownload sums the track count and duration of the albums and estimates the download size.
+// Durations come from the album details already fetched; missing ones use an average track length.
+func estimateDownload(items []Album, config *Config) (int, time.Duration, int64) {
+	trackCount := 0
+	var totalSeconds int64
+	for _, item := range items {
+		if len(item.Tracks) == 0 {
+			trackCount += item.TotalTracks
+			totalSeconds += int64(item.TotalTracks) * averageTrackSeconds
+			continue
+		}
+		for _, track := range item.Tracks {
+			trackCount++
+			if track.Duration > 0 {
+				totalSeconds += int64(track.Duration)
+			} else {
+				totalSeconds += averageTrackSeconds
+			}
+		}
+	}
+
+	kbps := estimatedFLACKbps
+	if !isLossless(config.Format) {
+		if bitrate, err := strconv.Atoi(config.Bitrate); err == nil && bitrate > 0 {
+			kbps = bitrate
+		}
+	}
+	size := totalSeconds * int64(kbps) * 1000 / 8
+
+	return trackCount, time.Duration(totalSeconds) * time.Second, size
+}
+
+// formatDuration formats a duration as hours and minutes (e.g. "9h 32m")
+func formatDuration(d time.Duration) string {
+	hours := int(d.Hours())
+	minutes := int(d.Minutes()) % 60
+	if hours > 0 {
+		return fmt.Sprintf("%dh %02dm", hours, minutes)
+	}
+	return fmt.Sprintf("%dm", minutes)
+}
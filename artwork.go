@@ -0,0 +1,440 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const (
+	fanartTVURL              = "https://webservice.fanart.tv/v3/music/"
+	defaultArtistArtFilename = "artist.jpg"
+	coverArtArchiveURL       = "https://coverartarchive.org/release/"
+	extraArtworkDir          = "Artwork"
+	defaultAlbumArtFilename  = "cover.jpg"
+	defaultThumbFilename     = "thumb.jpg"
+	jpegQuality              = 90
+	minEmbedQuality          = 50 // Lowest JPEG quality tried before shrinking an embedded cover further
+)
+
+// AlbumArtOptions controls the cover files saved next to an album when SaveAlbumArt is on
+type AlbumArtOptions struct {
+	Filenames     []string `json:"filenames,omitempty"`      // e.g. ["cover.jpg", "folder.jpg"], defaults to cover.jpg
+	ThumbSize     int      `json:"thumb_size,omitempty"`     // Longest side of an extra thumbnail in pixels, 0 disables
+	ThumbFilename string   `json:"thumb_filename,omitempty"` // Defaults to thumb.jpg
+	FullFilename  string   `json:"full_filename,omitempty"`  // Also save the highest resolution cover DAB offers, empty disables
+	Extra         bool     `json:"extra,omitempty"`          // Save back covers, booklets and media from the Cover Art Archive to Artwork/
+	MinSize       int      `json:"min_size,omitempty"`       // Use the Cover Art Archive front cover when DAB's is smaller than this many pixels
+	EmbedMaxSize  int      `json:"embed_max_size,omitempty"` // Longest side of the cover embedded in tracks in pixels, 0 keeps the size
+	EmbedMaxKB    int      `json:"embed_max_kb,omitempty"`   // Largest embedded cover in KB, re-encoded smaller until it fits, 0 disables
+}
+
+// ArtistArtOptions controls the artist image saved in the artist folder when SaveArtistArt is on
+type ArtistArtOptions struct {
+	Filenames    []string `json:"filenames,omitempty"`      // e.g. ["artist.jpg", "poster.jpg"], defaults to artist.jpg
+	FanartAPIKey string   `json:"fanart_api_key,omitempty"` // fanart.tv key, used when DAB has no artist picture
+}
+
+// coverSizePattern matches the size suffix of DAB cover URLs, e.g. "_600.jpg"
+var coverSizePattern = regexp.MustCompile(`_(\d+|max|org)\.jpg$`)
+
+// saveAlbumArt writes the album cover files configured in config.AlbumArt to albumDir
+func (api *DabAPI) saveAlbumArt(ctx context.Context, album *Album, albumDir string, coverData []byte, config *Config) error {
+	opts := config.AlbumArt
+	if opts == nil {
+		opts = &AlbumArtOptions{}
+	}
+	filenames := opts.Filenames
+	if len(filenames) == 0 {
+		filenames = []string{defaultAlbumArtFilename}
+	}
+	for _, name := range filenames {
+		if err := writeImageFile(filepath.Join(albumDir, name), coverData, 0); err != nil {
+			return err
+		}
+	}
+
+	if opts.ThumbSize > 0 {
+		name := opts.ThumbFilename
+		if name == "" {
+			name = defaultThumbFilename
+		}
+		if err := writeImageFile(filepath.Join(albumDir, name), coverData, opts.ThumbSize); err != nil {
+			return err
+		}
+	}
+
+	if opts.FullFilename != "" {
+		fullData := coverData
+		if coverSizePattern.MatchString(album.Cover) {
+			fullURL := coverSizePattern.ReplaceAllString(album.Cover, "_max.jpg")
+			if data, err := api.DownloadCover(ctx, fullURL); err == nil && len(data) > 0 {
+				fullData = data
+			}
+		}
+		if err := writeImageFile(filepath.Join(albumDir, opts.FullFilename), fullData, 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// coverArtImage is an image listed by the Cover Art Archive
+type coverArtImage struct {
+	Image string   `json:"image"`
+	Types []string `json:"types"`
+	Front bool     `json:"front"`
+}
+
+// saveExtraArtwork downloads the images other than the front cover that the Cover Art
+// Archive has for the album's MusicBrainz release into an Artwork/ subfolder
+func (api *DabAPI) saveExtraArtwork(ctx context.Context, album *Album, albumDir string, debug bool) error {
+	releaseID, err := albumReleaseID(album)
+	if err != nil {
+		return fmt.Errorf("no MusicBrainz release to look up artwork for: %w", err)
+	}
+	if releaseID == "" {
+		return nil
+	}
+
+	resp, err := api.Request(ctx, coverArtArchiveURL+releaseID, false, nil)
+	if err != nil {
+		// The archive answers 404 for releases without artwork
+		if debug {
+			fmt.Printf("DEBUG: No Cover Art Archive images for %s: %v\n", album.Title, err)
+		}
+		return nil
+	}
+	defer resp.Body.Close()
+	var listing struct {
+		Images []coverArtImage `json:"images"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&listing); err != nil {
+		return fmt.Errorf("failed to parse Cover Art Archive listing: %w", err)
+	}
+
+	dir := filepath.Join(albumDir, extraArtworkDir)
+	used := make(map[string]int)
+	saved := 0
+	for _, img := range listing.Images {
+		if img.Front || img.Image == "" {
+			continue
+		}
+		name := "other"
+		if len(img.Types) > 0 {
+			name = strings.ToLower(strings.Join(img.Types, "-"))
+		}
+		used[name]++
+		if used[name] > 1 {
+			name = fmt.Sprintf("%s-%02d", name, used[name])
+		}
+		ext := strings.ToLower(filepath.Ext(img.Image))
+		if ext == "" {
+			ext = ".jpg"
+		}
+		path := filepath.Join(dir, SanitizeFileName(name)+ext)
+		if FileExists(path) {
+			continue
+		}
+
+		data, err := api.DownloadCover(ctx, img.Image)
+		if err != nil {
+			return fmt.Errorf("failed to download %s: %w", name, err)
+		}
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create artwork directory: %w", err)
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			return err
+		}
+		saved++
+	}
+	if saved > 0 {
+		colorInfo.Printf("🖼️ Saved %d additional artwork images for %s\n", saved, album.Title)
+	}
+	return nil
+}
+
+// albumReleaseID returns the MusicBrainz release of an album, empty when MusicBrainz is
+// disabled
+func albumReleaseID(album *Album) (string, error) {
+	if album.MusicBrainzID != "" {
+		return album.MusicBrainzID, nil
+	}
+	if release := albumCache.GetCachedRelease(album.Artist, album.Title); release != nil {
+		return release.ID, nil
+	}
+	if !musicBrainzEnabled {
+		return "", nil
+	}
+	release, err := mbClient.SearchRelease(album.Artist, album.Title)
+	if err != nil {
+		return "", err
+	}
+	return release.ID, nil
+}
+
+// prepareCover applies the album_art cover options to a downloaded DAB cover. It returns
+// the cover to save next to the album, replaced by the Cover Art Archive front cover when
+// DAB's is smaller than MinSize, and the cover to embed in the tracks, limited to
+// EmbedMaxSize and EmbedMaxKB.
+func (api *DabAPI) prepareCover(ctx context.Context, album *Album, coverData []byte, config *Config, debug bool) ([]byte, []byte) {
+	opts := config.AlbumArt
+	if opts == nil || len(coverData) == 0 {
+		return coverData, coverData
+	}
+	if opts.MinSize > 0 && imageSize(coverData) < opts.MinSize {
+		if larger, err := api.coverArtArchiveFront(ctx, album); err != nil {
+			if debug {
+				fmt.Printf("DEBUG: No larger cover for %s: %v\n", album.Title, err)
+			}
+		} else if imageSize(larger) > imageSize(coverData) {
+			colorInfo.Printf("🖼️ Using the larger Cover Art Archive cover for %s\n", album.Title)
+			coverData = larger
+		}
+	}
+
+	embedded, err := limitEmbeddedCover(coverData, opts.EmbedMaxSize, opts.EmbedMaxKB)
+	if err != nil {
+		if debug {
+			fmt.Printf("DEBUG: Embedding the cover of %s unchanged: %v\n", album.Title, err)
+		}
+		embedded = coverData
+	}
+	return coverData, embedded
+}
+
+// coverArtArchiveFront downloads the front cover of the album's MusicBrainz release
+func (api *DabAPI) coverArtArchiveFront(ctx context.Context, album *Album) ([]byte, error) {
+	releaseID, err := albumReleaseID(album)
+	if err != nil {
+		return nil, err
+	}
+	if releaseID == "" {
+		return nil, fmt.Errorf("MusicBrainz is disabled")
+	}
+	return api.DownloadCover(ctx, coverArtArchiveURL+releaseID+"/front")
+}
+
+// imageSize returns the longest side of an image in pixels, 0 if it can't be read
+func imageSize(data []byte) int {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return 0
+	}
+	return max(cfg.Width, cfg.Height)
+}
+
+// limitEmbeddedCover scales a cover down to maxSize pixels and re-encodes it as JPEG with
+// decreasing quality, then smaller sizes, until it is at most maxKB. Covers already
+// within both limits are returned unchanged.
+func limitEmbeddedCover(data []byte, maxSize, maxKB int) ([]byte, error) {
+	maxBytes := maxKB * 1024
+	size := imageSize(data)
+	if (maxSize <= 0 || size <= maxSize) && (maxBytes <= 0 || len(data) <= maxBytes) {
+		return data, nil
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode cover: %w", err)
+	}
+	if maxSize > 0 {
+		img = scaleDown(img, maxSize)
+	}
+
+	quality := jpegQuality
+	for {
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+			return nil, fmt.Errorf("failed to encode cover: %w", err)
+		}
+		bounds := img.Bounds()
+		longest := max(bounds.Dx(), bounds.Dy())
+		if maxBytes <= 0 || buf.Len() <= maxBytes || longest <= 64 {
+			return buf.Bytes(), nil
+		}
+		if quality > minEmbedQuality {
+			quality -= 10
+		} else {
+			img = scaleDown(img, longest*3/4)
+		}
+	}
+}
+
+// saveArtistArt writes an artist image to artistDir. The picture DAB provides is used first,
+// then fanart.tv (with an API key and a known MusicBrainz artist) and finally Spotify.
+func (api *DabAPI) saveArtistArt(ctx context.Context, artist *Artist, albums []Album, artistDir string, config *Config, debug bool) error {
+	opts := config.ArtistArt
+	if opts == nil {
+		opts = &ArtistArtOptions{}
+	}
+	filenames := opts.Filenames
+	if len(filenames) == 0 {
+		filenames = []string{defaultArtistArtFilename}
+	}
+	missing := false
+	for _, name := range filenames {
+		if !FileExists(filepath.Join(artistDir, name)) {
+			missing = true
+		}
+	}
+	if !missing {
+		return nil
+	}
+
+	var sources []func() (string, error)
+	if artist.Picture != "" {
+		sources = append(sources, func() (string, error) { return artist.Picture, nil })
+	}
+	if opts.FanartAPIKey != "" {
+		sources = append(sources, func() (string, error) { return api.fanartArtistImage(ctx, artist, albums, opts.FanartAPIKey) })
+	}
+	if config.SpotifyClientID != "" && config.SpotifyClientSecret != "" {
+		sources = append(sources, func() (string, error) {
+			client := NewSpotifyClient(config.SpotifyClientID, config.SpotifyClientSecret)
+			if err := client.Authenticate(); err != nil {
+				return "", err
+			}
+			return client.GetArtistImage(artist.Name)
+		})
+	}
+
+	var data []byte
+	for _, source := range sources {
+		imageURL, err := source()
+		if err == nil {
+			data, err = api.DownloadCover(ctx, imageURL)
+		}
+		if err == nil && len(data) > 0 {
+			break
+		}
+		if debug {
+			fmt.Printf("DEBUG: Artist image source failed for %s: %v\n", artist.Name, err)
+		}
+		data = nil
+	}
+	if data == nil {
+		return fmt.Errorf("no artist image found for %s", artist.Name)
+	}
+
+	for _, name := range filenames {
+		path := filepath.Join(artistDir, name)
+		if FileExists(path) {
+			continue
+		}
+		if err := writeImageFile(path, data, 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fanartArtistImage looks up the artist thumbnail on fanart.tv. The MusicBrainz artist ID
+// comes from the releases cached while tagging the artist's albums.
+func (api *DabAPI) fanartArtistImage(ctx context.Context, artist *Artist, albums []Album, apiKey string) (string, error) {
+	mbid := ""
+	for _, album := range albums {
+		if release := albumCache.GetCachedRelease(album.Artist, album.Title); release != nil && len(release.ArtistCredit) > 0 {
+			mbid = release.ArtistCredit[0].Artist.ID
+			break
+		}
+	}
+	if mbid == "" {
+		return "", fmt.Errorf("MusicBrainz artist ID of %s is unknown", artist.Name)
+	}
+
+	resp, err := api.Request(ctx, fanartTVURL+mbid, false, []QueryParam{{Name: "api_key", Value: apiKey}})
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	var images struct {
+		ArtistThumb []struct {
+			URL string `json:"url"`
+		} `json:"artistthumb"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&images); err != nil {
+		return "", fmt.Errorf("failed to parse fanart.tv response: %w", err)
+	}
+	if len(images.ArtistThumb) == 0 {
+		return "", fmt.Errorf("fanart.tv has no image of %s", artist.Name)
+	}
+	return images.ArtistThumb[0].URL, nil
+}
+
+// writeImageFile saves image data to path, re-encoding it when the file extension asks for
+// a different format and scaling it down so its longest side is at most maxSize (0 keeps the size)
+func writeImageFile(path string, data []byte, maxSize int) error {
+	wantPNG := strings.EqualFold(filepath.Ext(path), ".png")
+	isPNG := detectImageFormat(data) == "image/png"
+	if wantPNG == isPNG && maxSize <= 0 {
+		return os.WriteFile(path, data, 0644)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to decode cover for %s: %w", filepath.Base(path), err)
+	}
+	if maxSize > 0 {
+		img = scaleDown(img, maxSize)
+	}
+
+	var buf bytes.Buffer
+	if wantPNG {
+		err = png.Encode(&buf, img)
+	} else {
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", filepath.Base(path), err)
+	}
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// scaleDown shrinks img so its longest side is maxSize, averaging the source pixels
+// covered by each target pixel. Smaller images are returned unchanged.
+func scaleDown(img image.Image, maxSize int) image.Image {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	if w <= maxSize && h <= maxSize {
+		return img
+	}
+	nw, nh := maxSize, h*maxSize/w
+	if h > w {
+		nw, nh = w*maxSize/h, maxSize
+	}
+	if nw < 1 {
+		nw = 1
+	}
+	if nh < 1 {
+		nh = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, nw, nh))
+	for y := 0; y < nh; y++ {
+		y0, y1 := bounds.Min.Y+y*h/nh, bounds.Min.Y+(y+1)*h/nh
+		for x := 0; x < nw; x++ {
+			x0, x1 := bounds.Min.X+x*w/nw, bounds.Min.X+(x+1)*w/nw
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := img.At(sx, sy).RGBA()
+					r, g, b, a = r+uint64(cr), g+uint64(cg), b+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+			dst.Set(x, y, color.RGBA64{uint16(r / n), uint16(g / n), uint16(b / n), uint16(a / n)})
+		}
+	}
+	return dst
+}
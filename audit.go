@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// AuditEntry is a single line in the audit log
+type AuditEntry struct {
+	Time      string `json:"time"`
+	Interface string `json:"interface"` // "cli", "web" or "daemon"
+	User      string `json:"user,omitempty"`
+	Action    string `json:"action"` // "download", "delete", "quarantine", "rename", "convert", "purge"
+	Target    string `json:"target"`
+	Details   string `json:"details,omitempty"`
+	Run       string `json:"run"` // Correlation ID of the queue item, job or run, also in its events and reports
+}
+
+// AuditLogger appends file actions to an append-only JSON lines log
+type AuditLogger struct {
+	path  string
+	iface string
+	user  string
+	mu    sync.Mutex
+}
+
+// auditLog is the global audit logger, nil when audit logging is disabled
+var auditLog *AuditLogger
+
+// auditInterface is the interface recorded in audit entries, set by the daemon and serve
+var auditInterface = "cli"
+
+// InitAuditLog enables audit logging to the configured path for the given interface
+func InitAuditLog(config *Config, iface string) error {
+	if config.AuditLogPath == "" {
+		auditLog = nil
+		return nil
+	}
+	if err := CreateDirIfNotExists(filepath.Dir(config.AuditLogPath)); err != nil {
+		return fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+
+	username := ""
+	if u, err := user.Current(); err == nil {
+		username = u.Username
+	}
+
+	auditLog = &AuditLogger{
+		path:  config.AuditLogPath,
+		iface: iface,
+		user:  username,
+	}
+	return nil
+}
+
+// Audit records an action of the job or run of ctx in the audit log if it is enabled
+func Audit(ctx context.Context, action, target, details string) {
+	if auditLog == nil {
+		return
+	}
+	if err := auditLog.Record(ctx, action, target, details); err != nil {
+		colorWarning.Printf("⚠️ Failed to write audit log: %v\n", err)
+	}
+}
+
+// Record appends a single entry to the audit log, with the correlation ID of ctx
+func (a *AuditLogger) Record(ctx context.Context, action, target, details string) error {
+	entry := AuditEntry{
+		Time:      time.Now().Format(time.RFC3339),
+		Interface: a.iface,
+		User:      a.user,
+		Action:    action,
+		Target:    target,
+		Details:   details,
+		Run:       correlationIDFrom(ctx),
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	// Open in append-only mode for every entry so the log is never truncated or rewritten
+	f, err := os.OpenFile(a.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
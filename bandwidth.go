@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"golang.org/x/time/rate"
+)
+
+// bandwidthChunk is the most a single read takes from the bandwidth limit, so one
+// download can't hold up the others for long
+const bandwidthChunk = 32 * 1024
+
+// limitRate caps the aggregate download speed, e.g. "5M", set by --limit-rate
+var limitRate string
+
+// downloadBandwidth is shared by all audio downloads, nil without a limit
+var downloadBandwidth *rate.Limiter
+
+// SetBandwidthLimit caps the combined speed of all audio downloads in bytes per second,
+// 0 removes the cap
+func SetBandwidthLimit(bytesPerSecond int64) {
+	if bytesPerSecond <= 0 {
+		downloadBandwidth = nil
+		return
+	}
+	burst := bandwidthChunk
+	if bytesPerSecond < int64(burst) {
+		burst = int(bytesPerSecond)
+	}
+	downloadBandwidth = rate.NewLimiter(rate.Limit(bytesPerSecond), burst)
+}
+
+// parseByteRate reads a speed in bytes per second like "500K", "5M" or "1.5M". K, M and G
+// are multiples of 1024, a trailing "B" or "/s" is ignored.
+func parseByteRate(s string) (int64, error) {
+	value := strings.ToUpper(strings.TrimSpace(s))
+	value = strings.TrimSuffix(value, "/S")
+	value = strings.TrimSuffix(value, "B")
+	multiplier := 1.0
+	if value != "" {
+		switch value[len(value)-1] {
+		case 'K':
+			multiplier = 1024
+		case 'M':
+			multiplier = 1024 * 1024
+		case 'G':
+			multiplier = 1024 * 1024 * 1024
+		}
+		if multiplier > 1 {
+			value = value[:len(value)-1]
+		}
+	}
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid rate '%s' (expected e.g. 500K or 5M)", s)
+	}
+	return int64(n * multiplier), nil
+}
+
+// bandwidthReader waits for the shared bandwidth limit after each read
+type bandwidthReader struct {
+	io.ReadCloser
+	ctx     context.Context
+	limiter *rate.Limiter
+}
+
+// newBandwidthReader limits body to the download bandwidth, if one is set
+func newBandwidthReader(ctx context.Context, body io.ReadCloser) io.ReadCloser {
+	if downloadBandwidth == nil {
+		return body
+	}
+	return &bandwidthReader{ReadCloser: body, ctx: ctx, limiter: downloadBandwidth}
+}
+
+func (r *bandwidthReader) Read(p []byte) (int, error) {
+	if burst := r.limiter.Burst(); len(p) > burst {
+		p = p[:burst]
+	}
+	n, err := r.ReadCloser.Read(p)
+	if n > 0 {
+		if waitErr := r.limiter.WaitN(r.ctx, n); waitErr != nil && err == nil {
+			err = waitErr
+		}
+	}
+	return n, err
+}
@@ -0,0 +1,406 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ErrExpansionLimit is returned when a batch item expands to more albums than allowed
+var ErrExpansionLimit = errors.New("expansion limit exceeded")
+
+// BatchOptions controls how batch items are expanded and confirmed
+type BatchOptions struct {
+	Filter       string // Item types downloaded for artist entries
+	Expand       bool   // Download the full album of tracks found by search
+	MaxExpansion int    // Albums an item may expand to before asking, 0 disables the guard
+	AssumeYes    bool   // Download items over MaxExpansion instead of asking
+	NoConfirm    bool   // Skip items over MaxExpansion instead of asking, wins over AssumeYes
+	PlaylistName string // Name of the .m3u8 written with WriteM3U
+
+	albums *batchAlbums // Albums already downloaded by earlier entries of the batch
+}
+
+// batchAlbums tracks the albums a batch has downloaded, so an album reached by several
+// entries (e.g. an artist discography and a search with --expand) is downloaded once
+// and every entry that wanted it is credited in the report
+type batchAlbums struct {
+	firstLine map[string]int    // Album key -> line of the entry that downloaded it
+	titles    map[string]string // Album key -> album title for the report
+	credits   map[string][]int  // Album key -> lines of later entries that shared the download
+	order     []string
+}
+
+func newBatchAlbums() *batchAlbums {
+	return &batchAlbums{firstLine: make(map[string]int), titles: make(map[string]string), credits: make(map[string][]int)}
+}
+
+// batchAlbumKey identifies an album download. The same album in another format or location
+// is a different download.
+func batchAlbumKey(albumID string, config *Config) string {
+	return albumID + "|" + config.Format + "|" + config.Bitrate + "|" + config.DownloadLocation
+}
+
+// shared reports whether an earlier entry already downloaded the album, crediting line for it
+func (b *batchAlbums) shared(albumID, title string, line int, config *Config) bool {
+	key := batchAlbumKey(albumID, config)
+	first, ok := b.firstLine[key]
+	if !ok || first == line {
+		return false
+	}
+	b.credits[key] = append(b.credits[key], line)
+	if title == "" {
+		title = b.titles[key]
+	}
+	colorInfo.Printf("🔁 %s was already downloaded by line %d, skipping\n", title, first)
+	return true
+}
+
+// add records an album downloaded by the entry on line
+func (b *batchAlbums) add(albumID, title string, line int, config *Config) {
+	key := batchAlbumKey(albumID, config)
+	if _, ok := b.firstLine[key]; ok {
+		return
+	}
+	b.firstLine[key] = line
+	b.titles[key] = title
+	b.order = append(b.order, key)
+}
+
+// Report prints the albums shared between entries
+func (b *batchAlbums) Report() {
+	var shared []string
+	for _, key := range b.order {
+		if len(b.credits[key]) > 0 {
+			shared = append(shared, key)
+		}
+	}
+	if len(shared) == 0 {
+		return
+	}
+	colorInfo.Printf("🔁 %d albums were shared between batch entries:\n", len(shared))
+	for _, key := range shared {
+		lines := make([]string, 0, len(b.credits[key])+1)
+		for _, line := range append([]int{b.firstLine[key]}, b.credits[key]...) {
+			lines = append(lines, strconv.Itoa(line))
+		}
+		colorInfo.Printf("  • %s (lines %s)\n", b.titles[key], strings.Join(lines, ", "))
+	}
+}
+
+// BatchItem is a single line of a batch file
+type BatchItem struct {
+	Type     string // "artist", "album", "track", "isrc", "upc" or "search"
+	Value    string
+	Line     int
+	Format   string // Per-item overrides, empty uses the batch settings
+	Bitrate  string
+	Filter   string
+	Location string
+}
+
+// batchTypes are the prefixes accepted in batch files
+var batchTypes = map[string]bool{
+	"artist": true,
+	"album":  true,
+	"track":  true,
+	"isrc":   true,
+	"upc":    true,
+}
+
+// ParseBatchFile reads a batch file. Lines are either `<type>:<value>` (artist:<id>,
+// album:<id>, track:<id>, isrc:<code>, upc:<code>), which bypass search entirely, or free text that
+// is searched as a track. Blank lines and lines starting with # are ignored.
+// Each line may end with overrides like `|format=mp3|bitrate=256|filter=albums|location=/music/lossy`.
+func ParseBatchFile(path string) ([]BatchItem, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open batch file: %w", err)
+	}
+	defer f.Close()
+
+	var items []BatchItem
+	scanner := bufio.NewScanner(f)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		parts := strings.Split(line, "|")
+		line = strings.TrimSpace(parts[0])
+		item := BatchItem{Type: "search", Value: line, Line: lineNum}
+		if err := parseBatchOverrides(&item, parts[1:]); err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNum, err)
+		}
+		if prefix, value, ok := strings.Cut(line, ":"); ok {
+			prefix = strings.ToLower(strings.TrimSpace(prefix))
+			if batchTypes[prefix] {
+				value = strings.TrimSpace(value)
+				if value == "" {
+					return nil, fmt.Errorf("line %d: missing value after '%s:'", lineNum, prefix)
+				}
+				item.Type = prefix
+				item.Value = value
+			}
+		}
+		items = append(items, item)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read batch file: %w", err)
+	}
+	return items, nil
+}
+
+// parseBatchOverrides applies `key=value` overrides to a batch item
+func parseBatchOverrides(item *BatchItem, overrides []string) error {
+	for _, override := range overrides {
+		override = strings.TrimSpace(override)
+		if override == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(override, "=")
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+		if !ok || value == "" {
+			return fmt.Errorf("invalid override '%s', expected key=value", override)
+		}
+		switch key {
+		case "format":
+			value = strings.ToLower(value)
+			if err := ValidateFormat(value); err != nil {
+				return err
+			}
+			item.Format = value
+		case "bitrate":
+			if _, err := strconv.Atoi(value); err != nil {
+				return fmt.Errorf("invalid bitrate '%s'", value)
+			}
+			item.Bitrate = value
+		case "filter":
+			item.Filter = value
+		case "location":
+			item.Location = value
+		default:
+			return fmt.Errorf("unknown override '%s' (expected format, bitrate, filter or location)", key)
+		}
+	}
+	return nil
+}
+
+// RunBatch downloads every item of a batch and returns the combined stats. Only items that
+// expand past opts.MaxExpansion prompt for confirmation. Tracks are written to a playlist
+// in batch order when WriteM3U is enabled; album and artist entries are not included.
+func (api *DabAPI) RunBatch(ctx context.Context, items []BatchItem, config *Config, debug bool, opts BatchOptions) *DownloadStats {
+	stats := &DownloadStats{CorrelationID: correlationIDFrom(ctx)}
+	// Batches are unattended, so "all" downloads everything instead of showing the menu
+	if opts.Filter == "" || opts.Filter == "all" {
+		opts.Filter = "albums,eps,singles"
+	}
+
+	defaultLocation := api.outputLocation
+	defer func() { api.outputLocation = defaultLocation }()
+
+	var playlist []PlaylistEntry
+	opts.albums = newBatchAlbums()
+
+	for i, item := range items {
+		colorInfo.Printf("📄 [%d/%d] %s:%s\n", i+1, len(items), item.Type, item.Value)
+
+		// Apply the per-item overrides on top of the batch settings
+		itemConfig := *config
+		itemOpts := opts
+		api.outputLocation = defaultLocation
+		if item.Format != "" {
+			itemConfig.Format = item.Format
+		}
+		if item.Bitrate != "" {
+			itemConfig.Bitrate = item.Bitrate
+		}
+		if item.Filter != "" && item.Filter != "all" {
+			itemOpts.Filter = item.Filter
+		}
+		if item.Location != "" {
+			itemConfig.DownloadLocation = item.Location
+			api.outputLocation = item.Location
+		}
+
+		var entry *PlaylistEntry
+		var err error
+		if itemConfig.Format != "flac" && !CheckFFmpeg() {
+			err = fmt.Errorf("format %s needs ffmpeg, which is not installed", itemConfig.Format)
+		} else {
+			entry, err = api.runBatchItem(ctx, item, &itemConfig, debug, itemOpts, stats)
+		}
+		if entry != nil {
+			playlist = append(playlist, *entry)
+		}
+		if err != nil {
+			colorError.Printf("❌ Line %d (%s:%s): %v\n", item.Line, item.Type, item.Value, err)
+			stats.FailedCount++
+			stats.FailedItems = append(stats.FailedItems, fmt.Sprintf("line %d %s:%s: %v", item.Line, item.Type, item.Value, err))
+		}
+	}
+
+	opts.albums.Report()
+	writePlaylistFile(config, opts.PlaylistName, playlist)
+	return stats
+}
+
+// runBatchItem downloads a single batch item, adding its results to stats. It returns the
+// playlist entry of a downloaded track, or nil for albums and artists.
+func (api *DabAPI) runBatchItem(ctx context.Context, item BatchItem, config *Config, debug bool, opts BatchOptions, stats *DownloadStats) (*PlaylistEntry, error) {
+	switch item.Type {
+	case "artist":
+		return nil, api.downloadBatchArtist(ctx, item, config, debug, opts, stats)
+	case "album":
+		return nil, api.downloadBatchAlbum(ctx, item.Value, "", item, config, debug, opts, stats)
+	case "track":
+		track, err := api.GetTrack(ctx, item.Value)
+		if err != nil {
+			return nil, err
+		}
+		return api.downloadBatchTrack(ctx, *track, config, debug, stats)
+	case "isrc":
+		track, err := api.FindTrackByISRC(ctx, item.Value, debug)
+		if err != nil {
+			return nil, err
+		}
+		return api.downloadBatchTrack(ctx, *track, config, debug, stats)
+	case "upc":
+		album, err := api.FindAlbumByUPC(ctx, item.Value, debug)
+		if err != nil {
+			return nil, err
+		}
+		return nil, api.downloadBatchAlbum(ctx, album.ID, album.Title, item, config, debug, opts, stats)
+	case "search":
+		tracks, err := findTracks(ctx, api, querySource(item.Value), debug, true)
+		if err != nil {
+			return nil, err
+		}
+		if len(tracks) == 0 {
+			return nil, fmt.Errorf("no tracks found")
+		}
+		track := tracks[0]
+		if opts.Expand && track.AlbumID != "" {
+			colorInfo.Printf("🔍 Matched %s by %s, downloading its album\n", track.Title, track.Artist)
+			return nil, api.downloadBatchAlbum(ctx, track.AlbumID, track.Album, item, config, debug, opts, stats)
+		}
+		return api.downloadBatchTrack(ctx, track, config, debug, stats)
+	}
+	return nil, fmt.Errorf("unknown item type '%s'", item.Type)
+}
+
+// downloadBatchAlbum downloads an album unless an earlier entry of the batch already did
+func (api *DabAPI) downloadBatchAlbum(ctx context.Context, albumID, title string, item BatchItem, config *Config, debug bool, opts BatchOptions, stats *DownloadStats) error {
+	if opts.albums != nil && opts.albums.shared(albumID, title, item.Line, config) {
+		stats.SkippedCount++
+		return nil
+	}
+	albumStats, err := api.DownloadAlbum(ctx, albumID, config, debug, nil, nil)
+	if err != nil {
+		return err
+	}
+	addStats(stats, albumStats)
+	if opts.albums != nil {
+		if title == "" {
+			title = albumID
+			if album, err := api.GetAlbum(ctx, albumID); err == nil {
+				title = album.Title
+			}
+		}
+		opts.albums.add(albumID, title, item.Line, config)
+	}
+	return nil
+}
+
+// downloadBatchArtist downloads an artist's discography, checking the expansion limit first
+func (api *DabAPI) downloadBatchArtist(ctx context.Context, item BatchItem, config *Config, debug bool, opts BatchOptions, stats *DownloadStats) error {
+	artist, err := api.GetArtist(ctx, item.Value, config, debug)
+	if err != nil {
+		return fmt.Errorf("failed to get artist info: %w", err)
+	}
+	albums, eps, singles, _ := api.categorizeAlbums(artist.Albums)
+	items, _ := excludeTitles(filterArtistItems(albums, eps, singles, opts.Filter))
+	if len(items) == 0 {
+		colorWarning.Printf("⚠️ No %s found for %s\n", opts.Filter, artist.Name)
+		return nil
+	}
+	if opts.albums != nil {
+		remaining := items[:0]
+		for _, album := range items {
+			if opts.albums.shared(album.ID, album.Title, item.Line, config) {
+				stats.SkippedCount++
+				continue
+			}
+			remaining = append(remaining, album)
+		}
+		items = remaining
+		if len(items) == 0 {
+			return nil
+		}
+	}
+
+	if err := confirmExpansion(artist.Name, len(items), opts); err != nil {
+		return err
+	}
+
+	colorInfo.Printf("🎤 %s: downloading %d items\n", artist.Name, len(items))
+	job := startArtistJob(item.Value, artist, items, config)
+	if err := api.downloadArtistItems(ctx, artist, items, config, debug, job); err != nil {
+		return err
+	}
+	if opts.albums != nil {
+		for _, album := range items {
+			opts.albums.add(album.ID, album.Title, item.Line, config)
+		}
+	}
+	stats.SuccessCount++
+	return nil
+}
+
+// confirmExpansion guards against an item silently expanding into a huge download. Items over
+// the limit are confirmed interactively or by --yes, and skipped with --no-confirm or when
+// nobody can answer.
+func confirmExpansion(name string, count int, opts BatchOptions) error {
+	if opts.MaxExpansion <= 0 || count <= opts.MaxExpansion || (opts.AssumeYes && !opts.NoConfirm) {
+		return nil
+	}
+	if opts.NoConfirm || !isTTY() {
+		return fmt.Errorf("%w: %s expands to %d albums (--max-expansion %d)", ErrExpansionLimit, name, count, opts.MaxExpansion)
+	}
+	if !Confirm(fmt.Sprintf("⚠️ %s expands to %d albums, continue? (y/N)", name, count)) {
+		return fmt.Errorf("%w: %s skipped by user", ErrExpansionLimit, name)
+	}
+	return nil
+}
+
+func (api *DabAPI) downloadBatchTrack(ctx context.Context, track Track, config *Config, debug bool, stats *DownloadStats) (*PlaylistEntry, error) {
+	path, err := api.DownloadSingleTrack(ctx, track, debug, config.Format, config.Bitrate, nil, config, nil)
+	if err != nil {
+		return nil, err
+	}
+	stats.SuccessCount++
+	entry := newPlaylistEntry(track, path)
+	return &entry, nil
+}
+
+// addStats adds the counts and failures of src to dst. Concurrent callers have to hold a
+// lock on dst.
+func addStats(dst, src *DownloadStats) {
+	if src == nil {
+		return
+	}
+	dst.SuccessCount += src.SuccessCount
+	dst.SkippedCount += src.SkippedCount
+	dst.FailedCount += src.FailedCount
+	dst.FailedItems = append(dst.FailedItems, src.FailedItems...)
+	dst.UnavailableCount += src.UnavailableCount
+	dst.UnavailableItems = append(dst.UnavailableItems, src.UnavailableItems...)
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"runtime"
+	rtdebug "runtime/debug"
+)
+
+// defaultAPIEndpoint is the DAB API a new config starts with
+const defaultAPIEndpoint = "https://dabmusic.xyz"
+
+// Set at build time with -ldflags "-X main.buildCommit=... -X main.buildDate=...". When
+// empty, the VCS information Go stamps into binaries built from a checkout is used.
+var (
+	buildCommit string
+	buildDate   string
+)
+
+// BuildInfo describes the binary, for bug reports and wrappers
+type BuildInfo struct {
+	Version       string          `json:"version"`
+	Commit        string          `json:"commit,omitempty"`
+	Modified      bool            `json:"modified,omitempty"` // Built from a checkout with uncommitted changes
+	CommitDate    string          `json:"commit_date,omitempty"`
+	BuildDate     string          `json:"build_date,omitempty"`
+	GoVersion     string          `json:"go_version"`
+	Platform      string          `json:"platform"`
+	DefaultAPIURL string          `json:"default_api_url"`
+	Features      map[string]bool `json:"features"`
+}
+
+// GetBuildInfo collects the version, VCS stamp and platform features of the running binary
+func GetBuildInfo() BuildInfo {
+	info := BuildInfo{
+		Version:       toolVersion,
+		Commit:        buildCommit,
+		BuildDate:     buildDate,
+		GoVersion:     runtime.Version(),
+		Platform:      runtime.GOOS + "/" + runtime.GOARCH,
+		DefaultAPIURL: defaultAPIEndpoint,
+		Features: map[string]bool{
+			"ffmpeg":      CheckFFmpeg(),
+			"preallocate": runtime.GOOS == "linux", // See preallocate_linux.go
+		},
+	}
+	if build, ok := rtdebug.ReadBuildInfo(); ok {
+		for _, setting := range build.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				if info.Commit == "" {
+					info.Commit = setting.Value
+				}
+			case "vcs.time":
+				info.CommitDate = setting.Value
+			case "vcs.modified":
+				info.Modified = setting.Value == "true"
+			case "CGO_ENABLED":
+				info.Features["cgo"] = setting.Value == "1"
+			}
+		}
+	}
+	return info
+}
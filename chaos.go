@@ -0,0 +1,172 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// chaosSpec turns on failure injection for testing retries and resumes, set by the
+// hidden --chaos flag or DAB_CHAOS
+var chaosSpec string
+
+// ChaosOptions are the failures injected into HTTP requests, as probabilities from 0 to 1
+type ChaosOptions struct {
+	RateLimit float64       // Answer with 429 Too Many Requests instead of sending the request
+	Truncate  float64       // End a successful response body early
+	Slow      float64       // Wait Delay before sending the request
+	Delay     time.Duration // How long slow requests wait
+	Seed      int64         // Makes the failures repeatable, 0 picks a random seed
+}
+
+// defaultChaos is used for "--chaos on"
+var defaultChaos = ChaosOptions{RateLimit: 0.1, Truncate: 0.1, Slow: 0.1, Delay: 2 * time.Second}
+
+// parseChaos reads a spec like "429=0.2,truncate=0.3,slow=0.1,delay=5s,seed=42". "on",
+// "true" and "1" use the defaults. It returns nil when chaos is off.
+func parseChaos(spec string) (*ChaosOptions, error) {
+	spec = strings.TrimSpace(spec)
+	switch strings.ToLower(spec) {
+	case "", "off", "false", "0":
+		return nil, nil
+	case "on", "true", "1":
+		options := defaultChaos
+		return &options, nil
+	}
+
+	options := ChaosOptions{Delay: defaultChaos.Delay}
+	for _, part := range strings.Split(spec, ",") {
+		key, value, found := strings.Cut(strings.TrimSpace(part), "=")
+		if !found {
+			return nil, fmt.Errorf("invalid chaos option '%s', expected key=value", part)
+		}
+		var err error
+		switch strings.ToLower(key) {
+		case "429", "ratelimit":
+			options.RateLimit, err = parseProbability(value)
+		case "truncate":
+			options.Truncate, err = parseProbability(value)
+		case "slow":
+			options.Slow, err = parseProbability(value)
+		case "delay":
+			options.Delay, err = time.ParseDuration(value)
+		case "seed":
+			options.Seed, err = strconv.ParseInt(value, 10, 64)
+		default:
+			return nil, fmt.Errorf("unknown chaos option '%s' (use 429, truncate, slow, delay or seed)", key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid chaos option '%s': %w", part, err)
+		}
+	}
+	return &options, nil
+}
+
+func parseProbability(value string) (float64, error) {
+	p, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, err
+	}
+	if p < 0 || p > 1 {
+		return 0, fmt.Errorf("%s is not between 0 and 1", value)
+	}
+	return p, nil
+}
+
+// chaosTransport injects failures into the requests of the wrapped transport
+type chaosTransport struct {
+	next    http.RoundTripper
+	options ChaosOptions
+	mu      sync.Mutex
+	rng     *rand.Rand
+}
+
+func newChaosTransport(next http.RoundTripper, options ChaosOptions) *chaosTransport {
+	seed := options.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return &chaosTransport{next: next, options: options, rng: rand.New(rand.NewSource(seed))}
+}
+
+// roll reports whether a failure with probability p happens
+func (t *chaosTransport) roll(p float64) bool {
+	if p <= 0 {
+		return false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.rng.Float64() < p
+}
+
+func (t *chaosTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.roll(t.options.Slow) {
+		t.log("delaying %s by %s", req.URL.Host, t.options.Delay)
+		select {
+		case <-time.After(t.options.Delay):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+	if t.roll(t.options.RateLimit) {
+		t.log("answering %s with 429", req.URL.Host)
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return &http.Response{
+			Status:     "429 Too Many Requests",
+			StatusCode: http.StatusTooManyRequests,
+			Proto:      "HTTP/1.1",
+			ProtoMajor: 1,
+			ProtoMinor: 1,
+			Header:     http.Header{"Retry-After": {"1"}, "Content-Type": {"text/plain"}},
+			Body:       io.NopCloser(strings.NewReader("chaos: rate limited")),
+			Request:    req,
+		}, nil
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil || (resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent) {
+		return resp, err
+	}
+	if t.roll(t.options.Truncate) {
+		limit := int64(64 * 1024)
+		if resp.ContentLength > 0 {
+			t.mu.Lock()
+			limit = t.rng.Int63n(resp.ContentLength)
+			t.mu.Unlock()
+		}
+		t.log("cutting the response of %s off after %d bytes", req.URL.Host, limit)
+		resp.Body = &truncatedBody{ReadCloser: resp.Body, remaining: limit}
+	}
+	return resp, nil
+}
+
+func (t *chaosTransport) log(format string, args ...interface{}) {
+	if debug {
+		colorWarning.Printf("🐒 chaos: "+format+"\n", args...)
+	}
+}
+
+// truncatedBody fails like a dropped connection after remaining bytes
+type truncatedBody struct {
+	io.ReadCloser
+	remaining int64
+}
+
+func (b *truncatedBody) Read(p []byte) (int, error) {
+	if b.remaining <= 0 {
+		return 0, io.ErrUnexpectedEOF
+	}
+	if int64(len(p)) > b.remaining {
+		p = p[:b.remaining]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.remaining -= int64(n)
+	return n, err
+}
@@ -1,12 +1,153 @@
 package main
 
-import "github.com/fatih/color"
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/fatih/color"
+)
 
 // Package-level color variables
 var (
 	colorInfo    = color.New(color.FgCyan)
 	colorSuccess = color.New(color.FgGreen)
 	colorWarning = color.New(color.FgYellow)
-	colorError   = color.New(color.FgRed)
+	colorError   = errorColor{color.New(color.FgRed)}
 	colorPrompt  = color.New(color.FgBlue, color.Bold) // Added for user prompts
 )
+
+// errorOutput receives error messages instead of color.Output when set, so they still
+// reach stderr while other output is hidden
+var errorOutput io.Writer
+
+// errorColor prints error messages, to errorOutput when it is set
+type errorColor struct {
+	*color.Color
+}
+
+func (c errorColor) writer() io.Writer {
+	if errorOutput != nil {
+		return errorOutput
+	}
+	return color.Output
+}
+
+func (c errorColor) Printf(format string, a ...interface{}) (int, error) {
+	return c.Fprintf(c.writer(), format, a...)
+}
+
+func (c errorColor) Println(a ...interface{}) (int, error) {
+	return c.Fprintln(c.writer(), a...)
+}
+
+// colorThemes holds the attributes for each color role (info, success, warning, error, prompt)
+var colorThemes = map[string]map[string][]color.Attribute{
+	"default": {
+		"info":    {color.FgCyan},
+		"success": {color.FgGreen},
+		"warning": {color.FgYellow},
+		"error":   {color.FgRed},
+		"prompt":  {color.FgBlue, color.Bold},
+	},
+	"high-contrast": {
+		"info":    {color.FgHiWhite, color.Bold},
+		"success": {color.FgHiGreen, color.Bold},
+		"warning": {color.FgHiYellow, color.Bold},
+		"error":   {color.FgHiRed, color.Bold},
+		"prompt":  {color.FgHiCyan, color.Bold, color.Underline},
+	},
+}
+
+// colorNames maps color names usable in the Colors config option to attributes
+var colorNames = map[string]color.Attribute{
+	"black":      color.FgBlack,
+	"red":        color.FgRed,
+	"green":      color.FgGreen,
+	"yellow":     color.FgYellow,
+	"blue":       color.FgBlue,
+	"magenta":    color.FgMagenta,
+	"cyan":       color.FgCyan,
+	"white":      color.FgWhite,
+	"hi-black":   color.FgHiBlack,
+	"hi-red":     color.FgHiRed,
+	"hi-green":   color.FgHiGreen,
+	"hi-yellow":  color.FgHiYellow,
+	"hi-blue":    color.FgHiBlue,
+	"hi-magenta": color.FgHiMagenta,
+	"hi-cyan":    color.FgHiCyan,
+	"hi-white":   color.FgHiWhite,
+	"bold":       color.Bold,
+	"underline":  color.Underline,
+}
+
+// ApplyColorTheme sets the package-level colors from a theme name and per-role overrides.
+// The "none" theme disables all colors; an override of "none" disables a single role.
+// Overrides may combine names, e.g. {"error": "hi-red,bold"}.
+func ApplyColorTheme(theme string, overrides map[string]string) error {
+	if theme == "" {
+		theme = "default"
+	}
+
+	var attrs map[string][]color.Attribute
+	if theme == "none" {
+		attrs = map[string][]color.Attribute{}
+	} else {
+		var ok bool
+		attrs, ok = colorThemes[theme]
+		if !ok {
+			return fmt.Errorf("unknown color theme '%s'", theme)
+		}
+	}
+
+	roles := map[string]**color.Color{
+		"info":    &colorInfo,
+		"success": &colorSuccess,
+		"warning": &colorWarning,
+		"error":   &colorError.Color,
+		"prompt":  &colorPrompt,
+	}
+
+	for role := range overrides {
+		if _, ok := roles[role]; !ok {
+			return fmt.Errorf("unknown color role '%s' (expected info, success, warning, error or prompt)", role)
+		}
+	}
+
+	for role, target := range roles {
+		roleAttrs := attrs[role]
+		if override, ok := overrides[role]; ok {
+			parsed, err := parseColorAttributes(override)
+			if err != nil {
+				return fmt.Errorf("invalid color for '%s': %w", role, err)
+			}
+			roleAttrs = parsed
+		}
+
+		c := color.New(roleAttrs...)
+		if len(roleAttrs) == 0 {
+			c.DisableColor()
+		}
+		*target = c
+	}
+
+	return nil
+}
+
+// parseColorAttributes parses a comma-separated list of color names
+func parseColorAttributes(value string) ([]color.Attribute, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "none" || value == "" {
+		return nil, nil
+	}
+
+	var attrs []color.Attribute
+	for _, name := range strings.Split(value, ",") {
+		attr, ok := colorNames[strings.TrimSpace(name)]
+		if !ok {
+			return nil, fmt.Errorf("unknown color '%s'", name)
+		}
+		attrs = append(attrs, attr)
+	}
+	return attrs, nil
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Values of CompilationExpansion
+const (
+	CompilationKeep     = "compilation" // --expand downloads the compilation a playlist track is from
+	CompilationOriginal = "original"    // --expand downloads the studio album the track first appeared on
+)
+
+// compilationPolicy overrides CompilationExpansion, set by --compilations
+var compilationPolicy string
+
+// variousArtists are album artists that mark a compilation
+var variousArtists = []string{"various artists", "various", "va", "verschiedene interpreten", "artistes divers"}
+
+// isCompilation reports whether a playlist track's album is a compilation, going by the
+// album type Spotify reports or a "Various Artists" album artist
+func isCompilation(track SpotifyTrack) bool {
+	return strings.EqualFold(track.AlbumType, "compilation") || containsString(variousArtists, strings.ToLower(strings.TrimSpace(track.AlbumArtist)))
+}
+
+// findOriginalAlbum returns the title of the earliest official studio album MusicBrainz
+// lists the track on. Studio albums are release groups of type Album without secondary
+// types, so other compilations, live albums and soundtracks don't count.
+func findOriginalAlbum(track SpotifyTrack) (string, error) {
+	recordings, err := mbClient.SearchRecordings(track.Artist, track.Name, 10)
+	if err != nil {
+		return "", err
+	}
+	type candidate struct{ title, date string }
+	var candidates []candidate
+	for _, recording := range recordings {
+		if !strings.EqualFold(recording.Title, track.Name) {
+			continue
+		}
+		for _, release := range recording.Releases {
+			group := release.ReleaseGroup
+			if group.PrimaryType != "Album" || len(group.SecondaryTypes) > 0 || (release.Status != "" && release.Status != "Official") {
+				continue
+			}
+			candidates = append(candidates, candidate{release.Title, release.Date})
+		}
+	}
+	if len(candidates) == 0 {
+		return "", fmt.Errorf("no studio album on MusicBrainz")
+	}
+	// Releases without a date sort last
+	sort.SliceStable(candidates, func(i, k int) bool {
+		a, b := candidates[i].date, candidates[k].date
+		return a != "" && (b == "" || a < b)
+	})
+	return candidates[0].title, nil
+}
+
+// expansionAlbums returns one track per album --expand downloads, in playlist order.
+// Under the original policy, tracks from compilations stand for their studio album
+// instead, and are left out when it can't be found.
+func expansionAlbums(tracks []SpotifyTrack, config *Config) []SpotifyTrack {
+	original := config.CompilationExpansion == CompilationOriginal
+	if original && !musicBrainzEnabled {
+		colorWarning.Println("⚠️ Finding the studio albums of compilation tracks needs MusicBrainz, expanding to the compilations")
+		original = false
+	}
+
+	var albums []SpotifyTrack
+	seen := make(map[string]bool)
+	for _, track := range tracks {
+		if original && isCompilation(track) {
+			title, err := findOriginalAlbum(track)
+			if err != nil {
+				colorWarning.Printf("⚠️ Skipping %s - %s from compilation %s: %v\n", track.Artist, track.Name, track.AlbumName, err)
+				continue
+			}
+			colorInfo.Printf("💿 %s - %s: expanding to %s instead of %s\n", track.Artist, track.Name, title, track.AlbumName)
+			track.AlbumName, track.AlbumArtist, track.AlbumType = title, track.Artist, "album"
+		}
+		// Use a consistent key for the map
+		albumKey := strings.ToLower(track.AlbumName + " - " + track.AlbumArtist)
+		if !seen[albumKey] {
+			seen[albumKey] = true
+			albums = append(albums, track)
+		}
+	}
+	return albums
+}
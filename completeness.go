@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// AlbumTracklist is the DAB track list of an album as it was when the album was downloaded
+type AlbumTracklist struct {
+	ID        string    `json:"id"`
+	Title     string    `json:"title"`
+	Artist    string    `json:"artist"`
+	TrackIDs  []string  `json:"track_ids"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// AlbumTracklists stores the expected tracks of every downloaded album, so tracks DAB adds
+// later (deluxe editions) can be told apart from tracks that failed or were skipped
+type AlbumTracklists struct {
+	path    string
+	entries map[string]*AlbumTracklist
+	loaded  bool
+	mu      sync.Mutex
+}
+
+// albumTracklists is the store used by album downloads and 'check-complete'
+var albumTracklists = NewAlbumTracklists(filepath.Join("config", "albums.json"))
+
+// NewAlbumTracklists creates a store backed by path
+func NewAlbumTracklists(path string) *AlbumTracklists {
+	return &AlbumTracklists{path: path, entries: make(map[string]*AlbumTracklist)}
+}
+
+// Get returns the stored track list of an album, nil if the album was never recorded
+func (s *AlbumTracklists) Get(albumID string) *AlbumTracklist {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.load()
+	return s.entries[albumID]
+}
+
+// IDs returns the IDs of every recorded album
+func (s *AlbumTracklists) IDs() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.load()
+	ids := make([]string, 0, len(s.entries))
+	for id := range s.entries {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// Record stores the current track list of an album and writes the store to disk
+func (s *AlbumTracklists) Record(album *Album) error {
+	entry := &AlbumTracklist{ID: album.ID, Title: album.Title, Artist: album.Artist, CheckedAt: time.Now()}
+	for _, track := range album.Tracks {
+		entry.TrackIDs = append(entry.TrackIDs, idToString(track.ID))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.load()
+	s.entries[album.ID] = entry
+
+	data, err := json.MarshalIndent(s.entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create album list directory: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write album list: %w", err)
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// load reads the store once. A missing or corrupt file starts empty.
+func (s *AlbumTracklists) load() {
+	if s.loaded {
+		return
+	}
+	s.loaded = true
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return
+	}
+	if err := json.Unmarshal(data, &s.entries); err != nil || s.entries == nil {
+		colorWarning.Printf("⚠️ Ignoring unreadable album list %s: %v\n", s.path, err)
+		s.entries = make(map[string]*AlbumTracklist)
+	}
+}
+
+// recordTracklist stores the track list of a downloaded album, a failure only produces a warning
+func recordTracklist(album *Album) {
+	if err := albumTracklists.Record(album); err != nil {
+		colorWarning.Printf("⚠️ Failed to update album list: %v\n", err)
+	}
+}
+
+// AlbumCompleteness compares an album on DAB with the tracks that were downloaded
+type AlbumCompleteness struct {
+	Album   *Album
+	Added   []Track // On DAB now but not when the album was downloaded
+	Missing []Track // Expected when the album was downloaded, but never downloaded
+}
+
+// Complete reports whether every track of the album is downloaded
+func (c *AlbumCompleteness) Complete() bool {
+	return len(c.Added) == 0 && len(c.Missing) == 0
+}
+
+// completenessAlbumIDs returns the recorded albums plus the albums of the download history,
+// so albums downloaded before track lists were stored are checked too
+func completenessAlbumIDs() []string {
+	seen := make(map[string]bool)
+	var ids []string
+	for _, id := range albumTracklists.IDs() {
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	for _, entry := range downloadHistory.Search("") {
+		if entry.AlbumID != "" && !seen[entry.AlbumID] {
+			seen[entry.AlbumID] = true
+			ids = append(ids, entry.AlbumID)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// CheckAlbumComplete fetches the current track list of an album and reports the tracks that
+// are neither in the download history nor in the album folder. Albums without a stored track
+// list count every such track as missing.
+func (api *DabAPI) CheckAlbumComplete(ctx context.Context, albumID string, config *Config) (*AlbumCompleteness, error) {
+	album, err := api.GetAlbum(ctx, albumID)
+	if err != nil {
+		return nil, err
+	}
+
+	expected := make(map[string]bool)
+	recorded := albumTracklists.Get(albumID)
+	if recorded != nil {
+		for _, id := range recorded.TrackIDs {
+			expected[id] = true
+		}
+	}
+
+	result := &AlbumCompleteness{Album: album}
+	albumDir := filepath.Join(api.outputLocation, albumFolder(config, album, album.Artist))
+	for idx, track := range album.Tracks {
+		id := idToString(track.ID)
+		if downloadHistory.Find(id, track.ISRC, historyAlbumScope(config, album.ID)) != nil {
+			continue
+		}
+		if track.TrackNumber == 0 {
+			track.TrackNumber = idx + 1
+		}
+		if FileExists(filepath.Join(albumDir, trackFile(config, album, track, album.Artist))) {
+			continue
+		}
+		if recorded != nil && !expected[id] {
+			result.Added = append(result.Added, track)
+		} else {
+			result.Missing = append(result.Missing, track)
+		}
+	}
+	return result, nil
+}
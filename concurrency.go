@@ -0,0 +1,53 @@
+package main
+
+// albumConcurrency returns how many albums an artist or watch download fetches at once
+func albumConcurrency(config *Config) int {
+	n := config.MaxConcurrentAlbums
+	if n <= 0 {
+		n = config.Parallelism
+	}
+	if n < 1 {
+		n = 1
+	}
+	return n
+}
+
+// trackConcurrency returns how many tracks of one album are downloaded at once
+func trackConcurrency(config *Config) int {
+	n := config.MaxConcurrentTracks
+	if n <= 0 {
+		n = config.Parallelism
+	}
+	if n < 1 {
+		n = 1
+	}
+	return n
+}
+
+// totalDownloadLimit returns the most tracks that may download at the same time across albums
+func totalDownloadLimit(config *Config) int {
+	if config.MaxTotalDownloads > 0 {
+		return config.MaxTotalDownloads
+	}
+	n := trackConcurrency(config)
+	if config.Parallelism > n {
+		n = config.Parallelism
+	}
+	return n
+}
+
+// nestedTrackConcurrency splits the total download limit between albums running in parallel,
+// so albums × tracks never oversubscribes the connection. Each album gets at least one track.
+func nestedTrackConcurrency(config *Config, albums int) int {
+	if albums < 1 {
+		albums = 1
+	}
+	n := totalDownloadLimit(config) / albums
+	if tracks := trackConcurrency(config); n > tracks {
+		n = tracks
+	}
+	if n < 1 {
+		n = 1
+	}
+	return n
+}
@@ -0,0 +1,363 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ImportedSettings are the settings read from another downloader's config. Empty fields
+// were not set there or have no equivalent.
+type ImportedSettings struct {
+	DownloadLocation string
+	Parallelism      int
+	Format           string
+	Bitrate          string
+	AlbumFolderMask  string
+	FileMask         string
+	SaveAlbumArt     *bool
+	CoverFilename    string
+	EmbedMaxSize     int
+	Notes            []string // Settings that couldn't be carried over
+}
+
+// placeholderPattern matches placeholders with an optional format spec, e.g. "{tracknumber:02}"
+var placeholderPattern = regexp.MustCompile(`\{([a-z_]+)(?::[^}]*)?\}`)
+
+// bracketGroupPattern matches a bracketed or parenthesized part of a naming format
+var bracketGroupPattern = regexp.MustCompile(`[\[(][^\[\]()]*[\])]`)
+
+// streamripPlaceholders maps streamrip's folder and track placeholders to naming mask ones
+var streamripPlaceholders = map[string]string{
+	"albumartist":   "album_artist",
+	"albumcomposer": "album_artist",
+	"artist":        "track_artist",
+	"title":         "title",
+	"year":          "year",
+	"tracknumber":   "track_number",
+	"discnumber":    "disc_number",
+}
+
+// orpheusPlaceholders maps OrpheusDL's album and track placeholders to naming mask ones
+var orpheusPlaceholders = map[string]string{
+	"name":         "title",
+	"album_name":   "album",
+	"artist":       "track_artist",
+	"album_artist": "album_artist",
+	"release_year": "year",
+	"track_number": "track_number",
+	"disc_number":  "disc_number",
+	"total_discs":  "total_discs",
+}
+
+// defaultImportPath returns where a downloader keeps its config by default, empty when
+// there is no fixed location
+func defaultImportPath(tool string) string {
+	if tool == "streamrip" {
+		if dir, err := os.UserConfigDir(); err == nil {
+			return filepath.Join(dir, "streamrip", "config.toml")
+		}
+	}
+	return ""
+}
+
+// ImportSettings reads the settings of streamrip (config.toml) or OrpheusDL (settings.json)
+func ImportSettings(tool, path string) (*ImportedSettings, error) {
+	switch tool {
+	case "streamrip":
+		return importStreamrip(path)
+	case "orpheusdl":
+		return importOrpheusDL(path)
+	}
+	return nil, fmt.Errorf("unknown downloader '%s' (expected streamrip or orpheusdl)", tool)
+}
+
+func importStreamrip(path string) (*ImportedSettings, error) {
+	values, err := readTOML(path)
+	if err != nil {
+		return nil, err
+	}
+	settings := &ImportedSettings{}
+	str := func(key string) string { s, _ := values[key].(string); return s }
+	num := func(key string) int { n, _ := values[key].(int); return n }
+	flag := func(key string) (bool, bool) { b, ok := values[key].(bool); return b, ok }
+
+	settings.DownloadLocation = expandHome(str("downloads.folder"))
+	if concurrent, ok := flag("downloads.concurrency"); ok && !concurrent {
+		settings.Parallelism = 1
+	} else if n := num("downloads.max_connections"); n > 0 {
+		settings.Parallelism = n
+	}
+
+	if enabled, _ := flag("conversion.enabled"); enabled {
+		settings.setCodec(str("conversion.codec"), num("conversion.lossy_bitrate"))
+	}
+
+	if folder := str("filepaths.folder_format"); folder != "" {
+		settings.AlbumFolderMask = settings.translateMask(folder, streamripPlaceholders, map[string]string{"title": "album"})
+	}
+	if track := str("filepaths.track_format"); track != "" {
+		settings.FileMask = settings.translateMask(track, streamripPlaceholders, nil)
+	}
+
+	if save, ok := flag("artwork.save_artwork"); ok {
+		settings.SaveAlbumArt = &save
+	}
+	if width := num("artwork.embed_max_width"); width > 0 {
+		settings.EmbedMaxSize = width
+	}
+	return settings, nil
+}
+
+func importOrpheusDL(path string) (*ImportedSettings, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	var file struct {
+		Global struct {
+			General struct {
+				DownloadPath string `json:"download_path"`
+			} `json:"general"`
+			Formatting struct {
+				AlbumFormat         string `json:"album_format"`
+				TrackFilenameFormat string `json:"track_filename_format"`
+			} `json:"formatting"`
+			Covers struct {
+				MainResolution int    `json:"main_resolution"`
+				SaveExternal   *bool  `json:"save_external"`
+				ExternalFormat string `json:"external_format"`
+			} `json:"covers"`
+			Advanced struct {
+				CodecConversions map[string]string `json:"codec_conversions"`
+			} `json:"advanced"`
+		} `json:"global"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	global := file.Global
+	settings := &ImportedSettings{}
+
+	if dir := global.General.DownloadPath; dir != "" {
+		// Relative paths are relative to the OrpheusDL folder, not ours
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(filepath.Dir(filepath.Dir(path)), dir)
+		}
+		settings.DownloadLocation = filepath.Clean(dir)
+	}
+
+	if album := global.Formatting.AlbumFormat; album != "" {
+		// In album formats {name} is the album and {artist} the album artist
+		settings.AlbumFolderMask = settings.translateMask(album, orpheusPlaceholders, map[string]string{"name": "album", "artist": "album_artist"})
+	}
+	if track := global.Formatting.TrackFilenameFormat; track != "" {
+		settings.FileMask = settings.translateMask(track, orpheusPlaceholders, nil)
+	}
+
+	if global.Covers.MainResolution > 0 {
+		settings.EmbedMaxSize = global.Covers.MainResolution
+	}
+	if save := global.Covers.SaveExternal; save != nil {
+		settings.SaveAlbumArt = save
+		if *save && global.Covers.ExternalFormat != "" {
+			settings.CoverFilename = "cover." + strings.ToLower(global.Covers.ExternalFormat)
+		}
+	}
+
+	if target := global.Advanced.CodecConversions["flac"]; target != "" && target != "flac" {
+		settings.setCodec(target, 0)
+	}
+	return settings, nil
+}
+
+// setCodec carries over the conversion target when dab-downloader can convert to it
+func (s *ImportedSettings) setCodec(codec string, bitrate int) {
+	switch strings.ToLower(codec) {
+	case "mp3":
+		s.Format = "mp3"
+	case "ogg", "vorbis":
+		s.Format = "ogg"
+	case "opus":
+		s.Format = "opus"
+	case "aac", "m4a":
+		s.Format = "aac"
+	case "alac":
+		s.Format = "alac"
+	case "", "flac":
+		return
+	default:
+		s.Notes = append(s.Notes, fmt.Sprintf("conversion to %s is not supported, keeping FLAC", codec))
+		return
+	}
+	if bitrate > 0 {
+		s.Bitrate = strconv.Itoa(bitrate)
+	}
+}
+
+// translateMask rewrites the placeholders of another tool's naming format. overrides take
+// precedence over placeholders, since album and track formats use some names differently.
+// Placeholders without an equivalent are dropped and noted, together with the brackets
+// around them, e.g. "[{bit_depth}B-{sampling_rate}kHz]".
+func (s *ImportedSettings) translateMask(format string, placeholders, overrides map[string]string) string {
+	mapped := func(name string) (string, bool) {
+		if to, ok := overrides[name]; ok {
+			return to, true
+		}
+		to, ok := placeholders[name]
+		return to, ok
+	}
+
+	var dropped []string
+	mask := bracketGroupPattern.ReplaceAllStringFunc(format, func(group string) string {
+		names := placeholderPattern.FindAllStringSubmatch(group, -1)
+		if len(names) == 0 {
+			return group
+		}
+		for _, name := range names {
+			if _, ok := mapped(name[1]); ok {
+				return group
+			}
+		}
+		for _, name := range names {
+			dropped = append(dropped, name[0])
+		}
+		return ""
+	})
+	mask = placeholderPattern.ReplaceAllStringFunc(mask, func(match string) string {
+		if to, ok := mapped(placeholderPattern.FindStringSubmatch(match)[1]); ok {
+			return "{" + to + "}"
+		}
+		dropped = append(dropped, match)
+		return ""
+	})
+	if len(dropped) > 0 {
+		s.Notes = append(s.Notes, fmt.Sprintf("%s has no equivalent for %s, left out", format, strings.Join(dropped, ", ")))
+	}
+	return strings.Join(strings.Fields(mask), " ")
+}
+
+// Apply copies the imported settings into config and describes each change
+func (s *ImportedSettings) Apply(config *Config) []string {
+	var changes []string
+	set := func(name string, target *string, value string) {
+		if value != "" && *target != value {
+			changes = append(changes, fmt.Sprintf("%s: %q → %q", name, *target, value))
+			*target = value
+		}
+	}
+	set("DownloadLocation", &config.DownloadLocation, s.DownloadLocation)
+	set("Format", &config.Format, s.Format)
+	set("Bitrate", &config.Bitrate, s.Bitrate)
+	set("naming.album_folder_mask", &config.NamingMasks.AlbumFolderMask, s.AlbumFolderMask)
+	set("naming.file_mask", &config.NamingMasks.FileMask, s.FileMask)
+	if (s.AlbumFolderMask != "" || s.FileMask != "") && !config.NamingMasks.Enabled {
+		changes = append(changes, "naming.enabled: false → true")
+		config.NamingMasks.Enabled = true
+	}
+
+	if s.Parallelism > 0 && config.Parallelism != s.Parallelism {
+		changes = append(changes, fmt.Sprintf("Parallelism: %d → %d", config.Parallelism, s.Parallelism))
+		config.Parallelism = s.Parallelism
+	}
+	if s.SaveAlbumArt != nil && config.SaveAlbumArt != *s.SaveAlbumArt {
+		changes = append(changes, fmt.Sprintf("SaveAlbumArt: %t → %t", config.SaveAlbumArt, *s.SaveAlbumArt))
+		config.SaveAlbumArt = *s.SaveAlbumArt
+	}
+	if s.CoverFilename != "" || s.EmbedMaxSize > 0 {
+		if config.AlbumArt == nil {
+			config.AlbumArt = &AlbumArtOptions{}
+		}
+		if s.CoverFilename != "" && !(len(config.AlbumArt.Filenames) == 1 && config.AlbumArt.Filenames[0] == s.CoverFilename) {
+			changes = append(changes, fmt.Sprintf("album_art.filenames: %v → [%s]", config.AlbumArt.Filenames, s.CoverFilename))
+			config.AlbumArt.Filenames = []string{s.CoverFilename}
+		}
+		if s.EmbedMaxSize > 0 && config.AlbumArt.EmbedMaxSize != s.EmbedMaxSize {
+			changes = append(changes, fmt.Sprintf("album_art.embed_max_size: %d → %d", config.AlbumArt.EmbedMaxSize, s.EmbedMaxSize))
+			config.AlbumArt.EmbedMaxSize = s.EmbedMaxSize
+		}
+	}
+	return changes
+}
+
+// readTOML reads the tables, strings, numbers and booleans of a TOML file into a map keyed
+// by "table.key". Arrays and inline tables are skipped, which is all the settings need.
+func readTOML(path string) (map[string]interface{}, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	defer file.Close()
+
+	values := make(map[string]interface{})
+	table := ""
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			table = strings.Trim(stripTOMLComment(line), "[] ")
+			continue
+		}
+		key, raw, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: expected key = value", path, lineNumber)
+		}
+		key = strings.Trim(strings.TrimSpace(key), `"`)
+		if table != "" {
+			key = table + "." + key
+		}
+		raw = stripTOMLComment(strings.TrimSpace(raw))
+
+		switch {
+		case strings.HasPrefix(raw, `"`):
+			s, err := strconv.Unquote(raw)
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: invalid string %s", path, lineNumber, raw)
+			}
+			values[key] = s
+		case strings.HasPrefix(raw, "'"):
+			values[key] = strings.Trim(raw, "'")
+		case raw == "true" || raw == "false":
+			values[key] = raw == "true"
+		default:
+			if n, err := strconv.Atoi(strings.ReplaceAll(raw, "_", "")); err == nil {
+				values[key] = n
+			}
+		}
+	}
+	return values, scanner.Err()
+}
+
+// stripTOMLComment removes a trailing comment from a value, keeping "#" inside strings
+func stripTOMLComment(value string) string {
+	var quote rune
+	for i, r := range value {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote == 0 && (r == '"' || r == '\''):
+			quote = r
+		case quote == 0 && r == '#':
+			return strings.TrimSpace(value[:i])
+		}
+	}
+	return value
+}
+
+// expandHome replaces a leading ~ with the home directory
+func expandHome(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, strings.TrimPrefix(path, "~"))
+		}
+	}
+	return path
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// currentConfigVersion is the config_version written by this version. Add a migration to
+// configMigrations and raise it whenever the structure of config.json changes.
+const currentConfigVersion = 3
+
+// configMigration upgrades the raw JSON of config.json from version-1 to version.
+// defaults holds the values the downloader uses for keys missing from the file.
+type configMigration struct {
+	version     int
+	description string
+	apply       func(raw map[string]interface{}, defaults *Config)
+}
+
+// configMigrations are applied in order to configs older than their version
+var configMigrations = []configMigration{
+	{1, "move flat naming masks into \"naming\"", migrateFlatNaming},
+	{2, "spell keys the way the schema does", migrateKeyCase},
+	{3, "write the defaults of missing options", migrateDefaults},
+}
+
+// flatNamingKeys are the naming masks older configs kept at the top level
+var flatNamingKeys = map[string]string{
+	"album_folder_mask":  "album_folder_mask",
+	"albumfoldermask":    "album_folder_mask",
+	"ep_folder_mask":     "ep_folder_mask",
+	"epfoldermask":       "ep_folder_mask",
+	"single_folder_mask": "single_folder_mask",
+	"singlefoldermask":   "single_folder_mask",
+	"file_mask":          "file_mask",
+	"filemask":           "file_mask",
+}
+
+// MigrateConfig upgrades config.json to currentConfigVersion. The previous file is kept as
+// <file>.v<version>.bak and the migrated one written in its place. Files that aren't valid
+// JSON are returned unchanged for validation to report.
+func MigrateConfig(filePath string, data []byte, defaults *Config) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var raw map[string]interface{}
+	if err := dec.Decode(&raw); err != nil || raw == nil {
+		return data, nil
+	}
+
+	version := 0
+	if number, ok := raw["config_version"].(json.Number); ok {
+		n, _ := number.Int64()
+		version = int(n)
+	}
+	if version > currentConfigVersion {
+		colorWarning.Printf("⚠️ %s was written by a newer version (config_version %d), some options may be ignored\n", filePath, version)
+		return data, nil
+	}
+	if version == currentConfigVersion {
+		return data, nil
+	}
+
+	for _, migration := range configMigrations {
+		if migration.version > version {
+			colorInfo.Printf("🔧 Migrating config to version %d: %s\n", migration.version, migration.description)
+			migration.apply(raw, defaults)
+		}
+	}
+	raw["config_version"] = currentConfigVersion
+
+	migrated, err := json.MarshalIndent(raw, "", "  ")
+	if err != nil {
+		return data, fmt.Errorf("failed to encode migrated config: %w", err)
+	}
+	// The backup holds the same tokens and passwords as the config, only the owner may read it
+	backup := fmt.Sprintf("%s.v%d.bak", filePath, version)
+	if err := os.WriteFile(backup, data, 0600); err != nil {
+		return data, fmt.Errorf("failed to back up config before migrating: %w", err)
+	}
+	if err := os.Chmod(backup, 0600); err != nil {
+		return data, fmt.Errorf("failed to back up config before migrating: %w", err)
+	}
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(filePath); err == nil {
+		mode = info.Mode().Perm()
+	}
+	if err := os.WriteFile(filePath, migrated, mode); err != nil {
+		return data, fmt.Errorf("failed to write migrated config: %w", err)
+	}
+	colorSuccess.Printf("✅ Config upgraded from version %d to %d, previous file saved as %s\n", version, currentConfigVersion, backup)
+	return migrated, nil
+}
+
+// migrateFlatNaming moves top-level naming masks, and a "NamingMasks" object named after
+// the Go field, into "naming". Masks already in "naming" win.
+func migrateFlatNaming(raw map[string]interface{}, defaults *Config) {
+	naming, _ := raw["naming"].(map[string]interface{})
+	if naming == nil {
+		naming = map[string]interface{}{}
+	}
+	for key, value := range raw {
+		lower := strings.ToLower(key)
+		if lower == "namingmasks" || lower == "naming_masks" {
+			if masks, ok := value.(map[string]interface{}); ok {
+				for name, mask := range masks {
+					if _, exists := naming[name]; !exists {
+						naming[name] = mask
+					}
+				}
+				delete(raw, key)
+			}
+			continue
+		}
+		if name, ok := flatNamingKeys[lower]; ok {
+			if _, exists := naming[name]; !exists {
+				naming[name] = value
+			}
+			delete(raw, key)
+		}
+	}
+	if len(naming) > 0 {
+		raw["naming"] = naming
+	}
+}
+
+// migrateKeyCase renames keys that only match an option ignoring case, e.g. "saveAlbumArt",
+// which encoding/json accepts but editors using the schema flag
+func migrateKeyCase(raw map[string]interface{}, defaults *Config) {
+	canonicalizeKeys(raw, reflect.TypeOf(Config{}))
+}
+
+func canonicalizeKeys(object map[string]interface{}, t reflect.Type) {
+	fields := configFields(t)
+	for key, value := range object {
+		field, ok := findConfigField(fields, key)
+		if !ok {
+			continue
+		}
+		if field.name != key {
+			if _, exists := object[field.name]; !exists {
+				object[field.name] = value
+			}
+			delete(object, key)
+		}
+
+		typ := field.typ
+		for typ.Kind() == reflect.Ptr {
+			typ = typ.Elem()
+		}
+		switch {
+		case typ.Kind() == reflect.Struct:
+			if nested, ok := value.(map[string]interface{}); ok {
+				canonicalizeKeys(nested, typ)
+			}
+		case typ.Kind() == reflect.Slice && typ.Elem().Kind() == reflect.Struct:
+			if items, ok := value.([]interface{}); ok {
+				for _, item := range items {
+					if nested, ok := item.(map[string]interface{}); ok {
+						canonicalizeKeys(nested, typ.Elem())
+					}
+				}
+			}
+		}
+	}
+}
+
+// migrateDefaults writes the options that default to something other than empty, so the
+// file shows the values in use instead of relying on defaults that may change
+func migrateDefaults(raw map[string]interface{}, defaults *Config) {
+	encoded, err := json.Marshal(defaults)
+	if err != nil {
+		return
+	}
+	var values map[string]interface{}
+	if err := json.Unmarshal(encoded, &values); err != nil {
+		return
+	}
+	value := reflect.ValueOf(defaults).Elem()
+	for _, field := range configFields(value.Type()) {
+		if _, exists := raw[field.name]; exists || value.Field(field.index).IsZero() {
+			continue
+		}
+		if v, ok := values[field.name]; ok {
+			raw[field.name] = v
+		}
+	}
+}
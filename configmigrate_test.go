@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMigrateConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		check   func(t *testing.T, raw map[string]interface{})
+		changed bool
+	}{
+		{
+			name: "flat naming masks",
+			in:   `{"file_mask": "{title}", "NamingMasks": {"album_folder_mask": "{album}"}}`,
+			check: func(t *testing.T, raw map[string]interface{}) {
+				naming, _ := raw["naming"].(map[string]interface{})
+				if naming["file_mask"] != "{title}" || naming["album_folder_mask"] != "{album}" {
+					t.Errorf("naming = %v, want both masks moved", raw["naming"])
+				}
+				if _, ok := raw["file_mask"]; ok {
+					t.Errorf("flat file_mask left in place")
+				}
+			},
+			changed: true,
+		},
+		{
+			name: "key case",
+			in:   `{"saveAlbumArt": true}`,
+			check: func(t *testing.T, raw map[string]interface{}) {
+				if raw["SaveAlbumArt"] != true {
+					t.Errorf("SaveAlbumArt = %v, want the key renamed", raw["SaveAlbumArt"])
+				}
+			},
+			changed: true,
+		},
+		{
+			name: "defaults",
+			in:   `{}`,
+			check: func(t *testing.T, raw map[string]interface{}) {
+				if raw["Parallelism"] != float64(5) {
+					t.Errorf("Parallelism = %v, want the default written", raw["Parallelism"])
+				}
+			},
+			changed: true,
+		},
+		{name: "current version", in: `{"config_version": 3}`, changed: false},
+		{name: "newer version", in: `{"config_version": 99}`, changed: false},
+		{name: "not JSON", in: `{"Parallelism": `, changed: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.json")
+			os.WriteFile(path, []byte(tt.in), 0600)
+
+			out, err := MigrateConfig(path, []byte(tt.in), &Config{Parallelism: 5})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !tt.changed {
+				if string(out) != tt.in {
+					t.Errorf("config changed to %s", out)
+				}
+				return
+			}
+			var raw map[string]interface{}
+			if err := json.Unmarshal(out, &raw); err != nil {
+				t.Fatal(err)
+			}
+			if raw["config_version"] != float64(currentConfigVersion) {
+				t.Errorf("config_version = %v, want %d", raw["config_version"], currentConfigVersion)
+			}
+			if backup, err := os.ReadFile(path + ".v0.bak"); err != nil || string(backup) != tt.in {
+				t.Errorf("backup = %q (%v), want the original file", backup, err)
+			}
+			tt.check(t, raw)
+		})
+	}
+}
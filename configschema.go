@@ -0,0 +1,380 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// configSchemaID is the $schema of the exported schema
+const configSchemaID = "http://json-schema.org/draft-07/schema#"
+
+// configEnums lists the allowed values of config keys that take a fixed set of strings,
+// keyed by their path as reported in validation messages
+var configEnums = map[string][]string{
+	"WarningBehavior":          {"immediate", "summary", "silent"},
+	"ColorTheme":               {"default", "high-contrast", "none"},
+	"IPVersion":                {"", "4", "6", "auto"},
+	"Format":                   {"flac", "mp3", "ogg", "opus", "aac", "alac"},
+	"CompilationExpansion":     {"", CompilationKeep, CompilationOriginal},
+	"OutputTargets[].type":     {"sftp", "webdav", "s3"},
+	"notifications[].type":     {"telegram", "discord", "webhook", "gotify"},
+	"notifications[].events[]": {EventAlbumComplete, EventBatchComplete, EventFailure},
+}
+
+// ConfigIssue is a problem found in config.json, with the position of the offending key
+// or value
+type ConfigIssue struct {
+	Line    int
+	Column  int
+	Path    string
+	Message string
+}
+
+func (i ConfigIssue) String() string {
+	return fmt.Sprintf("line %d, column %d: %s: %s", i.Line, i.Column, i.Path, i.Message)
+}
+
+// ConfigSchema returns a JSON Schema of config.json, generated from the Config type so it
+// always matches what the downloader reads
+func ConfigSchema() map[string]interface{} {
+	schema := typeSchema(reflect.TypeOf(Config{}), "")
+	schema["$schema"] = configSchemaID
+	schema["title"] = "dab-downloader configuration"
+	// Editors look up the schema through this key, so it must be allowed
+	schema["properties"].(map[string]interface{})["$schema"] = map[string]interface{}{"type": "string"}
+	return schema
+}
+
+// typeSchema describes a Go type the way encoding/json reads it
+func typeSchema(t reflect.Type, path string) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	schema := map[string]interface{}{}
+	switch t.Kind() {
+	case reflect.Struct:
+		properties := map[string]interface{}{}
+		for _, field := range configFields(t) {
+			properties[field.name] = typeSchema(field.typ, joinConfigPath(path, field.name))
+		}
+		schema["type"] = "object"
+		schema["properties"] = properties
+		schema["additionalProperties"] = false
+	case reflect.Map:
+		schema["type"] = "object"
+		schema["additionalProperties"] = typeSchema(t.Elem(), path+"{}")
+	case reflect.Slice, reflect.Array:
+		schema["type"] = "array"
+		schema["items"] = typeSchema(t.Elem(), path+"[]")
+	case reflect.String:
+		schema["type"] = "string"
+		if values := configEnums[path]; len(values) > 0 {
+			schema["enum"] = values
+		}
+	case reflect.Bool:
+		schema["type"] = "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		schema["type"] = "integer"
+	case reflect.Float32, reflect.Float64:
+		schema["type"] = "number"
+	}
+	return schema
+}
+
+// configField is a key of a JSON object read into a struct
+type configField struct {
+	name  string
+	typ   reflect.Type
+	index int // Index of the struct field
+}
+
+// configFields lists the JSON keys of a struct, honoring json tags
+func configFields(t reflect.Type) []configField {
+	var fields []configField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		name := field.Name
+		if tag := field.Tag.Get("json"); tag != "" {
+			tagName := strings.Split(tag, ",")[0]
+			if tagName == "-" {
+				continue
+			}
+			if tagName != "" {
+				name = tagName
+			}
+		}
+		fields = append(fields, configField{name: name, typ: field.Type, index: i})
+	}
+	return fields
+}
+
+func joinConfigPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// ValidateConfig checks config.json against the Config type. Unknown keys, which
+// encoding/json silently ignores, are returned as warnings; values of the wrong type and
+// invalid JSON are errors, since the config can't be loaded with them.
+func ValidateConfig(data []byte) (warnings []ConfigIssue, errs []ConfigIssue) {
+	v := &configValidator{data: data, dec: json.NewDecoder(bytes.NewReader(data))}
+	v.dec.UseNumber()
+	if err := v.value(reflect.TypeOf(Config{}), "", true); err != nil {
+		offset := v.dec.InputOffset()
+		if syntaxErr, ok := err.(*json.SyntaxError); ok {
+			offset = syntaxErr.Offset
+		}
+		line, column := v.position(offset)
+		v.errs = append(v.errs, ConfigIssue{Line: line, Column: column, Path: "(file)", Message: strings.TrimPrefix(err.Error(), "json: ")})
+	}
+	return v.warnings, v.errs
+}
+
+// configValidator walks the tokens of config.json alongside the Config type
+type configValidator struct {
+	data     []byte
+	dec      *json.Decoder
+	warnings []ConfigIssue
+	errs     []ConfigIssue
+}
+
+// position converts a byte offset to a 1-based line and column
+func (v *configValidator) position(offset int64) (int, int) {
+	if offset > int64(len(v.data)) {
+		offset = int64(len(v.data))
+	}
+	before := v.data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := int(offset) - bytes.LastIndexByte(before, '\n')
+	return line, column
+}
+
+// issue records a problem with the token that ends at the current offset
+func (v *configValidator) issue(list *[]ConfigIssue, length int, path, format string, args ...interface{}) {
+	line, column := v.position(v.dec.InputOffset() - int64(length))
+	if path == "" {
+		path = "(root)"
+	}
+	*list = append(*list, ConfigIssue{Line: line, Column: column, Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// value checks the next JSON value against t. root allows the $schema key.
+func (v *configValidator) value(t reflect.Type, path string, root bool) error {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	tok, err := v.dec.Token()
+	if err == io.EOF {
+		return fmt.Errorf("unexpected end of file")
+	}
+	if err != nil {
+		return err
+	}
+	if tok == nil {
+		return nil
+	}
+	length := tokenLength(tok)
+
+	switch t.Kind() {
+	case reflect.Struct, reflect.Map:
+		if tok != json.Delim('{') {
+			v.issue(&v.errs, length, path, "expected an object, got %s", describeToken(tok))
+			return v.skip(tok)
+		}
+		return v.object(t, path, root)
+	case reflect.Slice, reflect.Array:
+		if tok != json.Delim('[') {
+			v.issue(&v.errs, length, path, "expected an array, got %s", describeToken(tok))
+			return v.skip(tok)
+		}
+		for v.dec.More() {
+			if err := v.value(t.Elem(), path+"[]", false); err != nil {
+				return err
+			}
+		}
+		_, err := v.dec.Token()
+		return err
+	case reflect.Interface:
+		return v.skip(tok)
+	}
+
+	expected := ""
+	switch t.Kind() {
+	case reflect.String:
+		s, ok := tok.(string)
+		if !ok {
+			expected = "a string"
+		} else if values := configEnums[path]; len(values) > 0 && !containsString(values, s) {
+			v.issue(&v.warnings, length, path, "unknown value %q, expected one of %s", s, strings.Join(quoteAll(values), ", "))
+		}
+	case reflect.Bool:
+		if _, ok := tok.(bool); !ok {
+			expected = "true or false"
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if n, ok := tok.(json.Number); !ok {
+			expected = "a whole number"
+		} else if _, err := n.Int64(); err != nil {
+			expected = "a whole number"
+		}
+	case reflect.Float32, reflect.Float64:
+		if _, ok := tok.(json.Number); !ok {
+			expected = "a number"
+		}
+	}
+	if expected != "" {
+		v.issue(&v.errs, length, path, "expected %s, got %s", expected, describeToken(tok))
+		return v.skip(tok)
+	}
+	return nil
+}
+
+// object checks the keys and values of an object whose '{' was just read
+func (v *configValidator) object(t reflect.Type, path string, root bool) error {
+	var fields []configField
+	if t.Kind() == reflect.Struct {
+		fields = configFields(t)
+	}
+	for v.dec.More() {
+		tok, err := v.dec.Token()
+		if err != nil {
+			return err
+		}
+		key := tok.(string)
+
+		if t.Kind() == reflect.Map {
+			if err := v.value(t.Elem(), joinConfigPath(path, key), false); err != nil {
+				return err
+			}
+			continue
+		}
+
+		field, known := findConfigField(fields, key)
+		if !known {
+			if !(root && key == "$schema") {
+				message := "unknown key, it is ignored"
+				if suggestion := suggestConfigKey(fields, key); suggestion != "" {
+					message = fmt.Sprintf("unknown key, did you mean %q?", suggestion)
+				}
+				v.issue(&v.warnings, tokenLength(key), joinConfigPath(path, key), "%s", message)
+			}
+			var skipped json.RawMessage
+			if err := v.dec.Decode(&skipped); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := v.value(field.typ, joinConfigPath(path, field.name), false); err != nil {
+			return err
+		}
+	}
+	_, err := v.dec.Token()
+	return err
+}
+
+// skip consumes the rest of a value whose first token was tok
+func (v *configValidator) skip(tok json.Token) error {
+	if tok != json.Delim('{') && tok != json.Delim('[') {
+		return nil
+	}
+	for depth := 1; depth > 0; {
+		tok, err := v.dec.Token()
+		if err != nil {
+			return err
+		}
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+	}
+	return nil
+}
+
+// findConfigField matches a key like encoding/json does: exactly, then ignoring case
+func findConfigField(fields []configField, key string) (configField, bool) {
+	for _, field := range fields {
+		if field.name == key {
+			return field, true
+		}
+	}
+	for _, field := range fields {
+		if strings.EqualFold(field.name, key) {
+			return field, true
+		}
+	}
+	return configField{}, false
+}
+
+// suggestConfigKey returns the known key closest to a misspelled one, empty if none is close
+func suggestConfigKey(fields []configField, key string) string {
+	best, bestDistance := "", 0
+	for _, field := range fields {
+		distance := levenshtein([]rune(strings.ToLower(key)), []rune(strings.ToLower(field.name)))
+		if best == "" || distance < bestDistance {
+			best, bestDistance = field.name, distance
+		}
+	}
+	if best == "" || bestDistance > max(2, len(key)/4) {
+		return ""
+	}
+	return best
+}
+
+// tokenLength is the length of a token as written in the file, used to point at its start
+func tokenLength(tok json.Token) int {
+	switch t := tok.(type) {
+	case json.Delim:
+		return 1
+	case json.Number:
+		return len(t)
+	}
+	encoded, _ := json.Marshal(tok)
+	return len(encoded)
+}
+
+func describeToken(tok json.Token) string {
+	switch t := tok.(type) {
+	case json.Delim:
+		if t == '{' {
+			return "an object"
+		}
+		return "an array"
+	case string:
+		return fmt.Sprintf("the string %q", t)
+	case json.Number:
+		return "the number " + t.String()
+	case bool:
+		return fmt.Sprintf("%t", t)
+	}
+	return fmt.Sprintf("%v", tok)
+}
+
+func containsString(values []string, s string) bool {
+	for _, value := range values {
+		if value == s {
+			return true
+		}
+	}
+	return false
+}
+
+func quoteAll(values []string) []string {
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		quoted[i] = fmt.Sprintf("%q", value)
+	}
+	return quoted
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"regexp"
+)
+
+// correlationIDKey is the context key of the correlation ID of a job
+type correlationIDKey struct{}
+
+// runCorrelationID identifies this run of the downloader in logs, events and reports,
+// for everything that isn't part of a job with an ID of its own
+var runCorrelationID = newCorrelationID()
+
+// validCorrelationID is what a correlation ID given by a REST API client may look like
+var validCorrelationID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// newCorrelationID returns a short random ID, e.g. "3f9a1c07"
+func newCorrelationID() string {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return "00000000"
+	}
+	return hex.EncodeToString(b)
+}
+
+// withCorrelationID makes downloads made with ctx report the given ID. An empty ID keeps
+// the one ctx already has.
+func withCorrelationID(ctx context.Context, id string) context.Context {
+	if id == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, correlationIDKey{}, id)
+}
+
+// correlationIDFrom returns the correlation ID of the job ctx belongs to, the ID of the
+// run when it belongs to none
+func correlationIDFrom(ctx context.Context) string {
+	if ctx != nil {
+		if id, ok := ctx.Value(correlationIDKey{}).(string); ok {
+			return id
+		}
+	}
+	return runCorrelationID
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+const (
+	crashReportDir    = "config/crashes"
+	crashOutputLines  = 200     // Lines of recent output kept for crash reports
+	crashMaxStackSize = 1 << 20 // Stack traces of every goroutine are cut off here
+	crashExitCode     = 2
+)
+
+// crashConfig is the loaded config, included sanitized in crash reports
+var crashConfig *Config
+
+var (
+	crashOnce      sync.Once
+	recentOutput   = &outputTail{max: crashOutputLines}
+	ansiEscape     = regexp.MustCompile(`\x1b\[[0-9;]*[A-Za-z]`)
+	secretKeyParts = []string{"token", "secret", "password", "cookie", "key", "headers", "users"}
+	secretFlags    = []string{"--api-token", "--token", "--header", "--spotify-client-secret", "--navidrome-password"}
+)
+
+// handlePanic turns a panic into a crash report. Deferred at the start of main and of every
+// worker goroutine, since a panic can only be recovered in the goroutine it happens in.
+func handlePanic() {
+	r := recover()
+	if r == nil {
+		return
+	}
+	crashOnce.Do(func() {
+		stack := make([]byte, crashMaxStackSize)
+		stack = stack[:runtime.Stack(stack, true)]
+		path, err := writeCrashReport(r, stack)
+		fmt.Fprintf(os.Stderr, "\n💥 dab-downloader crashed: %v\n", r)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to write a crash report (%v), the stack trace follows:\n\n%s\n", err, stack)
+		} else {
+			repo := "PrathxmOp/dab-downloader"
+			if crashConfig != nil && crashConfig.UpdateRepo != "" {
+				repo = crashConfig.UpdateRepo
+			}
+			fmt.Fprintf(os.Stderr, "A crash report was saved to %s\n", path)
+			fmt.Fprintf(os.Stderr, "Please open an issue at https://github.com/%s/issues/new and attach it.\n", repo)
+			fmt.Fprintln(os.Stderr, "Secrets in the config are removed, but check the file before sharing it.")
+		}
+	})
+	os.Exit(crashExitCode)
+}
+
+// writeCrashReport saves the panic, the stacks of all goroutines, the sanitized config,
+// the recent output and the queue and job state, and returns the path of the report
+func writeCrashReport(panicValue interface{}, stack []byte) (string, error) {
+	var report bytes.Buffer
+	section := func(title string) {
+		fmt.Fprintf(&report, "\n===== %s =====\n", title)
+	}
+
+	fmt.Fprintf(&report, "dab-downloader crash report, %s\n", time.Now().Format(time.RFC3339))
+	fmt.Fprintf(&report, "Command: %s\n", strings.Join(sanitizeArgs(os.Args), " "))
+	fmt.Fprintf(&report, "Correlation ID: %s\n", runCorrelationID)
+	fmt.Fprintf(&report, "Panic: %v\n", panicValue)
+
+	section("Build")
+	if info, err := json.MarshalIndent(GetBuildInfo(), "", "  "); err == nil {
+		report.Write(info)
+		report.WriteString("\n")
+	}
+
+	section("Stack trace")
+	report.Write(stack)
+
+	section("Config (secrets removed)")
+	if crashConfig == nil {
+		report.WriteString("(not loaded)\n")
+	} else if config, err := sanitizedConfig(crashConfig); err != nil {
+		fmt.Fprintf(&report, "(failed to encode: %v)\n", err)
+	} else {
+		report.Write(config)
+		report.WriteString("\n")
+	}
+
+	section(fmt.Sprintf("Recent output (last %d lines)", crashOutputLines))
+	report.WriteString(recentOutput.String())
+
+	for _, store := range []string{downloadQueue.path, filepath.Join("config", "jobs.json")} {
+		section(store)
+		data, err := os.ReadFile(store)
+		if err != nil {
+			fmt.Fprintf(&report, "(%v)\n", err)
+			continue
+		}
+		report.Write(data)
+		report.WriteString("\n")
+	}
+
+	if err := os.MkdirAll(crashReportDir, 0755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(crashReportDir, fmt.Sprintf("crash-%s-%s.txt", time.Now().Format("20060102-150405"), runCorrelationID))
+	// Only the owner may read it, the output may still contain personal paths
+	if err := os.WriteFile(path, report.Bytes(), 0600); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// sanitizedConfig encodes the config with tokens, passwords, cookies, keys and custom
+// headers replaced, and credentials removed from URLs
+func sanitizedConfig(config *Config) ([]byte, error) {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	var raw interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(redactSecrets(raw, ""), "", "  ")
+}
+
+func redactSecrets(value interface{}, key string) interface{} {
+	lower := strings.ToLower(key)
+	for _, part := range secretKeyParts {
+		if strings.Contains(lower, part) && !isEmptyValue(value) {
+			return "REDACTED"
+		}
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for k, item := range v {
+			v[k] = redactSecrets(item, k)
+		}
+	case []interface{}:
+		for i, item := range v {
+			// The URL of a Discord or webhook target is its secret
+			if target, ok := item.(map[string]interface{}); ok && lower == "notifications" {
+				if u, ok := target["url"].(string); ok && u != "" {
+					target["url"] = maskNotificationURL(u)
+				}
+			}
+			v[i] = redactSecrets(item, "")
+		}
+	case string:
+		return stripURLCredentials(v)
+	}
+	return value
+}
+
+// stripURLCredentials removes the user and password from a URL, e.g. of a proxy. Other
+// strings are returned unchanged.
+func stripURLCredentials(value string) string {
+	if !strings.Contains(value, "@") {
+		return value
+	}
+	if u, err := url.Parse(value); err == nil && u.User != nil && u.Host != "" {
+		u.User = nil
+		return u.String()
+	}
+	return value
+}
+
+func isEmptyValue(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case map[string]interface{}:
+		return len(v) == 0
+	}
+	return false
+}
+
+// sanitizeArgs hides the values of flags that take secrets and credentials in URLs
+func sanitizeArgs(args []string) []string {
+	sanitized := make([]string, len(args))
+	for i, arg := range args {
+		if name, value, ok := strings.Cut(arg, "="); ok && strings.HasPrefix(name, "-") {
+			sanitized[i] = name + "=" + stripURLCredentials(value)
+		} else {
+			sanitized[i] = stripURLCredentials(arg)
+		}
+	}
+	for i, arg := range sanitized {
+		for _, flag := range secretFlags {
+			if arg == flag && i+1 < len(sanitized) {
+				sanitized[i+1] = "REDACTED"
+			} else if strings.HasPrefix(arg, flag+"=") {
+				sanitized[i] = flag + "=REDACTED"
+			}
+		}
+	}
+	return sanitized
+}
+
+// captureRecentOutput keeps the last lines of colored output for crash reports
+func captureRecentOutput() {
+	if _, ok := color.Output.(*teeWriter); !ok {
+		color.Output = &teeWriter{w: color.Output, tail: recentOutput}
+	}
+	if _, ok := color.Error.(*teeWriter); !ok {
+		color.Error = &teeWriter{w: color.Error, tail: recentOutput}
+	}
+}
+
+// teeWriter copies everything written to w into tail
+type teeWriter struct {
+	w    io.Writer
+	tail *outputTail
+}
+
+func (t *teeWriter) Write(p []byte) (int, error) {
+	t.tail.Write(p)
+	return t.w.Write(p)
+}
+
+// outputTail keeps the last max lines written to it
+type outputTail struct {
+	mu      sync.Mutex
+	max     int
+	lines   []string
+	partial string
+}
+
+func (o *outputTail) Write(p []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	text := o.partial + ansiEscape.ReplaceAllString(string(p), "")
+	parts := strings.Split(text, "\n")
+	o.partial = parts[len(parts)-1]
+	o.lines = append(o.lines, parts[:len(parts)-1]...)
+	if len(o.lines) > o.max {
+		o.lines = o.lines[len(o.lines)-o.max:]
+	}
+	return len(p), nil
+}
+
+func (o *outputTail) String() string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	text := strings.Join(o.lines, "\n")
+	if o.partial != "" {
+		text += "\n" + o.partial
+	}
+	return text + "\n"
+}
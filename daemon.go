@@ -0,0 +1,308 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	daemonDialTimeout      = 500 * time.Millisecond
+	daemonSearchCacheHours = 1 // Search cache of the daemon when SearchCacheHours is not set
+)
+
+// daemonSocketPath is where the daemon listens for the other dab-downloader processes
+var daemonSocketPath = filepath.Join("config", "daemon.sock")
+
+// daemonWake wakes the download loop of the daemon when items are queued
+var daemonWake = make(chan struct{}, 1)
+
+// wakeDaemon makes a waiting daemon check the queue right away
+func wakeDaemon() {
+	select {
+	case daemonWake <- struct{}{}:
+	default:
+	}
+}
+
+// noDaemon makes commands run on their own even when a daemon is running, set by --no-daemon
+var noDaemon bool
+
+// DaemonStatus describes a running daemon
+type DaemonStatus struct {
+	PID       int       `json:"pid"`
+	StartedAt time.Time `json:"started_at"`
+	Endpoint  string    `json:"endpoint"`
+	Pending   int       `json:"pending"`
+	Running   int       `json:"running"`
+	// Download throughput of the last minutes, in bytes per second
+	Throughput      []float64 `json:"throughput"` // One sample per 5 seconds, oldest first
+	AverageRate     float64   `json:"average_rate"`
+	PeakRate        float64   `json:"peak_rate"`
+	DownloadedBytes int64     `json:"downloaded_bytes"` // Audio downloaded since the daemon started
+}
+
+// addThroughput fills in the current download throughput
+func (s *DaemonStatus) addThroughput() {
+	s.Throughput = downloadThroughput.Rates()
+	s.AverageRate, s.PeakRate = downloadThroughput.Summary()
+	s.DownloadedBytes = downloadThroughput.Total()
+}
+
+// daemonEnqueueRequest adds items to the queue of the daemon
+type daemonEnqueueRequest struct {
+	Type    string        `json:"type"`
+	IDs     []string      `json:"ids"`
+	Format  string        `json:"format,omitempty"`
+	Bitrate string        `json:"bitrate,omitempty"`
+	Options *QueueOptions `json:"options,omitempty"`
+}
+
+// daemonQueueRequest pauses, resumes or clears the queue of the daemon
+type daemonQueueRequest struct {
+	Paused       bool `json:"paused,omitempty"`
+	FinishedOnly bool `json:"finished_only,omitempty"`
+}
+
+// daemonSearchRequest is a DAB search run by the daemon
+type daemonSearchRequest struct {
+	Query string `json:"query"`
+	Type  string `json:"type"`
+	Limit int    `json:"limit"`
+}
+
+// DaemonClient talks to a running daemon over its socket
+type DaemonClient struct {
+	client *http.Client
+}
+
+// queueDaemon returns the running daemon queue changes go through, nil if none is running
+// or --no-daemon is set
+func queueDaemon() *DaemonClient {
+	if noDaemon {
+		return nil
+	}
+	return connectDaemon()
+}
+
+// connectDaemon returns a client for the running daemon, nil if none is running
+func connectDaemon() *DaemonClient {
+	conn, err := net.DialTimeout("unix", daemonSocketPath, daemonDialTimeout)
+	if err != nil {
+		return nil
+	}
+	conn.Close()
+	return &DaemonClient{client: &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, "unix", daemonSocketPath)
+			},
+		},
+	}}
+}
+
+// call sends a request to the daemon and decodes the JSON response into out
+func (c *DaemonClient) call(ctx context.Context, method, path string, in, out interface{}) error {
+	var body bytes.Buffer
+	if in != nil {
+		if err := json.NewEncoder(&body).Encode(in); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, method, "http://daemon"+path, &body)
+	if err != nil {
+		return err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("daemon request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		var message struct {
+			Error string `json:"error"`
+		}
+		json.NewDecoder(resp.Body).Decode(&message)
+		return fmt.Errorf("daemon: %s", message.Error)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// Status returns the state of the daemon
+func (c *DaemonClient) Status(ctx context.Context) (*DaemonStatus, error) {
+	var status DaemonStatus
+	if err := c.call(ctx, http.MethodGet, "/status", nil, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// Enqueue adds albums or tracks to the queue the daemon downloads and returns how many
+// were new
+func (c *DaemonClient) Enqueue(ctx context.Context, itemType string, ids []string, format, bitrate string, options *QueueOptions) (int, error) {
+	var added int
+	err := c.call(ctx, http.MethodPost, "/enqueue", daemonEnqueueRequest{Type: itemType, IDs: ids, Format: format, Bitrate: bitrate, Options: options}, &added)
+	return added, err
+}
+
+// SetPaused pauses or resumes the queue of the daemon
+func (c *DaemonClient) SetPaused(ctx context.Context, paused bool) error {
+	var ok bool
+	return c.call(ctx, http.MethodPost, "/pause", daemonQueueRequest{Paused: paused}, &ok)
+}
+
+// Clear removes items from the queue of the daemon and returns how many were removed
+func (c *DaemonClient) Clear(ctx context.Context, finishedOnly bool) (int, error) {
+	var removed int
+	err := c.call(ctx, http.MethodPost, "/clear", daemonQueueRequest{FinishedOnly: finishedOnly}, &removed)
+	return removed, err
+}
+
+// Search runs a DAB search in the daemon, sharing its cache and rate limit
+func (c *DaemonClient) Search(ctx context.Context, query, searchType string, limit int) (*SearchResults, error) {
+	var results SearchResults
+	if err := c.call(ctx, http.MethodPost, "/search", daemonSearchRequest{Query: query, Type: searchType, Limit: limit}, &results); err != nil {
+		return nil, err
+	}
+	return &results, nil
+}
+
+// UseDaemon sends the searches of the API client through the daemon
+func (api *DabAPI) UseDaemon(daemon *DaemonClient) {
+	api.daemon = daemon
+}
+
+// RunDaemon serves the daemon socket and downloads queued items until ctx is cancelled.
+// All processes using the daemon share its API client, so their searches go through one
+// search cache and one rate limiter, and downloads through one queue.
+func (api *DabAPI) RunDaemon(ctx context.Context, config *Config, concurrency int, debug bool) error {
+	if connectDaemon() != nil {
+		return fmt.Errorf("a daemon is already running on %s", daemonSocketPath)
+	}
+	// A socket file left by a daemon that didn't shut down cleanly blocks Listen
+	os.Remove(daemonSocketPath)
+	if err := os.MkdirAll(filepath.Dir(daemonSocketPath), 0755); err != nil {
+		return err
+	}
+	listener, err := net.Listen("unix", daemonSocketPath)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", daemonSocketPath, err)
+	}
+	defer os.Remove(daemonSocketPath)
+	// Only the owner may hand work to the daemon
+	os.Chmod(daemonSocketPath, 0600)
+
+	if api.searchCache == nil {
+		api.SetSearchCache(NewSearchCache(filepath.Join("config", "search_cache.json"), daemonSearchCacheHours*time.Hour))
+	}
+
+	status := DaemonStatus{PID: os.Getpid(), StartedAt: time.Now(), Endpoint: config.APIURL}
+	server := &http.Server{Handler: api.daemonHandler(status)}
+	go server.Serve(listener)
+	defer server.Close()
+	colorSuccess.Printf("✅ Daemon listening on %s (Ctrl+C to stop)\n", daemonSocketPath)
+	go RunScheduler(ctx)
+
+	for {
+		if downloadQueue.hasPending() {
+			if err := api.RunQueue(ctx, concurrency, config, debug); err != nil && ctx.Err() == nil {
+				colorError.Printf("❌ %v\n", err)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			colorInfo.Println("👋 Daemon stopped")
+			return nil
+		case <-daemonWake:
+		case <-time.After(queuePollInterval):
+		}
+	}
+}
+
+// daemonHandler serves the requests of other processes
+func (api *DabAPI) daemonHandler(status DaemonStatus) http.Handler {
+	reply := func(w http.ResponseWriter, value interface{}, err error) {
+		w.Header().Set("Content-Type", "application/json")
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+		json.NewEncoder(w).Encode(value)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+		current := status
+		current.addThroughput()
+		if state, err := downloadQueue.State(); err == nil {
+			for _, item := range state.Items {
+				switch item.Status {
+				case QueuePending:
+					current.Pending++
+				case QueueRunning:
+					current.Running++
+				}
+			}
+		}
+		reply(w, current, nil)
+	})
+	mux.HandleFunc("/enqueue", func(w http.ResponseWriter, r *http.Request) {
+		var req daemonEnqueueRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			reply(w, nil, err)
+			return
+		}
+		if req.Type != "album" && req.Type != "track" {
+			reply(w, nil, fmt.Errorf("unknown item type '%s'", req.Type))
+			return
+		}
+		items := make([]*QueueItem, 0, len(req.IDs))
+		for _, id := range req.IDs {
+			items = append(items, &QueueItem{Type: req.Type, Value: id, Format: req.Format, Bitrate: req.Bitrate, Options: req.Options})
+		}
+		added, err := downloadQueue.Add(items...)
+		if err == nil {
+			wakeDaemon()
+		}
+		reply(w, len(added), err)
+	})
+	mux.HandleFunc("/pause", func(w http.ResponseWriter, r *http.Request) {
+		var req daemonQueueRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			reply(w, nil, err)
+			return
+		}
+		err := downloadQueue.SetPaused(req.Paused)
+		if err == nil && !req.Paused {
+			wakeDaemon()
+		}
+		reply(w, err == nil, err)
+	})
+	mux.HandleFunc("/clear", func(w http.ResponseWriter, r *http.Request) {
+		var req daemonQueueRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			reply(w, nil, err)
+			return
+		}
+		removed, err := downloadQueue.Clear(req.FinishedOnly)
+		reply(w, removed, err)
+	})
+	mux.HandleFunc("/search", func(w http.ResponseWriter, r *http.Request) {
+		var req daemonSearchRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			reply(w, nil, err)
+			return
+		}
+		results, err := api.Search(r.Context(), req.Query, req.Type, req.Limit, debug)
+		reply(w, results, err)
+	})
+	return mux
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+)
+
+const deezerAPIURL = "https://api.deezer.com"
+
+// deezerURLPattern matches the resource type and ID of Deezer web and app URLs,
+// e.g. https://www.deezer.com/en/playlist/1234567
+var deezerURLPattern = regexp.MustCompile(`deezer\.com/(?:[a-z]{2}/)?(playlist|album)/(\d+)`)
+
+// DeezerClient reads public playlists and albums from the Deezer API, no account needed
+type DeezerClient struct {
+	client *http.Client
+}
+
+// NewDeezerClient creates a new Deezer client
+func NewDeezerClient(client *http.Client) *DeezerClient {
+	return &DeezerClient{client: client}
+}
+
+// deezerTrack is a track as returned by the Deezer API
+type deezerTrack struct {
+	ID       int64  `json:"id"`
+	Title    string `json:"title"`
+	Duration int    `json:"duration"` // Seconds
+	Artist   struct {
+		Name string `json:"name"`
+	} `json:"artist"`
+	Album struct {
+		Title string `json:"title"`
+	} `json:"album"`
+}
+
+// deezerTrackPage is one page of a track list, Next is empty on the last page
+type deezerTrackPage struct {
+	Data []deezerTrack `json:"data"`
+	Next string        `json:"next"`
+}
+
+// ParseDeezerURL returns the resource type ("playlist" or "album") and ID of a Deezer URL
+func ParseDeezerURL(deezerURL string) (string, string, error) {
+	match := deezerURLPattern.FindStringSubmatch(deezerURL)
+	if match == nil {
+		return "", "", fmt.Errorf("invalid Deezer URL")
+	}
+	return match[1], match[2], nil
+}
+
+// GetPlaylistTracks gets the tracks from a Deezer playlist
+func (d *DeezerClient) GetPlaylistTracks(ctx context.Context, playlistID string) ([]SpotifyTrack, string, error) {
+	var playlist struct {
+		Title string `json:"title"`
+	}
+	if err := d.get(ctx, fmt.Sprintf("%s/playlist/%s", deezerAPIURL, playlistID), &playlist); err != nil {
+		return nil, "", err
+	}
+	log.Printf("Deezer Playlist Name: %s", playlist.Title)
+
+	tracks, err := d.getTracks(ctx, fmt.Sprintf("%s/playlist/%s/tracks", deezerAPIURL, playlistID), "")
+	return tracks, playlist.Title, err
+}
+
+// GetAlbumTracks gets the tracks from a Deezer album
+func (d *DeezerClient) GetAlbumTracks(ctx context.Context, albumID string) ([]SpotifyTrack, string, error) {
+	var album struct {
+		Title  string `json:"title"`
+		Artist struct {
+			Name string `json:"name"`
+		} `json:"artist"`
+	}
+	if err := d.get(ctx, fmt.Sprintf("%s/album/%s", deezerAPIURL, albumID), &album); err != nil {
+		return nil, "", err
+	}
+	log.Printf("Deezer Album Name: %s", album.Title)
+
+	tracks, err := d.getTracks(ctx, fmt.Sprintf("%s/album/%s/tracks", deezerAPIURL, albumID), album.Artist.Name)
+	for i := range tracks {
+		tracks[i].AlbumName = album.Title
+	}
+	return tracks, album.Title, err
+}
+
+// getTracks follows the pages of a track list. albumArtist is used for every track when
+// set, otherwise each track's own artist is used.
+func (d *DeezerClient) getTracks(ctx context.Context, pageURL, albumArtist string) ([]SpotifyTrack, error) {
+	var tracks []SpotifyTrack
+	for pageURL != "" {
+		var page deezerTrackPage
+		if err := d.get(ctx, pageURL, &page); err != nil {
+			return nil, err
+		}
+		for _, track := range page.Data {
+			artist := albumArtist
+			if artist == "" {
+				artist = track.Artist.Name
+			}
+			tracks = append(tracks, SpotifyTrack{
+				ID:          fmt.Sprintf("%d", track.ID),
+				Source:      "deezer",
+				Name:        track.Title,
+				Artist:      track.Artist.Name,
+				AlbumName:   track.Album.Title,
+				AlbumArtist: artist,
+				DurationSec: track.Duration,
+			})
+		}
+		pageURL = page.Next
+	}
+	return tracks, nil
+}
+
+// get fetches a Deezer API URL into v. Deezer reports errors in the body with status 200.
+func (d *DeezerClient) get(ctx context.Context, apiURL string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Deezer API returned status %d", resp.StatusCode)
+	}
+
+	var body json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return fmt.Errorf("failed to decode Deezer response: %w", err)
+	}
+	var apiErr struct {
+		Error *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(body, &apiErr) == nil && apiErr.Error != nil {
+		return fmt.Errorf("Deezer API error: %s", apiErr.Error.Message)
+	}
+	return json.Unmarshal(body, v)
+}
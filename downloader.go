@@ -4,10 +4,13 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/cheggaaa/pb/v3"
 	"github.com/go-flac/go-flac"
@@ -15,15 +18,33 @@ import (
 	"golang.org/x/sync/semaphore"
 )
 
-// DownloadTrack downloads a single track with metadata
-func (api *DabAPI) DownloadTrack(ctx context.Context, track Track, album *Album, outputPath string, coverData []byte, bar *pb.ProgressBar, debug bool, format string, bitrate string, config *Config, warningCollector *WarningCollector) (string, error) {
+// partSuffix is appended to files that are still being downloaded
+const partSuffix = ".part"
+
+// contentRangeStartsAt reports whether a Content-Range header ("bytes 100-999/1000") starts at offset
+func contentRangeStartsAt(contentRange string, offset int64) bool {
+	var start, end int64
+	if _, err := fmt.Sscanf(contentRange, "bytes %d-%d/", &start, &end); err != nil {
+		return false
+	}
+	return start == offset
+}
+
+// DownloadTrack downloads a single track with metadata. It returns the final path and how
+// fast the audio was transferred.
+func (api *DabAPI) DownloadTrack(ctx context.Context, track Track, album *Album, outputPath string, coverData []byte, bar *pb.ProgressBar, debug bool, format string, bitrate string, config *Config, warningCollector *WarningCollector) (string, *TransferStats, error) {
 	// Get stream URL
 	streamURL, err := api.GetStreamURL(ctx, idToString(track.ID))
 	if err != nil {
-		return "", fmt.Errorf("failed to get stream URL: %w", err)
+		return "", nil, fmt.Errorf("failed to get stream URL: %w", err)
 	}
 
 	var expectedFileSize int64 // Store expected size for final verification
+	transfer := &TransferStats{}
+	if u, err := url.Parse(streamURL); err == nil {
+		transfer.Host = u.Host
+	}
+	downloadStart := time.Now()
 
 	// Determine retry attempts
 	maxRetries := defaultMaxRetries
@@ -31,72 +52,146 @@ func (api *DabAPI) DownloadTrack(ctx context.Context, track Track, album *Album,
 		maxRetries = config.MaxRetryAttempts
 	}
 
+	// Download to a .part file first so an interrupted download can resume where it stopped
+	partPath := outputPath + partSuffix
+
 	// Download the audio file
 	err = RetryWithBackoff(maxRetries, 5, func() error {
-		audioResp, err := api.Request(ctx, streamURL, false, nil)
+		// Resume from whatever a previous attempt (or run) left behind
+		var offset int64
+		if info, err := os.Stat(partPath); err == nil {
+			offset = info.Size()
+		}
+
+		releaseThrottle, err := downloadThrottle.Acquire(ctx)
+		defer releaseThrottle()
+		if err != nil {
+			return err
+		}
+		release, err := streamLimit.acquire(ctx)
+		defer release()
+		if err != nil {
+			return err
+		}
+
+		audioResp, err := api.RequestRange(ctx, streamURL, offset)
 		if err != nil {
 			return fmt.Errorf("failed to download audio: %w", err)
 		}
 		defer audioResp.Body.Close()
 
-		expectedSize := audioResp.ContentLength
+		switch audioResp.StatusCode {
+		case http.StatusRequestedRangeNotSatisfiable:
+			// The partial file doesn't match the stream any more, start over
+			os.Remove(partPath)
+			return fmt.Errorf("cannot resume %s, restarting download", track.Title)
+		case http.StatusPartialContent:
+			if !contentRangeStartsAt(audioResp.Header.Get("Content-Range"), offset) {
+				os.Remove(partPath)
+				return fmt.Errorf("unexpected Content-Range %q, restarting download", audioResp.Header.Get("Content-Range"))
+			}
+			if debug {
+				fmt.Printf("DEBUG: Resuming %s at %d bytes\n", track.Title, offset)
+			}
+		default:
+			offset = 0 // The server sent the whole file
+		}
+
+		expectedSize := int64(-1)
+		if audioResp.ContentLength >= 0 {
+			expectedSize = offset + audioResp.ContentLength
+		}
 		expectedFileSize = expectedSize // Store for final verification
 		if debug && expectedSize > 0 {
 			fmt.Printf("DEBUG: Expected file size for %s: %d bytes\n", track.Title, expectedSize)
 		}
 
+		// Record throughput for the session graph, after the bandwidth limit
+		audioResp.Body = newBandwidthReader(ctx, audioResp.Body)
+		audioResp.Body = &throughputReader{ReadCloser: audioResp.Body, tracker: downloadThroughput}
+		if run := queueItemRunFrom(ctx); run != nil {
+			audioResp.Body = newQueueProgressReader(audioResp.Body, run, track, offset, expectedSize)
+		}
+
 		// Wrap the response body in the progress bar reader
 		if bar != nil {
 			if debug {
 				fmt.Println("DEBUG: Starting progress bar for", track.Title)
 			}
-			if audioResp.ContentLength <= 0 {
+			if expectedSize <= 0 {
 				bar.Set("indeterminate", true) // Force spinner for unknown size
 			} else {
-				bar.SetTotal(audioResp.ContentLength)
+				bar.SetTotal(expectedSize)
+				bar.SetCurrent(offset)
 			}
 			audioResp.Body = bar.NewProxyReader(audioResp.Body)
+		} else if !progressBarsEnabled() {
+			// No terminal to draw bars on, report progress as plain lines instead
+			audioResp.Body = newLineProgressReader(audioResp.Body, track.Title, offset, expectedSize)
 		}
 
 		// Create directory if needed
-		if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		if err := withFSRetry(config, func() error { return os.MkdirAll(filepath.Dir(outputPath), 0755) }); err != nil {
 			return fmt.Errorf("failed to create directory: %w", err)
 		}
 
-		// Create and write to the output file
-		out, err := os.Create(outputPath)
-		if err != nil {
+		// Append to the partial file when resuming, otherwise start a new one
+		flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+		if offset > 0 {
+			flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+		}
+		var out *os.File
+		if err := withFSRetry(config, func() error {
+			var createErr error
+			out, createErr = os.OpenFile(partPath, flags, 0644)
+			return createErr
+		}); err != nil {
 			return fmt.Errorf("failed to create output file: %w", err)
 		}
 		defer out.Close()
+		if offset == 0 && expectedSize > 0 && config != nil && config.PreallocateFiles {
+			preallocateFile(out, expectedSize)
+		}
 
-		bytesWritten, err := io.Copy(out, audioResp.Body)
+		buf := make([]byte, copyBufferSize(config, expectedSize))
+		bytesWritten, err := io.CopyBuffer(writerOnly{out}, audioResp.Body, buf)
+		transfer.Bytes += bytesWritten
 		if err != nil {
-			// Clean up the file on error to prevent partial files
-			os.Remove(outputPath)
+			// Keep the partial file, the next attempt resumes from it
+			syncFile(out, config)
 			return fmt.Errorf("failed to write audio file: %w", err)
 		}
 
+		// Make sure the data has reached the share before verifying it
+		if err := syncFile(out, config); err != nil {
+			return err
+		}
+
 		// Verify file size if ContentLength is available
-		if expectedSize > 0 && bytesWritten != expectedSize {
-			// Clean up the incomplete file
-			os.Remove(outputPath)
+		totalSize := offset + bytesWritten
+		if expectedSize > 0 && totalSize != expectedSize {
+			if totalSize > expectedSize {
+				// Corrupt partial file, move it out of the way and start over
+				RemoveFile(ctx, partPath, config)
+			}
 			if debug {
 				fmt.Printf("DEBUG: File size mismatch for %s - expected: %d, got: %d bytes\n", 
-					track.Title, expectedSize, bytesWritten)
+					track.Title, expectedSize, totalSize)
 			}
-			return fmt.Errorf("incomplete download: expected %d bytes, got %d bytes", expectedSize, bytesWritten)
+			return fmt.Errorf("incomplete download: expected %d bytes, got %d bytes", expectedSize, totalSize)
 		}
 
 		if debug && expectedSize > 0 {
-			fmt.Printf("DEBUG: Successfully downloaded %s - %d bytes verified\n", track.Title, bytesWritten)
+			fmt.Printf("DEBUG: Successfully downloaded %s - %d bytes verified\n", track.Title, totalSize)
 		}
 
-		return nil
+		out.Close()
+		return withFSRetry(config, func() error { return moveFile(partPath, outputPath, config) })
 	})
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
+	transfer.Duration = time.Since(downloadStart)
 
 	// Final verification: check if the file exists and has the correct size
 	// This catches any issues that might occur after the download completes
@@ -104,57 +199,79 @@ func (api *DabAPI) DownloadTrack(ctx context.Context, track Track, album *Album,
 		// Only verify if verification is enabled (default true if not specified)
 		verifyEnabled := config == nil || config.VerifyDownloads // Default to true
 		if verifyEnabled && expectedFileSize > 0 {
-			if verifyErr := VerifyFileIntegrity(outputPath, expectedFileSize, debug); verifyErr != nil {
-				// Remove the corrupted file and return error
-				os.Remove(outputPath)
-				return "", fmt.Errorf("post-download verification failed: %w", verifyErr)
+			// Network mounts may report a stale size for a moment after writing
+			verifyErr := withFSRetry(config, func() error { return VerifyFileIntegrity(outputPath, expectedFileSize, debug) })
+			if verifyErr != nil {
+				// Quarantine the corrupted file and return error
+				RemoveFile(ctx, outputPath, config)
+				return "", nil, fmt.Errorf("post-download verification failed: %w", verifyErr)
 			}
 		}
 	} else {
-		return "", fmt.Errorf("download completed but file not found on disk: %s", outputPath)
+		return "", nil, fmt.Errorf("download completed but file not found on disk: %s", outputPath)
 	}
 
 	// Add metadata to the downloaded file
 	err = AddMetadataWithDebug(outputPath, track, album, coverData, len(album.Tracks), warningCollector, debug)
 	if err != nil {
-		return "", fmt.Errorf("failed to add metadata: %w", err)
+		return "", nil, fmt.Errorf("failed to add metadata: %w", err)
 	}
 
 	finalPath := outputPath
 	if format != "flac" {
-		colorInfo.Printf("🎵 Compressing to %s with bitrate %s kbps...\n", format, bitrate)
-		convertedFile, err := ConvertTrack(outputPath, format, bitrate)
-		if err != nil {
-			return "", fmt.Errorf("failed to convert track: %w", err)
+		colorInfo.Printf("🎵 Converting to %s...\n", describeEncoding(format, bitrate, config.Encoders[format]))
+		convertedFile := convertedTarget(config, outputPath, format)
+		if err := os.MkdirAll(filepath.Dir(convertedFile), 0755); err != nil {
+			return "", nil, fmt.Errorf("failed to create directory for converted track: %w", err)
 		}
-		// Conversion successful, remove original FLAC file
-		if err := os.Remove(outputPath); err != nil {
-			colorWarning.Printf("⚠️ Failed to remove original FLAC file: %v\n", err)
+		if err := ConvertTrackTo(outputPath, convertedFile, format, bitrate, config.Encoders[format]); err != nil {
+			return "", nil, fmt.Errorf("failed to convert track: %w", err)
 		}
 		finalPath = convertedFile
+		if config.KeepOriginal {
+			Audit(ctx, "convert", outputPath, convertedFile)
+		} else {
+			// Conversion successful, remove original FLAC file
+			if err := RemoveFile(ctx, outputPath, config); err != nil {
+				colorWarning.Printf("⚠️ Failed to remove original FLAC file: %v\n", err)
+			}
+			Audit(ctx, "rename", outputPath, convertedFile)
+		}
 		if debug {
 			colorInfo.Printf("✅ Successfully converted to %s: %s\n", format, convertedFile)
 		}
 	}
 
-	return finalPath, nil
+	Audit(ctx, "download", finalPath, fmt.Sprintf("track %s", idToString(track.ID)))
+	return finalPath, transfer, nil
 }
 
 // DownloadSingleTrack downloads a single track.
 // It now accepts a full Track object, assuming it comes from search results.
-func (api *DabAPI) DownloadSingleTrack(ctx context.Context, track Track, debug bool, format string, bitrate string, pool *pb.Pool, config *Config, warningCollector *WarningCollector) error {
+// It returns the path of the track's file, which may already have existed.
+func (api *DabAPI) DownloadSingleTrack(ctx context.Context, track Track, debug bool, format string, bitrate string, pool *pb.Pool, config *Config, warningCollector *WarningCollector) (string, error) {
 	// Create warning collector if not provided (standalone track download)
 	var ownCollector bool
 	if warningCollector == nil {
 		warningCollector = NewWarningCollector(config.WarningBehavior != "silent")
+		warningCollector.correlationID = correlationIDFrom(ctx)
 		ownCollector = true
 	}
 	colorInfo.Printf("🎶 Preparing to download track: %s by %s (Album ID: %s)...\n", track.Title, track.Artist, track.AlbumID)
 
 	colorInfo.Printf("🎶 Preparing to download track: %s by %s (Album ID: %s)...\n", track.Title, track.Artist, track.AlbumID)
 
-	// Fetch the album information using the track's AlbumID
-	album, err := api.GetAlbum(ctx, track.AlbumID)
+	// Fetch the album information using the track's AlbumID, unless it was prefetched
+	var album *Album
+	var coverData []byte
+	var coverErr error
+	var err error
+	prefetched := api.takePrefetchedAlbum(ctx, track.AlbumID)
+	if prefetched != nil {
+		album, coverData, coverErr = prefetched.album, prefetched.cover, prefetched.coverErr
+	} else {
+		album, err = api.GetAlbum(ctx, track.AlbumID)
+	}
 	if err != nil {
 		if config.WarningBehavior == "immediate" {
 			colorWarning.Printf("⚠️ Could not fetch album info for track %s (ID: %s): %v. Attempting to proceed with limited album info.\n", track.Title, idToString(track.ID), err)
@@ -177,37 +294,53 @@ func (api *DabAPI) DownloadSingleTrack(ctx context.Context, track Track, debug b
 	}
 
 	if albumTrack == nil {
-		return fmt.Errorf("failed to find track %s (ID: %s) within its album %s (ID: %s)", track.Title, idToString(track.ID), album.Title, album.ID)
+		return "", fmt.Errorf("failed to find track %s (ID: %s) within its album %s (ID: %s)", track.Title, idToString(track.ID), album.Title, album.ID)
 	}
 
 	// Download cover
-	var coverData []byte
-	if album.Cover != "" {
-		coverData, err = api.DownloadCover(ctx, album.Cover)
-		if err != nil {
-			if config.WarningBehavior == "immediate" {
-				colorWarning.Printf("⚠️ Could not download cover art for album %s: %v\n", album.Title, err)
-			} else {
-				warningCollector.AddCoverArtDownloadWarning(album.Title, err.Error())
-			}
+	if prefetched == nil && album.Cover != "" {
+		coverData, coverErr = api.DownloadCover(ctx, album.Cover)
+	}
+	if coverErr != nil {
+		if config.WarningBehavior == "immediate" {
+			colorWarning.Printf("⚠️ Could not download cover art for album %s: %v\n", album.Title, coverErr)
+		} else {
+			warningCollector.AddCoverArtDownloadWarning(album.Title, coverErr.Error())
 		}
 	}
 
 	// Create track path
-	artistDir := filepath.Join(api.outputLocation, SanitizeFileName(albumTrack.Artist))
-	albumDir := filepath.Join(artistDir, SanitizeFileName(album.Title))
-	trackFileName := GetTrackFilename(albumTrack.TrackNumber, albumTrack.Title)
-	trackPath := filepath.Join(albumDir, trackFileName)
+	albumDir := filepath.Join(api.downloadRoot(ctx), albumFolder(config, album, albumTrack.Artist))
+	trackPath := filepath.Join(albumDir, trackFile(config, album, *albumTrack, albumTrack.Artist))
 
-	// Skip if already exists
-	if FileExists(trackPath) {
+	// Skip if already exists, or convert an existing FLAC to the requested format
+	local := findLocalCopy(ctx, config, *albumTrack, track.AlbumID, trackPath, format)
+	if local.existing != "" {
 		if config.WarningBehavior == "immediate" {
-			colorWarning.Printf("⭐ Track already exists: %s\n", trackPath)
+			colorWarning.Printf("⭐ Track already exists: %s\n", local.existing)
 		} else {
-			warningCollector.AddTrackSkippedWarning(trackPath)
+			warningCollector.AddTrackSkippedWarning(local.existing)
+		}
+		return local.existing, nil
+	}
+	if local.flac != "" {
+		converted, err := convertLocalCopy(config, local.flac, trackPath, format, bitrate)
+		if err == nil {
+			recordDownload(*albumTrack, album, converted, format, nil)
+			return converted, nil
 		}
-		return nil
+		colorWarning.Printf("⚠️ %v, downloading it instead\n", err)
 	}
+	if entry := previouslyDownloaded(ctx, *albumTrack, track.AlbumID, config); entry != nil {
+		colorWarning.Printf("⭐ %s\n", entry.skipReason(track.AlbumID))
+		return entry.Path, nil
+	}
+	if existing, how := duplicateOf(config, albumDir, *albumTrack); existing != "" {
+		colorWarning.Printf("⭐ Already in the album folder (%s): %s\n", how, existing)
+		return existing, nil
+	}
+
+	_, coverData = api.prepareCover(ctx, album, coverData, config, debug)
 
 	// Create progress bar
 	var bar *pb.ProgressBar
@@ -219,7 +352,7 @@ func (api *DabAPI) DownloadSingleTrack(ctx context.Context, track Track, debug b
 			fmt.Println("DEBUG: Creating single track progress bar for", albumTrack.Title)
 		}
 		pool.Add(bar) // Add to pool
-	} else if isTTY() { // Fallback to single bar if no pool and is TTY
+	} else if progressBarsEnabled() { // Fallback to single bar if no pool and is TTY
 		bar = pb.New(0)
 		bar.SetWriter(os.Stdout)
 		bar.SetTemplateString(`{{ string . "prefix" }} {{ bar . }} {{ percent . }} | {{ speed . "%s/s" }} | ETA {{ rtime . "%s" }}`)
@@ -231,25 +364,39 @@ func (api *DabAPI) DownloadSingleTrack(ctx context.Context, track Track, debug b
 	}
 
 	// Download the track
-	finalPath, err := api.DownloadTrack(ctx, *albumTrack, album, trackPath, coverData, bar, debug, format, bitrate, config, warningCollector)
+	finalPath, transfer, err := api.DownloadTrack(ctx, *albumTrack, album, trackPath, coverData, bar, debug, format, bitrate, config, warningCollector)
 	if err != nil {
 		if bar != nil && pool == nil { // Only finish if it's a standalone bar
 			bar.Finish()
 		}
-		return err
+		return "", err
 	}
 	if bar != nil && pool == nil { // Only finish if it's a standalone bar
 		bar.Finish()
 	}
 
+	if existing, how := downloadedDuplicate(ctx, config, albumDir, finalPath); existing != "" {
+		colorWarning.Printf("⭐ Removed the download, already in the album folder (%s): %s\n", how, existing)
+		recordDownload(*albumTrack, album, existing, format, transfer)
+		return existing, nil
+	}
+	recordDownload(*albumTrack, album, finalPath, format, transfer)
+	if run := queueItemRunFrom(ctx); run != nil {
+		run.trackDone()
+	}
+	if config.ReplayGain {
+		applyReplayGain([]string{finalPath}, false, debug)
+		updateHistoryChecksums([]string{finalPath})
+	}
 	colorSuccess.Printf("✅ Successfully downloaded: %s\n", finalPath)
+	uploadToTargets(ctx, config, api.downloadRoot(ctx), []string{finalPath})
 	
 	// Show warning summary only if we own the collector (standalone download)
 	if ownCollector && config.WarningBehavior == "summary" {
 		warningCollector.PrintSummary()
 	}
 	
-	return nil
+	return finalPath, nil
 }
 
 
@@ -259,6 +406,7 @@ func (api *DabAPI) DownloadAlbum(ctx context.Context, albumID string, config *Co
 	var ownCollector bool
 	if warningCollector == nil {
 		warningCollector = NewWarningCollector(config.WarningBehavior != "silent")
+		warningCollector.correlationID = correlationIDFrom(ctx)
 		ownCollector = true
 	}
 	
@@ -266,9 +414,23 @@ func (api *DabAPI) DownloadAlbum(ctx context.Context, albumID string, config *Co
 	if err != nil {
 		return nil, fmt.Errorf("failed to get album info: %w", err)
 	}
+	if len(album.Tracks) == 0 {
+		return nil, fmt.Errorf("album %s has no tracks: %w", albumID, ErrAlbumUnavailable)
+	}
+
+	// Unselected tracks are skipped rather than removed, so numbering and totals stay those of the album
+	selected := trackSelectionFrom(ctx)
+	wanted := func(idx int) bool { return selected == nil || selected[idx+1] }
+	trackCount := len(album.Tracks)
+	if selected != nil {
+		trackCount = len(selected)
+	}
 
-	artistDir := filepath.Join(api.outputLocation, SanitizeFileName(album.Artist))
-	albumDir := filepath.Join(artistDir, SanitizeFileName(album.Title))
+	albumDir := filepath.Join(api.downloadRoot(ctx), albumFolder(config, album, album.Artist))
+	emitEvent("album_start", jobEventFields(ctx, map[string]interface{}{"album_id": album.ID, "title": album.Title, "artist": album.Artist, "tracks": trackCount, "path": albumDir}))
+	if run := queueItemRunFrom(ctx); run != nil {
+		run.start(album.Title, trackCount)
+	}
 
 	if err := os.MkdirAll(albumDir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create album directory: %w", err)
@@ -287,9 +449,19 @@ func (api *DabAPI) DownloadAlbum(ctx context.Context, albumID string, config *Co
 		}
 	}
 
+	coverData, embeddedCover := api.prepareCover(ctx, album, coverData, config, debug)
+	if metadataOnly {
+		if err := api.writeAlbumMetadata(ctx, album, albumDir, coverData, config, warningCollector); err != nil {
+			return nil, err
+		}
+		if ownCollector && config.WarningBehavior == "summary" {
+			warningCollector.PrintSummary()
+		}
+		emitEvent("album_complete", jobEventFields(ctx, map[string]interface{}{"album_id": album.ID, "title": album.Title, "downloaded": 0, "skipped": 0, "failed": 0, "unavailable": 0}))
+		return &DownloadStats{}, nil
+	}
 	if config.SaveAlbumArt && coverData != nil {
-		coverPath := filepath.Join(albumDir, "cover.jpg")
-		if err := os.WriteFile(coverPath, coverData, 0644); err != nil {
+		if err := api.saveAlbumArt(ctx, album, albumDir, coverData, config); err != nil {
 			if config.WarningBehavior == "immediate" {
 				colorWarning.Printf("⚠️ Failed to save cover art for album %s: %v\n", album.Title, err)
 			} else {
@@ -300,12 +472,16 @@ func (api *DabAPI) DownloadAlbum(ctx context.Context, albumID string, config *Co
 
 	// Setup for concurrent downloads
 	var wg sync.WaitGroup
-	sem := semaphore.NewWeighted(int64(config.Parallelism))
-	stats := &DownloadStats{}
+	sem := semaphore.NewWeighted(int64(trackConcurrency(config)))
+	stats := &DownloadStats{CorrelationID: correlationIDFrom(ctx)}
 	errorChan := make(chan trackError, len(album.Tracks))
+	var newTracks bool
+	var albumFiles []string // Files of the album on disk, for the ReplayGain album scan
+	var newFiles []string   // Files downloaded by this run, for the output targets
+	var filesMu sync.Mutex
 
 	var localPool bool
-	if pool == nil && isTTY() {
+	if pool == nil && progressBarsEnabled() {
 		var err error
 		pool, err = pb.StartPool()
 		if err != nil {
@@ -320,6 +496,9 @@ func (api *DabAPI) DownloadAlbum(ctx context.Context, albumID string, config *Co
 	bars := make([]*pb.ProgressBar, len(album.Tracks))
 	if pool != nil {
 		for i, track := range album.Tracks {
+			if !wanted(i) {
+				continue
+			}
 			trackNumber := track.TrackNumber
 			if trackNumber == 0 {
 				trackNumber = i + 1
@@ -334,6 +513,9 @@ func (api *DabAPI) DownloadAlbum(ctx context.Context, albumID string, config *Co
 
 	// Loop through tracks and start a goroutine for each download
 	for idx, track := range album.Tracks {
+		if !wanted(idx) {
+			continue
+		}
 		wg.Add(1)
 		if err := sem.Acquire(ctx, 1); err != nil {
 			colorError.Printf("Failed to acquire semaphore: %v\n", err)
@@ -342,6 +524,7 @@ func (api *DabAPI) DownloadAlbum(ctx context.Context, albumID string, config *Co
 		}
 
 		go func(idx int, track Track) {
+			defer handlePanic()
 			defer wg.Done()
 			defer sem.Release(1)
 
@@ -350,16 +533,58 @@ func (api *DabAPI) DownloadAlbum(ctx context.Context, albumID string, config *Co
 				trackNumber = idx + 1
 			}
 
-			trackFileName := fmt.Sprintf("%02d - %s.flac", trackNumber, SanitizeFileName(track.Title))
-			trackPath := filepath.Join(albumDir, trackFileName)
+			track.TrackNumber = trackNumber
+			trackPath := filepath.Join(albumDir, trackFile(config, album, track, album.Artist))
 
-			// Skip if already exists
-			if FileExists(trackPath) {
+			// Skip if already exists, or convert an existing FLAC to the requested format
+			local := findLocalCopy(ctx, config, track, album.ID, trackPath, config.Format)
+			if local.existing != "" {
 				if config.WarningBehavior == "immediate" {
-					colorWarning.Printf("⭐ Track already exists: %s\n", trackPath)
+					colorWarning.Printf("⭐ Track already exists: %s\n", local.existing)
 				} else {
-					warningCollector.AddTrackSkippedWarning(trackPath)
+					warningCollector.AddTrackSkippedWarning(local.existing)
 				}
+				filesMu.Lock()
+				albumFiles = append(albumFiles, local.existing)
+				filesMu.Unlock()
+				stats.SkippedCount++
+				return
+			}
+			if local.flac != "" {
+				converted, err := convertLocalCopy(config, local.flac, trackPath, config.Format, config.Bitrate)
+				if err == nil {
+					recordDownload(track, album, converted, config.Format, nil)
+					if run := queueItemRunFrom(ctx); run != nil {
+						run.trackDone()
+					}
+					filesMu.Lock()
+					albumFiles = append(albumFiles, converted)
+					newFiles = append(newFiles, converted)
+					newTracks = true
+					filesMu.Unlock()
+					stats.SuccessCount++
+					return
+				}
+				colorWarning.Printf("⚠️ %v, downloading it instead\n", err)
+			}
+			if entry := previouslyDownloaded(ctx, track, album.ID, config); entry != nil {
+				if config.WarningBehavior == "immediate" {
+					colorWarning.Printf("⭐ %s\n", entry.skipReason(album.ID))
+				} else {
+					warningCollector.AddTrackSkippedWarning(entry.Path)
+				}
+				stats.SkippedCount++
+				return
+			}
+			if existing, how := duplicateOf(config, albumDir, track); existing != "" {
+				if config.WarningBehavior == "immediate" {
+					colorWarning.Printf("⭐ Already in the album folder (%s): %s\n", how, existing)
+				} else {
+					warningCollector.AddTrackSkippedWarning(existing)
+				}
+				filesMu.Lock()
+				albumFiles = append(albumFiles, existing)
+				filesMu.Unlock()
 				stats.SkippedCount++
 				return
 			}
@@ -369,10 +594,40 @@ func (api *DabAPI) DownloadAlbum(ctx context.Context, albumID string, config *Co
 				bar = bars[idx]
 			}
 
-			if _, err := api.DownloadTrack(ctx, track, album, trackPath, coverData, bar, debug, config.Format, config.Bitrate, config, warningCollector); err != nil {
+			finalPath, transfer, err := api.DownloadTrack(ctx, track, album, trackPath, embeddedCover, bar, debug, config.Format, config.Bitrate, config, warningCollector)
+			if err != nil && isTrackUnavailable(err) && config.FindAlternativeEditions {
+				if path, altTransfer, altErr := api.downloadAlternativeEdition(ctx, track, album, trackPath, embeddedCover, bar, debug, config, warningCollector); altErr == nil {
+					finalPath, transfer, err = path, altTransfer, nil
+				} else if debug {
+					fmt.Printf("DEBUG: %v\n", altErr)
+				}
+			}
+			if err != nil {
 				errorChan <- trackError{track.Title, fmt.Errorf("track %s: %w", track.Title, err)}
 				return
 			}
+			if existing, how := downloadedDuplicate(ctx, config, albumDir, finalPath); existing != "" {
+				if config.WarningBehavior == "immediate" {
+					colorWarning.Printf("⭐ Removed the download, already in the album folder (%s): %s\n", how, existing)
+				} else {
+					warningCollector.AddTrackSkippedWarning(existing)
+				}
+				recordDownload(track, album, existing, config.Format, transfer)
+				filesMu.Lock()
+				albumFiles = append(albumFiles, existing)
+				filesMu.Unlock()
+				stats.SkippedCount++
+				return
+			}
+			recordDownload(track, album, finalPath, config.Format, transfer)
+			if run := queueItemRunFrom(ctx); run != nil {
+				run.trackDone()
+			}
+			filesMu.Lock()
+			albumFiles = append(albumFiles, finalPath)
+			newFiles = append(newFiles, finalPath)
+			newTracks = true
+			filesMu.Unlock()
 
 			stats.SuccessCount++
 
@@ -386,8 +641,14 @@ func (api *DabAPI) DownloadAlbum(ctx context.Context, albumID string, config *Co
 	}
 	close(errorChan)
 
-	// Collect errors
+	// Collect errors. Unavailable tracks are reported on their own and don't fail the album.
 	for err := range errorChan {
+		emitEvent("track_error", jobEventFields(ctx, map[string]interface{}{"album_id": album.ID, "title": err.Title, "unavailable": isTrackUnavailable(err.Err), "message": err.Err.Error()}))
+		if isTrackUnavailable(err.Err) {
+			stats.UnavailableCount++
+			stats.UnavailableItems = append(stats.UnavailableItems, err.Title)
+			continue
+		}
 		stats.FailedCount++
 		stats.FailedItems = append(stats.FailedItems, fmt.Sprintf("%s: %v", err.Title, err.Err))
 	}
@@ -398,11 +659,45 @@ func (api *DabAPI) DownloadAlbum(ctx context.Context, albumID string, config *Co
 		updateFailedTracksWithReleaseMetadata(albumDir, album, warningCollector)
 	}
 
+	// Album gain needs every track, so it is only computed when the whole album is on disk
+	if config.ReplayGain && newTracks {
+		applyReplayGain(albumFiles, len(albumFiles) == len(album.Tracks), debug)
+	}
+
+	if config.SaveAlbumArt && config.AlbumArt != nil && config.AlbumArt.Extra {
+		if err := api.saveExtraArtwork(ctx, album, albumDir, debug); err != nil {
+			warningCollector.AddCoverArtDownloadWarning(album.Title, fmt.Sprintf("Additional artwork: %v", err))
+		}
+	}
+	// Written after tagging, so the MusicBrainz release is cached
+	if config.WriteNFO {
+		writeNFO(filepath.Join(albumDir, albumNFOFilename), albumNFOData(album))
+	}
+
+	// Checksums last, after ReplayGain and repaired tags changed the files
+	if newTracks {
+		updateHistoryChecksums(newFiles)
+	}
+	if newTracks && !config.NoChecksumManifest {
+		if err := UpdateChecksumManifest(albumDir, newFiles); err != nil {
+			colorWarning.Printf("⚠️ Failed to write %s: %v\n", checksumManifestName, err)
+		}
+	}
+
 	// Show warning summary only if we own the collector (standalone download)
 	if ownCollector && config.WarningBehavior == "summary" {
 		warningCollector.PrintSummary()
 	}
 
+	// Uploaded last, after ReplayGain and artwork changed the files
+	if newTracks && len(config.OutputTargets) > 0 {
+		uploadToTargets(ctx, config, api.downloadRoot(ctx), append(newFiles, albumArtFiles(albumDir)...))
+	}
+
+	RecordFeedItem(config, album, stats)
+	recordTracklist(album)
+	emitEvent("album_complete", jobEventFields(ctx, map[string]interface{}{"album_id": album.ID, "title": album.Title, "downloaded": stats.SuccessCount, "skipped": stats.SkippedCount, "failed": stats.FailedCount, "unavailable": stats.UnavailableCount}))
+
 	return stats, nil
 }
 
@@ -421,6 +716,9 @@ func updateFailedTracksWithReleaseMetadata(albumDir string, album *Album, warnin
 
 	// Find all FLAC files in the album directory
 	files, err := filepath.Glob(filepath.Join(albumDir, "*.flac"))
+	// Tracks of multi-disc albums may be in disc subfolders
+	discFiles, _ := filepath.Glob(filepath.Join(albumDir, "*", "*.flac"))
+	files = append(files, discFiles...)
 	if err != nil {
 		return
 	}
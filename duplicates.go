@@ -0,0 +1,202 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/go-flac/go-flac"
+)
+
+// audioExtensions are the files the duplicate check looks at
+var audioExtensions = map[string]bool{".flac": true, ".mp3": true, ".ogg": true, ".opus": true, ".oga": true, ".m4a": true, ".mp4": true}
+
+// AudioIdentity is what identifies the recording in an audio file, independent of its
+// name and format
+type AudioIdentity struct {
+	ISRC        string
+	RecordingID string // MUSICBRAINZ_TRACKID
+	AudioMD5    string // MD5 of the decoded audio from the FLAC stream info, FLAC only
+}
+
+// matches reports whether two identities are the same recording, and by what
+func (a AudioIdentity) matches(b AudioIdentity) (string, bool) {
+	switch {
+	case a.ISRC != "" && strings.EqualFold(a.ISRC, b.ISRC):
+		return "same ISRC", true
+	case a.RecordingID != "" && strings.EqualFold(a.RecordingID, b.RecordingID):
+		return "same MusicBrainz recording", true
+	case a.AudioMD5 != "" && a.AudioMD5 == b.AudioMD5:
+		return "same audio", true
+	}
+	return "", false
+}
+
+type indexedFile struct {
+	path     string
+	identity AudioIdentity
+}
+
+// DuplicateIndex knows the audio files of album folders, so a track already there under
+// another name or in another format isn't downloaded again. Each folder is read once.
+type DuplicateIndex struct {
+	mu   sync.Mutex
+	dirs map[string][]indexedFile
+}
+
+// duplicateIndex is the index used by album and track downloads
+var duplicateIndex = &DuplicateIndex{dirs: make(map[string][]indexedFile)}
+
+// Find returns the file in albumDir or its disc folders that holds the same recording as
+// identity, and how it matched. The file at exclude, usually the one just downloaded, is
+// ignored.
+func (x *DuplicateIndex) Find(albumDir string, identity AudioIdentity, exclude string) (string, string) {
+	if identity == (AudioIdentity{}) {
+		return "", ""
+	}
+	for _, file := range x.files(albumDir) {
+		if file.path == exclude {
+			continue
+		}
+		if how, ok := identity.matches(file.identity); ok && FileExists(file.path) {
+			return file.path, how
+		}
+	}
+	return "", ""
+}
+
+// Add records a file written to albumDir
+func (x *DuplicateIndex) Add(albumDir, path string, identity AudioIdentity) {
+	x.files(albumDir)
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.dirs[albumDir] = append(x.dirs[albumDir], indexedFile{path: path, identity: identity})
+}
+
+func (x *DuplicateIndex) files(albumDir string) []indexedFile {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if files, ok := x.dirs[albumDir]; ok {
+		return files
+	}
+	var files []indexedFile
+	for _, path := range albumAudioFiles(albumDir) {
+		if identity, err := readAudioIdentity(path); err == nil {
+			files = append(files, indexedFile{path: path, identity: identity})
+		}
+	}
+	x.dirs[albumDir] = files
+	return files
+}
+
+// albumAudioFiles lists the audio files of an album folder and of its disc folders
+func albumAudioFiles(albumDir string) []string {
+	var paths []string
+	entries, err := os.ReadDir(albumDir)
+	if err != nil {
+		return nil
+	}
+	for _, entry := range entries {
+		path := filepath.Join(albumDir, entry.Name())
+		if entry.IsDir() {
+			if entry.Name() == quarantineDirName || entry.Name() == extraArtworkDir {
+				continue
+			}
+			discEntries, _ := os.ReadDir(path)
+			for _, discEntry := range discEntries {
+				if !discEntry.IsDir() && audioExtensions[strings.ToLower(filepath.Ext(discEntry.Name()))] {
+					paths = append(paths, filepath.Join(path, discEntry.Name()))
+				}
+			}
+			continue
+		}
+		if audioExtensions[strings.ToLower(filepath.Ext(entry.Name()))] {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// readAudioIdentity reads the ISRC, MusicBrainz recording and audio checksum of a file.
+// FLACs are read directly, other formats with ffprobe when it is installed.
+func readAudioIdentity(path string) (AudioIdentity, error) {
+	if strings.EqualFold(filepath.Ext(path), ".flac") {
+		return readFLACIdentity(path)
+	}
+	probe, err := probeAudio(path)
+	if err != nil {
+		return AudioIdentity{}, err
+	}
+	return AudioIdentity{ISRC: firstTag(probe.Tags, "ISRC", "TSRC"), RecordingID: firstTag(probe.Tags, "MUSICBRAINZTRACKID")}, nil
+}
+
+func readFLACIdentity(path string) (AudioIdentity, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return AudioIdentity{}, err
+	}
+	defer f.Close()
+	file, err := flac.ParseMetadata(f)
+	if err != nil {
+		return AudioIdentity{}, err
+	}
+	var identity AudioIdentity
+	if info, err := file.GetStreamInfo(); err == nil && !bytes.Equal(info.AudioMD5, make([]byte, 16)) {
+		// Encoders that don't compute the checksum leave it zero
+		identity.AudioMD5 = hex.EncodeToString(info.AudioMD5)
+	}
+	tags, err := readVorbisTags(path)
+	if err == nil {
+		identity.ISRC = firstTag(tags, "ISRC")
+		identity.RecordingID = firstTag(tags, "MUSICBRAINZ_TRACKID")
+	}
+	return identity, nil
+}
+
+func firstTag(tags map[string][]string, names ...string) string {
+	for _, name := range names {
+		if len(tags[name]) > 0 {
+			return strings.TrimSpace(tags[name][0])
+		}
+	}
+	return ""
+}
+
+// duplicateOf returns the file in albumDir that already holds track, checked by ISRC
+// before it is downloaded
+func duplicateOf(config *Config, albumDir string, track Track) (string, string) {
+	if config.NoDuplicateDetection || track.ISRC == "" {
+		return "", ""
+	}
+	return duplicateIndex.Find(albumDir, AudioIdentity{ISRC: track.ISRC}, "")
+}
+
+// downloadedDuplicate checks a file that was just downloaded and tagged against the rest of
+// albumDir. When another file holds the same recording, the new file is removed and the
+// existing one returned. Otherwise the new file is added to the index.
+func downloadedDuplicate(ctx context.Context, config *Config, albumDir, path string) (string, string) {
+	if config.NoDuplicateDetection {
+		return "", ""
+	}
+	if rel, err := filepath.Rel(albumDir, path); err != nil || strings.HasPrefix(rel, "..") {
+		return "", "" // A converted copy kept apart from its FLAC
+	}
+	identity, err := readAudioIdentity(path)
+	if err != nil {
+		return "", ""
+	}
+	existing, how := duplicateIndex.Find(albumDir, identity, path)
+	if existing == "" {
+		duplicateIndex.Add(albumDir, path, identity)
+		return "", ""
+	}
+	// Quarantined rather than deleted, in case the match was wrong
+	if err := RemoveFile(ctx, path, config); err != nil {
+		colorWarning.Printf("⚠️ Failed to remove duplicate %s: %v\n", path, err)
+	}
+	return existing, how
+}
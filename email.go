@@ -0,0 +1,150 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SMTPConfig holds the mail server used to send download summaries
+type SMTPConfig struct {
+	Host     string   `json:"host"`
+	Port     int      `json:"port"` // 587 (STARTTLS) by default, 465 uses implicit TLS
+	Username string   `json:"username,omitempty"`
+	Password string   `json:"password,omitempty"`
+	From     string   `json:"from"`
+	To       []string `json:"to"`
+}
+
+// sessionStart is used to report how long a run took in summary emails
+var sessionStart = time.Now()
+
+// emailEnabled reports whether summary emails are configured
+func emailEnabled(config *Config) bool {
+	return config != nil && config.SMTP != nil && config.SMTP.Host != "" && len(config.SMTP.To) > 0
+}
+
+// NotifySummary emails a download summary if SMTP is configured and tells the
+// notification targets subscribed to event. Failures are reported as warnings since the
+// downloads themselves already finished.
+func NotifySummary(config *Config, event, title string, stats *DownloadStats) {
+	notifyTargets(config, event, title, stats)
+	if !emailEnabled(config) || stats == nil {
+		return
+	}
+	if err := SendSummaryEmail(config.SMTP, title, stats); err != nil {
+		colorWarning.Printf("⚠️ Failed to send summary email: %v\n", err)
+		return
+	}
+	colorInfo.Printf("📧 Summary emailed to %s\n", strings.Join(config.SMTP.To, ", "))
+}
+
+// SendSummaryEmail sends a plain-text report of a finished download
+func SendSummaryEmail(cfg *SMTPConfig, title string, stats *DownloadStats) error {
+	subject := fmt.Sprintf("dab-downloader: %s - %d downloaded, %d failed", title, stats.SuccessCount, len(stats.FailedItems))
+	msg := buildSummaryMessage(cfg, subject, formatSummaryBody(title, stats))
+
+	port := cfg.Port
+	if port == 0 {
+		port = 587
+	}
+	addr := net.JoinHostPort(cfg.Host, strconv.Itoa(port))
+
+	var auth smtp.Auth
+	if cfg.Username != "" {
+		auth = smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)
+	}
+
+	if port != 465 {
+		// smtp.SendMail upgrades to STARTTLS when the server supports it
+		return smtp.SendMail(addr, auth, cfg.From, cfg.To, msg)
+	}
+
+	conn, err := tls.Dial("tcp", addr, &tls.Config{ServerName: cfg.Host})
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", addr, err)
+	}
+	client, err := smtp.NewClient(conn, cfg.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if auth != nil {
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("authentication failed: %w", err)
+		}
+	}
+	if err := client.Mail(cfg.From); err != nil {
+		return err
+	}
+	for _, to := range cfg.To {
+		if err := client.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// formatSummaryBody formats the report sent in summary emails
+func formatSummaryBody(title string, stats *DownloadStats) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Download summary for %s\n\n", title)
+	fmt.Fprintf(&b, "Downloaded:  %d\n", stats.SuccessCount)
+	fmt.Fprintf(&b, "Skipped:     %d (already exist)\n", stats.SkippedCount)
+	fmt.Fprintf(&b, "Failed:      %d\n", len(stats.FailedItems))
+	if len(stats.UnavailableItems) > 0 {
+		fmt.Fprintf(&b, "Unavailable: %d (not streamable on DAB)\n", len(stats.UnavailableItems))
+	}
+	fmt.Fprintf(&b, "Total size:  %s\n", FormatBytes(downloadThroughput.Total()))
+	fmt.Fprintf(&b, "Duration:    %s\n", time.Since(sessionStart).Round(time.Second))
+	correlationID := stats.CorrelationID
+	if correlationID == "" {
+		correlationID = runCorrelationID
+	}
+	fmt.Fprintf(&b, "Correlation: %s\n", correlationID)
+
+	if len(stats.UnavailableItems) > 0 {
+		b.WriteString("\nUnavailable:\n")
+		for _, item := range stats.UnavailableItems {
+			fmt.Fprintf(&b, "  - %s\n", item)
+		}
+	}
+	if len(stats.FailedItems) > 0 {
+		b.WriteString("\nFailures:\n")
+		for _, item := range stats.FailedItems {
+			fmt.Fprintf(&b, "  - %s\n", item)
+		}
+	}
+
+	fmt.Fprintf(&b, "\nFinished: %s\n", time.Now().Format(time.RFC1123))
+	return b.String()
+}
+
+// buildSummaryMessage assembles the headers and body of a summary email
+func buildSummaryMessage(cfg *SMTPConfig, subject, body string) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", cfg.From)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(cfg.To, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", subject)
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	return []byte(b.String())
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// excludeWords are words of album titles skipped by artist downloads, e.g.
+// "live,remix,deluxe", set by --exclude
+var excludeWords string
+
+// titleExclusions are the patterns in use, from ExcludeTitles and --exclude
+var titleExclusions []*regexp.Regexp
+
+// SetTitleExclusions replaces the patterns of album titles skipped by artist, batch and
+// watch downloads. patterns are regular expressions, words are matched as whole words;
+// both ignore case.
+func SetTitleExclusions(patterns []string, words string) error {
+	titleExclusions = nil
+	for _, pattern := range patterns {
+		re, err := regexp.Compile("(?i)" + pattern)
+		if err != nil {
+			return fmt.Errorf("invalid exclude pattern '%s': %w", pattern, err)
+		}
+		titleExclusions = append(titleExclusions, re)
+	}
+	for _, word := range strings.Split(words, ",") {
+		word = strings.TrimSpace(word)
+		if word == "" {
+			continue
+		}
+		titleExclusions = append(titleExclusions, wordPattern(word))
+	}
+	return nil
+}
+
+// wordPattern matches a word anywhere in a title as a whole word, ignoring case
+func wordPattern(word string) *regexp.Regexp {
+	return regexp.MustCompile(`(?i)\b` + regexp.QuoteMeta(word) + `\b`)
+}
+
+// containsWord reports whether a title contains one of the words as a whole word
+func containsWord(title string, words []string) bool {
+	for _, word := range words {
+		if word = strings.TrimSpace(word); word != "" && wordPattern(word).MatchString(title) {
+			return true
+		}
+	}
+	return false
+}
+
+// isExcludedTitle reports whether an album title matches an exclude pattern
+func isExcludedTitle(title string) bool {
+	for _, re := range titleExclusions {
+		if re.MatchString(title) {
+			return true
+		}
+	}
+	return false
+}
+
+// excludeTitles returns the items whose title matches no exclude pattern, and the number
+// left out
+func excludeTitles(items []Album) ([]Album, int) {
+	if len(titleExclusions) == 0 {
+		return items, 0
+	}
+	kept := []Album{}
+	for _, item := range items {
+		if !isExcludedTitle(item.Title) {
+			kept = append(kept, item)
+		}
+	}
+	return kept, len(items) - len(kept)
+}
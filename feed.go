@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultFeedItems = 50
+
+// rssFeed is the RSS 2.0 document written to FeedPath
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link,omitempty"`
+	Description string  `xml:"description"`
+	PubDate     string  `xml:"pubDate"`
+	GUID        rssGUID `xml:"guid"`
+}
+
+type rssGUID struct {
+	Value       string `xml:",chardata"`
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+}
+
+// feedMu serializes feed updates from parallel album downloads
+var feedMu sync.Mutex
+
+// RecordFeedItem adds a completed album to the RSS feed if FeedPath is configured.
+// The feed is a static file, so it can be served by any web server next to the library.
+func RecordFeedItem(config *Config, album *Album, stats *DownloadStats) {
+	if config == nil || config.FeedPath == "" || album == nil || stats == nil || stats.SuccessCount == 0 {
+		return
+	}
+	if err := appendFeedItem(config, album, stats); err != nil {
+		colorWarning.Printf("⚠️ Failed to update feed %s: %v\n", config.FeedPath, err)
+	}
+}
+
+func appendFeedItem(config *Config, album *Album, stats *DownloadStats) error {
+	feedMu.Lock()
+	defer feedMu.Unlock()
+
+	feed := rssFeed{
+		Version: "2.0",
+		Channel: rssChannel{
+			Title:       "DAB Downloader",
+			Link:        config.FeedLink,
+			Description: "Recently downloaded albums",
+		},
+	}
+	if data, err := os.ReadFile(config.FeedPath); err == nil {
+		if err := xml.Unmarshal(data, &feed); err != nil {
+			return fmt.Errorf("failed to parse existing feed: %w", err)
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	now := time.Now()
+	item := rssItem{
+		Title:       fmt.Sprintf("%s - %s", album.Artist, album.Title),
+		Description: fmt.Sprintf("%d tracks downloaded", stats.SuccessCount),
+		PubDate:     now.Format(time.RFC1123Z),
+		GUID:        rssGUID{Value: fmt.Sprintf("dab-album-%s-%d", album.ID, now.Unix())},
+	}
+	if stats.FailedCount > 0 {
+		item.Description += fmt.Sprintf(", %d failed", stats.FailedCount)
+	}
+	if config.FeedLink != "" {
+		item.Link = strings.TrimSuffix(config.FeedLink, "/")
+	}
+
+	limit := config.FeedItems
+	if limit <= 0 {
+		limit = defaultFeedItems
+	}
+	feed.Channel.Items = append([]rssItem{item}, feed.Channel.Items...)
+	if len(feed.Channel.Items) > limit {
+		feed.Channel.Items = feed.Channel.Items[:limit]
+	}
+	feed.Channel.LastBuildDate = now.Format(time.RFC1123Z)
+
+	data, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(config.FeedPath), 0755); err != nil {
+		return err
+	}
+	tmp := config.FeedPath + ".tmp"
+	if err := os.WriteFile(tmp, append([]byte(xml.Header), data...), 0644); err != nil {
+		return err
+	}
+	return moveFile(tmp, config.FeedPath, config)
+}
@@ -1,11 +1,17 @@
 package main
 
 import (
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
+
+	"github.com/go-flac/go-flac"
 )
 
 // CheckFFmpeg checks if ffmpeg is installed and available in the system's PATH.
@@ -14,34 +20,361 @@ func CheckFFmpeg() bool {
 	return err == nil
 }
 
-// ConvertTrack converts a track to the specified format using ffmpeg.
-func ConvertTrack(inputFile, format, bitrate string) (string, error) {
-	outputFile := strings.TrimSuffix(inputFile, filepath.Ext(inputFile)) + "." + format
-
-	var cmd *exec.Cmd
-	switch format {
-	case "mp3":
-		cmd = exec.Command("ffmpeg", "-i", inputFile, "-b:a", bitrate+"k", "-vn", "-map_metadata", "0", outputFile)
-	case "ogg":
-		// For ogg, -q:a (quality) is often preferred over bitrate.
-		// A mapping from bitrate to quality could be implemented if needed.
-		// For now, using a high quality setting.
-		cmd = exec.Command("ffmpeg", "-i", inputFile, "-c:a", "libvorbis", "-q:a", "8", "-vn", "-map_metadata", "0", outputFile)
-	case "opus":
-		cmd = exec.Command("ffmpeg", "-i", inputFile, "-c:a", "libopus", "-b:a", bitrate+"k", "-vn", "-map_metadata", "0", outputFile)
+// EncoderOptions tune the conversion to one format, set per format in config.json
+type EncoderOptions struct {
+	Quality   string   `json:"quality,omitempty"`   // VBR quality used instead of the bitrate, e.g. "2" for MP3 or "5" for libfdk_aac
+	Container string   `json:"container,omitempty"` // File extension, e.g. "ogg" instead of "opus"
+	Encoder   string   `json:"encoder,omitempty"`   // AAC only: "libfdk_aac" or "aac"; libfdk_aac is used when ffmpeg has it
+	Args      []string `json:"args,omitempty"`      // Extra ffmpeg output arguments
+}
+
+// conversionFormat is how ffmpeg produces one output format
+type conversionFormat struct {
+	codec      string
+	encoders   []string // Encoders that can be chosen, the first is the default
+	containers []string // Allowed extensions, the first is the default
+	lossless   bool
+	cover      bool       // The container holds the cover as an attached picture
+	pictureTag bool       // The cover goes in a METADATA_BLOCK_PICTURE comment, Ogg has no attached pictures
+	bitrates   [2]int     // Allowed bitrate range in kbps
+	quality    [2]float64 // Allowed VBR quality range, zero when the format has none
+	args       []string   // Arguments always passed for the format
+}
+
+// conversionFormats are the formats FLACs can be converted to
+var conversionFormats = map[string]conversionFormat{
+	"mp3": {
+		codec: "libmp3lame", containers: []string{"mp3"}, cover: true,
+		bitrates: [2]int{32, 320}, quality: [2]float64{0, 9},
+		args: []string{"-id3v2_version", "3"},
+	},
+	"ogg": {
+		codec: "libvorbis", containers: []string{"ogg", "oga"}, pictureTag: true,
+		bitrates: [2]int{45, 500}, quality: [2]float64{-1, 10},
+	},
+	"opus": {
+		codec: "libopus", containers: []string{"opus", "ogg"}, pictureTag: true,
+		bitrates: [2]int{6, 510},
+	},
+	"aac": {
+		encoders: []string{"libfdk_aac", "aac"}, containers: []string{"m4a", "mp4"}, cover: true,
+		bitrates: [2]int{16, 512},
+	},
+	"alac": {
+		codec: "alac", containers: []string{"m4a"}, lossless: true, cover: true,
+	},
+}
+
+// aacQuality is the VBR range of each AAC encoder
+var aacQuality = map[string][2]float64{"libfdk_aac": {1, 5}, "aac": {0.1, 2}}
+
+var (
+	fdkAACOnce      sync.Once
+	fdkAACAvailable bool
+)
+
+// aacEncoder is the AAC encoder to use: the one configured, else libfdk_aac when this
+// ffmpeg was built with it and ffmpeg's own encoder otherwise
+func aacEncoder(options EncoderOptions) string {
+	if options.Encoder != "" {
+		return options.Encoder
+	}
+	fdkAACOnce.Do(func() {
+		output, err := exec.Command("ffmpeg", "-hide_banner", "-encoders").Output()
+		fdkAACAvailable = err == nil && strings.Contains(string(output), "libfdk_aac")
+	})
+	if fdkAACAvailable {
+		return "libfdk_aac"
+	}
+	return "aac"
+}
+
+// ValidateFormat checks that format is flac or a format tracks can be converted to
+func ValidateFormat(format string) error {
+	if format == "flac" {
+		return nil
+	}
+	if _, ok := conversionFormats[format]; !ok {
+		return fmt.Errorf("unsupported format '%s' (use flac, mp3, ogg, opus, aac or alac)", format)
+	}
+	return nil
+}
+
+// ValidateBitrate checks the bitrate for format. Lossless formats ignore it.
+func ValidateBitrate(format, bitrate string) error {
+	def, ok := conversionFormats[format]
+	if !ok || def.lossless {
+		return nil
+	}
+	kbps, err := strconv.Atoi(bitrate)
+	if err != nil {
+		return fmt.Errorf("invalid bitrate '%s'", bitrate)
+	}
+	if kbps < def.bitrates[0] || kbps > def.bitrates[1] {
+		return fmt.Errorf("bitrate %d is out of range for %s (%d-%d kbps)", kbps, format, def.bitrates[0], def.bitrates[1])
+	}
+	return nil
+}
+
+// ValidateEncoderOptions checks the encoder options configured for format
+func ValidateEncoderOptions(format string, options EncoderOptions) error {
+	def, ok := conversionFormats[format]
+	if !ok {
+		return fmt.Errorf("encoders: unsupported format '%s'", format)
+	}
+	if options.Container != "" && !containsString(def.containers, options.Container) {
+		return fmt.Errorf("encoders.%s: container must be one of %s", format, strings.Join(def.containers, ", "))
+	}
+	if options.Encoder != "" && !containsString(def.encoders, options.Encoder) {
+		if len(def.encoders) == 0 {
+			return fmt.Errorf("encoders.%s: the encoder can't be chosen", format)
+		}
+		return fmt.Errorf("encoders.%s: encoder must be one of %s", format, strings.Join(def.encoders, ", "))
+	}
+	if options.Quality == "" {
+		return nil
+	}
+	limits := def.quality
+	if format == "aac" {
+		limits = aacQuality[aacEncoder(options)]
+	}
+	if limits == ([2]float64{}) {
+		return fmt.Errorf("encoders.%s: %s has no VBR quality, use the bitrate", format, format)
+	}
+	quality, err := strconv.ParseFloat(options.Quality, 64)
+	if err != nil || quality < limits[0] || quality > limits[1] {
+		return fmt.Errorf("encoders.%s: quality must be between %g and %g", format, limits[0], limits[1])
+	}
+	return nil
+}
+
+// formatExtension is the file extension tracks converted to format get
+func formatExtension(config *Config, format string) string {
+	return containerFor(format, config.Encoders[format])
+}
+
+func containerFor(format string, options EncoderOptions) string {
+	if options.Container != "" {
+		return options.Container
+	}
+	if def, ok := conversionFormats[format]; ok {
+		return def.containers[0]
+	}
+	return format
+}
+
+// isLossless reports whether format keeps the audio as is
+func isLossless(format string) bool {
+	return format == "flac" || conversionFormats[format].lossless
+}
+
+// describeEncoding says how tracks are converted, for progress messages
+func describeEncoding(format, bitrate string, options EncoderOptions) string {
+	name := strings.ToUpper(format)
+	if format == "aac" {
+		name += " (" + aacEncoder(options) + ")"
+	}
+	switch {
+	case isLossless(format):
+		return name
+	case options.Quality != "":
+		return fmt.Sprintf("%s at VBR quality %s", name, options.Quality)
+	case format == "ogg":
+		return name + " at quality 8"
+	}
+	return fmt.Sprintf("%s at %s kbps", name, bitrate)
+}
+
+// ConvertTrack converts a track to the specified format using ffmpeg, next to the input.
+func ConvertTrack(inputFile, format, bitrate string, options EncoderOptions) (string, error) {
+	outputFile := strings.TrimSuffix(inputFile, filepath.Ext(inputFile)) + "." + containerFor(format, options)
+	return outputFile, ConvertTrackTo(inputFile, outputFile, format, bitrate, options)
+}
+
+// ConvertTrackTo converts a track into outputFile, which may be in another folder. Tags
+// and, where the container holds one, the cover are carried over and checked afterwards.
+func ConvertTrackTo(inputFile, outputFile, format, bitrate string, options EncoderOptions) error {
+	def, ok := conversionFormats[format]
+	if !ok {
+		return fmt.Errorf("unsupported format: %s", format)
+	}
+	args := []string{"-y", "-i", inputFile}
+	var pictureMetadata string
+	if def.pictureTag {
+		var err error
+		if pictureMetadata, err = writePictureMetadata(inputFile); err != nil {
+			colorWarning.Printf("⚠️ Failed to carry the cover of %s over: %v\n", filepath.Base(inputFile), err)
+		} else if pictureMetadata != "" {
+			defer os.Remove(pictureMetadata)
+			args = append(args, "-f", "ffmetadata", "-i", pictureMetadata)
+		}
+	}
+	args = append(args, "-map_metadata", "0")
+	switch {
+	case def.cover:
+		args = append(args, "-map", "0:a", "-map", "0:v?", "-c:v", "copy", "-disposition:v", "attached_pic")
+	case pictureMetadata != "":
+		// Tags of both inputs are merged, the FLAC's own win
+		args = append(args, "-map_metadata", "1", "-map", "0:a")
+	default:
+		args = append(args, "-vn")
+	}
+
+	codec := def.codec
+	if format == "aac" {
+		codec = aacEncoder(options)
+	}
+	args = append(args, "-c:a", codec)
+	switch {
+	case def.lossless:
+	case options.Quality != "" && codec == "libfdk_aac":
+		args = append(args, "-vbr", options.Quality)
+	case options.Quality != "":
+		args = append(args, "-q:a", options.Quality)
+	case format == "ogg":
+		// Vorbis sounds best in quality mode, the bitrate is ignored
+		args = append(args, "-q:a", "8")
 	default:
-		return "", fmt.Errorf("unsupported format: %s", format)
+		args = append(args, "-b:a", bitrate+"k")
 	}
+	args = append(args, def.args...)
+	args = append(args, options.Args...)
+	args = append(args, outputFile)
 
-	output, err := cmd.CombinedOutput()
+	output, err := exec.Command("ffmpeg", args...).CombinedOutput()
 	if err != nil {
-		return "", fmt.Errorf("failed to convert track: %w\nffmpeg output: %s", err, string(output))
+		return fmt.Errorf("failed to convert track: %w\nffmpeg output: %s", err, string(output))
 	}
 
 	// Verify that the output file was created
 	if _, err := os.Stat(outputFile); os.IsNotExist(err) {
-		return "", fmt.Errorf("converted file not found after conversion")
+		return fmt.Errorf("converted file not found after conversion")
+	}
+	if err := verifyConversion(inputFile, outputFile, def); err != nil {
+		os.Remove(outputFile)
+		return err
 	}
+	return nil
+}
 
-	return outputFile, nil
+// verifyConversion checks that the converted file kept the title and, when the container
+// can hold it, the cover of the FLAC. It needs ffprobe and is skipped without it.
+func verifyConversion(source, converted string, def conversionFormat) error {
+	if _, err := exec.LookPath("ffprobe"); err != nil {
+		return nil
+	}
+	probe, err := probeAudio(converted)
+	if err != nil {
+		return fmt.Errorf("failed to read converted file: %w", err)
+	}
+	tags, err := readVorbisTags(source)
+	if err != nil {
+		return nil
+	}
+	if len(tags["TITLE"]) > 0 && firstTag(probe.Tags, "TITLE") == "" {
+		return fmt.Errorf("tags were lost converting %s", filepath.Base(source))
+	}
+	if def.cover && flacHasPicture(source) && !probe.AttachedPicture {
+		return fmt.Errorf("cover art was lost converting %s", filepath.Base(source))
+	}
+	// Older ffmpeg builds may drop the comment, the audio is still fine
+	if def.pictureTag && flacHasPicture(source) && !probe.AttachedPicture && len(probe.Tags["METADATABLOCKPICTURE"]) == 0 {
+		colorWarning.Printf("⚠️ Cover art was not embedded in %s, this ffmpeg doesn't keep METADATA_BLOCK_PICTURE\n", filepath.Base(converted))
+	}
+	return nil
+}
+
+// flacHasPicture reports whether a FLAC has embedded art
+func flacHasPicture(path string) bool {
+	picture, _ := flacPicture(path)
+	return picture != nil
+}
+
+// flacPicture returns the first picture block of a FLAC, nil when it has none. Its data is
+// what Vorbis comments carry base64-encoded as METADATA_BLOCK_PICTURE.
+func flacPicture(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	file, err := flac.ParseMetadata(f)
+	if err != nil {
+		return nil, err
+	}
+	for _, block := range file.Meta {
+		if block.Type == flac.Picture {
+			return block.Data, nil
+		}
+	}
+	return nil, nil
+}
+
+// writePictureMetadata writes the cover of a FLAC as a METADATA_BLOCK_PICTURE tag to a
+// temporary ffmetadata file and returns its path, empty when the FLAC has no cover. The
+// tag is too large for the command line.
+func writePictureMetadata(flacPath string) (string, error) {
+	picture, err := flacPicture(flacPath)
+	if err != nil || picture == nil {
+		return "", err
+	}
+	f, err := os.CreateTemp("", "dab-cover-*.txt")
+	if err != nil {
+		return "", err
+	}
+	// "=" in the base64 padding has to be escaped in ffmetadata values
+	value := strings.ReplaceAll(base64.StdEncoding.EncodeToString(picture), "=", `\=`)
+	_, err = fmt.Fprintf(f, ";FFMETADATA1\nMETADATA_BLOCK_PICTURE=%s\n", value)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// audioProbe is what ffprobe reports of a file
+type audioProbe struct {
+	Tags            map[string][]string // Container and stream tags, keyed by upper-case name without spaces and underscores
+	AttachedPicture bool
+}
+
+// probeAudio reads the tags and embedded art of a file with ffprobe
+func probeAudio(path string) (*audioProbe, error) {
+	if _, err := exec.LookPath("ffprobe"); err != nil {
+		return nil, err
+	}
+	output, err := exec.Command("ffprobe", "-v", "quiet", "-print_format", "json", "-show_entries", "format_tags:stream_tags:stream_disposition", path).Output()
+	if err != nil {
+		return nil, err
+	}
+	var raw struct {
+		Format struct {
+			Tags map[string]string `json:"tags"`
+		} `json:"format"`
+		Streams []struct {
+			Tags        map[string]string `json:"tags"`
+			Disposition map[string]int    `json:"disposition"`
+		} `json:"streams"`
+	}
+	if err := json.Unmarshal(output, &raw); err != nil {
+		return nil, err
+	}
+	probe := &audioProbe{Tags: make(map[string][]string)}
+	add := func(values map[string]string) {
+		for key, value := range values {
+			key = strings.NewReplacer(" ", "", "_", "").Replace(strings.ToUpper(key))
+			probe.Tags[key] = append(probe.Tags[key], value)
+		}
+	}
+	add(raw.Format.Tags)
+	for _, stream := range raw.Streams {
+		add(stream.Tags)
+		if stream.Disposition["attached_pic"] == 1 {
+			probe.AttachedPicture = true
+		}
+	}
+	return probe, nil
 }
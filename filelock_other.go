@@ -0,0 +1,10 @@
+//go:build !unix && !windows
+
+package main
+
+import "os"
+
+// lockFile is only supported on Unix and Windows, elsewhere only one process may use the queue
+func lockFile(f *os.File) error { return nil }
+
+func unlockFile(f *os.File) error { return nil }
@@ -0,0 +1,308 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HistoryEntry is a track that was downloaded successfully
+type HistoryEntry struct {
+	TrackID      string    `json:"track_id"`
+	AlbumID      string    `json:"album_id,omitempty"`
+	ISRC         string    `json:"isrc,omitempty"`
+	Title        string    `json:"title"`
+	Artist       string    `json:"artist"`
+	Album        string    `json:"album,omitempty"`
+	Path         string    `json:"path"`
+	Format       string    `json:"format"`
+	Checksum     string    `json:"checksum,omitempty"` // SHA-256 of the file after the last tag changes
+	Bytes        int64     `json:"bytes,omitempty"`       // Audio bytes transferred
+	DurationMs   int64     `json:"duration_ms,omitempty"` // Time the transfer took
+	Host         string    `json:"host,omitempty"`        // Host the audio was streamed from
+	DownloadedAt time.Time `json:"downloaded_at"`
+}
+
+// BytesPerSecond returns the transfer speed of the download, 0 when it wasn't recorded
+func (e HistoryEntry) BytesPerSecond() float64 {
+	if e.DurationMs <= 0 {
+		return 0
+	}
+	return float64(e.Bytes) / (float64(e.DurationMs) / 1000)
+}
+
+// DownloadHistory records every downloaded track so duplicates are recognised even after
+// the files were renamed or moved by another tool
+type DownloadHistory struct {
+	path    string
+	entries []HistoryEntry
+	loaded  bool
+	mu      sync.Mutex
+}
+
+// downloadHistory is the history used by the download commands
+var downloadHistory = NewDownloadHistory(filepath.Join("config", "history.json"))
+
+// ignoreHistory makes downloads skip the history check, set by --ignore-history
+var ignoreHistory bool
+
+// NewDownloadHistory creates a history backed by the given file
+func NewDownloadHistory(path string) *DownloadHistory {
+	return &DownloadHistory{path: path}
+}
+
+// Find returns the entry of a track, matched by DAB track ID or ISRC. With an albumID, only
+// tracks of that album match by ISRC, so the same recording on another album doesn't count.
+// A match by track ID is preferred.
+func (h *DownloadHistory) Find(trackID, isrc, albumID string) *HistoryEntry {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.load()
+	var byISRC *HistoryEntry
+	for i := range h.entries {
+		entry := &h.entries[i]
+		if trackID != "" && entry.TrackID == trackID {
+			return entry
+		}
+		if byISRC == nil && isrc != "" && strings.EqualFold(entry.ISRC, isrc) && (albumID == "" || entry.AlbumID == albumID) {
+			byISRC = entry
+		}
+	}
+	return byISRC
+}
+
+// HasAlbum reports whether any track of an album was downloaded
+func (h *DownloadHistory) HasAlbum(albumID string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.load()
+	for _, entry := range h.entries {
+		if entry.AlbumID == albumID {
+			return true
+		}
+	}
+	return false
+}
+
+// Record adds a downloaded track to the history and writes it to disk. transfer may be nil.
+func (h *DownloadHistory) Record(track Track, album *Album, path, format string, transfer *TransferStats) error {
+	checksum, err := fileChecksum(path)
+	if err != nil {
+		return fmt.Errorf("failed to checksum %s: %w", path, err)
+	}
+	entry := HistoryEntry{
+		TrackID:      idToString(track.ID),
+		AlbumID:      track.AlbumID,
+		ISRC:         track.ISRC,
+		Title:        track.Title,
+		Artist:       track.Artist,
+		Path:         path,
+		Format:       format,
+		Checksum:     checksum,
+		DownloadedAt: time.Now(),
+	}
+	if transfer != nil {
+		entry.Bytes = transfer.Bytes
+		entry.DurationMs = transfer.Duration.Milliseconds()
+		entry.Host = transfer.Host
+	}
+	if album != nil {
+		entry.Album = album.Title
+		if entry.AlbumID == "" {
+			entry.AlbumID = album.ID
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.load()
+	// A re-download replaces the previous entry of the track
+	for i := range h.entries {
+		if h.entries[i].TrackID == entry.TrackID {
+			h.entries = append(h.entries[:i], h.entries[i+1:]...)
+			break
+		}
+	}
+	h.entries = append(h.entries, entry)
+	return h.save()
+}
+
+// UpdateChecksums recomputes the checksums of the entries of paths, for files changed after
+// they were recorded, e.g. by ReplayGain tags
+func (h *DownloadHistory) UpdateChecksums(paths []string) error {
+	checksums := make(map[string]string, len(paths))
+	for _, path := range paths {
+		checksum, err := fileChecksum(path)
+		if err != nil {
+			return fmt.Errorf("failed to checksum %s: %w", path, err)
+		}
+		checksums[path] = checksum
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.load()
+	changed := false
+	for i := range h.entries {
+		if checksum, ok := checksums[h.entries[i].Path]; ok && h.entries[i].Checksum != checksum {
+			h.entries[i].Checksum = checksum
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return h.save()
+}
+
+// save writes the entries to disk, the caller holds mu
+func (h *DownloadHistory) save() error {
+	data, err := json.MarshalIndent(h.entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(h.path), 0755); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+	tmp := h.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	return os.Rename(tmp, h.path)
+}
+
+// Search returns the entries whose title, artist or album contain query, newest first.
+// An empty query returns every entry.
+func (h *DownloadHistory) Search(query string) []HistoryEntry {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.load()
+	query = strings.ToLower(strings.TrimSpace(query))
+	var results []HistoryEntry
+	for _, entry := range h.entries {
+		if query == "" ||
+			strings.Contains(strings.ToLower(entry.Title), query) ||
+			strings.Contains(strings.ToLower(entry.Artist), query) ||
+			strings.Contains(strings.ToLower(entry.Album), query) {
+			results = append(results, entry)
+		}
+	}
+	sort.SliceStable(results, func(i, k int) bool { return results[i].DownloadedAt.After(results[k].DownloadedAt) })
+	return results
+}
+
+// load reads the history file once. A missing or corrupt file starts an empty history.
+func (h *DownloadHistory) load() {
+	if h.loaded {
+		return
+	}
+	h.loaded = true
+	data, err := os.ReadFile(h.path)
+	if err != nil {
+		return
+	}
+	if err := json.Unmarshal(data, &h.entries); err != nil {
+		colorWarning.Printf("⚠️ Ignoring unreadable download history %s: %v\n", h.path, err)
+		h.entries = nil
+	}
+}
+
+// ExportHistory writes entries as "json" or "csv"
+func ExportHistory(w io.Writer, entries []HistoryEntry, format string) error {
+	switch strings.ToLower(format) {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(entries)
+	case "csv":
+		cw := csv.NewWriter(w)
+		cw.Write([]string{"track_id", "album_id", "isrc", "title", "artist", "album", "path", "format", "checksum", "downloaded_at"})
+		for _, e := range entries {
+			cw.Write([]string{e.TrackID, e.AlbumID, e.ISRC, e.Title, e.Artist, e.Album, e.Path, e.Format, e.Checksum, e.DownloadedAt.Format(time.RFC3339)})
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+	return fmt.Errorf("unsupported export format '%s' (expected json or csv)", format)
+}
+
+// previouslyDownloaded returns the history entry of a track of albumID unless --ignore-history
+// is set, for this run or the queue item of ctx. The same recording downloaded with another
+// album only counts when the config skips such duplicates.
+func previouslyDownloaded(ctx context.Context, track Track, albumID string, config *Config) *HistoryEntry {
+	if ignoreHistory || queueOptionsFrom(ctx).IgnoreHistory {
+		return nil
+	}
+	return downloadHistory.Find(idToString(track.ID), track.ISRC, historyAlbumScope(config, albumID))
+}
+
+// skipReason says why a track with this entry is skipped, naming the album when the
+// recording was downloaded with another one
+func (e HistoryEntry) skipReason(albumID string) string {
+	if e.AlbumID != "" && albumID != "" && e.AlbumID != albumID && e.Album != "" {
+		return fmt.Sprintf("Same recording already downloaded with %s on %s: %s", e.Album, FormatDate(e.DownloadedAt), e.Path)
+	}
+	return fmt.Sprintf("Already downloaded on %s: %s", FormatDate(e.DownloadedAt), e.Path)
+}
+
+// historyAlbumScope is the album ISRC matches in the history are limited to, none when
+// SkipCrossAlbumDuplicates is set
+func historyAlbumScope(config *Config, albumID string) string {
+	if config != nil && config.SkipCrossAlbumDuplicates {
+		return ""
+	}
+	return albumID
+}
+
+// recordDownload adds a track to the history, a failure only produces a warning
+func recordDownload(track Track, album *Album, path, format string, transfer *TransferStats) {
+	fields := map[string]interface{}{"track_id": idToString(track.ID), "title": track.Title, "artist": track.Artist, "path": path}
+	if album != nil {
+		fields["album_id"] = album.ID
+		fields["album"] = album.Title
+	}
+	emitEvent("track_complete", fields)
+	if err := downloadHistory.Record(track, album, path, format, transfer); err != nil {
+		colorWarning.Printf("⚠️ Failed to update download history: %v\n", err)
+	}
+}
+
+// updateHistoryChecksums records the final checksums of files changed after they were
+// downloaded, a failure only produces a warning
+func updateHistoryChecksums(paths []string) {
+	if len(paths) == 0 {
+		return
+	}
+	if err := downloadHistory.UpdateChecksums(paths); err != nil {
+		colorWarning.Printf("⚠️ Failed to update download history: %v\n", err)
+	}
+}
+
+// fileChecksum returns the hex SHA-256 of a file
+func fileChecksum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/net/http/httpproxy"
+	"golang.org/x/oauth2"
+)
+
+// sharedTransport is the HTTP transport used by the DAB, Spotify, MusicBrainz and Navidrome
+// clients. It is configured from the TLS, network and proxy settings by ConfigureTransport.
+var sharedTransport http.RoundTripper = http.DefaultTransport
+
+// ConfigureTransport builds the shared transport from the config's TLS and network options.
+// Every option is checked before the transport is replaced, so an error leaves none of
+// them applied rather than some.
+func ConfigureTransport(config *Config) error {
+	if err := validateNetworkConfig(config); err != nil {
+		return err
+	}
+	tlsConfig, err := buildTLSConfig(config)
+	if err != nil {
+		return err
+	}
+	dialContext, err := buildDialContext(config)
+	if err != nil {
+		return err
+	}
+	proxy, err := buildProxy(config)
+	if err != nil {
+		return err
+	}
+	spec := chaosSpec
+	if spec == "" {
+		spec = os.Getenv("DAB_CHAOS")
+	}
+	chaos, err := parseChaos(spec)
+	if err != nil {
+		return err
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	transport.DialContext = dialContext
+	transport.Proxy = proxy
+	sharedTransport = transport
+	if chaos != nil {
+		colorWarning.Printf("🐒 Chaos mode: %.0f%% rate limits, %.0f%% truncated responses, %.0f%% delayed by %s\n", chaos.RateLimit*100, chaos.Truncate*100, chaos.Slow*100, chaos.Delay)
+		sharedTransport = newChaosTransport(transport, *chaos)
+	}
+	if verbosity >= LevelInfo {
+		sharedTransport = &loggingTransport{next: sharedTransport}
+	}
+	return nil
+}
+
+// validateNetworkConfig checks the IP version and host overrides
+func validateNetworkConfig(config *Config) error {
+	switch config.IPVersion {
+	case "", "auto", "4", "6":
+	default:
+		return fmt.Errorf("invalid IP version '%s' (expected 4, 6 or auto)", config.IPVersion)
+	}
+	for host, ip := range config.HostOverrides {
+		if net.ParseIP(ip) == nil {
+			return fmt.Errorf("invalid IP address '%s' in the host override of %s", ip, host)
+		}
+	}
+	return nil
+}
+
+// buildDialContext returns a dialer honoring the IP version preference, DNS server and host overrides
+func buildDialContext(config *Config) (func(ctx context.Context, network, addr string) (net.Conn, error), error) {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+
+	var forcedNetwork string
+	switch config.IPVersion {
+	case "", "auto":
+	case "4":
+		forcedNetwork = "tcp4"
+	case "6":
+		forcedNetwork = "tcp6"
+	default:
+		return nil, fmt.Errorf("invalid IP version '%s' (expected 4, 6 or auto)", config.IPVersion)
+	}
+
+	if config.DNSServer != "" {
+		dnsServer := config.DNSServer
+		if _, _, err := net.SplitHostPort(dnsServer); err != nil {
+			dnsServer = net.JoinHostPort(dnsServer, "53")
+		}
+		dialer.Resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				return (&net.Dialer{Timeout: 10 * time.Second}).DialContext(ctx, network, dnsServer)
+			},
+		}
+	}
+
+	hostOverrides := make(map[string]string, len(config.HostOverrides))
+	for host, ip := range config.HostOverrides {
+		hostOverrides[strings.ToLower(host)] = ip
+	}
+
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		// Static host mappings replace DNS resolution; TLS still verifies the original host name
+		if host, port, err := net.SplitHostPort(addr); err == nil {
+			if override, ok := hostOverrides[strings.ToLower(host)]; ok {
+				addr = net.JoinHostPort(override, port)
+			}
+		}
+		if forcedNetwork != "" && strings.HasPrefix(network, "tcp") {
+			network = forcedNetwork
+		}
+		return dialer.DialContext(ctx, network, addr)
+	}, nil
+}
+
+// streamRequestKey marks the context of audio stream requests, which may use their own proxy
+type streamRequestKey struct{}
+
+// streamContext marks requests made with ctx as audio stream downloads
+func streamContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, streamRequestKey{}, true)
+}
+
+// buildProxy returns the proxy selection of the transport. ProxyURL replaces the
+// HTTP_PROXY and HTTPS_PROXY environment variables and NoProxy replaces NO_PROXY. Audio
+// streams use StreamProxyURL when it is set, where "direct" means no proxy.
+func buildProxy(config *Config) (func(*http.Request) (*url.URL, error), error) {
+	if config.ProxyURL == "" && config.StreamProxyURL == "" && config.NoProxy == "" {
+		return http.ProxyFromEnvironment, nil
+	}
+
+	apiProxy := httpproxy.FromEnvironment()
+	if config.ProxyURL != "" {
+		if err := validateProxyURL(config.ProxyURL); err != nil {
+			return nil, err
+		}
+		apiProxy.HTTPProxy, apiProxy.HTTPSProxy = config.ProxyURL, config.ProxyURL
+	}
+	if config.NoProxy != "" {
+		apiProxy.NoProxy = config.NoProxy
+	}
+	apiFunc := apiProxy.ProxyFunc()
+
+	streamFunc := apiFunc
+	switch config.StreamProxyURL {
+	case "":
+	case "direct":
+		streamFunc = func(*url.URL) (*url.URL, error) { return nil, nil }
+	default:
+		if err := validateProxyURL(config.StreamProxyURL); err != nil {
+			return nil, err
+		}
+		streamProxy := *apiProxy
+		streamProxy.HTTPProxy, streamProxy.HTTPSProxy = config.StreamProxyURL, config.StreamProxyURL
+		streamFunc = streamProxy.ProxyFunc()
+	}
+
+	return func(req *http.Request) (*url.URL, error) {
+		if req.Context().Value(streamRequestKey{}) != nil {
+			return streamFunc(req.URL)
+		}
+		return apiFunc(req.URL)
+	}, nil
+}
+
+// validateProxyURL checks that a proxy URL has a scheme the transport supports
+func validateProxyURL(proxyURL string) error {
+	u, err := url.Parse(proxyURL)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("invalid proxy URL '%s'", proxyURL)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+		return nil
+	}
+	return fmt.Errorf("unsupported proxy scheme '%s' (expected http, https, socks5 or socks5h)", u.Scheme)
+}
+
+// buildTLSConfig returns the TLS configuration for a custom CA bundle and/or insecure mode
+func buildTLSConfig(config *Config) (*tls.Config, error) {
+	tlsConfig := &tls.Config{}
+
+	if config.CACertFile != "" {
+		pem, err := os.ReadFile(config.CACertFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA certificate file: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no valid PEM certificates found in %s", config.CACertFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if config.InsecureSkipVerify {
+		colorWarning.Println("⚠️ TLS certificate verification is DISABLED. Connections can be intercepted; prefer CACertFile for self-signed certificates.")
+		tlsConfig.InsecureSkipVerify = true
+	}
+
+	return tlsConfig, nil
+}
+
+// newHTTPClient creates an HTTP client using the shared transport
+func newHTTPClient(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Transport: sharedTransport,
+		Timeout:   timeout,
+	}
+}
+
+// oauthContext makes the OAuth2 token requests of ctx, and the Spotify clients built from
+// it, go through the shared transport
+func oauthContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, oauth2.HTTPClient, newHTTPClient(30*time.Second))
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+const defaultLocale = "en"
+
+// currentLocale is the locale used by T, set from config, --lang or the environment
+var currentLocale = defaultLocale
+
+// messages holds user-facing strings keyed by locale and message ID.
+// Every message must exist in "en", other locales fall back to it when a key is missing.
+var messages = map[string]map[string]string{
+	"en": {
+		"artist.start":          "🎵 Starting artist discography download for ID: %s",
+		"artist.start_name":     "🎵 Starting artist discography download for: %s",
+		"artist.cancelled":      "⚠️ Discography download cancelled by user.",
+		"artist.no_items":       "⚠️ No items were selected for download.",
+		"artist.failed":         "❌ Failed to download discography: %v",
+		"artist.failed_name":    "❌ Failed to download discography for %s: %v",
+		"artist.completed":      "✅ Discography download completed!",
+		"artist.completed_name": "✅ Discography download completed for %s",
+		"album.start":           "🎵 Starting album download for ID: %s",
+		"album.start_name":      "🎵 Starting album download for: %s by %s",
+		"album.failed":          "❌ Failed to download album: %v",
+		"album.failed_name":     "❌ Failed to download album %s: %v",
+		"album.completed":       "✅ Album download completed!",
+		"album.completed_name":  "✅ Album download completed for %s",
+		"track.start_name":      "🎵 Starting track download for: %s by %s",
+		"track.failed_name":     "❌ Failed to download track %s: %v",
+		"track.completed_name":  "✅ Track download completed for %s",
+		"search.failed":         "❌ Search failed: %v",
+		"search.unknown_type":   "❌ Unknown item type selected.",
+		"ffmpeg.missing":        "❌ ffmpeg is not installed or not in your PATH. Please install ffmpeg to use the format conversion feature.",
+		"spotify.auth_failed":   "❌ Failed to authenticate with Spotify: %v",
+		"spotify.invalid_url":   "❌ Invalid Spotify URL. Please provide a playlist or album URL.",
+		"spotify.tracks_failed": "❌ Failed to get tracks from Spotify: %v",
+		"deezer.invalid_url":    "❌ Invalid Deezer URL. Please provide a playlist or album URL.",
+		"deezer.tracks_failed":  "❌ Failed to get tracks from Deezer: %v",
+		"stats.header":          "📊 Download Summary for %s:",
+		"stats.success":         "✅ Successfully downloaded: %d items",
+		"stats.skipped":         "⭐ Skipped (already exist): %d items",
+		"stats.failed":          "❌ Failed to download: %d items",
+		"stats.unavailable":     "🚫 Unavailable on DAB (not failed): %d tracks",
+		"stats.location":        "🎉 Artist discography downloaded to: %s",
+		"stats.finished_at":     "🕒 Finished: %s",
+		"warnings.header":       "⚠️  Warning Summary (%d warnings):",
+		"warnings.mb_track":     "MusicBrainz Track Lookup Failures",
+		"warnings.mb_release":   "MusicBrainz Release Lookup Failures",
+		"warnings.cover_dl":     "Cover Art Download Failures",
+		"warnings.cover_meta":   "Cover Art Metadata Failures",
+		"warnings.album_fetch":  "Album Information Fetch Failures",
+		"warnings.skipped":      "Tracks Skipped (Already Exist)",
+		"warnings.other":        "Other Warnings",
+	},
+	"es": {
+		"artist.start":          "🎵 Iniciando la descarga de la discografía del artista con ID: %s",
+		"artist.start_name":     "🎵 Iniciando la descarga de la discografía de: %s",
+		"artist.cancelled":      "⚠️ Descarga de la discografía cancelada por el usuario.",
+		"artist.no_items":       "⚠️ No se seleccionó ningún elemento para descargar.",
+		"artist.failed":         "❌ Error al descargar la discografía: %v",
+		"artist.failed_name":    "❌ Error al descargar la discografía de %s: %v",
+		"artist.completed":      "✅ ¡Descarga de la discografía completada!",
+		"artist.completed_name": "✅ Descarga de la discografía completada para %s",
+		"album.start":           "🎵 Iniciando la descarga del álbum con ID: %s",
+		"album.start_name":      "🎵 Iniciando la descarga del álbum: %s de %s",
+		"album.failed":          "❌ Error al descargar el álbum: %v",
+		"album.failed_name":     "❌ Error al descargar el álbum %s: %v",
+		"album.completed":       "✅ ¡Descarga del álbum completada!",
+		"album.completed_name":  "✅ Descarga del álbum completada para %s",
+		"track.start_name":      "🎵 Iniciando la descarga de la pista: %s de %s",
+		"track.failed_name":     "❌ Error al descargar la pista %s: %v",
+		"track.completed_name":  "✅ Descarga de la pista completada para %s",
+		"search.failed":         "❌ La búsqueda falló: %v",
+		"search.unknown_type":   "❌ Tipo de elemento seleccionado desconocido.",
+		"ffmpeg.missing":        "❌ ffmpeg no está instalado o no está en tu PATH. Instala ffmpeg para usar la conversión de formato.",
+		"spotify.auth_failed":   "❌ Error al autenticarse con Spotify: %v",
+		"spotify.invalid_url":   "❌ URL de Spotify no válida. Proporciona la URL de una lista de reproducción o de un álbum.",
+		"spotify.tracks_failed": "❌ Error al obtener las pistas de Spotify: %v",
+		"deezer.invalid_url":    "❌ URL de Deezer no válida. Proporciona la URL de una lista de reproducción o de un álbum.",
+		"deezer.tracks_failed":  "❌ Error al obtener las pistas de Deezer: %v",
+		"stats.header":          "📊 Resumen de descargas de %s:",
+		"stats.success":         "✅ Descargados correctamente: %d elementos",
+		"stats.skipped":         "⭐ Omitidos (ya existen): %d elementos",
+		"stats.failed":          "❌ No se pudieron descargar: %d elementos",
+		"stats.unavailable":     "🚫 No disponibles en DAB (no son errores): %d pistas",
+		"stats.location":        "🎉 Discografía del artista descargada en: %s",
+		"stats.finished_at":     "🕒 Finalizado: %s",
+		"warnings.header":       "⚠️  Resumen de advertencias (%d advertencias):",
+		"warnings.mb_track":     "Fallos al buscar pistas en MusicBrainz",
+		"warnings.mb_release":   "Fallos al buscar lanzamientos en MusicBrainz",
+		"warnings.cover_dl":     "Fallos al descargar la portada",
+		"warnings.cover_meta":   "Fallos al añadir la portada a los metadatos",
+		"warnings.album_fetch":  "Fallos al obtener la información del álbum",
+		"warnings.skipped":      "Pistas omitidas (ya existen)",
+		"warnings.other":        "Otras advertencias",
+	},
+	"de": {
+		"artist.start":          "🎵 Starte Download der Diskografie für Künstler-ID: %s",
+		"artist.start_name":     "🎵 Starte Download der Diskografie von: %s",
+		"artist.cancelled":      "⚠️ Download der Diskografie vom Benutzer abgebrochen.",
+		"artist.no_items":       "⚠️ Es wurden keine Elemente zum Herunterladen ausgewählt.",
+		"artist.failed":         "❌ Download der Diskografie fehlgeschlagen: %v",
+		"artist.failed_name":    "❌ Download der Diskografie von %s fehlgeschlagen: %v",
+		"artist.completed":      "✅ Download der Diskografie abgeschlossen!",
+		"artist.completed_name": "✅ Download der Diskografie von %s abgeschlossen",
+		"album.start":           "🎵 Starte Album-Download für ID: %s",
+		"album.start_name":      "🎵 Starte Album-Download: %s von %s",
+		"album.failed":          "❌ Album-Download fehlgeschlagen: %v",
+		"album.failed_name":     "❌ Download des Albums %s fehlgeschlagen: %v",
+		"album.completed":       "✅ Album-Download abgeschlossen!",
+		"album.completed_name":  "✅ Album-Download abgeschlossen für %s",
+		"track.start_name":      "🎵 Starte Titel-Download: %s von %s",
+		"track.failed_name":     "❌ Download des Titels %s fehlgeschlagen: %v",
+		"track.completed_name":  "✅ Titel-Download abgeschlossen für %s",
+		"search.failed":         "❌ Suche fehlgeschlagen: %v",
+		"search.unknown_type":   "❌ Unbekannter Elementtyp ausgewählt.",
+		"ffmpeg.missing":        "❌ ffmpeg ist nicht installiert oder nicht im PATH. Bitte installiere ffmpeg, um die Formatkonvertierung zu nutzen.",
+		"spotify.auth_failed":   "❌ Anmeldung bei Spotify fehlgeschlagen: %v",
+		"spotify.invalid_url":   "❌ Ungültige Spotify-URL. Bitte gib die URL einer Playlist oder eines Albums an.",
+		"spotify.tracks_failed": "❌ Titel konnten nicht von Spotify abgerufen werden: %v",
+		"deezer.invalid_url":    "❌ Ungültige Deezer-URL. Bitte gib die URL einer Playlist oder eines Albums an.",
+		"deezer.tracks_failed":  "❌ Titel konnten nicht von Deezer abgerufen werden: %v",
+		"stats.header":          "📊 Download-Zusammenfassung für %s:",
+		"stats.success":         "✅ Erfolgreich heruntergeladen: %d Elemente",
+		"stats.skipped":         "⭐ Übersprungen (bereits vorhanden): %d Elemente",
+		"stats.failed":          "❌ Download fehlgeschlagen: %d Elemente",
+		"stats.unavailable":     "🚫 Auf DAB nicht verfügbar (kein Fehler): %d Titel",
+		"stats.location":        "🎉 Diskografie heruntergeladen nach: %s",
+		"stats.finished_at":     "🕒 Abgeschlossen: %s",
+		"warnings.header":       "⚠️  Zusammenfassung der Warnungen (%d Warnungen):",
+		"warnings.mb_track":     "Fehlgeschlagene MusicBrainz-Titelsuchen",
+		"warnings.mb_release":   "Fehlgeschlagene MusicBrainz-Veröffentlichungssuchen",
+		"warnings.cover_dl":     "Fehlgeschlagene Cover-Downloads",
+		"warnings.cover_meta":   "Fehler beim Einbetten des Covers",
+		"warnings.album_fetch":  "Fehler beim Abrufen der Albuminformationen",
+		"warnings.skipped":      "Übersprungene Titel (bereits vorhanden)",
+		"warnings.other":        "Sonstige Warnungen",
+	},
+}
+
+// dateFormats holds the date layout used in reports for each locale
+var dateFormats = map[string]string{
+	"en": "Jan 2, 2006 15:04",
+	"es": "02/01/2006 15:04",
+	"de": "02.01.2006 15:04",
+}
+
+// SetLocale selects the locale for user-facing messages.
+// Accepts values like "de", "es_ES" or "es_ES.UTF-8"; unsupported locales fall back to English.
+func SetLocale(locale string) {
+	currentLocale = normalizeLocale(locale)
+}
+
+// DetectLocale returns the locale from the environment (LC_ALL, LC_MESSAGES, LANG)
+func DetectLocale() string {
+	for _, env := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if value := os.Getenv(env); value != "" {
+			return normalizeLocale(value)
+		}
+	}
+	return defaultLocale
+}
+
+// normalizeLocale reduces a locale string to a supported language code
+func normalizeLocale(locale string) string {
+	locale = strings.ToLower(strings.TrimSpace(locale))
+	if i := strings.IndexAny(locale, "_-."); i >= 0 {
+		locale = locale[:i]
+	}
+	if _, ok := messages[locale]; ok {
+		return locale
+	}
+	return defaultLocale
+}
+
+// T returns the message for key in the current locale, formatted with args
+func T(key string, args ...interface{}) string {
+	msg, ok := messages[currentLocale][key]
+	if !ok {
+		msg, ok = messages[defaultLocale][key]
+		if !ok {
+			msg = key
+		}
+	}
+	if len(args) == 0 {
+		return msg
+	}
+	return fmt.Sprintf(msg, args...)
+}
+
+// FormatDate formats a timestamp for reports using the current locale
+func FormatDate(t time.Time) string {
+	layout, ok := dateFormats[currentLocale]
+	if !ok {
+		layout = dateFormats[defaultLocale]
+	}
+	return t.Format(layout)
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// identifierSearchLimit is the number of DAB results checked for an exact identifier match
+const identifierSearchLimit = 10
+
+// FindTrackByISRC returns the DAB track with exactly the given ISRC. When searching DAB for
+// the code finds nothing, the recording is looked up on MusicBrainz and DAB is searched
+// for its title and artist instead, still only accepting an exact ISRC match (or, if DAB
+// has no ISRC for the result, the same title and artist).
+func (api *DabAPI) FindTrackByISRC(ctx context.Context, isrc string, debug bool) (*Track, error) {
+	isrc = strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(isrc), "-", ""))
+	results, err := api.Search(ctx, isrc, "track", identifierSearchLimit, debug)
+	if err != nil {
+		return nil, err
+	}
+	for i := range results.Tracks {
+		if strings.EqualFold(results.Tracks[i].ISRC, isrc) {
+			return &results.Tracks[i], nil
+		}
+	}
+
+	if !musicBrainzEnabled {
+		return nil, fmt.Errorf("no track found with ISRC %s", isrc)
+	}
+	recordings, err := mbClient.LookupISRC(isrc)
+	if err != nil {
+		return nil, fmt.Errorf("no track found with ISRC %s: %w", isrc, err)
+	}
+	for _, recording := range recordings {
+		artist := ""
+		if len(recording.ArtistCredit) > 0 {
+			artist = recording.ArtistCredit[0].Artist.Name
+		}
+		if debug {
+			fmt.Printf("DEBUG: ISRC %s is %s by %s on MusicBrainz\n", isrc, recording.Title, artist)
+		}
+		results, err := api.Search(ctx, recording.Title+" "+artist, "track", identifierSearchLimit, debug)
+		if err != nil {
+			return nil, err
+		}
+		for i := range results.Tracks {
+			track := &results.Tracks[i]
+			if strings.EqualFold(track.ISRC, isrc) {
+				return track, nil
+			}
+			if track.ISRC == "" && sameName(track.Title, recording.Title) && sameName(track.Artist, artist) {
+				return track, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("no track found with ISRC %s", isrc)
+}
+
+// FindAlbumByUPC returns the DAB album with the given UPC/EAN barcode. Like FindTrackByISRC
+// it falls back to the MusicBrainz release with that barcode to search DAB by title.
+func (api *DabAPI) FindAlbumByUPC(ctx context.Context, upc string, debug bool) (*Album, error) {
+	upc = strings.TrimSpace(upc)
+	results, err := api.Search(ctx, upc, "album", identifierSearchLimit, debug)
+	if err != nil {
+		return nil, err
+	}
+	if album := api.albumWithUPC(ctx, results.Albums, upc, debug); album != nil {
+		return album, nil
+	}
+
+	if !musicBrainzEnabled {
+		return nil, fmt.Errorf("no album found with UPC %s", upc)
+	}
+	release, err := mbClient.SearchReleaseByBarcode(upc)
+	if err != nil {
+		return nil, fmt.Errorf("no album found with UPC %s: %w", upc, err)
+	}
+	artist := ""
+	if len(release.ArtistCredit) > 0 {
+		artist = release.ArtistCredit[0].Artist.Name
+	}
+	if debug {
+		fmt.Printf("DEBUG: UPC %s is %s by %s on MusicBrainz\n", upc, release.Title, artist)
+	}
+	results, err = api.Search(ctx, release.Title+" "+artist, "album", identifierSearchLimit, debug)
+	if err != nil {
+		return nil, err
+	}
+	if album := api.albumWithUPC(ctx, results.Albums, upc, debug); album != nil {
+		return album, nil
+	}
+	for i := range results.Albums {
+		album := &results.Albums[i]
+		if album.UPC == "" && sameName(album.Title, release.Title) && sameName(album.Artist, artist) {
+			return album, nil
+		}
+	}
+	return nil, fmt.Errorf("no album found with UPC %s", upc)
+}
+
+// albumWithUPC returns the album whose UPC matches. Search results often leave the UPC
+// out, so candidates without one are fetched in full.
+func (api *DabAPI) albumWithUPC(ctx context.Context, albums []Album, upc string, debug bool) *Album {
+	for i := range albums {
+		album := &albums[i]
+		if album.UPC == "" {
+			full, err := api.GetAlbum(ctx, album.ID)
+			if err != nil {
+				if debug {
+					fmt.Printf("DEBUG: Failed to fetch album %s: %v\n", album.ID, err)
+				}
+				continue
+			}
+			album = full
+		}
+		if sameBarcode(album.UPC, upc) {
+			return album
+		}
+	}
+	return nil
+}
+
+// sameBarcode compares UPC/EAN codes, which differ only by leading zeros between a
+// 12-digit UPC and its 13-digit EAN form
+func sameBarcode(a, b string) bool {
+	a, b = strings.TrimLeft(strings.TrimSpace(a), "0"), strings.TrimLeft(strings.TrimSpace(b), "0")
+	return a != "" && a == b
+}
+
+// sameName compares titles or artist names ignoring case, punctuation and spacing
+func sameName(a, b string) bool {
+	simplify := func(s string) string {
+		var sb strings.Builder
+		for _, r := range strings.ToLower(s) {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				sb.WriteRune(r)
+			}
+		}
+		return sb.String()
+	}
+	return simplify(a) != "" && simplify(a) == simplify(b)
+}
@@ -0,0 +1,329 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/go-flac/go-flac"
+)
+
+// checksumManifestName is the file in each album folder listing the SHA-256 of its tracks,
+// in the format of sha256sum so `sha256sum -c` can check it too
+const checksumManifestName = "checksums.sha256"
+
+// manifestFiles returns the audio files of an album folder and its disc folders, with
+// slash-separated paths relative to the folder as written to the manifest
+func manifestFiles(albumDir string) []string {
+	var files []string
+	for _, path := range albumAudioFiles(albumDir) {
+		if rel, err := filepath.Rel(albumDir, path); err == nil {
+			files = append(files, filepath.ToSlash(rel))
+		}
+	}
+	sort.Strings(files)
+	return files
+}
+
+// UpdateChecksumManifest records the SHA-256 of the given files in the checksums.sha256 of
+// their album folder. Entries of other files are kept as they are, so a file that changed
+// on disk since its checksum was written still shows up as changed.
+func UpdateChecksumManifest(albumDir string, written []string) error {
+	manifestPath := filepath.Join(albumDir, checksumManifestName)
+	checksums, err := ReadChecksumManifest(manifestPath)
+	if os.IsNotExist(err) {
+		checksums, err = make(map[string]string), nil
+	}
+	if err != nil {
+		return err
+	}
+	updated := false
+	for _, path := range written {
+		rel, err := filepath.Rel(albumDir, path)
+		if err != nil || strings.HasPrefix(rel, "..") || !audioExtensions[strings.ToLower(filepath.Ext(path))] {
+			continue // Converted copies of KeepOriginal live in another tree
+		}
+		checksum, err := fileChecksum(path)
+		if err != nil {
+			return fmt.Errorf("failed to checksum %s: %w", rel, err)
+		}
+		checksums[filepath.ToSlash(rel)] = checksum
+		updated = true
+	}
+	if !updated {
+		return nil
+	}
+
+	files := make([]string, 0, len(checksums))
+	for file := range checksums {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	var manifest strings.Builder
+	for _, file := range files {
+		fmt.Fprintf(&manifest, "%s  %s\n", checksums[file], file)
+	}
+	return os.WriteFile(manifestPath, []byte(manifest.String()), 0644)
+}
+
+// ReadChecksumManifest returns the checksums of a manifest by path relative to its folder
+func ReadChecksumManifest(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	checksums := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		checksum, file, ok := strings.Cut(line, " ")
+		if !ok {
+			return nil, fmt.Errorf("invalid line in %s: %s", path, line)
+		}
+		// sha256sum marks binary mode with a '*' before the name
+		file = strings.TrimPrefix(strings.TrimLeft(file, " "), "*")
+		checksums[file] = strings.ToLower(checksum)
+	}
+	return checksums, scanner.Err()
+}
+
+// flacTestAvailable reports whether FLAC audio can be decoded for VerifyFLACAudio
+func flacTestAvailable() bool {
+	if _, err := exec.LookPath("flac"); err == nil {
+		return true
+	}
+	return CheckFFmpeg()
+}
+
+// pcmCodecs are the ffmpeg codecs writing samples the way the FLAC stream info MD5 hashes
+// them, by bits per sample: signed, little-endian, in whole bytes
+var pcmCodecs = map[int]string{8: "pcm_s8", 16: "pcm_s16le", 24: "pcm_s24le", 32: "pcm_s32le"}
+
+// VerifyFLACAudio decodes a FLAC and checks its frames and the MD5 of the decoded audio
+// against the stream info, like `flac -t`. The flac tool is used when installed, ffmpeg
+// otherwise.
+func VerifyFLACAudio(path string) error {
+	if _, err := exec.LookPath("flac"); err == nil {
+		if output, err := exec.Command("flac", "-t", "-s", path).CombinedOutput(); err != nil {
+			return fmt.Errorf("flac -t failed: %s", TruncateString(strings.TrimSpace(string(output)), 300))
+		}
+		return nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	file, err := flac.ParseMetadata(f)
+	f.Close()
+	if err != nil {
+		return fmt.Errorf("not a valid FLAC: %w", err)
+	}
+	info, err := file.GetStreamInfo()
+	if err != nil {
+		return fmt.Errorf("not a valid FLAC: %w", err)
+	}
+	codec, ok := pcmCodecs[(info.BitDepth+7)/8*8]
+	if !ok {
+		return fmt.Errorf("unsupported bit depth %d", info.BitDepth)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("ffmpeg", "-v", "error", "-nostdin", "-i", path, "-map", "0:a:0", "-c:a", codec, "-f", "md5", "-")
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("decoding failed: %s", TruncateString(strings.TrimSpace(stderr.String()), 300))
+	}
+	if stderr.Len() > 0 {
+		return fmt.Errorf("decoding errors: %s", TruncateString(strings.TrimSpace(stderr.String()), 300))
+	}
+	// An encoder may leave the MD5 unset, then only decoding errors count
+	if bytes.Equal(info.AudioMD5, make([]byte, 16)) {
+		return nil
+	}
+	decoded := strings.TrimPrefix(strings.TrimSpace(stdout.String()), "MD5=")
+	if !strings.EqualFold(decoded, hex.EncodeToString(info.AudioMD5)) {
+		return fmt.Errorf("decoded audio doesn't match the MD5 in the stream info (truncated or damaged)")
+	}
+	return nil
+}
+
+// FileCheck is the result of verifying one file of an album folder
+type FileCheck struct {
+	Path    string
+	Problem string // Empty when the file is fine
+	Changed bool   // The checksum differs but the audio decodes correctly, e.g. after retagging
+	Damaged bool   // Missing, unreadable or the audio doesn't decode
+}
+
+// VerifyAlbumFolder checks the files listed in the manifest of an album folder against
+// their checksums, and decodes its FLACs unless decode is false. Folders without a
+// manifest only get the decoding check.
+func VerifyAlbumFolder(albumDir string, decode bool) ([]FileCheck, error) {
+	checksums, err := ReadChecksumManifest(filepath.Join(albumDir, checksumManifestName))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	files := manifestFiles(albumDir)
+	seen := make(map[string]bool, len(files))
+	for _, file := range files {
+		seen[file] = true
+	}
+	for file := range checksums {
+		if !seen[file] {
+			files = append(files, file)
+		}
+	}
+	sort.Strings(files)
+
+	var checks []FileCheck
+	for _, file := range files {
+		path := filepath.Join(albumDir, filepath.FromSlash(file))
+		check := FileCheck{Path: path}
+		if !seen[file] {
+			check.Problem, check.Damaged = "missing", true
+			checks = append(checks, check)
+			continue
+		}
+		var mismatch bool
+		if expected, ok := checksums[file]; ok {
+			checksum, err := fileChecksum(path)
+			if err != nil {
+				check.Problem, check.Damaged = fmt.Sprintf("unreadable: %v", err), true
+				checks = append(checks, check)
+				continue
+			}
+			mismatch = checksum != expected
+		}
+		if decode && strings.EqualFold(filepath.Ext(path), ".flac") {
+			if err := VerifyFLACAudio(path); err != nil {
+				check.Problem, check.Damaged = err.Error(), true
+				checks = append(checks, check)
+				continue
+			}
+			if mismatch {
+				check.Problem, check.Changed = "checksum changed, audio intact (tags edited?)", true
+			}
+		} else if mismatch {
+			check.Problem, check.Damaged = "checksum mismatch", true
+		}
+		checks = append(checks, check)
+	}
+	return checks, nil
+}
+
+// findAlbumFolders returns the folders below root holding a checksum manifest or FLAC
+// files, without their disc subfolders
+func findAlbumFolders(root string) ([]string, error) {
+	folders := make(map[string]bool)
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == quarantineDirName {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Name() == checksumManifestName || strings.EqualFold(filepath.Ext(path), ".flac") {
+			folders[filepath.Dir(path)] = true
+		}
+		return nil
+	})
+	var result []string
+	for folder := range folders {
+		// A disc subfolder is checked with its album folder
+		if parent := filepath.Dir(folder); folder != root && (folders[parent] || FileExists(filepath.Join(parent, checksumManifestName))) {
+			continue
+		}
+		result = append(result, folder)
+	}
+	sort.Strings(result)
+	return result, err
+}
+
+// RedownloadFile moves a damaged FLAC into the quarantine and downloads the track again
+// to the same place. The track is found through the download history.
+func (api *DabAPI) RedownloadFile(ctx context.Context, path string, config *Config, debug bool) error {
+	var entry *HistoryEntry
+	for _, e := range downloadHistory.Search("") {
+		if filepath.Clean(e.Path) == filepath.Clean(path) {
+			e := e
+			entry = &e
+		}
+	}
+	if entry == nil || entry.AlbumID == "" {
+		return fmt.Errorf("not in the download history")
+	}
+	album, err := api.GetAlbum(ctx, entry.AlbumID)
+	if err != nil {
+		return fmt.Errorf("failed to get album: %w", err)
+	}
+	var track *Track
+	for i := range album.Tracks {
+		if idToString(album.Tracks[i].ID) == entry.TrackID {
+			track = &album.Tracks[i]
+			break
+		}
+	}
+	if track == nil {
+		return fmt.Errorf("track %s is no longer on album %s", entry.TrackID, album.Title)
+	}
+	var coverData []byte
+	if album.Cover != "" {
+		coverData, _ = api.DownloadCover(ctx, album.Cover)
+	}
+	_, coverData = api.prepareCover(ctx, album, coverData, config, debug)
+
+	if FileExists(path) {
+		if err := RemoveFile(ctx, path, config); err != nil {
+			return err
+		}
+	}
+	warningCollector := NewWarningCollector(config.WarningBehavior != "silent")
+	finalPath, transfer, err := api.DownloadTrack(ctx, *track, album, path, coverData, nil, debug, "flac", "", config, warningCollector)
+	if err != nil {
+		return err
+	}
+	recordDownload(*track, album, finalPath, "flac", transfer)
+	return nil
+}
+
+// PrintFileChecks lists the files of an album folder with problems, and all files when
+// verbose is set
+func PrintFileChecks(albumDir string, checks []FileCheck, verbose bool) {
+	var problems []FileCheck
+	for _, check := range checks {
+		if check.Problem != "" {
+			problems = append(problems, check)
+		}
+	}
+	if len(problems) == 0 {
+		if verbose {
+			colorSuccess.Printf("✅ %s: %d files OK\n", albumDir, len(checks))
+		}
+		return
+	}
+	colorWarning.Printf("⚠️ %s: %d of %d files with problems\n", albumDir, len(problems), len(checks))
+	for _, check := range problems {
+		rel, err := filepath.Rel(albumDir, check.Path)
+		if err != nil {
+			rel = check.Path
+		}
+		fmt.Printf("   %s: %s\n", rel, check.Problem)
+	}
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	maxIssueURLLength  = 8000 // GitHub rejects longer new issue links
+	issueStackLines    = 20   // Lines of the crashing goroutine's stack included
+	issueFailedItems   = 5    // Most recent failed queue items included
+	issueAuditLogLines = 10
+)
+
+// IssueReport is a prefilled GitHub issue
+type IssueReport struct {
+	Title string
+	Body  string
+}
+
+// BuildIssueReport collects the environment, the config without secrets and what the last
+// runs left behind (crash report, jobs, failed queue items, audit log) into an issue
+func BuildIssueReport(config *Config) IssueReport {
+	report := IssueReport{Title: "Bug: "}
+	var body strings.Builder
+	body.WriteString("### What happened?\n\n<!-- What did you run, what did you expect, and what happened instead? -->\n\n")
+
+	info := GetBuildInfo()
+	body.WriteString("### Environment\n\n")
+	fmt.Fprintf(&body, "- Version: %s", info.Version)
+	if info.Commit != "" {
+		fmt.Fprintf(&body, " (%s", info.Commit[:min(12, len(info.Commit))])
+		if info.Modified {
+			body.WriteString(", modified")
+		}
+		body.WriteString(")")
+	}
+	fmt.Fprintf(&body, "\n- Platform: %s, %s\n", info.Platform, info.GoVersion)
+	fmt.Fprintf(&body, "- ffmpeg: %t, Docker: %t\n", info.Features["ffmpeg"], config.IsDockerContainer)
+	fmt.Fprintf(&body, "- API endpoint is the default: %t\n\n", config.APIURL == defaultAPIEndpoint)
+
+	if crash := latestCrashReport(); crash != nil {
+		report.Title += crash.panic
+		// The command line stays out of the public issue, it is in the attached report
+		body.WriteString("### Last crash\n\n")
+		fmt.Fprintf(&body, "```\npanic: %s\n%s\n```\n\n", crash.panic, strings.Join(crash.stack, "\n"))
+		fmt.Fprintf(&body, "<!-- Please also attach %s -->\n\n", crash.path)
+	}
+
+	if last := lastRunSummary(config); len(last) > 0 {
+		body.WriteString("### Last runs\n\n")
+		for _, line := range last {
+			fmt.Fprintf(&body, "- %s\n", line)
+		}
+		body.WriteString("\n")
+	}
+
+	withoutConfig := body.String()
+	if sanitized, err := compactSanitizedConfig(config); err == nil {
+		fmt.Fprintf(&body, "### Configuration (secrets removed)\n\n```json\n%s\n```\n", sanitized)
+	}
+	report.Body = body.String()
+	if len(report.URL(config.UpdateRepo)) > maxIssueURLLength {
+		report.Body = withoutConfig + "### Configuration\n\n<!-- Too long for the link, run 'dab-downloader report-issue --print' and paste it here -->\n"
+	}
+	return report
+}
+
+// URL returns the link that opens the prefilled issue on GitHub, cut to the length GitHub
+// accepts
+func (r IssueReport) URL(repo string) string {
+	if repo == "" {
+		repo = "PrathxmOp/dab-downloader"
+	}
+	base := fmt.Sprintf("https://github.com/%s/issues/new?", repo)
+	body := r.Body
+	for {
+		link := base + url.Values{"title": {r.Title}, "body": {body}}.Encode()
+		if len(link) <= maxIssueURLLength || body == "" {
+			return link
+		}
+		body = strings.ToValidUTF8(body[:len(body)*9/10], "")
+	}
+}
+
+// crashSummary is the part of a crash report that fits into an issue
+type crashSummary struct {
+	path  string
+	panic string
+	stack []string
+}
+
+// latestCrashReport reads the newest crash report, nil if there is none
+func latestCrashReport() *crashSummary {
+	paths, _ := filepath.Glob(filepath.Join(crashReportDir, "crash-*.txt"))
+	if len(paths) == 0 {
+		return nil
+	}
+	sort.Strings(paths) // Named by date
+	path := paths[len(paths)-1]
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	crash := &crashSummary{path: path}
+	scanner := bufio.NewScanner(file)
+	inStack := false
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "Panic: "):
+			crash.panic = strings.TrimPrefix(line, "Panic: ")
+		case line == "===== Stack trace =====":
+			inStack = true
+		case inStack:
+			// Only the first goroutine, the one that panicked
+			if line == "" || len(crash.stack) >= issueStackLines {
+				return crash
+			}
+			crash.stack = append(crash.stack, line)
+		}
+	}
+	return crash
+}
+
+// lastRunSummary describes the newest job, the recently failed queue items and the end
+// of the audit log
+func lastRunSummary(config *Config) []string {
+	var lines []string
+	if jobs, err := jobStore.List(); err == nil && len(jobs) > 0 {
+		job := jobs[len(jobs)-1]
+		line := fmt.Sprintf("Last job: %s %s, %s", job.Type, job.Target, job.Status)
+		if job.Error != "" {
+			line += ": " + job.Error
+		}
+		lines = append(lines, line)
+	}
+
+	if state, err := downloadQueue.State(); err == nil {
+		var failed []string
+		for i := len(state.Items) - 1; i >= 0 && len(failed) < issueFailedItems; i-- {
+			if item := state.Items[i]; item.Status == QueueFailed {
+				failed = append(failed, fmt.Sprintf("Failed queue item: %s %s: %s", item.Type, item.Value, item.Error))
+			}
+		}
+		lines = append(lines, failed...)
+	}
+
+	if config.AuditLogPath != "" {
+		if data, err := os.ReadFile(config.AuditLogPath); err == nil {
+			entries := strings.Split(strings.TrimSpace(string(data)), "\n")
+			if len(entries) > issueAuditLogLines {
+				entries = entries[len(entries)-issueAuditLogLines:]
+			}
+			for _, entry := range entries {
+				var audit AuditEntry
+				if json.Unmarshal([]byte(entry), &audit) == nil {
+					lines = append(lines, fmt.Sprintf("Audit: %s %s %s", audit.Time, audit.Action, filepath.Base(audit.Target)))
+				}
+			}
+		}
+	}
+	return lines
+}
+
+// compactSanitizedConfig is the sanitized config without empty options, to keep the
+// issue short
+func compactSanitizedConfig(config *Config) (string, error) {
+	data, err := sanitizedConfig(config)
+	if err != nil {
+		return "", err
+	}
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return "", err
+	}
+	dropEmptyOptions(raw)
+	compact, err := json.MarshalIndent(raw, "", "  ")
+	return string(compact), err
+}
+
+// dropEmptyOptions removes empty strings, false, zero and options left empty by that
+func dropEmptyOptions(object map[string]interface{}) {
+	for key, value := range object {
+		if nested, ok := value.(map[string]interface{}); ok {
+			dropEmptyOptions(nested)
+		}
+		if isEmptyValue(value) || value == false || value == float64(0) {
+			delete(object, key)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	JobRunning   = "running"
+	JobCompleted = "completed"
+	JobFailed    = "failed"
+
+	maxFinishedJobs = 50 // Completed jobs kept in the store, older ones are pruned
+)
+
+// Job records a download so it can be inspected and resumed after an interruption
+type Job struct {
+	ID            int       `json:"id"`
+	Type          string    `json:"type"` // "artist" or "album"
+	Target        string    `json:"target"`
+	Name          string    `json:"name,omitempty"`
+	Items         []string  `json:"items,omitempty"`     // Album IDs selected for an artist job
+	Completed     []string  `json:"completed,omitempty"` // Album IDs already downloaded
+	Format        string    `json:"format"`
+	Bitrate       string    `json:"bitrate"`
+	Status        string    `json:"status"`
+	Error         string    `json:"error,omitempty"`
+	CorrelationID string    `json:"correlation_id,omitempty"` // Shown in logs, events and reports of the job
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// Pending returns the items of the job that have not been downloaded yet
+func (j *Job) Pending() []string {
+	done := make(map[string]bool, len(j.Completed))
+	for _, id := range j.Completed {
+		done[id] = true
+	}
+	var pending []string
+	for _, id := range j.Items {
+		if !done[id] {
+			pending = append(pending, id)
+		}
+	}
+	return pending
+}
+
+// JobStore persists jobs to a JSON file next to the config
+type JobStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// jobStore is the store used by the download commands
+var jobStore = NewJobStore(filepath.Join("config", "jobs.json"))
+
+// NewJobStore creates a job store backed by the given file
+func NewJobStore(path string) *JobStore {
+	return &JobStore{path: path}
+}
+
+// List returns all recorded jobs, newest first
+func (s *JobStore) List() ([]*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	jobs, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(jobs, func(i, k int) bool { return jobs[i].ID > jobs[k].ID })
+	return jobs, nil
+}
+
+// Get returns the job with the given ID
+func (s *JobStore) Get(id int) (*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	jobs, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	for _, job := range jobs {
+		if job.ID == id {
+			return job, nil
+		}
+	}
+	return nil, fmt.Errorf("job %d not found", id)
+}
+
+// Start records a new running job and assigns its ID
+func (s *JobStore) Start(job *Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	jobs, err := s.load()
+	if err != nil {
+		return err
+	}
+	for _, existing := range jobs {
+		if existing.ID >= job.ID {
+			job.ID = existing.ID + 1
+		}
+	}
+	if job.ID == 0 {
+		job.ID = 1
+	}
+	if job.CorrelationID == "" {
+		job.CorrelationID = newCorrelationID()
+	}
+	job.Status = JobRunning
+	job.CreatedAt = time.Now()
+	job.UpdatedAt = job.CreatedAt
+	return s.save(append(jobs, job))
+}
+
+// Update saves the current state of a job
+func (s *JobStore) Update(job *Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	jobs, err := s.load()
+	if err != nil {
+		return err
+	}
+	job.UpdatedAt = time.Now()
+	for i, existing := range jobs {
+		if existing.ID == job.ID {
+			jobs[i] = job
+			return s.save(jobs)
+		}
+	}
+	return s.save(append(jobs, job))
+}
+
+// MarkItemDone records an item of the job as downloaded
+func (s *JobStore) MarkItemDone(job *Job, itemID string) error {
+	s.mu.Lock()
+	job.Completed = append(job.Completed, itemID)
+	s.mu.Unlock()
+	return s.Update(job)
+}
+
+// Finish marks a job as completed, or failed when err is not nil
+func (s *JobStore) Finish(job *Job, err error) error {
+	if err != nil {
+		job.Status = JobFailed
+		job.Error = err.Error()
+	} else {
+		job.Status = JobCompleted
+		job.Error = ""
+	}
+	return s.Update(job)
+}
+
+func (s *JobStore) load() ([]*Job, error) {
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read jobs: %w", err)
+	}
+	var jobs []*Job
+	if err := json.Unmarshal(data, &jobs); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", s.path, err)
+	}
+	return jobs, nil
+}
+
+func (s *JobStore) save(jobs []*Job) error {
+	// Keep every unfinished job but only the most recent completed ones
+	sort.Slice(jobs, func(i, k int) bool { return jobs[i].ID > jobs[k].ID })
+	kept := jobs[:0]
+	finished := 0
+	for _, job := range jobs {
+		if job.Status == JobCompleted {
+			finished++
+			if finished > maxFinishedJobs {
+				continue
+			}
+		}
+		kept = append(kept, job)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create jobs directory: %w", err)
+	}
+	data, err := json.MarshalIndent(kept, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write jobs: %w", err)
+	}
+	return os.Rename(tmp, s.path)
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// jsonOutput makes commands write JSON events to stdout, set by --json
+var jsonOutput bool
+
+var (
+	jsonOut     io.Writer = os.Stdout // Where events go; messages move to stderr
+	jsonMu      sync.Mutex
+	jsonEnabled bool
+)
+
+// eventSubscriberBuffer is how many events a slow subscriber may fall behind before
+// events are dropped for it
+const eventSubscriberBuffer = 256
+
+var (
+	eventSubscribers   = map[chan map[string]interface{}]struct{}{}
+	eventSubscribersMu sync.Mutex
+)
+
+// enableJSONOutput keeps stdout for JSON events and sends every other message, including
+// fmt output, to stderr, so scripts can read stdout line by line
+func enableJSONOutput() {
+	if jsonEnabled {
+		return
+	}
+	jsonEnabled = true
+	jsonOut = os.Stdout
+	os.Stdout = os.Stderr
+	color.Output = color.Error
+}
+
+// subscribeEvents returns a channel receiving every event, whether or not --json is used,
+// and a function to stop the subscription. Events are dropped while the channel is full,
+// so a slow subscriber never holds up downloads.
+func subscribeEvents() (<-chan map[string]interface{}, func()) {
+	events := make(chan map[string]interface{}, eventSubscriberBuffer)
+	eventSubscribersMu.Lock()
+	eventSubscribers[events] = struct{}{}
+	eventSubscribersMu.Unlock()
+	return events, func() {
+		eventSubscribersMu.Lock()
+		delete(eventSubscribers, events)
+		eventSubscribersMu.Unlock()
+	}
+}
+
+// emitEvent writes one event as a line of JSON when --json is used, and passes it to the
+// subscribers. fields are added to the "event" and "time" keys.
+func emitEvent(event string, fields map[string]interface{}) {
+	eventSubscribersMu.Lock()
+	subscribed := len(eventSubscribers) > 0
+	eventSubscribersMu.Unlock()
+	if !jsonOutput && !subscribed {
+		return
+	}
+	line := map[string]interface{}{"event": event, "time": time.Now().UTC().Format(time.RFC3339), "correlation_id": runCorrelationID}
+	for key, value := range fields {
+		line[key] = value
+	}
+	if subscribed {
+		eventSubscribersMu.Lock()
+		for events := range eventSubscribers {
+			select {
+			case events <- line:
+			default:
+			}
+		}
+		eventSubscribersMu.Unlock()
+	}
+	if !jsonOutput {
+		return
+	}
+	data, err := json.Marshal(line)
+	if err != nil {
+		return
+	}
+
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonOut.Write(append(data, '\n'))
+}
+
+// emitComplete ends a command with its download counts
+func emitComplete(command, target string, stats *DownloadStats) {
+	fields := map[string]interface{}{"command": command, "target": target}
+	if stats != nil {
+		fields["downloaded"] = stats.SuccessCount
+		fields["skipped"] = stats.SkippedCount
+		fields["failed"] = stats.FailedCount
+		fields["unavailable"] = stats.UnavailableCount
+		fields["failures"] = stats.FailedItems
+	}
+	emitEvent("complete", fields)
+}
+
+// emitError reports an error that ended a command or one of its items
+func emitError(command, target string, err error) {
+	emitEvent("error", map[string]interface{}{"command": command, "target": target, "message": err.Error()})
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// HostStats sums up the downloads streamed from one host
+type HostStats struct {
+	Host     string
+	Tracks   int
+	Bytes    int64
+	Duration time.Duration
+}
+
+// BytesPerSecond returns the average transfer speed of the host
+func (s HostStats) BytesPerSecond() float64 {
+	if s.Duration <= 0 {
+		return 0
+	}
+	return float64(s.Bytes) / s.Duration.Seconds()
+}
+
+// LibraryStats is the download performance recorded in the history
+type LibraryStats struct {
+	Tracks   int            // Tracks in the history
+	Measured int            // Tracks with recorded transfer speed
+	Bytes    int64          // Audio bytes transferred by measured tracks
+	Duration time.Duration  // Time spent transferring them
+	Hosts    []HostStats    // Slowest host first
+	Slowest  []HistoryEntry // Slowest tracks first
+}
+
+// ComputeLibraryStats summarizes the transfer speeds of entries, keeping the slowest
+// limit tracks (all when limit is 0)
+func ComputeLibraryStats(entries []HistoryEntry, limit int) *LibraryStats {
+	stats := &LibraryStats{Tracks: len(entries)}
+	hosts := make(map[string]*HostStats)
+	var measured []HistoryEntry
+	for _, entry := range entries {
+		if entry.DurationMs <= 0 || entry.Bytes <= 0 {
+			continue
+		}
+		measured = append(measured, entry)
+		duration := time.Duration(entry.DurationMs) * time.Millisecond
+		stats.Bytes += entry.Bytes
+		stats.Duration += duration
+
+		host := entry.Host
+		if host == "" {
+			host = "unknown"
+		}
+		if hosts[host] == nil {
+			hosts[host] = &HostStats{Host: host}
+		}
+		hosts[host].Tracks++
+		hosts[host].Bytes += entry.Bytes
+		hosts[host].Duration += duration
+	}
+	stats.Measured = len(measured)
+
+	for _, host := range hosts {
+		stats.Hosts = append(stats.Hosts, *host)
+	}
+	sort.Slice(stats.Hosts, func(i, k int) bool { return stats.Hosts[i].BytesPerSecond() < stats.Hosts[k].BytesPerSecond() })
+
+	sort.SliceStable(measured, func(i, k int) bool { return measured[i].BytesPerSecond() < measured[k].BytesPerSecond() })
+	if limit > 0 && len(measured) > limit {
+		measured = measured[:limit]
+	}
+	stats.Slowest = measured
+	return stats
+}
+
+// formatSpeed formats a transfer speed, e.g. "1.2 MB/s"
+func formatSpeed(bytesPerSecond float64) string {
+	return FormatBytes(int64(bytesPerSecond)) + "/s"
+}
+
+// Print shows the statistics
+func (s *LibraryStats) Print() {
+	colorInfo.Printf("📚 %d tracks in the download history, %d with recorded transfer speed\n", s.Tracks, s.Measured)
+	if s.Measured == 0 {
+		colorWarning.Println("⚠️ No transfer speeds recorded yet, they are stored for tracks downloaded from now on.")
+		return
+	}
+	average := float64(s.Bytes) / s.Duration.Seconds()
+	colorInfo.Printf("   %s transferred in %s, %s on average\n", FormatBytes(s.Bytes), s.Duration.Round(time.Second), formatSpeed(average))
+
+	fmt.Println()
+	colorInfo.Println("🌐 Hosts (slowest first):")
+	for _, host := range s.Hosts {
+		line := fmt.Sprintf("   %-40s %5d tracks  %12s", TruncateString(host.Host, 40), host.Tracks, formatSpeed(host.BytesPerSecond()))
+		// Hosts at less than half the average speed are worth a look
+		if host.BytesPerSecond() < average/2 {
+			colorWarning.Println(line)
+		} else {
+			fmt.Println(line)
+		}
+	}
+
+	fmt.Println()
+	colorInfo.Println("🐢 Slowest tracks:")
+	for _, entry := range s.Slowest {
+		fmt.Printf("   %12s  %-30s %-25s %s  %s\n", formatSpeed(entry.BytesPerSecond()), TruncateString(entry.Title, 30), TruncateString(entry.Artist, 25), FormatDate(entry.DownloadedAt), entry.Host)
+	}
+}
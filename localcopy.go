@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// localCopy is what is already on disk of a track that is about to be downloaded
+type localCopy struct {
+	existing string // The track in the requested format, nothing to do
+	flac     string // A FLAC of the track the requested format can be converted from
+}
+
+// findLocalCopy looks for the track at trackPath in the requested format, and for lossy
+// formats also for a FLAC of it, next to it or anywhere the download history knows of
+func findLocalCopy(ctx context.Context, config *Config, track Track, albumID, trackPath, format string) localCopy {
+	if format == "" || format == "flac" {
+		if FileExists(trackPath) {
+			return localCopy{existing: trackPath}
+		}
+		return localCopy{}
+	}
+	if target := convertedTarget(config, trackPath, format); FileExists(target) {
+		return localCopy{existing: target}
+	}
+	if FileExists(trackPath) {
+		if config.NoLocalConversion {
+			return localCopy{existing: trackPath}
+		}
+		return localCopy{flac: trackPath}
+	}
+	if config.NoLocalConversion {
+		return localCopy{}
+	}
+	// The history keeps the last copy of a track, the FLAC may have been converted since
+	if entry := previouslyDownloaded(ctx, track, albumID, config); entry != nil {
+		if flac := convertedPath(entry.Path, "flac"); FileExists(flac) {
+			return localCopy{flac: flac}
+		}
+	}
+	return localCopy{}
+}
+
+// convertedPath is where a track at the FLAC path trackPath ends up with extension
+func convertedPath(trackPath, extension string) string {
+	return strings.TrimSuffix(trackPath, filepath.Ext(trackPath)) + "." + extension
+}
+
+// convertedTarget is where the track at the FLAC path trackPath is converted to. With
+// KeepOriginal, that is the same place in a separate tree for the format, e.g. Music-mp3.
+func convertedTarget(config *Config, trackPath, format string) string {
+	target := convertedPath(trackPath, formatExtension(config, format))
+	if !config.KeepOriginal {
+		return target
+	}
+	rel, err := filepath.Rel(filepath.Clean(config.DownloadLocation), target)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return target
+	}
+	return filepath.Join(convertedLocation(config, format), rel)
+}
+
+// convertedLocation is the root of the tree converted copies are kept in
+func convertedLocation(config *Config, format string) string {
+	if config.ConvertedLocation != "" {
+		return strings.ReplaceAll(config.ConvertedLocation, "{format}", format)
+	}
+	return filepath.Clean(config.DownloadLocation) + "-" + format
+}
+
+// convertLocalCopy converts an existing FLAC to the requested format next to trackPath
+// instead of downloading the track again. The FLAC is kept.
+func convertLocalCopy(config *Config, source, trackPath, format, bitrate string) (string, error) {
+	target := convertedTarget(config, trackPath, format)
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return "", err
+	}
+	if err := ConvertTrackTo(source, target, format, bitrate, config.Encoders[format]); err != nil {
+		return "", fmt.Errorf("failed to convert %s: %w", source, err)
+	}
+	colorInfo.Printf("♻️ Converted existing %s to %s instead of downloading it\n", filepath.Base(source), strings.ToUpper(format))
+	return target, nil
+}
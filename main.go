@@ -7,12 +7,12 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
-	"crypto/tls"
-	"net/http"
 
 	"github.com/cheggaaa/pb/v3"
 	"github.com/fatih/color"
@@ -27,9 +27,12 @@ var versionJSON []byte
 
 var (
 	apiURL              string
+	apiToken            string
+	apiHeaderFlags      []string
 	downloadLocation    string
 	debug               bool
 	filter              string
+	assumeYes           bool
 	noConfirm           bool
 	searchType          string
 	spotifyPlaylist     string
@@ -45,12 +48,68 @@ var (
 	bitrate             string = "320"
 	ignoreSuffix        string
 	insecure            bool
+	ipVersion           string
+	proxyURL            string
+	streamProxyURL      string
+	noProxy             string
+	configSchemaOutput  string
+	importFrom          string
+	importDryRun        bool
 	warningBehavior     string = "summary"
+	language            string
+	plainOutput         bool
+	colorTheme          string
+	expandBatch         bool
+	selfTestQuery       string
+	noMusicBrainz       bool
+	replayGain          bool
+	findAlternatives    bool
+	writeNFOFiles       bool
+	keepFLAC            bool
+	libraryGaps         bool
+	libraryScan         bool
+	verifyQuick         bool
+	verifyRedownload    bool
+	verifyUpdate        bool
+	maxExpansion        int
+	historyLimit        int
+	historyFormat       string
+	historyOutput       string
+	auditAll            bool
+	watchBackfill       bool
+	enqueue             bool
+	queueConcurrency    int
+	queueClearFinished  bool
+	requestStatus       string
+	rejectReason        string
+	serveOptions        ServeConfig
+	issuePrint          bool
+	issueTitle          string
+	libraryLimit        int
+	repairDryRun        bool
+	repairOffline       bool
+	fetchMissing        bool
+	spotifyLiked        bool
+	spotifySavedAlbums  bool
+	exportNavidrome     string
+	exportName          string
+	exportPublic        bool
+	exportForce         bool
+	watchInterval       time.Duration
+	watchOnce           bool
+	watchMaxAge         int
+	watchExclude        string
 )
 
 var rootCmd = &cobra.Command{
 	Use:     "dab-downloader",
 	Short:   "A high-quality FLAC music downloader for the DAB API.",
+	PersistentPostRun: func(cmd *cobra.Command, args []string) {
+		// Keep this run's API statistics for `debug stats`
+		if err := apiStats.Save(apiStatsPath); err != nil && debug {
+			colorWarning.Printf("DEBUG: Failed to save API statistics: %v\n", err)
+		}
+	},
 }
 
 var artistCmd = &cobra.Command{
@@ -59,44 +118,1364 @@ var artistCmd = &cobra.Command{
 	Args:  cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 			config, api := initConfigAndAPI()
-			if config.Format != "flac" && !CheckFFmpeg() {
+			if config.Format != "flac" && !metadataOnly && !CheckFFmpeg() {
 				printInstallInstructions()
 				return
 			}
 			artistID := args[0]
-			colorInfo.Println("🎵 Starting artist discography download for ID:", artistID)
-			if err := api.DownloadArtistDiscography(context.Background(), artistID, config, debug, filter, noConfirm); err != nil {
+			colorInfo.Println(T("artist.start", artistID))
+			if err := api.DownloadArtistDiscography(context.Background(), artistID, config, debug, filter, assumeYes || noConfirm); err != nil {
+				emitError("artist", artistID, err)
 				if errors.Is(err, ErrDownloadCancelled) {
-					colorWarning.Println("⚠️ Discography download cancelled by user.")
+					colorWarning.Println(T("artist.cancelled"))
 				} else if errors.Is(err, ErrNoItemsSelected) {
-                    colorWarning.Println("⚠️ No items were selected for download.")
+                    colorWarning.Println(T("artist.no_items"))
                 } else {
-                    colorError.Printf("❌ Failed to download discography: %v\n", err)
+                    colorError.Println(T("artist.failed", err))
                 }
 			} else {
-				colorSuccess.Println("✅ Discography download completed!")
+				emitComplete("artist", artistID, nil)
+				colorSuccess.Println(T("artist.completed"))
+			}
+		},
+}
+
+var albumCmd = &cobra.Command{
+	Use:   "album [album_id]",
+	Short: "Download an album by its ID.",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+			config, api := initConfigAndAPI()
+			if config.Format != "flac" && !metadataOnly && !CheckFFmpeg() {
+				printInstallInstructions()
+				return
+			}
+			albumID := args[0]
+			if enqueue {
+				if albumTrackSelection != "" {
+					colorError.Println("❌ --tracks can't be used with --queue, queued albums are downloaded whole")
+					return
+				}
+				enqueueItems("album", args)
+				return
+			}
+			ctx, err := selectAlbumTracks(context.Background(), api, albumID)
+			if err != nil {
+				colorError.Printf("❌ %v\n", err)
+				return
+			}
+			selected := trackSelectionFrom(ctx) != nil
+			// MusicBrainz lookups are switched on or off for the whole daemon, not per album
+			if api.daemon != nil && !metadataOnly && !selected && !noMusicBrainz {
+				sendToDaemon(api.daemon, "album", args)
+				return
+			}
+			colorInfo.Println(T("album.start", albumID))
+			var job *Job
+			if !selected {
+				// A job resumes the whole album, so part of one isn't recorded
+				job = &Job{Type: "album", Target: albumID, Items: []string{albumID}, Format: config.Format, Bitrate: config.Bitrate}
+				if err := jobStore.Start(job); err != nil {
+					colorWarning.Printf("⚠️ Failed to record job: %v\n", err)
+					job = nil
+				}
 			}
+			runAlbumJob(ctx, api, albumID, config, job)
 		},
 }
 
-var albumCmd = &cobra.Command{
-	Use:   "album [album_id]",
-	Short: "Download an album by its ID.",
+// runAlbumJob downloads an album and records the outcome in job if set
+func runAlbumJob(ctx context.Context, api *DabAPI, albumID string, config *Config, job *Job) {
+	if job != nil {
+		ctx = withCorrelationID(ctx, job.CorrelationID)
+	}
+	stats, err := api.DownloadAlbum(ctx, albumID, config, debug, nil, nil)
+	// Selected tracks are positions on this album, another edition doesn't stand in for them
+	if err != nil && isAlbumUnavailable(err) && trackSelectionFrom(ctx) == nil {
+		if alternative := chooseAlternativeAlbum(ctx, api, albumID, config); alternative != nil {
+			colorInfo.Printf("🔀 Downloading %s (%s) instead\n", alternative.Title, alternative.ID)
+			stats, err = api.DownloadAlbum(ctx, alternative.ID, config, debug, nil, nil)
+		}
+	}
+	if err != nil {
+		emitError("album", albumID, err)
+		colorError.Println(T("album.failed", err))
+		stats = &DownloadStats{FailedCount: 1, FailedItems: []string{fmt.Sprintf("album %s: %v", albumID, err)}}
+	} else {
+		emitComplete("album", albumID, stats)
+		colorSuccess.Println(T("album.completed"))
+		if stats.FailedCount > 0 || stats.UnavailableCount > 0 {
+			printStatsCounts("album "+albumID, stats)
+		}
+		printThroughputGraph()
+	}
+	NotifySummary(config, EventAlbumComplete, "album "+albumID, stats)
+	if job == nil {
+		return
+	}
+	if err == nil {
+		job.Completed = []string{albumID}
+	}
+	if ferr := jobStore.Finish(job, err); ferr != nil {
+		colorWarning.Printf("⚠️ Failed to update job %d: %v\n", job.ID, ferr)
+	}
+}
+
+var batchCmd = &cobra.Command{
+	Use:   "batch [file]",
+	Short: "Download every item listed in a batch file.",
+	Long:  "Downloads the items listed in a file, one per line. Lines may be artist:<id>, album:<id>, track:<id> or isrc:<code> to bypass search, or free text to download the first matching track. Each line may end with overrides such as |format=mp3|bitrate=256|filter=albums|location=/music/lossy. Blank lines and lines starting with # are ignored.",
+	Args:  cobra.ExactArgs(1),
+	Example: `  # Download everything listed in my-list.txt
+  dab-downloader batch my-list.txt`,
+	Run: func(cmd *cobra.Command, args []string) {
+		config, api := initConfigAndAPI()
+		items, err := ParseBatchFile(args[0])
+		if err != nil {
+			emitError("batch", args[0], err)
+			colorError.Printf("❌ %v\n", err)
+			return
+		}
+		if len(items) == 0 {
+			colorWarning.Println("⚠️ The batch file has no items.")
+			return
+		}
+
+		colorInfo.Printf("📄 Processing %d items from %s\n", len(items), args[0])
+		playlistName := strings.TrimSuffix(filepath.Base(args[0]), filepath.Ext(args[0]))
+		opts := BatchOptions{Filter: filter, Expand: expandBatch, MaxExpansion: maxExpansion, AssumeYes: assumeYes, NoConfirm: noConfirm, PlaylistName: playlistName}
+		stats := api.RunBatch(context.Background(), items, config, debug, opts)
+		emitComplete("batch", args[0], stats)
+		printStatsCounts(filepath.Base(args[0]), stats)
+		reviewQueue.Report()
+		printThroughputGraph()
+		colorInfo.Println(T("stats.finished_at", FormatDate(time.Now())))
+		NotifySummary(config, EventBatchComplete, "batch "+filepath.Base(args[0]), stats)
+	},
+}
+
+var jobsCmd = &cobra.Command{
+	Use:   "jobs",
+	Short: "Inspect and resume previous downloads.",
+}
+
+var jobsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List recorded download jobs.",
+	Run: func(cmd *cobra.Command, args []string) {
+		jobs, err := jobStore.List()
+		if err != nil {
+			colorError.Printf("❌ Failed to load jobs: %v\n", err)
+			return
+		}
+		if len(jobs) == 0 {
+			colorInfo.Println("No jobs recorded yet.")
+			return
+		}
+		for _, job := range jobs {
+			name := job.Name
+			if name == "" {
+				name = job.Target
+			}
+			status := job.Status
+			if status == JobRunning {
+				status = "running/interrupted"
+			}
+			line := fmt.Sprintf("%4d  %-7s %-20s %d/%d items  %s  %s", job.ID, job.Type, TruncateString(name, 20), len(job.Completed), len(job.Items), FormatDate(job.UpdatedAt), status)
+			switch job.Status {
+			case JobCompleted:
+				colorSuccess.Println(line)
+			case JobFailed:
+				colorError.Println(line)
+				if job.Error != "" {
+					colorError.Printf("      %s\n", job.Error)
+				}
+			default:
+				colorWarning.Println(line)
+			}
+		}
+	},
+}
+
+var jobsResumeCmd = &cobra.Command{
+	Use:   "resume [job_id]",
+	Short: "Continue an interrupted or failed job.",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		id, err := strconv.Atoi(args[0])
+		if err != nil {
+			colorError.Printf("❌ Invalid job ID '%s'\n", args[0])
+			return
+		}
+		job, err := jobStore.Get(id)
+		if err != nil {
+			colorError.Printf("❌ %v\n", err)
+			return
+		}
+		if job.Status == JobCompleted {
+			colorSuccess.Printf("✅ Job %d is already completed.\n", job.ID)
+			return
+		}
+
+		config, api := initConfigAndAPI()
+		// Resume with the settings the job was started with
+		config.Format = job.Format
+		config.Bitrate = job.Bitrate
+		if config.Format != "flac" && !CheckFFmpeg() {
+			printInstallInstructions()
+			return
+		}
+
+		switch job.Type {
+		case "artist":
+			if err := api.ResumeArtistJob(context.Background(), job, config, debug); err != nil {
+				colorError.Println(T("artist.failed", err))
+				jobStore.Finish(job, err)
+			} else {
+				colorSuccess.Println(T("artist.completed"))
+			}
+		case "album":
+			colorInfo.Printf("🔁 Resuming job %d\n", job.ID)
+			job.Status = JobRunning
+			runAlbumJob(context.Background(), api, job.Target, config, job)
+		default:
+			colorError.Printf("❌ Unknown job type '%s'\n", job.Type)
+		}
+	},
+}
+
+var historyCmd = &cobra.Command{
+	Use:   "history [query]",
+	Short: "List downloaded tracks, optionally filtered by title, artist or album.",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		query := ""
+		if len(args) > 0 {
+			query = args[0]
+		}
+		entries := downloadHistory.Search(query)
+		if len(entries) == 0 {
+			colorInfo.Println("No downloads recorded yet.")
+			return
+		}
+		for i, entry := range entries {
+			if historyLimit > 0 && i >= historyLimit {
+				colorInfo.Printf("... %d more, use --limit 0 to show all\n", len(entries)-historyLimit)
+				break
+			}
+			colorInfo.Printf("%s  %-30s %-25s %-5s %s\n", FormatDate(entry.DownloadedAt), TruncateString(entry.Title, 30), TruncateString(entry.Artist, 25), entry.Format, entry.Path)
+		}
+	},
+}
+
+var historyExportCmd = &cobra.Command{
+	Use:   "export [query]",
+	Short: "Export the download history as JSON or CSV.",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		query := ""
+		if len(args) > 0 {
+			query = args[0]
+		}
+		out := os.Stdout
+		if historyOutput != "" {
+			f, err := os.Create(historyOutput)
+			if err != nil {
+				colorError.Printf("❌ Failed to create %s: %v\n", historyOutput, err)
+				return
+			}
+			defer f.Close()
+			out = f
+		}
+		entries := downloadHistory.Search(query)
+		if err := ExportHistory(out, entries, historyFormat); err != nil {
+			colorError.Printf("❌ Failed to export history: %v\n", err)
+			return
+		}
+		if historyOutput != "" {
+			colorSuccess.Printf("✅ Exported %d entries to %s\n", len(entries), historyOutput)
+		}
+	},
+}
+
+var libraryCmd = &cobra.Command{
+	Use:   "library",
+	Short: "Inspect the downloaded library.",
+}
+
+var libraryStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show download speeds per host and the slowest tracks, to spot mirror or network problems.",
+	Run: func(cmd *cobra.Command, args []string) {
+		ComputeLibraryStats(downloadHistory.Search(""), libraryLimit).Print()
+	},
+}
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export downloads to other services.",
+}
+
+var exportSpotifyCmd = &cobra.Command{
+	Use:   "spotify [folder]",
+	Short: "Create or update a Spotify playlist with the tracks of a local folder or Navidrome playlist.",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		config, _ := initConfigAndAPI()
+		if (len(args) == 0) == (exportNavidrome == "") {
+			colorError.Println("❌ Pass a folder or --navidrome-playlist, but not both")
+			return
+		}
+
+		var tracks []ExportTrack
+		var err error
+		name := exportName
+		if exportNavidrome != "" {
+			navidromeClient := NewNavidromeClient(config.NavidromeURL, config.NavidromeUsername, config.NavidromePassword)
+			if err := navidromeClient.Authenticate(); err != nil {
+				colorError.Printf("❌ Failed to authenticate with Navidrome: %v\n", err)
+				return
+			}
+			tracks, err = ExportTracksFromNavidrome(navidromeClient, exportNavidrome)
+			if name == "" {
+				name = exportNavidrome
+			}
+		} else {
+			tracks, err = ExportTracksFromFolder(args[0])
+			if name == "" {
+				name = filepath.Base(filepath.Clean(args[0]))
+			}
+		}
+		if err != nil {
+			colorError.Printf("❌ Failed to read tracks: %v\n", err)
+			return
+		}
+		if len(tracks) == 0 {
+			colorWarning.Println("⚠️ No tracks to export")
+			return
+		}
+		colorInfo.Printf("🔍 Matching %d tracks on Spotify...\n", len(tracks))
+
+		ctx := context.Background()
+		spotifyClient := NewSpotifyClient(config.SpotifyClientID, config.SpotifyClientSecret)
+		if err := spotifyClient.AuthenticateUser(ctx, config.SpotifyRedirectURL, spotifyPlaylistScopes...); err != nil {
+			colorError.Println(T("spotify.auth_failed", err))
+			return
+		}
+		if err := spotifyClient.ExportToSpotify(ctx, name, tracks, exportPublic, exportForce); err != nil {
+			colorError.Printf("❌ %v\n", err)
+		}
+	},
+}
+
+var tagAuditCmd = &cobra.Command{
+	Use:   "tag-audit [path]",
+	Short: "Report which Picard tags are missing or inconsistent, to see whether a Picard pass is still needed.",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var root string
+		if len(args) > 0 {
+			root = args[0]
+		} else {
+			config, _ := initConfigAndAPI()
+			root = config.DownloadLocation
+		}
+		colorInfo.Printf("🏷️ Auditing tags in %s\n", root)
+		albums, skipped, err := AuditTags(root)
+		if err != nil {
+			colorError.Printf("❌ Failed to audit tags: %v\n", err)
+			return
+		}
+		if len(albums) == 0 {
+			colorWarning.Println("⚠️ No FLAC files found.")
+			return
+		}
+		PrintTagAudit(albums, skipped, auditAll)
+	},
+}
+
+var repairCmd = &cobra.Command{
+	Use:   "repair [path]",
+	Short: "Fill in missing embedded art, cover files and key tags of downloaded tracks.",
+	Long:  "Checks the tracks in the download history, or the FLAC files below path, for missing embedded art, cover files and key tags, and fills in only those gaps. Complete files are left untouched.",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		config, api := initConfigAndAPI()
+		var paths []string
+		if len(args) > 0 {
+			colorInfo.Printf("🔧 Scanning %s\n", args[0])
+			var err error
+			if paths, err = RepairFilesInDir(args[0]); err != nil {
+				colorError.Printf("❌ Failed to scan %s: %v\n", args[0], err)
+				return
+			}
+		} else {
+			colorInfo.Println("🔧 Checking the tracks in the download history")
+			paths = RepairFilesFromHistory()
+		}
+		if len(paths) == 0 {
+			colorWarning.Println("⚠️ No FLAC files found.")
+			return
+		}
+
+		albums, err := FindRepairs(paths, config)
+		if err != nil {
+			colorError.Printf("❌ Failed to check files: %v\n", err)
+			return
+		}
+		var broken []*RepairAlbum
+		for _, album := range albums {
+			if album.NeedsRepair() {
+				broken = append(broken, album)
+			}
+		}
+		if len(broken) == 0 {
+			colorSuccess.Printf("✅ All %d files are complete\n", len(paths))
+			return
+		}
+		PrintRepairs(broken)
+		if repairDryRun {
+			colorInfo.Printf("%d of %d albums need repairs, run without --dry-run to fix them\n", len(broken), len(albums))
+			return
+		}
+
+		ctx := context.Background()
+		written := 0
+		for _, album := range broken {
+			n, err := api.RepairAlbum(ctx, album, config, repairOffline, debug)
+			written += n
+			if err != nil {
+				colorError.Printf("❌ %v\n", err)
+			}
+		}
+		colorSuccess.Printf("✅ Repaired %d files in %d albums\n", written, len(broken))
+
+		// Report what couldn't be filled in
+		remaining, err := FindRepairs(paths, config)
+		if err == nil {
+			var left []*RepairAlbum
+			for _, album := range remaining {
+				if album.NeedsRepair() {
+					left = append(left, album)
+				}
+			}
+			if len(left) > 0 {
+				colorWarning.Println("⚠️ Still missing, no metadata was found for:")
+				PrintRepairs(left)
+			}
+		}
+	},
+}
+
+var checkCompleteCmd = &cobra.Command{
+	Use:   "check-complete [album_id... | --scan [path]]",
+	Short: "Report downloaded albums with tracks missing locally or added on DAB since, optionally fetching them.",
+	Long:  "Checks the albums downloaded with dab-downloader (or the album IDs given) against their current DAB track list. With --scan, the album folders under path (the download location by default) are checked instead, including folders that were not downloaded with dab-downloader.",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if libraryScan {
+			return cobra.MaximumNArgs(1)(cmd, args)
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		config, api := initConfigAndAPI()
+		if libraryScan {
+			root := config.DownloadLocation
+			if len(args) > 0 {
+				root = args[0]
+			}
+			checkLibrary(context.Background(), api, root, config)
+			return
+		}
+		if libraryGaps || repairOffline || auditAll {
+			colorError.Println("❌ --gaps, --offline and --all only apply with --scan")
+			os.Exit(1)
+		}
+		ids := args
+		if len(ids) == 0 {
+			ids = completenessAlbumIDs()
+		}
+		if len(ids) == 0 {
+			colorInfo.Println("No downloaded albums recorded yet.")
+			return
+		}
+
+		ctx := context.Background()
+		colorInfo.Printf("🧩 Checking %d albums against DAB\n", len(ids))
+		var incomplete []*AlbumCompleteness
+		for _, id := range ids {
+			result, err := api.CheckAlbumComplete(ctx, id, config)
+			if err != nil {
+				colorError.Printf("❌ Album %s: %v\n", id, err)
+				continue
+			}
+			if result.Complete() {
+				continue
+			}
+			incomplete = append(incomplete, result)
+			colorWarning.Printf("⚠️ %s - %s\n", result.Album.Artist, result.Album.Title)
+			for _, track := range result.Missing {
+				fmt.Printf("   missing:   %02d - %s\n", track.TrackNumber, track.Title)
+			}
+			for _, track := range result.Added {
+				fmt.Printf("   new on DAB: %02d - %s\n", track.TrackNumber, track.Title)
+			}
+		}
+		if len(incomplete) == 0 {
+			colorSuccess.Printf("✅ All %d albums are complete\n", len(ids))
+			return
+		}
+		if !fetchMissing {
+			colorInfo.Printf("%d albums are incomplete, run with --fetch to download the missing tracks\n", len(incomplete))
+			return
+		}
+
+		if config.Format != "flac" && !CheckFFmpeg() {
+			printInstallInstructions()
+			return
+		}
+		for _, result := range incomplete {
+			// Tracks already on disk or in the history are skipped, so only the gaps are downloaded
+			colorInfo.Printf("📥 Completing %s - %s\n", result.Album.Artist, result.Album.Title)
+			stats, err := api.DownloadAlbum(ctx, result.Album.ID, config, debug, nil, nil)
+			if err != nil {
+				colorError.Printf("❌ Failed to complete %s: %v\n", result.Album.Title, err)
+				continue
+			}
+			colorSuccess.Printf("✅ %d downloaded, %d failed\n", stats.SuccessCount, stats.FailedCount)
+		}
+	},
+}
+
+var verifyLibraryCmd = &cobra.Command{
+	Use:   "verify-library [path]",
+	Short: "Report album folders with fewer tracks than the album has on DAB or MusicBrainz, optionally fetching the missing ones. Same as check-complete --scan.",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		config, api := initConfigAndAPI()
+		root := config.DownloadLocation
+		if len(args) > 0 {
+			root = args[0]
+		}
+		checkLibrary(context.Background(), api, root, config)
+	},
+}
+
+// checkLibrary compares the album folders under root with the albums they hold on DAB,
+// MusicBrainz or in their tags and fetches the missing tracks with --fetch
+func checkLibrary(ctx context.Context, api *DabAPI, root string, config *Config) {
+	colorInfo.Printf("📚 Scanning %s\n", root)
+	paths, err := RepairFilesInDir(root)
+	if err != nil {
+		colorError.Printf("❌ Failed to scan %s: %v\n", root, err)
+		return
+	}
+	if len(paths) == 0 {
+		colorWarning.Println("⚠️ No FLAC files found.")
+		return
+	}
+
+	albums := ScanLibrary(paths, config)
+	if libraryGaps {
+		reportLibraryGaps(ctx, api, albums, config)
+		return
+	}
+	var incomplete []*LibraryAlbum
+	unknown := 0
+	for _, album := range albums {
+		api.VerifyLibraryAlbum(ctx, album, repairOffline, debug)
+		if !album.Complete() {
+			incomplete = append(incomplete, album)
+		} else if album.Expected == 0 {
+			unknown++
+		}
+		if !album.Complete() || auditAll {
+			PrintLibraryAlbum(album)
+		}
+	}
+	if unknown > 0 {
+		colorInfo.Printf("❔ %d albums could not be identified\n", unknown)
+	}
+	if len(incomplete) == 0 {
+		colorSuccess.Printf("✅ No incomplete albums among %d checked\n", len(albums))
+		return
+	}
+	if !fetchMissing {
+		colorInfo.Printf("%d of %d albums are incomplete, run with --fetch to download the missing tracks\n", len(incomplete), len(albums))
+		return
+	}
+
+	if config.Format != "flac" && !CheckFFmpeg() {
+		printInstallInstructions()
+		return
+	}
+	fetched := 0
+	for _, album := range incomplete {
+		if album.DabAlbum == nil {
+			colorWarning.Printf("⚠️ %s is not on DAB, its missing tracks can't be fetched\n", album.Dir)
+			continue
+		}
+		colorInfo.Printf("📥 Completing %s - %s\n", album.DabAlbum.Artist, album.DabAlbum.Title)
+		n, err := api.FetchMissingTracks(ctx, album, config, debug)
+		fetched += n
+		if err != nil {
+			colorError.Printf("❌ Some tracks of %s failed: %v\n", album.DabAlbum.Title, err)
+		}
+	}
+	colorSuccess.Printf("✅ Downloaded %d missing tracks\n", fetched)
+}
+
+// reportLibraryGaps lists the albums with missing track numbers and offers to download the
+// missing tracks found on DAB into their folders
+func reportLibraryGaps(ctx context.Context, api *DabAPI, albums []*LibraryAlbum, config *Config) {
+	var withGaps []*LibraryAlbum
+	fetchable := 0
+	for _, album := range albums {
+		if len(album.Gaps) == 0 {
+			if auditAll {
+				PrintLibraryGaps(album)
+			}
+			continue
+		}
+		api.FindGapTracks(ctx, album, repairOffline, debug)
+		PrintLibraryGaps(album)
+		withGaps = append(withGaps, album)
+		fetchable += len(album.Missing)
+	}
+	if len(withGaps) == 0 {
+		colorSuccess.Printf("✅ No gaps in the track numbers of %d albums\n", len(albums))
+		return
+	}
+	if repairOffline {
+		colorInfo.Printf("%d of %d albums have gaps, run without --offline to look the missing tracks up on DAB\n", len(withGaps), len(albums))
+		return
+	}
+	if fetchable == 0 {
+		colorInfo.Printf("%d of %d albums have gaps, none of the missing tracks were found on DAB\n", len(withGaps), len(albums))
+		return
+	}
+	if !fetchMissing {
+		if !assumeYes && (!isTTY() || !Confirm(fmt.Sprintf("📥 Download the %d missing tracks found on DAB? (y/N)", fetchable))) {
+			colorInfo.Printf("%d of %d albums have gaps, run with --fetch to download the %d missing tracks found on DAB\n", len(withGaps), len(albums), fetchable)
+			return
+		}
+	}
+
+	if config.Format != "flac" && !CheckFFmpeg() {
+		printInstallInstructions()
+		return
+	}
+	fetched := 0
+	for _, album := range withGaps {
+		if len(album.Missing) == 0 {
+			continue
+		}
+		colorInfo.Printf("📥 Filling the gaps of %s - %s\n", album.DabAlbum.Artist, album.DabAlbum.Title)
+		n, err := api.FetchMissingTracks(ctx, album, config, debug)
+		fetched += n
+		if err != nil {
+			colorError.Printf("❌ Some tracks of %s failed: %v\n", album.DabAlbum.Title, err)
+		}
+	}
+	colorSuccess.Printf("✅ Downloaded %d missing tracks\n", fetched)
+}
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify [path]",
+	Short: "Check downloaded files against their checksums.sha256 and decode FLACs to find damaged or truncated files.",
+	Long:  "Checks the files of each album folder below path (the download location by default) against the checksums.sha256 written after downloading, and decodes every FLAC to check its frames and audio MD5 like `flac -t` (needs flac or ffmpeg). Damaged files can be downloaded again with --redownload.",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		config, api := initConfigAndAPI()
+		root := config.DownloadLocation
+		if len(args) > 0 {
+			root = args[0]
+		}
+		decode := !verifyQuick
+		if decode && !flacTestAvailable() {
+			colorWarning.Println("⚠️ Neither flac nor ffmpeg is installed, only checksums are verified.")
+			decode = false
+		}
+		colorInfo.Printf("🔎 Verifying %s\n", root)
+		folders, err := findAlbumFolders(root)
+		if err != nil {
+			colorError.Printf("❌ Failed to scan %s: %v\n", root, err)
+			return
+		}
+		if len(folders) == 0 {
+			colorWarning.Println("⚠️ No album folders found.")
+			return
+		}
+
+		ctx := context.Background()
+		var files, damaged, changed, redownloaded int
+		for _, folder := range folders {
+			checks, err := VerifyAlbumFolder(folder, decode)
+			if err != nil {
+				colorError.Printf("❌ %s: %v\n", folder, err)
+				continue
+			}
+			PrintFileChecks(folder, checks, auditAll)
+			files += len(checks)
+			// Only files checked as intact or downloaded again get a new checksum
+			var rewrite []string
+			for _, check := range checks {
+				switch {
+				case check.Changed:
+					changed++
+					if verifyUpdate {
+						rewrite = append(rewrite, check.Path)
+					}
+				case check.Damaged:
+					damaged++
+					if !verifyRedownload || !strings.EqualFold(filepath.Ext(check.Path), ".flac") {
+						continue
+					}
+					colorInfo.Printf("📥 Downloading %s again\n", filepath.Base(check.Path))
+					if err := api.RedownloadFile(ctx, check.Path, config, debug); err != nil {
+						colorError.Printf("❌ %s: %v\n", filepath.Base(check.Path), err)
+						continue
+					}
+					redownloaded++
+					rewrite = append(rewrite, check.Path)
+				}
+			}
+			if len(rewrite) > 0 && !config.NoChecksumManifest {
+				if err := UpdateChecksumManifest(folder, rewrite); err != nil {
+					colorWarning.Printf("⚠️ Failed to write %s: %v\n", checksumManifestName, err)
+				}
+			}
+		}
+
+		if damaged == 0 && changed == 0 {
+			colorSuccess.Printf("✅ %d files in %d folders verified\n", files, len(folders))
+			return
+		}
+		if changed > 0 && !verifyUpdate {
+			colorInfo.Printf("%d files changed since their checksum was recorded but decode correctly, run with --update to record their new checksums\n", changed)
+		}
+		if damaged > 0 {
+			if verifyRedownload {
+				colorInfo.Printf("%d of %d damaged files downloaded again\n", redownloaded, damaged)
+			} else {
+				colorWarning.Printf("⚠️ %d of %d files are damaged or missing, run with --redownload to download them again\n", damaged, files)
+			}
+		}
+	},
+}
+
+var isrcCmd = &cobra.Command{
+	Use:   "isrc [isrc...]",
+	Short: "Download tracks by ISRC, matched exactly instead of by search.",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		config, api := initConfigAndAPI()
+		if config.Format != "flac" && !CheckFFmpeg() {
+			printInstallInstructions()
+			return
+		}
+		ctx := context.Background()
+		stats := &DownloadStats{}
+		for _, isrc := range args {
+			track, err := api.FindTrackByISRC(ctx, isrc, debug)
+			if err == nil {
+				colorInfo.Println(T("track.start_name", track.Title, track.Artist))
+				_, err = api.DownloadSingleTrack(ctx, *track, debug, config.Format, config.Bitrate, nil, config, nil)
+			}
+			if err != nil {
+				colorError.Printf("❌ %s: %v\n", isrc, err)
+				stats.FailedCount++
+				stats.FailedItems = append(stats.FailedItems, fmt.Sprintf("isrc %s: %v", isrc, err))
+				continue
+			}
+			stats.SuccessCount++
+		}
+		NotifySummary(config, EventBatchComplete, "isrc "+strings.Join(args, " "), stats)
+	},
+}
+
+var upcCmd = &cobra.Command{
+	Use:   "upc [upc]",
+	Short: "Download an album by its UPC/EAN barcode, matched exactly instead of by search.",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		config, api := initConfigAndAPI()
+		if config.Format != "flac" && !CheckFFmpeg() {
+			printInstallInstructions()
+			return
+		}
+		album, err := api.FindAlbumByUPC(context.Background(), args[0], debug)
+		if err != nil {
+			colorError.Printf("❌ %v\n", err)
+			return
+		}
+		colorInfo.Printf("💿 UPC %s is %s by %s\n", args[0], album.Title, album.Artist)
+		runAlbumJob(context.Background(), api, album.ID, config, nil)
+	},
+}
+
+var queueCmd = &cobra.Command{
+	Use:   "queue",
+	Short: "Queue albums and tracks and download them later.",
+}
+
+var queueAddCmd = &cobra.Command{
+	Use:   "add [album|track] [id...]",
+	Short: "Add albums or tracks to the download queue.",
+	Args:  cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		if args[0] != "album" && args[0] != "track" {
+			colorError.Printf("❌ Unknown item type '%s', expected album or track\n", args[0])
+			return
+		}
+		enqueueItems(args[0], args[1:])
+	},
+}
+
+// enqueueItems adds IDs of the given type to the download queue, with --format, --bitrate
+// and the other download options that were set. A running daemon adds them itself.
+func enqueueItems(itemType string, ids []string) {
+	itemFormat, itemBitrate := queueFormat()
+	if daemon := queueDaemon(); daemon != nil {
+		added, err := daemon.Enqueue(context.Background(), itemType, ids, itemFormat, itemBitrate, currentQueueOptions())
+		if err != nil {
+			colorError.Printf("❌ Failed to update queue: %v\n", err)
+			return
+		}
+		colorSuccess.Printf("✅ Queued %d items (%d already waiting), the running daemon downloads them.\n", added, len(ids)-added)
+		return
+	}
+	items := make([]*QueueItem, 0, len(ids))
+	for _, id := range ids {
+		items = append(items, &QueueItem{Type: itemType, Value: id, Format: itemFormat, Bitrate: itemBitrate, Options: currentQueueOptions()})
+	}
+	added, err := downloadQueue.Add(items...)
+	if err != nil {
+		colorError.Printf("❌ Failed to update queue: %v\n", err)
+		return
+	}
+	colorSuccess.Printf("✅ Queued %d items (%d already waiting), start them with 'queue run'.\n", len(added), len(ids)-len(added))
+	if shared := sharedQueueItems(added); shared > 0 {
+		colorInfo.Printf("🔗 %d of them are queued by another job too and are downloaded once for both\n", shared)
+	}
+}
+
+// queueFormat returns --format and --bitrate for queue items, empty when they weren't set
+func queueFormat() (string, string) {
+	itemFormat, itemBitrate := "", ""
+	if format != "flac" {
+		itemFormat = format
+	}
+	if bitrate != "320" {
+		itemBitrate = bitrate
+	}
+	return itemFormat, itemBitrate
+}
+
+// sendToDaemon hands downloads to the running daemon instead of downloading them here,
+// so they don't compete with the daemon for the DAB rate limit
+func sendToDaemon(daemon *DaemonClient, itemType string, ids []string) {
+	itemFormat, itemBitrate := queueFormat()
+	added, err := daemon.Enqueue(context.Background(), itemType, ids, itemFormat, itemBitrate, currentQueueOptions())
+	if err != nil {
+		colorError.Printf("❌ Failed to hand the download to the daemon: %v\n", err)
+		return
+	}
+	colorSuccess.Printf("✅ The running daemon is downloading %d items (%d already waiting), see 'queue list'. Use --no-daemon to download here.\n", added, len(ids)-added)
+}
+
+var queueListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the download queue.",
+	Run: func(cmd *cobra.Command, args []string) {
+		state, err := downloadQueue.State()
+		if err != nil {
+			colorError.Printf("❌ Failed to load queue: %v\n", err)
+			return
+		}
+		if state.Paused {
+			colorWarning.Println("⏸️ The queue is paused.")
+		}
+		if len(state.Items) == 0 {
+			colorInfo.Println("The queue is empty.")
+			return
+		}
+		for _, item := range state.Items {
+			status := item.Status
+			if item.interrupted(time.Now()) {
+				status = "interrupted"
+			}
+			if item.SharedWith != 0 {
+				status += fmt.Sprintf(" (with #%d)", item.SharedWith)
+			}
+			line := fmt.Sprintf("%4d  %-5s %-24s %-6s %s  %s", item.ID, item.Type, TruncateString(item.Value, 24), item.Format, FormatDate(item.UpdatedAt), status)
+			switch item.Status {
+			case QueueDone:
+				colorSuccess.Println(line)
+			case QueueFailed:
+				colorError.Println(line)
+				if item.Error != "" {
+					colorError.Printf("      %s\n", item.Error)
+				}
+			case QueueRunning, QueueCancelled:
+				colorWarning.Println(line)
+			default:
+				colorInfo.Println(line)
+			}
+		}
+	},
+}
+
+var requestsCmd = &cobra.Command{
+	Use:   "requests",
+	Short: "Review download requests of request portal users (see 'serve').",
+}
+
+var requestsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List download requests, newest first.",
+	Run: func(cmd *cobra.Command, args []string) {
+		requests, err := downloadRequests.List("", requestStatus)
+		if err != nil {
+			colorError.Printf("❌ Failed to load requests: %v\n", err)
+			return
+		}
+		if len(requests) == 0 {
+			colorInfo.Println("There are no requests.")
+			return
+		}
+		for _, request := range requests {
+			line := fmt.Sprintf("%4d  %-6s %-30s %-12s %s  %s", request.ID, request.Type, TruncateString(request.Title, 30), TruncateString(request.User, 12), FormatDate(request.CreatedAt), request.Status)
+			switch request.Status {
+			case RequestApproved:
+				colorSuccess.Println(line)
+			case RequestRejected:
+				colorWarning.Println(line)
+			default:
+				colorInfo.Println(line)
+			}
+			if request.Note != "" {
+				fmt.Printf("      %s\n", request.Note)
+			}
+		}
+	},
+}
+
+var requestsApproveCmd = &cobra.Command{
+	Use:   "approve [request_id]",
+	Short: "Approve a request and queue its albums.",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		id, err := strconv.Atoi(args[0])
+		if err != nil {
+			colorError.Printf("❌ Invalid request ID '%s'\n", args[0])
+			return
+		}
+		config, api := initConfigAndAPI()
+		request, err := api.ApproveRequest(context.Background(), id, filter, "cli", config)
+		if err != nil {
+			colorError.Printf("❌ Failed to approve request: %v\n", err)
+			return
+		}
+		colorSuccess.Printf("✅ Approved request %d for %s, %d albums queued.\n", request.ID, request.Title, len(request.Jobs))
+	},
+}
+
+var requestsRejectCmd = &cobra.Command{
+	Use:   "reject [request_id]",
+	Short: "Reject a request.",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		id, err := strconv.Atoi(args[0])
+		if err != nil {
+			colorError.Printf("❌ Invalid request ID '%s'\n", args[0])
+			return
+		}
+		request, err := RejectRequest(id, rejectReason, "cli")
+		if err != nil {
+			colorError.Printf("❌ Failed to reject request: %v\n", err)
+			return
+		}
+		colorSuccess.Printf("✅ Rejected request %d for %s.\n", request.ID, request.Title)
+	},
+}
+
+var queueRunCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Download the queued items, picking up items an interrupted run left unfinished.",
+	Run: func(cmd *cobra.Command, args []string) {
+		config, api := initConfigAndAPI()
+		if config.Format != "flac" && !CheckFFmpeg() {
+			printInstallInstructions()
+			return
+		}
+		if api.daemon != nil {
+			colorInfo.Println("The running daemon is already downloading the queue, see 'queue list'.")
+			return
+		}
+		if err := api.RunQueue(context.Background(), queueConcurrency, config, debug); err != nil {
+			colorError.Printf("❌ %v\n", err)
+		}
+	},
+}
+
+var queuePauseCmd = &cobra.Command{
+	Use:   "pause",
+	Short: "Stop starting new queue items; items already downloading finish.",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := setQueuePaused(true); err != nil {
+			colorError.Printf("❌ Failed to update queue: %v\n", err)
+			return
+		}
+		colorSuccess.Println("⏸️ Queue paused.")
+	},
+}
+
+var queueResumeCmd = &cobra.Command{
+	Use:   "resume",
+	Short: "Resume a paused queue.",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := setQueuePaused(false); err != nil {
+			colorError.Printf("❌ Failed to update queue: %v\n", err)
+			return
+		}
+		colorSuccess.Println("▶️ Queue resumed.")
+	},
+}
+
+var queueClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Remove queued items (items downloading right now are kept).",
+	Run: func(cmd *cobra.Command, args []string) {
+		var removed int
+		var err error
+		if daemon := queueDaemon(); daemon != nil {
+			removed, err = daemon.Clear(context.Background(), queueClearFinished)
+		} else {
+			removed, err = downloadQueue.Clear(queueClearFinished)
+		}
+		if err != nil {
+			colorError.Printf("❌ Failed to update queue: %v\n", err)
+			return
+		}
+		colorSuccess.Printf("✅ Removed %d items from the queue.\n", removed)
+	},
+}
+
+// setQueuePaused pauses or resumes the queue, through the daemon when one is running so
+// it starts a resumed queue right away
+func setQueuePaused(paused bool) error {
+	if daemon := queueDaemon(); daemon != nil {
+		return daemon.SetPaused(context.Background(), paused)
+	}
+	return downloadQueue.SetPaused(paused)
+}
+
+var daemonCmd = &cobra.Command{
+	Use:   "daemon",
+	Short: "Keep running to download the queue and serve other dab-downloader commands from shared caches.",
+	Long:  "Runs in the foreground and downloads queued items as they are added. While it runs, 'album' downloads from other terminals are handed to it, and searches of other commands go through its search cache and rate limiter instead of competing with it. Use --no-daemon to bypass it.",
+	Run: func(cmd *cobra.Command, args []string) {
+		noDaemon = true
+		auditInterface = "daemon"
+		config, api := initConfigAndAPI()
+		if config.Format != "flac" && !CheckFFmpeg() {
+			printInstallInstructions()
+			return
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		if err := api.RunDaemon(ctx, config, queueConcurrency, debug); err != nil {
+			colorError.Printf("❌ %v\n", err)
+		}
+	},
+}
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Run the daemon with a REST API to submit, follow and cancel download jobs.",
+	Long:  "Runs like 'daemon' and also serves a JSON REST API over HTTP. POST /api/jobs queues albums or tracks and returns their job IDs right away; GET /api/jobs/{id} reports status and progress, DELETE /api/jobs/{id} cancels a job and GET /api/history lists downloaded tracks.",
+	Run: func(cmd *cobra.Command, args []string) {
+		noDaemon = true
+		auditInterface = "web"
+		config, api := initConfigAndAPI()
+		if config.Format != "flac" && !CheckFFmpeg() {
+			printInstallInstructions()
+			return
+		}
+		options := ServeConfig{}
+		if config.Serve != nil {
+			options = *config.Serve
+		}
+		if token := os.Getenv("DAB_SERVE_TOKEN"); token != "" {
+			options.Token = token
+		}
+		flags := cmd.Flags()
+		if flags.Changed("listen") {
+			options.Listen = serveOptions.Listen
+		}
+		if flags.Changed("token") {
+			options.Token = serveOptions.Token
+		}
+		if flags.Changed("tls-cert") {
+			options.TLSCert = serveOptions.TLSCert
+		}
+		if flags.Changed("tls-key") {
+			options.TLSKey = serveOptions.TLSKey
+		}
+		options.NoAuth = serveOptions.NoAuth
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		if err := api.RunServe(ctx, config, options, queueConcurrency, debug); err != nil {
+			colorError.Printf("❌ %v\n", err)
+		}
+	},
+}
+
+var reportIssueCmd = &cobra.Command{
+	Use:   "report-issue",
+	Short: "Open a GitHub issue prefilled with your environment, config and the last crash.",
+	Long:  "Collects the version, platform, your config with secrets removed, the latest crash report, the last job, recently failed queue items and the end of the audit log, then opens a prefilled GitHub issue in the browser. Nothing is sent until you submit the issue yourself.",
+	Run: func(cmd *cobra.Command, args []string) {
+		config, _ := initConfigAndAPI()
+		report := BuildIssueReport(config)
+		if issueTitle != "" {
+			report.Title = issueTitle
+		}
+		if issuePrint {
+			fmt.Printf("%s\n\n%s", report.Title, report.Body)
+			return
+		}
+		link := report.URL(config.UpdateRepo)
+		colorInfo.Println("📝 Opening a prefilled issue in your browser. Check it for anything you'd rather not share before submitting.")
+		if err := openBrowser(link, config); err != nil || config.IsDockerContainer {
+			colorWarning.Println("⚠️ Open this link to report the issue:")
+			fmt.Println(link)
+		}
+	},
+}
+
+var notifyCmd = &cobra.Command{
+	Use:   "notify",
+	Short: "Manage download notifications.",
+}
+
+var notifyTestCmd = &cobra.Command{
+	Use:   "test",
+	Short: "Send a test notification to every configured target.",
+	Run: func(cmd *cobra.Command, args []string) {
+		config, _ := initConfigAndAPI()
+		if len(config.Notifications) == 0 {
+			colorWarning.Println("⚠️ No notification targets are configured, add them to \"notifications\" in config.json.")
+			return
+		}
+		n := Notification{Event: "test", Title: "test notification", Time: time.Now()}
+		n.Message = formatNotification(n)
+		for _, target := range config.Notifications {
+			if sendNotification(target, n) == nil {
+				colorSuccess.Printf("✅ Sent a test notification to %s\n", target.label())
+			}
+		}
+	},
+}
+
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Automatically download new releases of watched artists.",
+}
+
+var watchAddCmd = &cobra.Command{
+	Use:   "add [artist_id]",
+	Short: "Add an artist to the watchlist.",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		config, api := initConfigAndAPI()
+		if releasedAfter != "" {
+			if _, err := time.Parse("2006-01-02", releasedAfter); err != nil {
+				colorError.Printf("❌ Invalid --released-after date '%s' (expected YYYY-MM-DD)\n", releasedAfter)
+				return
+			}
+		}
+		if watchMaxAge < 0 {
+			colorError.Println("❌ --max-age must be a positive number of days")
+			return
+		}
+		options := &WatchedArtist{Filter: filter, ReleasedAfter: releasedAfter, MaxAgeDays: watchMaxAge}
+		for _, word := range strings.Split(watchExclude, ",") {
+			if word = strings.TrimSpace(word); word != "" {
+				options.Exclude = append(options.Exclude, word)
+			}
+		}
+		watched, err := api.WatchArtist(context.Background(), args[0], options, watchBackfill, config, debug)
+		if err != nil {
+			colorError.Printf("❌ Failed to watch artist: %v\n", err)
+			return
+		}
+		if watchBackfill {
+			colorSuccess.Printf("✅ Watching %s, the next 'watch run' downloads the whole discography.\n", watched.Name)
+		} else {
+			colorSuccess.Printf("✅ Watching %s, %d existing releases will be ignored.\n", watched.Name, len(watched.Known))
+		}
+	},
+}
+
+var watchRemoveCmd = &cobra.Command{
+	Use:   "remove [artist_id]",
+	Short: "Remove an artist from the watchlist.",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := watchlist.Remove(args[0]); err != nil {
+			colorError.Printf("❌ %v\n", err)
+			return
+		}
+		colorSuccess.Printf("✅ Stopped watching %s.\n", args[0])
+	},
+}
+
+var watchListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List watched artists.",
+	Run: func(cmd *cobra.Command, args []string) {
+		artists, err := watchlist.List()
+		if err != nil {
+			colorError.Printf("❌ Failed to load watchlist: %v\n", err)
+			return
+		}
+		if len(artists) == 0 {
+			colorInfo.Println("No artists are watched yet.")
+			return
+		}
+		for _, watched := range artists {
+			lastChecked := "never"
+			if !watched.LastChecked.IsZero() {
+				lastChecked = FormatDate(watched.LastChecked)
+			}
+			colorInfo.Printf("%-12s %-30s %-20s last checked: %s\n", watched.ID, TruncateString(watched.Name, 30), watched.Filter, lastChecked)
+			if filters := watched.describeFilters(); filters != "" {
+				fmt.Printf("%-12s only releases %s\n", "", filters)
+			}
+		}
+	},
+}
+
+var watchRunCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Check watched artists for new releases and download them, repeating every --interval.",
+	Run: func(cmd *cobra.Command, args []string) {
+		config, api := initConfigAndAPI()
+		if config.Format != "flac" && !CheckFFmpeg() {
+			printInstallInstructions()
+			return
+		}
+		if err := api.RunWatch(context.Background(), watchInterval, watchOnce, config, debug); err != nil {
+			colorError.Printf("❌ %v\n", err)
+		}
+	},
+}
+
+var scheduleCmd = &cobra.Command{
+	Use:   "schedule",
+	Short: "Run commands on a cron schedule while the daemon or 'serve' is running.",
+}
+
+var scheduleAddCmd = &cobra.Command{
+	Use:   "add [cron] [command] [args...]",
+	Short: "Schedule a command, e.g. schedule add \"0 3 * * *\" batch playlist.txt --expand",
+	Long:  "Stores a command in config.json that the daemon and 'serve' run whenever the cron expression matches, in their local time. The expression has five fields (minute hour day month weekday) or is one of @hourly, @daily, @weekly, @monthly and @yearly. Everything after it is the command with its flags, as you would type it.",
+	Example: `  # Mirror a playlist every night at 3:00
+  dab-downloader schedule add "0 3 * * *" batch playlist.txt --expand
+
+  # Check watched artists every Monday morning
+  dab-downloader schedule add "30 7 * * mon" watch run --once`,
+	// The flags belong to the scheduled command
+	DisableFlagParsing: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) > 0 && (args[0] == "-h" || args[0] == "--help") {
+			cmd.Help()
+			return
+		}
+		if len(args) < 2 {
+			colorError.Println("❌ Please give a cron expression and the command to run")
+			os.Exit(1)
+		}
+		schedule, err := AddSchedule(args[0], args[1:])
+		if err != nil {
+			colorError.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		spec, _ := parseCron(schedule.Cron)
+		colorSuccess.Printf("✅ Added schedule %d, next run %s. It runs while 'daemon' or 'serve' is running.\n", schedule.ID, FormatDate(spec.Next(time.Now())))
+	},
+}
+
+var scheduleListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List scheduled commands and when they run next.",
+	Run: func(cmd *cobra.Command, args []string) {
+		schedules, err := loadSchedules()
+		if err != nil {
+			colorError.Printf("❌ %v\n", err)
+			return
+		}
+		if len(schedules) == 0 {
+			colorInfo.Println("Nothing is scheduled yet.")
+			return
+		}
+		for _, schedule := range schedules {
+			next := "disabled"
+			if spec, err := parseCron(schedule.Cron); err != nil {
+				next = err.Error()
+			} else if !schedule.Disabled {
+				next = "next: " + FormatDate(spec.Next(time.Now()))
+			}
+			colorInfo.Printf("%4d  %-15s %-40s %s\n", schedule.ID, schedule.Cron, TruncateString(schedule.String(), 40), next)
+		}
+	},
+}
+
+var scheduleRemoveCmd = &cobra.Command{
+	Use:   "remove [id]",
+	Short: "Remove a scheduled command.",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		id, err := strconv.Atoi(args[0])
+		if err != nil {
+			colorError.Printf("❌ Invalid schedule ID '%s'\n", args[0])
+			return
+		}
+		if err := RemoveSchedule(id); err != nil {
+			colorError.Printf("❌ %v\n", err)
+			return
+		}
+		colorSuccess.Printf("✅ Removed schedule %d.\n", id)
+	},
+}
+
+var scheduleRunCmd = &cobra.Command{
+	Use:   "run [id]",
+	Short: "Run a scheduled command now, to try it out.",
 	Args:  cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-			config, api := initConfigAndAPI()
-			if config.Format != "flac" && !CheckFFmpeg() {
-				printInstallInstructions()
+		id, err := strconv.Atoi(args[0])
+		if err != nil {
+			colorError.Printf("❌ Invalid schedule ID '%s'\n", args[0])
+			return
+		}
+		schedules, err := loadSchedules()
+		if err != nil {
+			colorError.Printf("❌ %v\n", err)
+			return
+		}
+		for _, schedule := range schedules {
+			if schedule.ID == id {
+				if RunSchedule(context.Background(), schedule) != nil {
+					os.Exit(1)
+				}
 				return
 			}
-			albumID := args[0]
-			colorInfo.Println("🎵 Starting album download for ID:", albumID)
-			if _, err := api.DownloadAlbum(context.Background(), albumID, config, debug, nil, nil); err != nil {
-				colorError.Printf("❌ Failed to download album: %v\n", err)
-			} else {
-				colorSuccess.Println("✅ Album download completed!")
-			}
-		},
+		}
+		colorError.Printf("❌ No schedule with ID %d\n", id)
+	},
 }
 
 var searchCmd = &cobra.Command{
@@ -107,16 +1486,18 @@ var searchCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		config, api := initConfigAndAPI() // Get config for parallelism
 			if config.Format != "flac" && !CheckFFmpeg() {
-				colorError.Println("❌ ffmpeg is not installed or not in your PATH. Please install ffmpeg to use the format conversion feature.")
+				colorError.Println(T("ffmpeg.missing"))
 				return
 			}
 			query := args[0]
 			selectedItems, itemTypes, err := handleSearch(context.Background(), api, query, searchType, debug, auto)
 			if err != nil {
-				colorError.Printf("❌ Search failed: %v\n", err)
+				emitError("search", query, err)
+				colorError.Println(T("search.failed", err))
 				return
 			}
 			if len(selectedItems) == 0 { // User quit or no results
+				emitComplete("search", query, nil)
 				return
 			}
 
@@ -124,7 +1505,7 @@ var searchCmd = &cobra.Command{
 			// Initialize pool for multiple track downloads
 			var pool *pb.Pool
 			var localPool bool
-			if isTTY() && len(selectedItems) > 1 { // Only create pool if multiple items and TTY
+			if progressBarsEnabled() && len(selectedItems) > 1 { // Only create pool if multiple items and TTY
 				var err error
 				pool, err = pb.StartPool()
 				if err != nil {
@@ -140,183 +1521,274 @@ var searchCmd = &cobra.Command{
 				switch itemType {
 				case "artist":
 					artist := selectedItem.(Artist)
-					colorInfo.Println("🎵 Starting artist discography download for:", artist.Name)
+					colorInfo.Println(T("artist.start_name", artist.Name))
 					artistIDStr := idToString(artist.ID) // Convert ID to string using idToString
 					if debug { // Add this debug print
 						colorInfo.Printf("DEBUG - Passing artistIDStr to DownloadArtistDiscography: '%s'\n", artistIDStr)
 					}
-					if err := api.DownloadArtistDiscography(context.Background(), artistIDStr, config, debug, filter, noConfirm); err != nil {
-						colorError.Printf("❌ Failed to download discography for %s: %v\n", artist.Name, err)
+					if err := api.DownloadArtistDiscography(context.Background(), artistIDStr, config, debug, filter, assumeYes); err != nil {
+						emitError("artist", artistIDStr, err)
+						colorError.Println(T("artist.failed_name", artist.Name, err))
 					} else {
-						colorSuccess.Println("✅ Discography download completed for", artist.Name)
+						colorSuccess.Println(T("artist.completed_name", artist.Name))
 					}
 				case "album":
 					album := selectedItem.(Album)
-					colorInfo.Println("🎵 Starting album download for:", album.Title, "by", album.Artist)
+					colorInfo.Println(T("album.start_name", album.Title, album.Artist))
 					if _, err := api.DownloadAlbum(context.Background(), album.ID, config, debug, nil, nil); err != nil {
-						colorError.Printf("❌ Failed to download album %s: %v\n", album.Title, err)
+						emitError("album", album.ID, err)
+						colorError.Println(T("album.failed_name", album.Title, err))
 					} else {
-						colorSuccess.Println("✅ Album download completed for", album.Title)
+						colorSuccess.Println(T("album.completed_name", album.Title))
 					}
 				case "track":
 					track := selectedItem.(Track)
-					colorInfo.Println("🎵 Starting track download for:", track.Title, "by", track.Artist)
+					colorInfo.Println(T("track.start_name", track.Title, track.Artist))
 					// Now call the modified DownloadSingleTrack which expects a Track object and potentially a pool
-					if err := api.DownloadSingleTrack(context.Background(), track, debug, config.Format, config.Bitrate, pool, config, nil); err != nil {
-						colorError.Printf("❌ Failed to download track %s: %v\n", track.Title, err)
+					if _, err := api.DownloadSingleTrack(context.Background(), track, debug, config.Format, config.Bitrate, pool, config, nil); err != nil {
+						emitError("track", idToString(track.ID), err)
+						colorError.Println(T("track.failed_name", track.Title, err))
 					} else {
-						colorSuccess.Println("✅ Track download completed for", track.Title)
+						colorSuccess.Println(T("track.completed_name", track.Title))
 					}
 				default:
-					colorError.Println("❌ Unknown item type selected.")
+					colorError.Println(T("search.unknown_type"))
 				}
 			}
 
 			if localPool && pool != nil {
 				pool.Stop()
 			}
+			emitComplete("search", query, nil)
 		},
 }
 
 var spotifyCmd = &cobra.Command{
 	Use:   "spotify [url]",
-	Short: "Download a Spotify playlist or album.",
-	Args:  cobra.ExactArgs(1),
+	Short: "Download a Spotify playlist or album, or your liked songs and saved albums.",
+	Args:  cobra.RangeArgs(0, 1),
 	Run: func(cmd *cobra.Command, args []string) {
 		config, api := initConfigAndAPI()
 			if config.Format != "flac" && !CheckFFmpeg() {
-				colorError.Println("❌ ffmpeg is not installed or not in your PATH. Please install ffmpeg to use the format conversion feature.")
+				colorError.Println(T("ffmpeg.missing"))
+				return
+			}
+			spotifyClient := NewSpotifyClient(config.SpotifyClientID, config.SpotifyClientSecret)
+			if spotifyLiked || spotifySavedAlbums {
+				downloadSpotifyLibrary(api, config, spotifyClient)
+				return
+			}
+			if len(args) == 0 {
+				colorError.Println("❌ Pass a playlist or album URL, or use --liked or --saved-albums")
 				return
 			}
 			url := args[0]
 
-			spotifyClient := NewSpotifyClient(config.SpotifyClientID, config.SpotifyClientSecret)
 			if err := spotifyClient.Authenticate(); err != nil {
-				colorError.Printf("❌ Failed to authenticate with Spotify: %v\n", err)
+				colorError.Println(T("spotify.auth_failed", err))
 				return
 			}
 
 			var spotifyTracks []SpotifyTrack
+			var name string
 			var err error
 
 			if strings.Contains(url, "/playlist/") {
-				spotifyTracks, _, err = spotifyClient.GetPlaylistTracks(url)
+				spotifyTracks, name, err = spotifyClient.GetPlaylistTracks(url)
 			} else if strings.Contains(url, "/album/") {
-				spotifyTracks, _, err = spotifyClient.GetAlbumTracks(url) // I need to implement this
+				spotifyTracks, name, err = spotifyClient.GetAlbumTracks(url) // I need to implement this
 			} else {
-				colorError.Println("❌ Invalid Spotify URL. Please provide a playlist or album URL.")
+				colorError.Println(T("spotify.invalid_url"))
 				return
 			}
 
 			if err != nil {
-				colorError.Printf("❌ Failed to get tracks from Spotify: %v\n", err)
+				colorError.Println(T("spotify.tracks_failed", err))
 				return
 			}
 
-			if expandPlaylist {
-				colorInfo.Println("Expanding playlist to download full albums...")
-
-				// --- Logic for --expand flag ---
-			
-uniqueAlbums := make(map[string]SpotifyTrack)
-				for _, track := range spotifyTracks {
-					// Use a consistent key for the map
-					albumKey := strings.ToLower(track.AlbumName + " - " + track.AlbumArtist)
-					if _, exists := uniqueAlbums[albumKey]; !exists {
-					
-uniqueAlbums[albumKey] = track
-					}
-				}
+			downloadPlaylistTracks(api, config, name, spotifyTracks, expandPlaylist)
+		},
+}
 
-				colorInfo.Printf("Found %d unique albums in the playlist.\n", len(uniqueAlbums))
+// downloadSpotifyLibrary downloads the liked songs or saved albums of the Spotify user,
+// logging in first if no stored login covers the library
+func downloadSpotifyLibrary(api *DabAPI, config *Config, spotifyClient *SpotifyClient) {
+	ctx := context.Background()
+	if err := spotifyClient.AuthenticateUser(ctx, config.SpotifyRedirectURL, spotifyLibraryScopes...); err != nil {
+		colorError.Println(T("spotify.auth_failed", err))
+		return
+	}
+	if spotifyLiked {
+		tracks, err := spotifyClient.GetLikedTracks(ctx)
+		if err != nil {
+			colorError.Println(T("spotify.tracks_failed", err))
+			return
+		}
+		colorInfo.Printf("💚 %d liked songs\n", len(tracks))
+		downloadPlaylistTracks(api, config, "Liked Songs", tracks, expandPlaylist)
+	}
+	if spotifySavedAlbums {
+		albums, err := spotifyClient.GetSavedAlbums(ctx)
+		if err != nil {
+			colorError.Println(T("spotify.tracks_failed", err))
+			return
+		}
+		colorInfo.Printf("💿 %d saved albums\n", len(albums))
+		// Saved albums are always downloaded in full
+		downloadPlaylistTracks(api, config, "Saved Albums", albums, true)
+	}
+}
 
-				for _, track := range uniqueAlbums {
-					albumSearchQuery := track.AlbumName + " - " + track.AlbumArtist
-					colorInfo.Printf("Searching for album: %s\n", albumSearchQuery)
+// downloadPlaylistTracks matches imported playlist or album tracks on DAB and downloads
+// them, or their full albums when expand is set. Downloaded tracks are written to an
+// .m3u8 playlist named after the source when WriteM3U is enabled.
+func downloadPlaylistTracks(api *DabAPI, config *Config, name string, spotifyTracks []SpotifyTrack, expand bool) {
+	if expand {
+		colorInfo.Println("Expanding playlist to download full albums...")
 
-					// Use handleSearch to find the album on DAB
-					selectedItems, itemTypes, err := handleSearch(context.Background(), api, albumSearchQuery, "album", debug, auto)
-					if err != nil {
-						colorError.Printf("❌ Search failed for album '%s': %v\n", albumSearchQuery, err)
-						continue // Move to the next album
-					}
+		// --- Logic for --expand flag ---
+		uniqueAlbums := expansionAlbums(spotifyTracks, config)
 
-					if len(selectedItems) == 0 {
-						colorWarning.Printf("⚠️ No results found for album: %s\n", albumSearchQuery)
-						continue
-					}
+		colorInfo.Printf("Found %d unique albums in the playlist.\n", len(uniqueAlbums))
 
-					// Download the first result (or the one selected by the user)
-					for i, selectedItem := range selectedItems {
-						if itemTypes[i] == "album" {
-							album := selectedItem.(Album)
-							colorInfo.Println("🎵 Starting album download for:", album.Title, "by", album.Artist)
-							if _, err := api.DownloadAlbum(context.Background(), album.ID, config, debug, nil, nil); err != nil {
-								colorError.Printf("❌ Failed to download album %s: %v\n", album.Title, err)
-							} else {
-								colorSuccess.Println("✅ Album download completed for", album.Title)
-							}
-						break // Only download the first album result for this search
-						}
-					}
-				}
-				// --- End of logic for --expand flag ---
-				return // Exit after album downloads are done
-			}
+		for _, track := range uniqueAlbums {
+			albumSearchQuery := track.AlbumName + " - " + track.AlbumArtist
+			colorInfo.Printf("Searching for album: %s\n", albumSearchQuery)
 
-			var tracks []string
-			for _, spotifyTrack := range spotifyTracks {
-				tracks = append(tracks, spotifyTrack.Name+" - "+spotifyTrack.Artist)
+			// Use handleSearch to find the album on DAB
+			selectedItems, itemTypes, err := handleSearch(context.Background(), api, albumSearchQuery, "album", debug, auto)
+			if err != nil {
+				colorError.Printf("❌ Search failed for album '%s': %v\n", albumSearchQuery, err)
+				continue // Move to the next album
 			}
 
-			// Initialize pool for multiple track downloads
-			var pool *pb.Pool
-			var localPool bool
-			if isTTY() && len(spotifyTracks) > 1 { // Only create pool if multiple items and TTY
-				var err error
-				pool, err = pb.StartPool()
-				if err != nil {
-					colorError.Printf("❌ Failed to start progress bar pool: %v\n", err)
-					// Continue without the pool
-				} else {
-					localPool = true
-				}
+			if len(selectedItems) == 0 {
+				colorWarning.Printf("⚠️ No results found for album: %s\n", albumSearchQuery)
+				continue
 			}
 
-			for _, spotifyTrack := range spotifyTracks {
-				trackName := spotifyTrack.Name + " - " + spotifyTrack.Artist // Construct search query
-				selectedItems, itemTypes, err := handleSearch(context.Background(), api, trackName, "track", debug, auto)
-				if err != nil {
-					colorError.Printf("❌ Search failed for track %s: %v\n", trackName, err)
-					if pool != nil {
-						pool.Stop() // Stop pool on error
+			// Download the first result (or the one selected by the user)
+			for i, selectedItem := range selectedItems {
+				if itemTypes[i] == "album" {
+					album := selectedItem.(Album)
+					colorInfo.Println(T("album.start_name", album.Title, album.Artist))
+					if _, err := api.DownloadAlbum(context.Background(), album.ID, config, debug, nil, nil); err != nil {
+						colorError.Println(T("album.failed_name", album.Title, err))
+					} else {
+						colorSuccess.Println(T("album.completed_name", album.Title))
 					}
-					return // Exit on search error
+					break // Only download the first album result for this search
 				}
+			}
+		}
+		// --- End of logic for --expand flag ---
+		return // Exit after album downloads are done
+	}
 
-				if len(selectedItems) == 0 {
-					colorWarning.Printf("⚠️ No results found for track: %s\n", trackName)
-					continue
-				}
+	// Initialize pool for multiple track downloads
+	var pool *pb.Pool
+	var localPool bool
+	if progressBarsEnabled() && len(spotifyTracks) > 1 { // Only create pool if multiple items and TTY
+		var err error
+		pool, err = pb.StartPool()
+		if err != nil {
+			colorError.Printf("❌ Failed to start progress bar pool: %v\n", err)
+			// Continue without the pool
+		} else {
+			localPool = true
+		}
+	}
 
-				for i, selectedItem := range selectedItems {
-					itemType := itemTypes[i]
-					if itemType == "track" {
-						track := selectedItem.(Track)
-						colorInfo.Println("🎵 Starting track download for:", track.Title, "by", track.Artist)
-						if err := api.DownloadSingleTrack(context.Background(), track, debug, config.Format, config.Bitrate, pool, config, nil); err != nil {
-							colorError.Printf("❌ Failed to download track %s: %v\n", track.Title, err)
-						} else {
-							colorSuccess.Println("✅ Track download completed for", track.Title)
-						}
-					}
-				}
+	// Match every track first, so the albums can be prefetched while tracks download
+	var matched []Track
+	for _, spotifyTrack := range spotifyTracks {
+		trackName := spotifyTrack.Name + " - " + spotifyTrack.Artist // Construct search query
+		matchedTracks, err := findTracks(context.Background(), api, spotifySource(spotifyTrack, trackName), debug, auto)
+		if err != nil {
+			colorError.Printf("❌ Search failed for track %s: %v\n", trackName, err)
+			if pool != nil {
+				pool.Stop() // Stop pool on error
 			}
+			return // Exit on search error
+		}
 
+		if len(matchedTracks) == 0 {
+			colorWarning.Printf("⚠️ No results found for track: %s\n", trackName)
+			continue
+		}
+		matched = append(matched, matchedTracks...)
+	}
+
+	albumIDs := make([]string, 0, len(matched))
+	for _, track := range matched {
+		albumIDs = append(albumIDs, track.AlbumID)
+	}
+	api.PrefetchAlbums(context.Background(), albumIDs, config.Parallelism)
+
+	// Nobody picked the matches, so show what is about to happen before downloading
+	if auto && len(matched) > 0 {
+		preview := api.previewPlaylistDownload(context.Background(), matched, config)
+		if !confirmPlaylistDownload(preview, len(spotifyTracks)) {
+			api.releasePrefetched(albumIDs)
+			colorWarning.Println("⚠️ Download cancelled.")
 			if localPool && pool != nil {
 				pool.Stop()
 			}
-		},
+			return
+		}
+	}
+
+	var playlist []PlaylistEntry
+	for _, track := range matched {
+		colorInfo.Println(T("track.start_name", track.Title, track.Artist))
+		if path, err := api.DownloadSingleTrack(context.Background(), track, debug, config.Format, config.Bitrate, pool, config, nil); err != nil {
+			colorError.Println(T("track.failed_name", track.Title, err))
+		} else {
+			colorSuccess.Println(T("track.completed_name", track.Title))
+			playlist = append(playlist, newPlaylistEntry(track, path))
+		}
+	}
+
+	if localPool && pool != nil {
+		pool.Stop()
+	}
+	reviewQueue.Report()
+	writePlaylistFile(config, name, playlist)
+}
+
+var deezerCmd = &cobra.Command{
+	Use:   "deezer [url]",
+	Short: "Download a public Deezer playlist or album.",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		config, api := initConfigAndAPI()
+		if config.Format != "flac" && !CheckFFmpeg() {
+			colorError.Println(T("ffmpeg.missing"))
+			return
+		}
+
+		kind, id, err := ParseDeezerURL(args[0])
+		if err != nil {
+			colorError.Println(T("deezer.invalid_url"))
+			return
+		}
+
+		deezerClient := NewDeezerClient(newHTTPClient(30 * time.Second))
+		var deezerTracks []SpotifyTrack
+		var name string
+		if kind == "playlist" {
+			deezerTracks, name, err = deezerClient.GetPlaylistTracks(context.Background(), id)
+		} else {
+			deezerTracks, name, err = deezerClient.GetAlbumTracks(context.Background(), id)
+		}
+		if err != nil {
+			colorError.Println(T("deezer.tracks_failed", err))
+			return
+		}
+
+		downloadPlaylistTracks(api, config, name, deezerTracks, expandPlaylist)
+	},
 }
 
 var navidromeCmd = &cobra.Command{
@@ -329,7 +1801,7 @@ var navidromeCmd = &cobra.Command{
 
 		spotifyClient := NewSpotifyClient(config.SpotifyClientID, config.SpotifyClientSecret)
 		if err := spotifyClient.Authenticate(); err != nil {
-			colorError.Printf("❌ Failed to authenticate with Spotify: %v\n", err)
+			colorError.Println(T("spotify.auth_failed", err))
 			return
 		}
 
@@ -342,12 +1814,12 @@ var navidromeCmd = &cobra.Command{
 		} else if strings.Contains(spotifyURL, "/album/") {
 			spotifyTracks, spotifyName, err = spotifyClient.GetAlbumTracks(spotifyURL)
 		} else {
-			colorError.Println("❌ Invalid Spotify URL. Please provide a playlist or album URL.")
+			colorError.Println(T("spotify.invalid_url"))
 			return
 		}
 
 		if err != nil {
-			colorError.Printf("❌ Failed to get tracks from Spotify: %v\n", err)
+			colorError.Println(T("spotify.tracks_failed", err))
 			return
 		}
 
@@ -361,14 +1833,7 @@ var navidromeCmd = &cobra.Command{
 					colorInfo.Println("Expanding playlist to download full albums...")
 		
 					// --- Logic for --expand flag ---
-					uniqueAlbums := make(map[string]SpotifyTrack)
-					for _, track := range spotifyTracks {
-						// Use a consistent key for the map
-						albumKey := strings.ToLower(track.AlbumName + " - " + track.AlbumArtist)
-						if _, exists := uniqueAlbums[albumKey]; !exists {
-							uniqueAlbums[albumKey] = track
-						}
-					}
+					uniqueAlbums := expansionAlbums(spotifyTracks, config)
 		
 					colorInfo.Printf("Found %d unique albums in the playlist.\n", len(uniqueAlbums))
 		
@@ -401,11 +1866,11 @@ var navidromeCmd = &cobra.Command{
 						for i, selectedItem := range selectedItems {
 							if itemTypes[i] == "album" {
 								album := selectedItem.(Album)
-								colorInfo.Println("🎵 Starting album download for:", album.Title, "by", album.Artist)
+								colorInfo.Println(T("album.start_name", album.Title, album.Artist))
 								if _, err := api.DownloadAlbum(context.Background(), album.ID, config, debug, nil, nil); err != nil {
-									colorError.Printf("❌ Failed to download album %s: %v\n", album.Title, err)
+									colorError.Println(T("album.failed_name", album.Title, err))
 								} else {
-									colorSuccess.Println("✅ Album download completed for", album.Title)
+									colorSuccess.Println(T("album.completed_name", album.Title))
 								}
 								break // Only download the first album result for this search
 							}
@@ -449,39 +1914,32 @@ var navidromeCmd = &cobra.Command{
 				if ignoreSuffix != "" {
 					dabSearchQuery = trackName + " - " + spotifyTrack.Artist
 				}
-				dabSearchResults, dabItemTypes, err := handleSearch(context.Background(), api, dabSearchQuery, "track", debug, auto)
+				dabTracks, err := findTracks(context.Background(), api, spotifySource(spotifyTrack, dabSearchQuery), debug, auto)
 				if err != nil {
 					colorError.Printf("❌ Failed to search DAB for %s: %v\n", spotifyTrack.Name, err)
 					continue
 				}
 
-				if len(dabSearchResults) > 0 {
+				if len(dabTracks) > 0 {
 					// Assuming the first result is the desired one if auto is true, or user selected one
-					selectedDabItem := dabSearchResults[0]
-					selectedDabItemType := dabItemTypes[0]
-
-					if selectedDabItemType == "track" {
-						dabTrack := selectedDabItem.(Track)
+					dabTrack := dabTracks[0]
 					colorInfo.Printf("🎵 Downloading %s by %s from DAB...\n", dabTrack.Title, dabTrack.Artist)
-						if err := api.DownloadSingleTrack(context.Background(), dabTrack, debug, config.Format, config.Bitrate, nil, config, nil); err != nil {
-							colorError.Printf("❌ Failed to download track %s from DAB: %v\n", dabTrack.Title, err)
+					if _, err := api.DownloadSingleTrack(context.Background(), dabTrack, debug, config.Format, config.Bitrate, nil, config, nil); err != nil {
+						colorError.Printf("❌ Failed to download track %s from DAB: %v\n", dabTrack.Title, err)
+					} else {
+						colorSuccess.Printf("✅ Downloaded %s by %s from DAB. It should appear in Navidrome soon.\n", dabTrack.Title, dabTrack.Artist)
+						// After downloading, try to search for it in Navidrome again and add to playlist
+						// This might require a small delay for Navidrome to scan the new file
+						time.Sleep(5 * time.Second) // Give Navidrome some time to scan
+						reScannedTrack, err := navidromeClient.SearchTrack(dabTrack.Title, dabTrack.Artist, dabTrack.Album)
+						if err != nil {
+							colorWarning.Printf("⚠️ Failed to re-search for downloaded track %s in Navidrome: %v\n", dabTrack.Title, err)
+						} else if reScannedTrack != nil {
+							navidromeTrackIDs = append(navidromeTrackIDs, reScannedTrack.ID)
+							colorSuccess.Printf("✅ Found newly downloaded track %s in Navidrome (ID: %s) and added to list for playlist.\n", reScannedTrack.Title, reScannedTrack.ID)
 						} else {
-							colorSuccess.Printf("✅ Downloaded %s by %s from DAB. It should appear in Navidrome soon.\n", dabTrack.Title, dabTrack.Artist)
-							// After downloading, try to search for it in Navidrome again and add to playlist
-							// This might require a small delay for Navidrome to scan the new file
-							time.Sleep(5 * time.Second) // Give Navidrome some time to scan
-							reScannedTrack, err := navidromeClient.SearchTrack(dabTrack.Title, dabTrack.Artist, dabTrack.Album)
-							if err != nil {
-								colorWarning.Printf("⚠️ Failed to re-search for downloaded track %s in Navidrome: %v\n", dabTrack.Title, err)
-							} else if reScannedTrack != nil {
-								navidromeTrackIDs = append(navidromeTrackIDs, reScannedTrack.ID)
-								colorSuccess.Printf("✅ Found newly downloaded track %s in Navidrome (ID: %s) and added to list for playlist.\n", reScannedTrack.Title, reScannedTrack.ID)
-							} else {
-								colorWarning.Printf("⚠️ Downloaded track %s not found in Navidrome after re-scan. It might be added later manually.\n", dabTrack.Title)
-							}
+							colorWarning.Printf("⚠️ Downloaded track %s not found in Navidrome after re-scan. It might be added later manually.\n", dabTrack.Title)
 						}
-					} else {
-						colorWarning.Printf("⚠️ DAB search for %s returned a non-track item type: %s. Skipping download.\n", spotifyTrack.Name, selectedDabItemType)
 					}
 				} else {
 					colorWarning.Printf("⚠️ No results found on DAB for %s.\n", spotifyTrack.Name)
@@ -489,6 +1947,8 @@ var navidromeCmd = &cobra.Command{
 			}
 		}
 
+		reviewQueue.Report()
+
 		// Add all collected tracks to the playlist in a single call
 		if len(navidromeTrackIDs) > 0 {
 			if err := navidromeClient.AddTracksToPlaylist(playlistID, navidromeTrackIDs); err != nil { // New method call
@@ -577,11 +2037,203 @@ var comprehensiveArtistDebugCmd = &cobra.Command{
 	},
 }
 
+var debugStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show per-endpoint latency, error and rate-limit statistics of the last run.",
+	Run: func(cmd *cobra.Command, args []string) {
+		stats, err := LoadAPIStats(apiStatsPath)
+		if err != nil {
+			if os.IsNotExist(err) {
+				colorWarning.Println("⚠️ No API statistics recorded yet. Run a download or search first.")
+			} else {
+				colorError.Printf("❌ Failed to load API statistics: %v\n", err)
+			}
+			return
+		}
+		stats.Print()
+	},
+}
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Check DAB, Spotify, Navidrome, MusicBrainz and the daemon, and show versions and library size.",
+	Run: func(cmd *cobra.Command, args []string) {
+		config, api := initConfigAndAPI()
+		PrintLocalStatus(config)
+		colorInfo.Println("🌐 Services")
+		statuses := api.CheckServices(context.Background(), config)
+		PrintServiceStatus(statuses)
+		for _, status := range statuses {
+			if status.Err != nil {
+				os.Exit(1)
+			}
+		}
+	},
+}
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Work with config.json.",
+}
+
+var configSchemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Export a JSON Schema of config.json for editor autocompletion and validation.",
+	Run: func(cmd *cobra.Command, args []string) {
+		data, err := json.MarshalIndent(ConfigSchema(), "", "  ")
+		if err != nil {
+			colorError.Printf("❌ Failed to build schema: %v\n", err)
+			os.Exit(1)
+		}
+		if configSchemaOutput == "-" {
+			fmt.Println(string(data))
+			return
+		}
+		if err := os.WriteFile(configSchemaOutput, append(data, '\n'), 0644); err != nil {
+			colorError.Printf("❌ Failed to write schema: %v\n", err)
+			os.Exit(1)
+		}
+		colorSuccess.Printf("✅ Schema written to %s\n", configSchemaOutput)
+	},
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate [file]",
+	Short: "Check config.json for unknown keys and values of the wrong type.",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		path := filepath.Join("config", "config.json")
+		if len(args) > 0 {
+			path = args[0]
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			colorError.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		warnings, errs := ValidateConfig(data)
+		for _, issue := range warnings {
+			colorWarning.Printf("⚠️ %s\n", issue)
+		}
+		for _, issue := range errs {
+			colorError.Printf("❌ %s\n", issue)
+		}
+		var parsed Config
+		if json.Unmarshal(data, &parsed) == nil {
+			for format, options := range parsed.Encoders {
+				if err := ValidateEncoderOptions(format, options); err != nil {
+					colorError.Printf("❌ %v\n", err)
+					errs = append(errs, ConfigIssue{Message: err.Error()})
+				}
+			}
+		}
+		if len(errs) > 0 {
+			os.Exit(1)
+		}
+		if len(warnings) == 0 {
+			colorSuccess.Printf("✅ %s is valid\n", path)
+		}
+	},
+}
+
+var configImportCmd = &cobra.Command{
+	Use:   "import [path]",
+	Short: "Import download folder, quality, naming and cover settings from streamrip or OrpheusDL.",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		path := defaultImportPath(importFrom)
+		if len(args) > 0 {
+			path = args[0]
+		}
+		if path == "" {
+			colorError.Printf("❌ Please give the path of the %s settings file\n", importFrom)
+			os.Exit(1)
+		}
+		settings, err := ImportSettings(importFrom, path)
+		if err != nil {
+			colorError.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+
+		// The file is loaded again so flags given on this run are not saved
+		configFile := filepath.Join("config", "config.json")
+		config := &Config{}
+		if err := LoadConfig(configFile, config); err != nil {
+			colorError.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		changes := settings.Apply(config)
+		for _, note := range settings.Notes {
+			colorWarning.Printf("⚠️ %s\n", note)
+		}
+		if len(changes) == 0 {
+			colorInfo.Printf("✅ Nothing to import, your config already matches %s\n", path)
+			return
+		}
+		for _, change := range changes {
+			fmt.Printf("   %s\n", change)
+		}
+		if importDryRun {
+			colorInfo.Println("ℹ️ Dry run, config.json was not changed")
+			return
+		}
+
+		backup := configFile + ".import.bak"
+		if data, err := os.ReadFile(configFile); err == nil {
+			if err := os.WriteFile(backup, data, 0644); err != nil {
+				colorError.Printf("❌ Failed to back up config: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		if err := SaveConfig(configFile, config); err != nil {
+			colorError.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		colorSuccess.Printf("✅ Imported %d settings from %s, previous config saved as %s\n", len(changes), path, backup)
+	},
+}
+
+var selfTestCmd = &cobra.Command{
+	Use:   "self-test",
+	Short: "Check the whole pipeline by downloading, tagging and converting one short track to a temporary directory.",
+	Run: func(cmd *cobra.Command, args []string) {
+		config, api := initConfigAndAPI()
+		if api.RunSelfTest(context.Background(), config, selfTestQuery, debug) {
+			colorSuccess.Println("✅ Self-test passed, your installation is working.")
+		} else {
+			colorError.Println("❌ Self-test failed, see the steps above.")
+			os.Exit(1)
+		}
+	},
+}
+
 var versionCmd = &cobra.Command{
 	Use:   "version",
 	Short: "Print the version number of dab-downloader",
+	Long:  "Prints the version with the commit, build date and Go version. With --json it prints them as a JSON object, along with the default API endpoint and the optional features available (such as ffmpeg), for bug reports and wrappers.",
 	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Printf("dab-downloader %s\n", toolVersion)
+		info := GetBuildInfo()
+		if jsonOutput {
+			data, err := json.MarshalIndent(info, "", "  ")
+			if err != nil {
+				colorError.Printf("❌ Failed to encode build info: %v\n", err)
+				return
+			}
+			fmt.Fprintln(jsonOut, string(data))
+			return
+		}
+		fmt.Printf("dab-downloader %s\n", info.Version)
+		if info.Commit != "" {
+			commit := info.Commit
+			if info.Modified {
+				commit += " (modified)"
+			}
+			fmt.Printf("   Commit:     %s\n", commit)
+		}
+		if info.BuildDate != "" {
+			fmt.Printf("   Built:      %s\n", info.BuildDate)
+		}
+		fmt.Printf("   Go:         %s %s\n", info.GoVersion, info.Platform)
 	},
 }
 
@@ -596,7 +2248,17 @@ func printInstallInstructions() {
 }
 
 func initConfigAndAPI() (*Config, *DabAPI) {
+	if jsonOutput {
+		enableJSONOutput()
+	}
 	color.NoColor = !isTTY() // Initialize color output
+	if plainOutput {
+		enablePlainOutput()
+	}
+	if summaryOnly && !jsonOutput {
+		enableSummaryOutput()
+	}
+	captureRecentOutput()
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		colorWarning.Println("⚠️ Could not determine home directory, will use current directory for downloads.")
@@ -604,13 +2266,17 @@ func initConfigAndAPI() (*Config, *DabAPI) {
 	}
 
 	config := &Config{
-		APIURL:           "https://dabmusic.xyz",
+		ConfigVersion:    currentConfigVersion,
+		APIURL:           defaultAPIEndpoint,
 		DownloadLocation: filepath.Join(homeDir, "Music"),
 		Parallelism:      5,
 		UpdateRepo:       "PrathxmOp/dab-downloader", // Default value
 		VerifyDownloads:  true, // Enable download verification by default
 		MaxRetryAttempts: defaultMaxRetries, // Use default retry attempts
 		WarningBehavior:  "summary", // Default to summary mode for cleaner output
+		QuarantineDays:   defaultQuarantineDays, // Keep removed files for a week before purging
+		OutageWaitMinutes: defaultOutageWaitMinutes, // Pause and wait out endpoint outages
+		DurationToleranceSec: defaultDurationToleranceSec, // Reject auto matches that are clearly a different recording
 	}
 
 	// Define the config file path in the current directory
@@ -647,7 +2313,7 @@ func initConfigAndAPI() (*Config, *DabAPI) {
 		config.NavidromePassword = GetUserInput("Enter your Navidrome Password", "")
 
 		// Prompt for Format and Bitrate
-		config.Format = GetUserInput("Enter default output format (e.g., flac, mp3, ogg, opus)", "flac")
+		config.Format = GetUserInput("Enter default output format (flac, mp3, ogg, opus, aac or alac)", "flac")
 		config.Bitrate = GetUserInput("Enter default bitrate for lossy formats (e.g., 320)", "320")
 
 		// Prompt for Update Repository
@@ -683,6 +2349,17 @@ func initConfigAndAPI() (*Config, *DabAPI) {
 	if downloadLocation != "" {
 		config.DownloadLocation = downloadLocation
 	}
+	if apiToken != "" {
+		config.APIToken = apiToken
+	} else if token := os.Getenv("DAB_API_TOKEN"); token != "" && config.APIToken == "" {
+		config.APIToken = token
+	}
+	if headers, err := mergeHeaderFlags(config.APIHeaders, apiHeaderFlags); err != nil {
+		colorError.Printf("❌ %v\n", err)
+		os.Exit(1)
+	} else {
+		config.APIHeaders = headers
+	}
 	if spotifyClientID != "" {
 		config.SpotifyClientID = spotifyClientID
 	}
@@ -710,52 +2387,207 @@ func initConfigAndAPI() (*Config, *DabAPI) {
 		config.WarningBehavior = warningBehavior
 	}
 	
+	if language != "" {
+		config.Language = language
+	}
+
+	// Select the language for user-facing messages, falling back to the environment
+	if config.Language != "" {
+		SetLocale(config.Language)
+	} else {
+		SetLocale(DetectLocale())
+	}
+
+	if colorTheme != "" {
+		config.ColorTheme = colorTheme
+	}
+	if err := ApplyColorTheme(config.ColorTheme, config.Colors); err != nil {
+		colorWarning.Printf("⚠️ %v, using default colors\n", err)
+		ApplyColorTheme("default", nil)
+	}
+
+	if err := InitAuditLog(config, auditInterface); err != nil {
+		colorWarning.Printf("⚠️ Audit logging disabled: %v\n", err)
+	}
+
 	// Validate warning behavior
 	if config.WarningBehavior != "immediate" && config.WarningBehavior != "summary" && config.WarningBehavior != "silent" {
 		colorWarning.Printf("⚠️ Invalid warning behavior '%s', using default 'summary'\n", config.WarningBehavior)
 		config.WarningBehavior = "summary"
 	}
 
-	// Create a new http.Client
-	client := &http.Client{
-		Timeout: requestTimeout,
+	// Check the conversion settings up front instead of failing on every track
+	config.Format = strings.ToLower(config.Format)
+	if config.Format != "flac" {
+		for _, err := range []error{ValidateFormat(config.Format), ValidateBitrate(config.Format, config.Bitrate), ValidateEncoderOptions(config.Format, config.Encoders[config.Format])} {
+			if err != nil {
+				colorError.Printf("❌ %v\n", err)
+				os.Exit(1)
+			}
+		}
 	}
 
 	if insecure {
-		client.Transport = &http.Transport{
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		config.InsecureSkipVerify = true
+	}
+	if ipVersion != "" {
+		config.IPVersion = ipVersion
+	}
+	if proxyURL != "" {
+		config.ProxyURL = proxyURL
+	}
+	if streamProxyURL != "" {
+		config.StreamProxyURL = streamProxyURL
+	}
+	if noProxy != "" {
+		config.NoProxy = noProxy
+	}
+	if limitRate != "" {
+		config.MaxBandwidth = limitRate
+	}
+	if config.MaxBandwidth != "" {
+		bytesPerSecond, err := parseByteRate(config.MaxBandwidth)
+		if err != nil {
+			colorError.Printf("❌ Invalid bandwidth limit: %v\n", err)
+			os.Exit(1)
 		}
+		SetBandwidthLimit(bytesPerSecond)
+	}
+
+	// Configure the transport shared by the DAB, Spotify, MusicBrainz and Navidrome clients.
+	// Running without them would bypass a configured proxy, so a mistake is fatal.
+	if err := ConfigureTransport(config); err != nil {
+		colorError.Printf("❌ Failed to apply network settings: %v\n", err)
+		os.Exit(1)
+	}
+
+	ConfigureSourceLimits(config.SourceLimits)
+	downloadThrottle.Configure(totalDownloadLimit(config))
+	mbClient.SetHTTPClient(newHTTPClient(30 * time.Second))
+	mbClient.SetMirror(config.MusicBrainzURL, config.MusicBrainzRate, config.MusicBrainzNoContact)
+	mbClient.SetReleasePreferences(config.ReleasePreferences)
+	if noMusicBrainz {
+		config.DisableMusicBrainz = true
+	}
+	SetMusicBrainzEnabled(!config.DisableMusicBrainz)
+	if findAlternatives {
+		config.FindAlternativeEditions = true
+	}
+	if replayGain {
+		config.ReplayGain = true
+	}
+	if writeNFOFiles {
+		config.WriteNFO = true
+	}
+	if keepFLAC {
+		config.KeepOriginal = true
+	}
+	if compilationPolicy != "" {
+		config.CompilationExpansion = compilationPolicy
+	}
+	if config.CompilationExpansion != "" && config.CompilationExpansion != CompilationKeep && config.CompilationExpansion != CompilationOriginal {
+		colorWarning.Printf("⚠️ Invalid compilation expansion '%s', using '%s'\n", config.CompilationExpansion, CompilationKeep)
+		config.CompilationExpansion = CompilationKeep
 	}
 
-	api := NewDabAPI(config.APIURL, config.DownloadLocation, client)
+	api := NewDabAPI(config.APIURL, config.DownloadLocation, newHTTPClient(requestTimeout))
+	api.SetAuth(config.APIToken, config.APICookie)
+	api.SetHeaders(config.APIHeaders)
+	api.SetOutageWait(time.Duration(config.OutageWaitMinutes) * time.Minute)
+	SetBlocklist(config.Blocklist)
+	if err := SetTitleExclusions(config.ExcludeTitles, excludeWords); err != nil {
+		colorError.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+	SetDurationTolerance(config.DurationToleranceSec)
+	if config.SearchCacheHours > 0 {
+		api.SetSearchCache(NewSearchCache(filepath.Join("config", "search_cache.json"), time.Duration(config.SearchCacheHours)*time.Hour))
+	}
+	if !noDaemon {
+		if daemon := connectDaemon(); daemon != nil {
+			if debug {
+				fmt.Println("DEBUG: Sending searches and downloads through the running daemon")
+			}
+			api.UseDaemon(daemon)
+		}
+	}
+	crashConfig = config
 	return config, api
 }
 
 func init() {
 	rootCmd.PersistentFlags().StringVar(&apiURL, "api-url", "", "DAB API URL")
 	rootCmd.PersistentFlags().StringVar(&downloadLocation, "download-location", "", "Directory to save downloads")
+	rootCmd.PersistentFlags().StringVar(&chaosSpec, "chaos", "", "Inject failures into HTTP requests for testing, e.g. '429=0.2,truncate=0.3,slow=0.1,delay=5s,seed=42' or 'on'")
+	rootCmd.PersistentFlags().MarkHidden("chaos")
+	rootCmd.PersistentFlags().StringVar(&apiToken, "api-token", "", "Bearer token for DAB instances that require authentication (default $DAB_API_TOKEN)")
+	rootCmd.PersistentFlags().StringArrayVar(&apiHeaderFlags, "header", nil, "Extra header for the DAB API as \"Name: Value\", repeatable")
+	rootCmd.PersistentFlags().CountVarP(&verbosity, "verbose", "v", "More output: -v logs every HTTP request, -vv adds debug details, -vvv raw API responses")
 	rootCmd.PersistentFlags().BoolVar(&debug, "debug", false, "Enable debug logging")
+	rootCmd.PersistentFlags().MarkDeprecated("debug", "use -vv instead")
 	rootCmd.PersistentFlags().BoolVar(&insecure, "insecure", false, "Skip TLS certificate verification")
+	rootCmd.PersistentFlags().StringVar(&ipVersion, "ip-version", "", "Force IPv4 or IPv6 connections: '4', '6', or 'auto'")
+	rootCmd.PersistentFlags().StringVar(&proxyURL, "proxy", "", "HTTP(S) or SOCKS5 proxy URL, e.g. socks5://127.0.0.1:1080")
+	rootCmd.PersistentFlags().StringVar(&limitRate, "limit-rate", "", "Cap the combined download speed in bytes per second, e.g. 500K or 5M")
+	rootCmd.PersistentFlags().StringVar(&streamProxyURL, "stream-proxy", "", "Proxy URL for audio streams only, or 'direct' to download them without a proxy")
+	rootCmd.PersistentFlags().StringVar(&noProxy, "no-proxy", "", "Comma-separated hosts, domains and CIDRs reached without a proxy")
 	rootCmd.PersistentFlags().StringVar(&warningBehavior, "warnings", "summary", "Warning behavior: 'immediate', 'summary', or 'silent'")
-
-	albumCmd.Flags().StringVar(&format, "format", "flac", "Format to convert to after downloading (e.g., mp3, ogg, opus)")
+	rootCmd.PersistentFlags().BoolVar(&jsonOutput, "json", false, "Write search results, progress, completion and errors as JSON lines to stdout; other messages go to stderr")
+	rootCmd.PersistentFlags().BoolVarP(&assumeYes, "yes", "y", false, "Answer yes to every confirmation prompt")
+	rootCmd.PersistentFlags().BoolVar(&summaryOnly, "summary-only", false, "Print only a table of the downloaded items (name, result, size, time) at the end, for scheduled runs")
+	rootCmd.PersistentFlags().BoolVar(&plainOutput, "plain", false, "Plain output without colors, emojis or progress bars (for screen readers and logs)")
+	rootCmd.PersistentFlags().StringVar(&colorTheme, "theme", "", "Color theme: 'default', 'high-contrast', or 'none'")
+	rootCmd.PersistentFlags().BoolVar(&noMusicBrainz, "no-musicbrainz", false, "Skip MusicBrainz lookups and keep only DAB-provided tags")
+	rootCmd.PersistentFlags().BoolVar(&replayGain, "replaygain", false, "Write ReplayGain tags to downloaded FLACs (requires ffmpeg)")
+	rootCmd.PersistentFlags().BoolVar(&writeNFOFiles, "nfo", false, "Write album.nfo and artist.nfo files and artist images for Kodi and Jellyfin")
+	rootCmd.PersistentFlags().BoolVar(&keepFLAC, "keep-flac", false, "Keep the FLAC when converting, writing converted copies to a separate folder tree")
+	rootCmd.PersistentFlags().BoolVar(&findAlternatives, "find-alternatives", false, "Download album tracks that are unavailable on DAB from another edition of the album")
+	rootCmd.PersistentFlags().BoolVar(&noDaemon, "no-daemon", false, "Run on its own even when a daemon is running")
+	rootCmd.PersistentFlags().BoolVar(&ignoreHistory, "ignore-history", false, "Download tracks again even if the download history has them")
+	rootCmd.PersistentFlags().StringVar(&language, "lang", "", "Language for messages (en, es, de), defaults to the system locale")
+
+	albumCmd.Flags().StringVar(&format, "format", "flac", "Format to convert to after downloading (mp3, ogg, opus, aac or alac)")
 	albumCmd.Flags().StringVar(&bitrate, "bitrate", "320", "Bitrate for lossy formats (in kbps, e.g., 192, 256, 320)")
+	albumCmd.Flags().BoolVar(&enqueue, "queue", false, "Add the album to the download queue instead of downloading it now")
+	albumCmd.Flags().StringVar(&albumTrackSelection, "tracks", "", "Tracks to download by position, e.g. \"1,3,5-8\" (asks when interactive)")
+	albumCmd.Flags().BoolVar(&metadataOnly, "metadata-only", false, "Write the cover, album.nfo and a tag report without downloading audio")
 
 	artistCmd.Flags().StringVar(&filter, "filter", "all", "Filter by item type (albums, eps, singles), comma-separated")
 	artistCmd.Flags().BoolVar(&noConfirm, "no-confirm", false, "Skip confirmation prompt")
-	artistCmd.Flags().StringVar(&format, "format", "flac", "Format to convert to after downloading (e.g., mp3, ogg, opus)")
+	artistCmd.Flags().IntVar(&fromYear, "from-year", 0, "Only download items released in or after this year")
+	artistCmd.Flags().IntVar(&toYear, "to-year", 0, "Only download items released in or before this year")
+	artistCmd.Flags().StringVar(&excludeWords, "exclude", "", "Skip items whose title contains one of these words, comma-separated (e.g. \"live,remix,deluxe\")")
+	artistCmd.Flags().StringVar(&releasedAfter, "released-after", "", "Only download items released on or after this date (YYYY-MM-DD)")
+	artistCmd.Flags().BoolVar(&metadataOnly, "metadata-only", false, "Write the cover, album.nfo and a tag report of each release without downloading audio")
+	artistCmd.Flags().StringVar(&format, "format", "flac", "Format to convert to after downloading (mp3, ogg, opus, aac or alac)")
 	artistCmd.Flags().StringVar(&bitrate, "bitrate", "320", "Bitrate for lossy formats (in kbps, e.g., 192, 256, 320)")
 
+	batchCmd.Flags().StringVar(&filter, "filter", "all", "Item types to download for artist entries (albums, eps, singles), comma-separated")
+	batchCmd.Flags().StringVar(&excludeWords, "exclude", "", "Skip releases of artist entries whose title contains one of these words, comma-separated")
+	batchCmd.Flags().BoolVar(&expandBatch, "expand", false, "Download the full album of tracks matched by search")
+	batchCmd.Flags().IntVar(&maxExpansion, "max-expansion", 50, "Ask before an artist entry expands to more albums than this (0 disables)")
+	batchCmd.Flags().BoolVar(&noConfirm, "no-confirm", false, "Skip items over --max-expansion instead of asking")
+	batchCmd.Flags().StringVar(&format, "format", "flac", "Format to convert to after downloading (mp3, ogg, opus, aac or alac)")
+	batchCmd.Flags().StringVar(&bitrate, "bitrate", "320", "Bitrate for lossy formats (in kbps, e.g., 192, 256, 320)")
+
 	searchCmd.Flags().StringVar(&searchType, "type", "all", "Type of content to search for (artist, album, track, all)")
 	searchCmd.Flags().BoolVar(&auto, "auto", false, "Automatically download the first result")
-	searchCmd.Flags().StringVar(&format, "format", "flac", "Format to convert to after downloading (e.g., mp3, ogg, opus)")
+	searchCmd.Flags().StringVar(&format, "format", "flac", "Format to convert to after downloading (mp3, ogg, opus, aac or alac)")
 	searchCmd.Flags().StringVar(&bitrate, "bitrate", "320", "Bitrate for lossy formats (in kbps, e.g., 192, 256, 320)")
 
 	spotifyCmd.Flags().StringVar(&spotifyPlaylist, "spotify", "", "Spotify playlist URL to download")
 	spotifyCmd.Flags().BoolVar(&auto, "auto", false, "Automatically download the first result")
 	spotifyCmd.Flags().BoolVar(&expandPlaylist, "expand", false, "Expand playlist tracks to download the full albums")
-	spotifyCmd.Flags().StringVar(&format, "format", "flac", "Format to convert to after downloading (e.g., mp3, ogg, opus)")
+	spotifyCmd.Flags().StringVar(&compilationPolicy, "compilations", "", "What --expand downloads for compilation tracks: 'compilation' or 'original' (their studio album)")
+	spotifyCmd.Flags().BoolVar(&spotifyLiked, "liked", false, "Download your liked songs (logs in to your Spotify account)")
+	spotifyCmd.Flags().BoolVar(&spotifySavedAlbums, "saved-albums", false, "Download the albums saved in your library (logs in to your Spotify account)")
+	spotifyCmd.Flags().StringVar(&format, "format", "flac", "Format to convert to after downloading (mp3, ogg, opus, aac or alac)")
 	spotifyCmd.Flags().StringVar(&bitrate, "bitrate", "320", "Bitrate for lossy formats (in kbps, e.g., 192, 256, 320)")
+	deezerCmd.Flags().BoolVar(&auto, "auto", false, "Automatically download the first result")
+	deezerCmd.Flags().BoolVar(&expandPlaylist, "expand", false, "Expand playlist tracks to download the full albums")
+	deezerCmd.Flags().StringVar(&compilationPolicy, "compilations", "", "What --expand downloads for compilation tracks: 'compilation' or 'original' (their studio album)")
+	deezerCmd.Flags().StringVar(&format, "format", "flac", "Format to convert to after downloading (mp3, ogg, opus, aac or alac)")
+	deezerCmd.Flags().StringVar(&bitrate, "bitrate", "320", "Bitrate for lossy formats (in kbps, e.g., 192, 256, 320)")
 	rootCmd.PersistentFlags().StringVar(&spotifyClientID, "spotify-client-id", "", "Spotify Client ID")
 	rootCmd.PersistentFlags().StringVar(&spotifyClientSecret, "spotify-client-secret", "", "Spotify Client Secret")
 
@@ -764,24 +2596,150 @@ func init() {
 	rootCmd.PersistentFlags().StringVar(&navidromePassword, "navidrome-password", "", "Navidrome Password")
 	navidromeCmd.Flags().StringVar(&ignoreSuffix, "ignore-suffix", "", "Ignore suffix when searching for tracks")
 	navidromeCmd.Flags().BoolVar(&expandNavidrome, "expand", false, "Expand playlist tracks to download the full albums")
+	navidromeCmd.Flags().StringVar(&compilationPolicy, "compilations", "", "What --expand downloads for compilation tracks: 'compilation' or 'original' (their studio album)")
 	navidromeCmd.Flags().BoolVar(&auto, "auto", false, "Automatically download the first result")
 
 	rootCmd.AddCommand(artistCmd)
 	rootCmd.AddCommand(albumCmd)
 	rootCmd.AddCommand(searchCmd)
 	rootCmd.AddCommand(spotifyCmd)
+	rootCmd.AddCommand(deezerCmd)
 	rootCmd.AddCommand(navidromeCmd)
 	rootCmd.AddCommand(addToPlaylistCmd)
 	rootCmd.AddCommand(debugCmd)
+	rootCmd.AddCommand(jobsCmd)
+	rootCmd.AddCommand(batchCmd)
+
+	rootCmd.AddCommand(historyCmd)
+	rootCmd.AddCommand(watchCmd)
+	rootCmd.AddCommand(tagAuditCmd)
+	tagAuditCmd.Flags().BoolVarP(&auditAll, "all", "a", false, "Also list albums that need no changes")
+	rootCmd.AddCommand(repairCmd)
+	rootCmd.AddCommand(checkCompleteCmd)
+	checkCompleteCmd.Flags().BoolVar(&fetchMissing, "fetch", false, "Download the missing and newly added tracks")
+	checkCompleteCmd.Flags().BoolVar(&libraryScan, "scan", false, "Scan the album folders under a path instead of the downloaded albums")
+	checkCompleteCmd.Flags().BoolVar(&repairOffline, "offline", false, "With --scan, only compare with the TOTALTRACKS tag, never ask DAB or MusicBrainz")
+	checkCompleteCmd.Flags().BoolVarP(&auditAll, "all", "a", false, "With --scan, also list complete albums")
+	checkCompleteCmd.Flags().BoolVar(&libraryGaps, "gaps", false, "With --scan, report missing track numbers (e.g. 1, 2, 4, 5) instead of comparing track counts, and offer to download the missing tracks")
+	rootCmd.AddCommand(verifyLibraryCmd)
+	verifyLibraryCmd.Flags().BoolVar(&fetchMissing, "fetch", false, "Download the missing tracks into the existing album folders")
+	verifyLibraryCmd.Flags().BoolVar(&repairOffline, "offline", false, "Only compare with the TOTALTRACKS tag, never ask DAB or MusicBrainz")
+	verifyLibraryCmd.Flags().BoolVarP(&auditAll, "all", "a", false, "Also list complete albums")
+	verifyLibraryCmd.Flags().BoolVar(&libraryGaps, "gaps", false, "Report missing track numbers (e.g. 1, 2, 4, 5) instead of comparing track counts, and offer to download the missing tracks")
+	repairCmd.Flags().BoolVar(&repairDryRun, "dry-run", false, "Only list what is missing")
+	repairCmd.Flags().BoolVar(&repairOffline, "offline", false, "Only use local metadata (history, other tracks, cover files), never ask DAB")
+
+	rootCmd.AddCommand(verifyCmd)
+	verifyCmd.Flags().BoolVar(&verifyQuick, "quick", false, "Only compare checksums, don't decode FLACs")
+	verifyCmd.Flags().BoolVar(&verifyRedownload, "redownload", false, "Download damaged and missing FLACs again, moving the damaged files to the quarantine")
+	verifyCmd.Flags().BoolVar(&verifyUpdate, "update", false, "Record the new checksum of files that changed but decode correctly, e.g. after retagging")
+	verifyCmd.Flags().BoolVarP(&auditAll, "all", "a", false, "Also list folders without problems")
+
+
+	rootCmd.AddCommand(isrcCmd)
+	rootCmd.AddCommand(upcCmd)
+	isrcCmd.Flags().StringVar(&format, "format", "flac", "Format to convert to after downloading (mp3, ogg, opus, aac or alac)")
+	isrcCmd.Flags().StringVar(&bitrate, "bitrate", "320", "Bitrate for lossy formats (in kbps, e.g., 192, 256, 320)")
+	upcCmd.Flags().StringVar(&format, "format", "flac", "Format to convert to after downloading (mp3, ogg, opus, aac or alac)")
+	upcCmd.Flags().StringVar(&bitrate, "bitrate", "320", "Bitrate for lossy formats (in kbps, e.g., 192, 256, 320)")
+
+	rootCmd.AddCommand(exportCmd)
+	exportCmd.AddCommand(exportSpotifyCmd)
+	exportSpotifyCmd.Flags().StringVar(&exportNavidrome, "navidrome-playlist", "", "Export this Navidrome playlist instead of a folder")
+	exportSpotifyCmd.Flags().StringVar(&exportName, "name", "", "Name of the Spotify playlist (default: folder or Navidrome playlist name)")
+	exportSpotifyCmd.Flags().BoolVar(&exportPublic, "public", false, "Make a newly created playlist public")
+	exportSpotifyCmd.Flags().BoolVar(&exportForce, "force", false, "Replace an existing playlist even when it would lose more than half its tracks")
+
+	rootCmd.AddCommand(libraryCmd)
+	libraryCmd.AddCommand(libraryStatsCmd)
+	libraryStatsCmd.Flags().IntVar(&libraryLimit, "limit", 10, "Number of slowest tracks to show (0 shows all)")
+
+	rootCmd.AddCommand(statusCmd)
+	configCmd.AddCommand(configSchemaCmd)
+	configCmd.AddCommand(configValidateCmd)
+	configCmd.AddCommand(configImportCmd)
+	rootCmd.AddCommand(configCmd)
+	rootCmd.AddCommand(daemonCmd)
+	daemonCmd.Flags().IntVar(&queueConcurrency, "concurrency", 0, "Queue items downloaded at once (0 uses MaxConcurrentAlbums)")
+	rootCmd.AddCommand(reportIssueCmd)
+	notifyCmd.AddCommand(notifyTestCmd)
+	rootCmd.AddCommand(notifyCmd)
+	reportIssueCmd.Flags().BoolVar(&issuePrint, "print", false, "Print the issue instead of opening the browser")
+	reportIssueCmd.Flags().StringVar(&issueTitle, "title", "", "Title of the issue")
+	rootCmd.AddCommand(serveCmd)
+	serveCmd.Flags().StringVar(&serveOptions.Listen, "listen", defaultServeAddress, "Address and port the REST API listens on")
+	serveCmd.Flags().StringVar(&serveOptions.Token, "token", "", "Token clients must send as 'Authorization: Bearer <token>' (default $DAB_SERVE_TOKEN)")
+	serveCmd.Flags().StringVar(&serveOptions.TLSCert, "tls-cert", "", "PEM certificate to serve HTTPS with")
+	serveCmd.Flags().StringVar(&serveOptions.TLSKey, "tls-key", "", "PEM private key of --tls-cert")
+	serveCmd.Flags().BoolVar(&serveOptions.NoAuth, "no-auth", false, "Allow listening on other addresses than localhost without authentication")
+	serveCmd.Flags().IntVar(&queueConcurrency, "concurrency", 0, "Queue items downloaded at once (0 uses MaxConcurrentAlbums)")
+
+	rootCmd.AddCommand(queueCmd)
+	queueCmd.AddCommand(queueAddCmd)
+	queueCmd.AddCommand(queueListCmd)
+	queueCmd.AddCommand(queueRunCmd)
+	queueCmd.AddCommand(queuePauseCmd)
+	queueCmd.AddCommand(queueResumeCmd)
+	queueCmd.AddCommand(queueClearCmd)
+	queueAddCmd.Flags().StringVar(&format, "format", "flac", "Format to convert to after downloading (mp3, ogg, opus, aac or alac)")
+	queueAddCmd.Flags().StringVar(&bitrate, "bitrate", "320", "Bitrate for lossy formats (in kbps, e.g., 192, 256, 320)")
+	queueRunCmd.Flags().IntVar(&queueConcurrency, "concurrency", 0, "Queue items downloaded at once (0 uses MaxConcurrentAlbums)")
+	queueRunCmd.Flags().StringVar(&format, "format", "flac", "Format for items queued without one (e.g., mp3, ogg, opus)")
+	queueRunCmd.Flags().StringVar(&bitrate, "bitrate", "320", "Bitrate for items queued without one (in kbps)")
+	queueClearCmd.Flags().BoolVar(&queueClearFinished, "finished", false, "Only remove finished and failed items")
+
+	rootCmd.AddCommand(requestsCmd)
+	requestsCmd.AddCommand(requestsListCmd)
+	requestsCmd.AddCommand(requestsApproveCmd)
+	requestsCmd.AddCommand(requestsRejectCmd)
+	requestsListCmd.Flags().StringVar(&requestStatus, "status", "", "Only list requests with this status (pending, approved or rejected)")
+	requestsApproveCmd.Flags().StringVar(&filter, "filter", "all", "Releases of an artist request to queue (albums, eps, singles), comma-separated")
+	requestsRejectCmd.Flags().StringVar(&rejectReason, "reason", "", "Reason shown to the user")
+
+	rootCmd.AddCommand(scheduleCmd)
+	scheduleCmd.AddCommand(scheduleAddCmd)
+	scheduleCmd.AddCommand(scheduleListCmd)
+	scheduleCmd.AddCommand(scheduleRemoveCmd)
+	scheduleCmd.AddCommand(scheduleRunCmd)
+	watchCmd.AddCommand(watchAddCmd)
+	watchCmd.AddCommand(watchRemoveCmd)
+	watchCmd.AddCommand(watchListCmd)
+	watchCmd.AddCommand(watchRunCmd)
+	watchAddCmd.Flags().StringVar(&filter, "filter", "all", "Item types to download (albums, eps, singles), comma-separated")
+	watchAddCmd.Flags().BoolVar(&watchBackfill, "backfill", false, "Also download releases that already exist, not just new ones")
+	watchAddCmd.Flags().StringVar(&releasedAfter, "released-after", "", "Ignore releases from before this date (YYYY-MM-DD)")
+	watchAddCmd.Flags().IntVar(&watchMaxAge, "max-age", 0, "Ignore releases older than this many days (default WatchMaxAgeDays)")
+	watchAddCmd.Flags().StringVar(&watchExclude, "exclude", "", "Ignore releases whose title contains one of these words, comma-separated (e.g. \"live,remix,demo\")")
+	watchRunCmd.Flags().DurationVar(&watchInterval, "interval", defaultWatchInterval, "Time between checks")
+	watchRunCmd.Flags().BoolVar(&watchOnce, "once", false, "Check once and exit, for running from cron")
+	watchRunCmd.Flags().StringVar(&format, "format", "flac", "Format to convert to after downloading (mp3, ogg, opus, aac or alac)")
+	watchRunCmd.Flags().StringVar(&bitrate, "bitrate", "320", "Bitrate for lossy formats (in kbps, e.g., 192, 256, 320)")
+
+	historyCmd.AddCommand(historyExportCmd)
+	historyCmd.Flags().IntVar(&historyLimit, "limit", 50, "Maximum number of entries to show (0 shows all)")
+	historyExportCmd.Flags().StringVar(&historyFormat, "format", "json", "Export format: 'json' or 'csv'")
+	historyExportCmd.Flags().StringVarP(&historyOutput, "output", "o", "", "File to write the export to (defaults to stdout)")
+
+	jobsCmd.AddCommand(jobsListCmd)
+	jobsCmd.AddCommand(jobsResumeCmd)
 
 	debugCmd.AddCommand(testApiAvailabilityCmd)
 	debugCmd.AddCommand(testArtistEndpointsCmd)
 	debugCmd.AddCommand(comprehensiveArtistDebugCmd)
+	debugCmd.AddCommand(debugStatsCmd)
+	debugCmd.AddCommand(selfTestCmd)
+	selfTestCmd.Flags().StringVar(&selfTestQuery, "query", defaultSelfTestQuery, "Search query used to find a test track")
+	configImportCmd.Flags().StringVar(&importFrom, "from", "", "Downloader to import from: 'streamrip' or 'orpheusdl'")
+	configImportCmd.Flags().BoolVar(&importDryRun, "dry-run", false, "Show the changes without saving them")
+	configImportCmd.MarkFlagRequired("from")
+	configSchemaCmd.Flags().StringVarP(&configSchemaOutput, "output", "o", filepath.Join("config", "config.schema.json"), "File to write the schema to, or - for standard output")
 
 	rootCmd.AddCommand(versionCmd)
 }
 
 func main() {
+	defer handlePanic()
+
 	var versionInfo VersionInfo
 	if err := json.Unmarshal(versionJSON, &versionInfo); err != nil {
 		fmt.Fprintf(os.Stderr, "Error reading embedded version.json: %v\n", err)
@@ -789,6 +2747,20 @@ func main() {
 	}
 	toolVersion = versionInfo.Version
 
+	// Messages are printed before flags are parsed, so --json is needed this early to keep
+	// stdout for events only
+	for _, arg := range os.Args[1:] {
+		switch arg {
+		case "--json":
+			jsonOutput = true
+		case "--summary-only":
+			summaryOnly = true
+		case "--yes", "-y":
+			// Also answers the update prompt, which is asked before flags are parsed
+			assumeYes = true
+		}
+	}
+
 	// Set rootCmd.Version after toolVersion is populated
 	rootCmd.Version = toolVersion
 
@@ -803,9 +2775,18 @@ func main() {
 		config.IsDockerContainer = true
 	}
 
+	// Clean up expired files from the quarantine folder
+	if purged, err := PurgeQuarantine(config); err != nil {
+		colorWarning.Printf("⚠️ Failed to purge quarantine: %v\n", err)
+	} else if purged > 0 {
+		colorInfo.Printf("🗑️ Purged %d expired file(s) from quarantine\n", purged)
+	}
+
 	CheckForUpdates(config, toolVersion)
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Println(err)
+	err := rootCmd.Execute()
+	printSummary()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
 }
@@ -0,0 +1,323 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Blocklist holds DAB IDs that are never picked automatically, e.g. karaoke versions or tribute bands
+type Blocklist struct {
+	Artists []string `json:"artists,omitempty"`
+	Albums  []string `json:"albums,omitempty"`
+	Tracks  []string `json:"tracks,omitempty"`
+}
+
+// blockedIDs is the blocklist in use, keyed by "<type>:<id>"
+var blockedIDs = map[string]bool{}
+
+// SetBlocklist replaces the blocklist used when selecting results automatically
+func SetBlocklist(list *Blocklist) {
+	blockedIDs = map[string]bool{}
+	if list == nil {
+		return
+	}
+	for _, id := range list.Artists {
+		blockedIDs["artist:"+strings.TrimSpace(id)] = true
+	}
+	for _, id := range list.Albums {
+		blockedIDs["album:"+strings.TrimSpace(id)] = true
+	}
+	for _, id := range list.Tracks {
+		blockedIDs["track:"+strings.TrimSpace(id)] = true
+	}
+}
+
+// isBlocked reports whether a DAB ID of the given type is blocklisted
+func isBlocked(itemType string, id interface{}) bool {
+	return blockedIDs[itemType+":"+idToString(id)]
+}
+
+// blockedLabel marks blocklisted items in interactive result lists
+func blockedLabel(itemType string, id interface{}) string {
+	if isBlocked(itemType, id) {
+		return " [blocklisted]"
+	}
+	return ""
+}
+
+// filterBlocked returns the results without blocklisted artists, albums and tracks.
+// Tracks are also dropped when their artist or album is blocklisted.
+func filterBlocked(results *SearchResults) *SearchResults {
+	if len(blockedIDs) == 0 {
+		return results
+	}
+	filtered := &SearchResults{}
+	for _, artist := range results.Artists {
+		if !isBlocked("artist", artist.ID) {
+			filtered.Artists = append(filtered.Artists, artist)
+		}
+	}
+	for _, album := range results.Albums {
+		if !isBlocked("album", album.ID) {
+			filtered.Albums = append(filtered.Albums, album)
+		}
+	}
+	for _, track := range results.Tracks {
+		if !isBlocked("track", track.ID) && !isBlocked("artist", track.ArtistId) && !isBlocked("album", track.AlbumID) {
+			filtered.Tracks = append(filtered.Tracks, track)
+		}
+	}
+	return filtered
+}
+
+// variantTerms match versions that are rarely wanted unless asked for explicitly
+var variantTerms = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)\bkaraoke\b`),
+	regexp.MustCompile(`(?i)\btribute\b`),
+	regexp.MustCompile(`(?i)\bcover\b`),
+	regexp.MustCompile(`(?i)\blive\b`),
+	regexp.MustCompile(`(?i)\bsped[ -]?up\b`),
+	regexp.MustCompile(`(?i)\b8d\b`),
+}
+
+// variantPenalty counts the variant terms found in text that the source query doesn't contain
+func variantPenalty(query, text string) int {
+	penalty := 0
+	for _, term := range variantTerms {
+		if term.MatchString(text) && !term.MatchString(query) {
+			penalty++
+		}
+	}
+	return penalty
+}
+
+// MatchMemory remembers which DAB track the user picked for a source track (a Spotify
+// track or a batch query), so later imports reuse the decision instead of asking again
+type MatchMemory struct {
+	path    string
+	entries map[string]string // Source key -> DAB track ID
+	loaded  bool
+	mu      sync.Mutex
+}
+
+// matchMemory is the store used by the import commands
+var matchMemory = NewMatchMemory(filepath.Join("config", "matches.json"))
+
+// NewMatchMemory creates a match memory backed by path
+func NewMatchMemory(path string) *MatchMemory {
+	return &MatchMemory{path: path, entries: make(map[string]string)}
+}
+
+// MatchSource describes the track being looked up on DAB
+type MatchSource struct {
+	Key         string // Match memory key, e.g. "spotify:<id>"
+	Query       string // Search query
+	DurationSec int    // Duration of the source track, 0 if unknown
+}
+
+// spotifySource returns the match source of an imported playlist track searched with query
+func spotifySource(track SpotifyTrack, query string) MatchSource {
+	source := track.Source
+	if source == "" {
+		source = "spotify"
+	}
+	key := source + ":" + track.ID
+	if track.ID == "" {
+		key = querySourceKey(track.Name + " - " + track.Artist)
+	}
+	return MatchSource{Key: key, Query: query, DurationSec: track.DurationSec}
+}
+
+// querySource returns the match source of a free-text query
+func querySource(query string) MatchSource {
+	return MatchSource{Key: querySourceKey(query), Query: query}
+}
+
+// querySourceKey returns the match memory key of a free-text query
+func querySourceKey(query string) string {
+	return "query:" + strings.ToLower(strings.TrimSpace(query))
+}
+
+// Lookup returns the remembered DAB track ID for a source key
+func (m *MatchMemory) Lookup(key string) (string, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.load()
+	id, ok := m.entries[key]
+	return id, ok
+}
+
+// Remember stores the DAB track chosen for a source key
+func (m *MatchMemory) Remember(key, dabID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.load()
+	m.entries[key] = dabID
+
+	data, err := json.MarshalIndent(m.entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(m.path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(m.path, data, 0644)
+}
+
+// load reads the memory file once. A missing or corrupt file starts empty.
+func (m *MatchMemory) load() {
+	if m.loaded {
+		return
+	}
+	m.loaded = true
+	data, err := os.ReadFile(m.path)
+	if err != nil {
+		return
+	}
+	if err := json.Unmarshal(data, &m.entries); err != nil || m.entries == nil {
+		m.entries = make(map[string]string)
+	}
+}
+
+// findTracks resolves a source track to DAB tracks. A remembered match is used directly;
+// otherwise the query is searched, and a single track picked manually is remembered.
+// Automatic matches whose duration is too far from the source are flagged for review instead.
+func findTracks(ctx context.Context, api *DabAPI, source MatchSource, debug bool, auto bool) ([]Track, error) {
+	if id, ok := matchMemory.Lookup(source.Key); ok {
+		track, err := api.GetTrack(ctx, id)
+		if err == nil {
+			colorInfo.Printf("🧠 Using remembered match for '%s': %s - %s\n", source.Query, track.Title, track.Artist)
+			return []Track{*track}, nil
+		}
+		if debug {
+			fmt.Printf("DEBUG - Remembered match %s for '%s' failed: %v\n", id, source.Query, err)
+		}
+	}
+
+	selectedItems, itemTypes, err := handleSearch(ctx, api, source.Query, "track", debug, auto)
+	if err != nil {
+		return nil, err
+	}
+
+	var tracks []Track
+	for i, item := range selectedItems {
+		if itemTypes[i] == "track" {
+			tracks = append(tracks, item.(Track))
+		}
+	}
+
+	if auto {
+		var accepted []Track
+		for _, track := range tracks {
+			if durationMismatch(source.DurationSec, track.Duration) {
+				colorWarning.Printf("⚠️ %s - %s is %s long but the source is %s, flagged for review\n", track.Title, track.Artist, formatTrackLength(track.Duration), formatTrackLength(source.DurationSec))
+				reviewQueue.Flag(source, track)
+				continue
+			}
+			accepted = append(accepted, track)
+		}
+		return accepted, nil
+	}
+
+	if len(tracks) == 1 {
+		if err := matchMemory.Remember(source.Key, idToString(tracks[0].ID)); err != nil {
+			colorWarning.Printf("⚠️ Failed to remember match: %v\n", err)
+		}
+	}
+	return tracks, nil
+}
+
+// durationToleranceSec is the largest duration difference accepted for automatic matches, 0 disables the check
+var durationToleranceSec = defaultDurationToleranceSec
+
+// SetDurationTolerance sets the duration tolerance for automatic matches
+func SetDurationTolerance(seconds int) {
+	durationToleranceSec = seconds
+}
+
+// durationMismatch reports whether a match is too long or too short compared to the source.
+// Unknown durations are never a mismatch.
+func durationMismatch(sourceSec, matchSec int) bool {
+	if durationToleranceSec <= 0 || sourceSec <= 0 || matchSec <= 0 {
+		return false
+	}
+	diff := sourceSec - matchSec
+	if diff < 0 {
+		diff = -diff
+	}
+	return diff > durationToleranceSec
+}
+
+// formatTrackLength formats seconds as m:ss
+func formatTrackLength(seconds int) string {
+	return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+}
+
+// ReviewItem is an automatic match that was rejected and needs a manual decision
+type ReviewItem struct {
+	Source       string `json:"source"`
+	Query        string `json:"query"`
+	SourceLength int    `json:"source_length"`
+	MatchID      string `json:"match_id"`
+	Match        string `json:"match"`
+	MatchLength  int    `json:"match_length"`
+}
+
+// ReviewQueue collects rejected matches during a run
+type ReviewQueue struct {
+	path  string
+	items []ReviewItem
+	mu    sync.Mutex
+}
+
+// reviewQueue holds the matches flagged for review in this run
+var reviewQueue = &ReviewQueue{path: filepath.Join("config", "review.json")}
+
+// Flag adds a rejected match to the queue
+func (q *ReviewQueue) Flag(source MatchSource, track Track) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.items = append(q.items, ReviewItem{
+		Source:       source.Key,
+		Query:        source.Query,
+		SourceLength: source.DurationSec,
+		MatchID:      idToString(track.ID),
+		Match:        track.Title + " - " + track.Artist,
+		MatchLength:  track.Duration,
+	})
+}
+
+// Report prints the flagged matches and saves them to config/review.json
+func (q *ReviewQueue) Report() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.items) == 0 {
+		return
+	}
+
+	colorWarning.Printf("\n🔎 %d matches need review (duration outside the tolerance):\n", len(q.items))
+	for _, item := range q.items {
+		colorWarning.Printf("   - %s (%s) -> %s (%s, ID %s)\n", item.Query, formatTrackLength(item.SourceLength), item.Match, formatTrackLength(item.MatchLength), item.MatchID)
+	}
+
+	data, err := json.MarshalIndent(q.items, "", "  ")
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(q.path), 0755)
+	}
+	if err == nil {
+		err = os.WriteFile(q.path, data, 0644)
+	}
+	if err != nil {
+		colorWarning.Printf("⚠️ Failed to save review list: %v\n", err)
+		return
+	}
+	colorInfo.Printf("💡 Saved to %s. Re-run without --auto to pick these tracks manually.\n", q.path)
+}
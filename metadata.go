@@ -17,6 +17,14 @@ func SetMusicBrainzDebug(debug bool) {
 	mbClient.SetDebug(debug)
 }
 
+// musicBrainzEnabled controls whether tags are enriched with MusicBrainz IDs
+var musicBrainzEnabled = true
+
+// SetMusicBrainzEnabled enables or disables all MusicBrainz lookups, leaving only DAB-provided tags
+func SetMusicBrainzEnabled(enabled bool) {
+	musicBrainzEnabled = enabled
+}
+
 // AlbumMetadataCache holds cached MusicBrainz release metadata for albums
 type AlbumMetadataCache struct {
 	releases map[string]*MusicBrainzRelease // key: "artist|album"
@@ -78,6 +86,31 @@ func AddMetadataWithDebug(filePath string, track Track, album *Album, coverData
 	}
 	f.Meta = newMetaData
 
+	comment := buildVorbisComment(track, album, totalTracks, warningCollector)
+
+	// Marshal the comment to a FLAC metadata block
+	vorbisCommentBlock := comment.Marshal()
+	f.Meta = append(f.Meta, &vorbisCommentBlock)
+
+	// Add cover art if available
+	if err := addCoverArt(f, coverData); err != nil {
+		if warningCollector != nil {
+			context := fmt.Sprintf("%s - %s", track.Artist, track.Title)
+			warningCollector.AddCoverArtMetadataWarning(context, err.Error())
+		}
+	}
+
+	// Save the file with new metadata
+	if err := f.Save(filePath); err != nil {
+		return fmt.Errorf("failed to save FLAC file with metadata: %w", err)
+	}
+
+	return nil
+}
+
+// buildVorbisComment returns the tags written to a track, including those found on
+// MusicBrainz
+func buildVorbisComment(track Track, album *Album, totalTracks int, warningCollector *WarningCollector) *flacvorbis.MetaDataBlockVorbisComment {
 	// Create a new Vorbis comment block with comprehensive metadata
 	comment := flacvorbis.New()
 
@@ -174,7 +207,9 @@ func AddMetadataWithDebug(filePath string, track Track, album *Album, coverData
 	// }
 
 	// Fetch and add MusicBrainz metadata with optimized caching
-	addMusicBrainzMetadata(comment, track, album, albumTitle, warningCollector)
+	if musicBrainzEnabled {
+		addMusicBrainzMetadata(comment, track, album, albumTitle, warningCollector)
+	}
 
 	addField(comment, "ENCODER", "EnhancedFLACDownloader/2.0")
 	addField(comment, "ENCODING", "FLAC")
@@ -185,24 +220,7 @@ func AddMetadataWithDebug(filePath string, track Track, album *Album, coverData
 		addField(comment, "LENGTH", fmt.Sprintf("%d", track.Duration))
 	}
 
-	// Marshal the comment to a FLAC metadata block
-	vorbisCommentBlock := comment.Marshal()
-	f.Meta = append(f.Meta, &vorbisCommentBlock)
-
-	// Add cover art if available
-	if err := addCoverArt(f, coverData); err != nil {
-		if warningCollector != nil {
-			context := fmt.Sprintf("%s - %s", track.Artist, track.Title)
-			warningCollector.AddCoverArtMetadataWarning(context, err.Error())
-		}
-	}
-
-	// Save the file with new metadata
-	if err := f.Save(filePath); err != nil {
-		return fmt.Errorf("failed to save FLAC file with metadata: %w", err)
-	}
-
-	return nil
+	return comment
 }
 
 // addField adds a field to vorbis comment only if value is not empty
@@ -272,13 +290,37 @@ func addMusicBrainzMetadata(comment *flacvorbis.MetaDataBlockVorbisComment, trac
 	}
 
 	// Handle release-level metadata with caching
+	var mbRelease *MusicBrainzRelease
 	if album != nil {
-		addReleaseMetadata(comment, album.Artist, album.Title, warningCollector)
+		mbRelease = addReleaseMetadata(comment, album.Artist, album.Title, warningCollector)
+	}
+	if mbTrack == nil {
+		return
+	}
+
+	// Picard also tags the track's position on the release and the work it performs
+	releaseID := ""
+	if mbRelease != nil {
+		releaseID = mbRelease.ID
+	}
+	if releaseTrackID := mbTrack.ReleaseTrackID(releaseID, albumTitle); releaseTrackID != "" {
+		addField(comment, "MUSICBRAINZ_RELEASETRACKID", releaseTrackID)
+	}
+	works, err := mbClient.GetRecordingWorks(mbTrack.ID)
+	if err != nil {
+		if warningCollector != nil {
+			warningCollector.AddMusicBrainzTrackWarning(track.Artist, track.Title, fmt.Sprintf("work lookup failed: %v", err))
+		}
+		return
+	}
+	for _, workID := range works {
+		addField(comment, "MUSICBRAINZ_WORKID", workID)
 	}
 }
 
-// addReleaseMetadata handles release-level MusicBrainz metadata with caching and retry logic
-func addReleaseMetadata(comment *flacvorbis.MetaDataBlockVorbisComment, artist, albumTitle string, warningCollector *WarningCollector) {
+// addReleaseMetadata handles release-level MusicBrainz metadata with caching and retry logic.
+// It returns the release that was tagged, or nil when none was found.
+func addReleaseMetadata(comment *flacvorbis.MetaDataBlockVorbisComment, artist, albumTitle string, warningCollector *WarningCollector) *MusicBrainzRelease {
 	// Check cache first
 	mbRelease := albumCache.GetCachedRelease(artist, albumTitle)
 	
@@ -290,7 +332,7 @@ func addReleaseMetadata(comment *flacvorbis.MetaDataBlockVorbisComment, artist,
 			if warningCollector != nil {
 				warningCollector.AddMusicBrainzReleaseWarning(artist, albumTitle, err.Error())
 			}
-			return
+			return nil
 		}
 		
 		// Cache the successful result
@@ -310,6 +352,7 @@ func addReleaseMetadata(comment *flacvorbis.MetaDataBlockVorbisComment, artist,
 	if mbRelease.ReleaseGroup.ID != "" {
 		addField(comment, "MUSICBRAINZ_RELEASEGROUPID", mbRelease.ReleaseGroup.ID)
 	}
+	return mbRelease
 }
 
 // addCoverArt adds cover art to the FLAC file
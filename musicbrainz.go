@@ -8,14 +8,19 @@ import (
 	"net"
 	"net/http"
 	"net/url"
+	"sort"
+	"strings"
 	"time"
 
+	"golang.org/x/sync/singleflight"
 	"golang.org/x/time/rate"
 )
 
 const (
 	musicBrainzAPI       = "https://musicbrainz.org/ws/2/"
 	musicBrainzUserAgent = "dab-downloader/2.0 ( prathxm.in@gmail.com )" // Replace with your actual email or project contact
+	musicBrainzPlainUserAgent = "dab-downloader/2.0"                     // For mirrors that don't need a contact address
+	musicBrainzPublicRate     = 1.0                                      // Requests per second allowed by musicbrainz.org
 )
 
 // MusicBrainzConfig holds retry configuration for MusicBrainz API calls
@@ -41,8 +46,26 @@ type MusicBrainzClient struct {
 	debug       bool
 	rateLimiter *rate.Limiter
 	baseURL     string // Add this field
+	userAgent   string
+	releasePrefs *ReleasePreferences // Rules for picking among release candidates, nil takes the top result
+	lookups     singleflight.Group  // Shares identical lookups made at the same time by parallel downloads
 }
 
+// ReleasePreferences controls which release is picked when MusicBrainz returns several editions.
+// Rules are applied in order: official status, preferred country, then earliest date.
+type ReleasePreferences struct {
+	Countries      []string `json:"countries,omitempty"` // Preferred release countries in order, e.g. ["US", "GB", "XW"]
+	PreferEarliest bool     `json:"prefer_earliest"`     // Prefer the earliest release date (the original edition)
+	PreferOfficial bool     `json:"prefer_official"`     // Prefer releases with status "Official"
+}
+
+// releaseCandidates is the number of releases considered when preferences are set
+const releaseCandidates = 10
+
+// releaseScoreMargin keeps only candidates whose search score is close to the best match,
+// so preferences choose between editions of the same album and never pick a different album
+const releaseScoreMargin = 10
+
 
 
 
@@ -56,6 +79,7 @@ func NewMusicBrainzClientWithConfig(config MusicBrainzConfig) *MusicBrainzClient
 		debug:  false,
 		rateLimiter: rate.NewLimiter(rate.Every(time.Second), 1),
 		baseURL: musicBrainzAPI,
+		userAgent: musicBrainzUserAgent,
 	}
 }
 
@@ -69,9 +93,44 @@ func NewMusicBrainzClientWithDebug(debug bool) *MusicBrainzClient {
 		debug:  debug,
 		rateLimiter: rate.NewLimiter(rate.Every(time.Second), 1),
 		baseURL: musicBrainzAPI,
+		userAgent: musicBrainzUserAgent,
+	}
+}
+
+// SetMirror points the client at a MusicBrainz mirror with its own rate limit (requests per second).
+// The public server is always limited to one request per second. With noContact the user agent
+// omits the contact address, which self-hosted mirrors don't need.
+func (mb *MusicBrainzClient) SetMirror(baseURL string, requestsPerSecond float64, noContact bool) {
+	if baseURL != "" {
+		if !strings.HasSuffix(baseURL, "/") {
+			baseURL += "/"
+		}
+		mb.baseURL = baseURL
+	}
+	if mb.baseURL == musicBrainzAPI && requestsPerSecond > musicBrainzPublicRate {
+		colorWarning.Println("⚠️ MusicBrainzRate above 1 request/second is only allowed with a mirror, using 1")
+		requestsPerSecond = musicBrainzPublicRate
+	}
+	if requestsPerSecond > 0 {
+		mb.rateLimiter = rate.NewLimiter(rate.Limit(requestsPerSecond), 1)
+	}
+	if noContact {
+		mb.userAgent = musicBrainzPlainUserAgent
+	} else {
+		mb.userAgent = musicBrainzUserAgent
 	}
 }
 
+// SetReleasePreferences sets the rules used to pick a release among several editions
+func (mb *MusicBrainzClient) SetReleasePreferences(prefs *ReleasePreferences) {
+	mb.releasePrefs = prefs
+}
+
+// SetHTTPClient replaces the HTTP client, e.g. to use the shared transport with custom CAs
+func (mb *MusicBrainzClient) SetHTTPClient(client *http.Client) {
+	mb.client = client
+}
+
 // UpdateRetryConfig updates the retry configuration for the client
 func (mb *MusicBrainzClient) UpdateRetryConfig(config MusicBrainzConfig) {
 	mb.config = config
@@ -98,19 +157,24 @@ func (mb *MusicBrainzClient) get(path string) ([]byte, error) {
 		return nil, fmt.Errorf("failed to parse MusicBrainz API URL: %w", err)
 	}
 
-	// Wait for the rate limiter
-	mb.rateLimiter.Wait(context.Background())
-
 	err = RetryWithBackoffForHTTPWithDebug(
 		mb.config.MaxRetries,    // maxRetries from client config
 		mb.config.InitialDelay,  // initialDelay from client config
 		mb.config.MaxDelay,      // maxDelay from client config
 		func() error {
+			// Every attempt, retries included, waits for the shared rate limiter so
+			// parallel downloads never exceed the MusicBrainz request rate together
+			mb.rateLimiter.Wait(context.Background())
+			release, err := musicBrainzLimit.acquire(context.Background())
+			defer release()
+			if err != nil {
+				return err
+			}
 			req, err := http.NewRequest("GET", reqURL.String(), nil)
 			if err != nil {
 				return fmt.Errorf("failed to create request: %w", err)
 			}
-			req.Header.Set("User-Agent", musicBrainzUserAgent)
+			req.Header.Set("User-Agent", mb.userAgent)
 			req.Header.Set("Accept", "application/json")
 
 			resp, err := mb.client.Do(req) // Use mb.client
@@ -162,8 +226,23 @@ func (mb *MusicBrainzClient) get(path string) ([]byte, error) {
 	return body, nil
 }
 
-// getWithRetry makes a GET request to the MusicBrainz API with retry logic for retryable errors
+// getWithRetry makes a GET request to the MusicBrainz API with retry logic for retryable errors.
+// Parallel downloads asking for the same path while a request is in flight share its result.
 func (mb *MusicBrainzClient) getWithRetry(path string) ([]byte, error) {
+	result, err, shared := mb.lookups.Do(path, func() (interface{}, error) {
+		return mb.getWithRetryOnce(path)
+	})
+	if shared && mb.debug {
+		fmt.Printf("DEBUG: Shared in-flight MusicBrainz lookup %s\n", path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result.([]byte), nil
+}
+
+// getWithRetryOnce performs a single rate-limited lookup with retries
+func (mb *MusicBrainzClient) getWithRetryOnce(path string) ([]byte, error) {
 	var result []byte
 	var err error
 
@@ -237,10 +316,68 @@ func (mb *MusicBrainzClient) SearchTrack(artist, album, title string) (*MusicBra
 	return nil, fmt.Errorf("no track found on MusicBrainz for: %s - %s - %s", artist, album, title)
 }
 
+// SearchRecordings returns the recordings of a title by an artist, with the releases
+// they are on
+func (mb *MusicBrainzClient) SearchRecordings(artist, title string, limit int) ([]MusicBrainzTrack, error) {
+	query := fmt.Sprintf("artist:\"%s\" AND recording:\"%s\"", artist, title)
+	body, err := mb.getWithRetry(fmt.Sprintf("recording?query=%s&limit=%d", url.QueryEscape(query), limit))
+	if err != nil {
+		return nil, err
+	}
+	var result struct {
+		Recordings []MusicBrainzTrack `json:"recordings"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal MusicBrainz recording search result: %w", err)
+	}
+	return result.Recordings, nil
+}
+
+// LookupISRC returns the recordings MusicBrainz has for an ISRC
+func (mb *MusicBrainzClient) LookupISRC(isrc string) ([]MusicBrainzTrack, error) {
+	body, err := mb.getWithRetry(fmt.Sprintf("isrc/%s?inc=artist-credits", url.PathEscape(isrc)))
+	if err != nil {
+		return nil, err
+	}
+	var result struct {
+		Recordings []MusicBrainzTrack `json:"recordings"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal MusicBrainz ISRC lookup: %w", err)
+	}
+	if len(result.Recordings) == 0 {
+		return nil, fmt.Errorf("no recording found on MusicBrainz for ISRC %s", isrc)
+	}
+	return result.Recordings, nil
+}
+
+// SearchReleaseByBarcode returns the release with the given UPC/EAN barcode
+func (mb *MusicBrainzClient) SearchReleaseByBarcode(barcode string) (*MusicBrainzRelease, error) {
+	path := fmt.Sprintf("release?query=%s&limit=1", url.QueryEscape("barcode:"+barcode))
+	body, err := mb.getWithRetry(path)
+	if err != nil {
+		return nil, err
+	}
+	var result struct {
+		Releases []MusicBrainzRelease `json:"releases"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal MusicBrainz release search result: %w", err)
+	}
+	if len(result.Releases) == 0 {
+		return nil, fmt.Errorf("no release found on MusicBrainz for barcode %s", barcode)
+	}
+	return &result.Releases[0], nil
+}
+
 // SearchRelease searches for a release on MusicBrainz
 func (mb *MusicBrainzClient) SearchRelease(artist, album string) (*MusicBrainzRelease, error) {
 	query := fmt.Sprintf("artist:\"%s\" AND release:\"%s\"", artist, album)
-	path := fmt.Sprintf("release?query=%s&limit=1", url.QueryEscape(query))
+	limit := 1
+	if mb.releasePrefs != nil {
+		limit = releaseCandidates
+	}
+	path := fmt.Sprintf("release?query=%s&limit=%d", url.QueryEscape(query), limit)
 	body, err := mb.getWithRetry(path)
 	if err != nil {
 		return nil, err
@@ -254,12 +391,59 @@ func (mb *MusicBrainzClient) SearchRelease(artist, album string) (*MusicBrainzRe
 	}
 
 	if len(searchResult.Releases) > 0 {
-		return &searchResult.Releases[0], nil
+		return selectBestRelease(searchResult.Releases, mb.releasePrefs), nil
 	}
 
 	return nil, fmt.Errorf("no release found on MusicBrainz for: %s - %s", artist, album)
 }
 
+// selectBestRelease picks a release from search results according to prefs.
+// Without preferences the top search result is used.
+func selectBestRelease(releases []MusicBrainzRelease, prefs *ReleasePreferences) *MusicBrainzRelease {
+	if prefs == nil || len(releases) == 1 {
+		return &releases[0]
+	}
+
+	topScore := releases[0].Score
+	candidates := []MusicBrainzRelease{}
+	for _, release := range releases {
+		if release.Score >= topScore-releaseScoreMargin {
+			candidates = append(candidates, release)
+		}
+	}
+
+	countryRank := func(country string) int {
+		for i, preferred := range prefs.Countries {
+			if strings.EqualFold(preferred, country) {
+				return i
+			}
+		}
+		return len(prefs.Countries)
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if prefs.PreferOfficial {
+			aOfficial, bOfficial := strings.EqualFold(a.Status, "Official"), strings.EqualFold(b.Status, "Official")
+			if aOfficial != bOfficial {
+				return aOfficial
+			}
+		}
+		if ra, rb := countryRank(a.Country), countryRank(b.Country); ra != rb {
+			return ra < rb
+		}
+		if prefs.PreferEarliest && a.Date != b.Date {
+			// Releases without a date go last
+			if a.Date == "" || b.Date == "" {
+				return b.Date == ""
+			}
+			return a.Date < b.Date
+		}
+		return false
+	})
+	return &candidates[0]
+}
+
 // MusicBrainzTrack represents a simplified MusicBrainz recording (track)
 type MusicBrainzTrack struct {
 	ID           string `json:"id"`
@@ -271,10 +455,12 @@ type MusicBrainzTrack struct {
 		} `json:"artist"`
 	} `json:"artist-credit"`
 	Releases []struct {
-		ID    string `json:"id"`
-		Title string `json:"title"`
-		Date  string `json:"date"`
-		Media []struct {
+		ID           string       `json:"id"`
+		Title        string       `json:"title"`
+		Date         string       `json:"date"`
+		Status       string       `json:"status"`        // Only set in search results
+		ReleaseGroup ReleaseGroup `json:"release-group"` // Only set in search results
+		Media        []struct {
 			Format string `json:"format"`
 			Discs  []struct {
 				ID string `json:"id"`
@@ -285,11 +471,62 @@ type MusicBrainzTrack struct {
 				Title  string `json:"title"`
 				Length int    `json:"length"`
 			} `json:"tracks"`
+			// Search results list the matched track under "track" instead of "tracks"
+			Track []struct {
+				ID     string `json:"id"`
+				Number string `json:"number"`
+			} `json:"track"`
 		} `json:"media"`
 	} `json:"releases"`
+	Relations []struct {
+		Type string `json:"type"`
+		Work struct {
+			ID    string `json:"id"`
+			Title string `json:"title"`
+		} `json:"work"`
+	} `json:"relations"` // Only set when fetched with inc=work-rels
 	Length int `json:"length"` // Duration in milliseconds
 }
 
+// ReleaseTrackID returns the ID of this recording's track on the given release. When the
+// release isn't among the recording's releases, one with the same title is used instead.
+func (t *MusicBrainzTrack) ReleaseTrackID(releaseID, albumTitle string) string {
+	fallback := ""
+	for _, release := range t.Releases {
+		for _, medium := range release.Media {
+			for _, track := range medium.Track {
+				if release.ID == releaseID {
+					return track.ID
+				}
+				if fallback == "" && strings.EqualFold(release.Title, albumTitle) {
+					fallback = track.ID
+				}
+			}
+		}
+	}
+	return fallback
+}
+
+// GetRecordingWorks fetches the IDs of the works a recording is a performance of
+func (mb *MusicBrainzClient) GetRecordingWorks(mbid string) ([]string, error) {
+	body, err := mb.getWithRetry(fmt.Sprintf("recording/%s?inc=work-rels", mbid))
+	if err != nil {
+		return nil, err
+	}
+
+	var recording MusicBrainzTrack
+	if err := json.Unmarshal(body, &recording); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal MusicBrainz work relations: %w", err)
+	}
+	var works []string
+	for _, rel := range recording.Relations {
+		if rel.Type == "performance" && rel.Work.ID != "" {
+			works = append(works, rel.Work.ID)
+		}
+	}
+	return works, nil
+}
+
 // MusicBrainzRelease represents a simplified MusicBrainz release (album)
 type MusicBrainzRelease struct {
 	ID           string `json:"id"`
@@ -297,6 +534,7 @@ type MusicBrainzRelease struct {
 	Status       string `json:"status"`
 	Date         string `json:"date"`
 	Country      string `json:"country"`
+	Score        int    `json:"score"` // Search relevance (0-100), only set in search results
 	ArtistCredit []struct {
 		Artist struct {
 			ID   string `json:"id"`
@@ -333,5 +571,7 @@ type MusicBrainzRelease struct {
 }
 
 type ReleaseGroup struct {
-	ID string `json:"id"`
+	ID             string   `json:"id"`
+	PrimaryType    string   `json:"primary-type,omitempty"`
+	SecondaryTypes []string `json:"secondary-types,omitempty"` // e.g. Compilation, Live, Soundtrack
 }
\ No newline at end of file
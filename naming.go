@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// totalDiscs returns the number of discs of an album, from the album or its tracks
+func totalDiscs(album *Album) int {
+	total := album.TotalDiscs
+	for _, track := range album.Tracks {
+		if track.DiscNumber > total {
+			total = track.DiscNumber
+		}
+	}
+	if total < 1 {
+		total = 1
+	}
+	return total
+}
+
+// namingValues returns the placeholder values of a track. track may be nil for folder masks.
+func namingValues(album *Album, track *Track, artist string) map[string]string {
+	year := album.Year
+	if year == "" && len(album.ReleaseDate) >= 4 {
+		year = album.ReleaseDate[:4]
+	}
+	values := map[string]string{
+		"artist":       artist,
+		"album_artist": album.Artist,
+		"album":        album.Title,
+		"year":         year,
+		"type":         strings.ToLower(album.Type),
+		"total_discs":  strconv.Itoa(totalDiscs(album)),
+	}
+	if track != nil {
+		disc := track.DiscNumber
+		if disc < 1 {
+			disc = 1
+		}
+		values["title"] = track.Title
+		values["track_artist"] = track.Artist
+		values["track_number"] = fmt.Sprintf("%02d", track.TrackNumber)
+		values["disc_number"] = strconv.Itoa(disc)
+	}
+	return values
+}
+
+// ApplyNamingMask replaces the placeholders of mask and sanitizes every path component.
+// Unknown placeholders are left as they are, and "/" in a mask separates folders.
+func ApplyNamingMask(mask string, values map[string]string) string {
+	parts := strings.Split(filepath.ToSlash(mask), "/")
+	for i, part := range parts {
+		for name, value := range values {
+			// Values are sanitized first, so an artist like "AC/DC" can't create folders
+			if value != "" {
+				value = SanitizeFileName(value)
+			}
+			part = strings.ReplaceAll(part, "{"+name+"}", value)
+		}
+		// Drop brackets left empty by missing values, e.g. "Album ()" without a year
+		part = strings.TrimSpace(strings.NewReplacer("()", "", "[]", "").Replace(part))
+		parts[i] = SanitizeFileName(part)
+	}
+	return filepath.Join(parts...)
+}
+
+// masksEnabled reports whether the folder and file masks apply. They only do once
+// naming.enabled is set, so masks that older versions ignored don't move existing libraries.
+func masksEnabled(config *Config) bool {
+	return config.NamingMasks.Enabled
+}
+
+// albumFolder returns the folder of an album below the download location. The folder mask
+// matching the album type is used when masks are enabled, otherwise <artist>/<album>.
+func albumFolder(config *Config, album *Album, artist string) string {
+	if !masksEnabled(config) {
+		return filepath.Join(SanitizeFileName(artist), SanitizeFileName(album.Title))
+	}
+	mask := config.NamingMasks.AlbumFolderMask
+	switch strings.ToLower(album.Type) {
+	case "ep":
+		if config.NamingMasks.EpFolderMask != "" {
+			mask = config.NamingMasks.EpFolderMask
+		}
+	case "single":
+		if config.NamingMasks.SingleFolderMask != "" {
+			mask = config.NamingMasks.SingleFolderMask
+		}
+	}
+	if mask == "" {
+		return filepath.Join(SanitizeFileName(artist), SanitizeFileName(album.Title))
+	}
+	return ApplyNamingMask(mask, namingValues(album, nil, artist))
+}
+
+// trackFile returns the path of a track relative to its album folder. Tracks of multi-disc
+// albums go to a disc subfolder when disc_folder_mask is set; otherwise the default file
+// name gets a disc prefix ("2-01 - Title") so track numbers of different discs don't collide.
+func trackFile(config *Config, album *Album, track Track, artist string) string {
+	values := namingValues(album, &track, artist)
+	multiDisc := totalDiscs(album) > 1
+
+	var name string
+	switch {
+	case masksEnabled(config) && config.NamingMasks.FileMask != "":
+		name = ApplyNamingMask(config.NamingMasks.FileMask, values)
+	case track.TrackNumber == 0:
+		name = SanitizeFileName(track.Title)
+	case multiDisc && config.NamingMasks.DiscFolderMask == "":
+		name = fmt.Sprintf("%s-%02d - %s", values["disc_number"], track.TrackNumber, SanitizeFileName(track.Title))
+	default:
+		name = fmt.Sprintf("%02d - %s", track.TrackNumber, SanitizeFileName(track.Title))
+	}
+	name += ".flac"
+
+	if multiDisc && config.NamingMasks.DiscFolderMask != "" {
+		return filepath.Join(ApplyNamingMask(config.NamingMasks.DiscFolderMask, values), name)
+	}
+	return name
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"crypto/md5"
+	"crypto/rand"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
@@ -19,7 +20,7 @@ import (
 func (n *NavidromeClient) Authenticate() error {
 	// Ping the server to get the salt
 	pingURL := fmt.Sprintf("%s/rest/ping.view?v=1.16.1&c=dab-downloader&f=json", n.URL)
-	resp, err := http.Get(pingURL)
+	resp, err := n.HTTPClient.Get(pingURL)
 	if err != nil {
 		return err
 	}
@@ -44,7 +45,7 @@ func (n *NavidromeClient) Authenticate() error {
 	if pingResponse.SubsonicResponse.Status != "ok" {
 		// Try with auth
 		pingURL = fmt.Sprintf("%s/rest/ping.view?u=%s&p=%s&v=1.16.1&c=dab-downloader&f=json", n.URL, n.Username, n.Password)
-		resp, err = http.Get(pingURL)
+		resp, err = n.HTTPClient.Get(pingURL)
 		if err != nil {
 			return err
 		}
@@ -68,7 +69,7 @@ func (n *NavidromeClient) Authenticate() error {
 	n.Token = getSaltedPassword(n.Password, n.Salt)
 
 	n.Client = subsonic.Client{
-		Client:       http.DefaultClient,
+		Client:       n.HTTPClient,
 		BaseUrl:      n.URL,
 		User:         n.Username,
 		ClientName:   "dab-downloader",
@@ -196,7 +197,7 @@ func (n *NavidromeClient) CreatePlaylist(name string) error {
 	}
 
 	// Execute the request
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := n.HTTPClient.Do(req)
 	if err != nil {
 		return fmt.Errorf("failed to execute request: %w", err)
 	}
@@ -261,7 +262,7 @@ func (n *NavidromeClient) AddTracksToPlaylist(playlistID string, trackIDs []stri
 	}
 
 	// Execute the request
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := n.HTTPClient.Do(req)
 	if err != nil {
 		return fmt.Errorf("failed to execute request: %w", err)
 	}
@@ -330,4 +331,17 @@ func getSaltedPassword(password string, salt string) string {
 	hasher := md5.New()
 	hasher.Write([]byte(password + salt))
 	return hex.EncodeToString(hasher.Sum(nil))
+}
+
+// subsonicAuth returns the token authentication parameters of a Subsonic request, with a
+// fresh random salt so the password never appears in a URL
+func subsonicAuth(username, password string) url.Values {
+	random := make([]byte, 8)
+	rand.Read(random)
+	salt := hex.EncodeToString(random)
+	return url.Values{
+		"u": {username},
+		"t": {getSaltedPassword(password, salt)},
+		"s": {salt},
+	}
 }
\ No newline at end of file
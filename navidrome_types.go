@@ -1,25 +1,29 @@
 package main
 
 import (
+	"net/http"
+
 	subsonic "github.com/delucks/go-subsonic"
 )
 
 // NavidromeClient holds the navidrome client and other required fields
 
 type NavidromeClient struct {
-	URL      string
-	Username string
-	Password string
-	Client   subsonic.Client
-	Salt     string
-	Token    string
+	URL        string
+	Username   string
+	Password   string
+	Client     subsonic.Client
+	Salt       string
+	Token      string
+	HTTPClient *http.Client
 }
 
 // NewNavidromeClient creates a new navidrome client
 func NewNavidromeClient(url, username, password string) *NavidromeClient {
 	return &NavidromeClient{
-		URL:      url,
-		Username: username,
-		Password: password,
+		URL:        url,
+		Username:   username,
+		Password:   password,
+		HTTPClient: newHTTPClient(0),
 	}
 }
\ No newline at end of file
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"syscall"
+	"time"
+)
+
+const (
+	networkFSRetries    = 5
+	networkFSRetryDelay = 500 * time.Millisecond
+)
+
+// networkSafe reports whether NFS/SMB-safe writes are enabled
+func networkSafe(config *Config) bool {
+	return config != nil && config.NetworkSafeWrites
+}
+
+// withFSRetry runs a filesystem operation, retrying it a few times when network-safe
+// writes are enabled so that latency spikes on network mounts don't fail the download
+func withFSRetry(config *Config, fn func() error) error {
+	if !networkSafe(config) {
+		return fn()
+	}
+
+	var err error
+	for attempt := 0; attempt < networkFSRetries; attempt++ {
+		if err = fn(); err == nil {
+			return nil
+		}
+		time.Sleep(networkFSRetryDelay * time.Duration(attempt+1))
+	}
+	return err
+}
+
+// syncFile flushes a file to stable storage when network-safe writes are enabled
+func syncFile(f *os.File, config *Config) error {
+	if !networkSafe(config) {
+		return nil
+	}
+	if err := f.Sync(); err != nil {
+		return fmt.Errorf("failed to sync %s: %w", f.Name(), err)
+	}
+	return nil
+}
+
+// moveFile renames src to dst. Renames across filesystems are not possible and are not
+// reliable on SMB/NFS shares, so with network-safe writes (or when the rename fails with
+// EXDEV) the file is copied, synced and the source removed instead.
+func moveFile(src, dst string, config *Config) error {
+	if !networkSafe(config) {
+		err := os.Rename(src, dst)
+		if err == nil || !errors.Is(err, syscall.EXDEV) {
+			return err
+		}
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return fmt.Errorf("failed to copy %s: %w", src, err)
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return fmt.Errorf("failed to sync %s: %w", dst, err)
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+
+	in.Close()
+	return os.Remove(src)
+}
+
+const (
+	defaultCopyBuffer = 256 << 10 // Copy buffer for audio downloads
+	largeCopyBuffer   = 1 << 20   // Used for hi-res files over largeFileSize
+	largeFileSize     = 100 << 20
+)
+
+// copyBufferSize returns the buffer used to write a download of the given size (-1 if
+// unknown): CopyBufferKB when set, otherwise larger buffers for big hi-res files
+func copyBufferSize(config *Config, size int64) int {
+	if config != nil && config.CopyBufferKB > 0 {
+		return config.CopyBufferKB << 10
+	}
+	if size > largeFileSize {
+		return largeCopyBuffer
+	}
+	return defaultCopyBuffer
+}
+
+// writerOnly hides the ReadFrom method of *os.File, which would make io.CopyBuffer
+// ignore the buffer and fall back to 32 KB writes for network streams
+type writerOnly struct {
+	io.Writer
+}
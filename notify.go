@@ -0,0 +1,275 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	notifyTimeout       = 15 * time.Second
+	notifyMaxFailures   = 10   // Failures listed in a message, the rest are counted
+	discordMessageLimit = 2000 // Characters Discord accepts in a message
+)
+
+// Notification events a target can subscribe to
+const (
+	EventAlbumComplete = "album_complete"
+	EventBatchComplete = "batch_complete"
+	EventFailure       = "failure"
+)
+
+// NotificationTarget is a chat or webhook that is told when downloads finish
+type NotificationTarget struct {
+	Name     string            `json:"name,omitempty"`
+	Type     string            `json:"type"`                // "telegram", "discord", "webhook" or "gotify"
+	URL      string            `json:"url,omitempty"`       // Discord, webhook or Gotify URL; for Telegram a self-hosted Bot API server
+	BotToken string            `json:"bot_token,omitempty"` // Telegram bot token
+	ChatID   string            `json:"chat_id,omitempty"`   // Telegram chat, user or channel ID
+	Token    string            `json:"token,omitempty"`     // Gotify application token
+	Headers  map[string]string `json:"headers,omitempty"`   // Extra headers for generic webhooks
+	Events   []string          `json:"events,omitempty"`    // album_complete, batch_complete, failure; empty for all
+}
+
+// label names the target in messages
+func (t NotificationTarget) label() string {
+	if t.Name != "" {
+		return t.Name
+	}
+	return t.Type
+}
+
+// wants reports whether the target subscribed to event
+func (t NotificationTarget) wants(event string) bool {
+	if len(t.Events) == 0 {
+		return true
+	}
+	for _, e := range t.Events {
+		if strings.EqualFold(e, event) {
+			return true
+		}
+	}
+	return false
+}
+
+// Notification is a finished download as sent to notification targets
+type Notification struct {
+	Event       string    `json:"event"`
+	Title       string    `json:"title"`
+	Downloaded  int       `json:"downloaded"`
+	Skipped     int       `json:"skipped"`
+	Failed      int       `json:"failed"`
+	Unavailable int       `json:"unavailable"`
+	Failures    []string  `json:"failures,omitempty"`
+	Message     string    `json:"message"` // The text sent to chat services
+	Time        time.Time `json:"time"`
+}
+
+// Notifier delivers notifications to one service
+type Notifier interface {
+	Notify(ctx context.Context, n Notification) error
+}
+
+// newNotifier returns the notifier for a target
+func newNotifier(target NotificationTarget) (Notifier, error) {
+	switch strings.ToLower(target.Type) {
+	case "telegram":
+		if target.BotToken == "" || target.ChatID == "" {
+			return nil, fmt.Errorf("Telegram target needs bot_token and chat_id")
+		}
+		base := target.URL
+		if base == "" {
+			base = "https://api.telegram.org"
+		}
+		return &telegramNotifier{base: strings.TrimSuffix(base, "/"), token: target.BotToken, chatID: target.ChatID}, nil
+	case "discord", "webhook", "gotify":
+		if u, err := url.Parse(target.URL); err != nil || u.Host == "" {
+			return nil, fmt.Errorf("invalid %s URL '%s'", target.Type, maskNotificationURL(target.URL))
+		}
+		switch strings.ToLower(target.Type) {
+		case "discord":
+			return &discordNotifier{url: target.URL}, nil
+		case "gotify":
+			if target.Token == "" {
+				return nil, fmt.Errorf("Gotify target needs token")
+			}
+			return &gotifyNotifier{url: strings.TrimSuffix(target.URL, "/") + "/message", token: target.Token}, nil
+		}
+		return &webhookNotifier{url: target.URL, headers: target.Headers}, nil
+	default:
+		return nil, fmt.Errorf("unknown notification type '%s' (use telegram, discord, webhook or gotify)", target.Type)
+	}
+}
+
+// notifyTargets sends a finished download to the targets subscribed to its event, or to
+// failure when something failed. Failures to notify only produce warnings.
+func notifyTargets(config *Config, event, title string, stats *DownloadStats) {
+	if config == nil || len(config.Notifications) == 0 || stats == nil {
+		return
+	}
+	failed := stats.FailedCount > 0
+	n := Notification{
+		Event:       event,
+		Title:       title,
+		Downloaded:  stats.SuccessCount,
+		Skipped:     stats.SkippedCount,
+		Failed:      stats.FailedCount,
+		Unavailable: stats.UnavailableCount,
+		Failures:    stats.FailedItems,
+		Time:        time.Now(),
+	}
+	if failed {
+		n.Event = EventFailure
+	}
+	n.Message = formatNotification(n)
+
+	for _, target := range config.Notifications {
+		if !target.wants(event) && !(failed && target.wants(EventFailure)) {
+			continue
+		}
+		sendNotification(target, n)
+	}
+}
+
+// sendNotification delivers n to a single target
+func sendNotification(target NotificationTarget, n Notification) error {
+	notifier, err := newNotifier(target)
+	if err != nil {
+		colorWarning.Printf("⚠️ Skipping notification target %s: %v\n", target.label(), err)
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+	if err := notifier.Notify(ctx, n); err != nil {
+		colorWarning.Printf("⚠️ Failed to notify %s: %v\n", target.label(), err)
+		return err
+	}
+	return nil
+}
+
+// formatNotification is the text of a notification for chat services
+func formatNotification(n Notification) string {
+	icon := "✅"
+	if n.Failed > 0 {
+		icon = "❌"
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s dab-downloader: %s\n", icon, n.Title)
+	fmt.Fprintf(&b, "%d downloaded, %d skipped, %d failed", n.Downloaded, n.Skipped, n.Failed)
+	if n.Unavailable > 0 {
+		fmt.Fprintf(&b, ", %d unavailable", n.Unavailable)
+	}
+	b.WriteString("\n")
+	for i, failure := range n.Failures {
+		if i == notifyMaxFailures {
+			fmt.Fprintf(&b, "… and %d more\n", len(n.Failures)-notifyMaxFailures)
+			break
+		}
+		fmt.Fprintf(&b, "• %s\n", failure)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// maskNotificationURL hides the path and query of a notification URL, which for Discord
+// and most webhooks are the secret, e.g. "https://discord.com/***"
+func maskNotificationURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return "***"
+	}
+	masked := u.Scheme + "://" + u.Host
+	if (u.Path != "" && u.Path != "/") || u.RawQuery != "" {
+		masked += "/***"
+	}
+	return masked
+}
+
+// postJSON sends body as JSON and fails on non-2xx answers
+func postJSON(ctx context.Context, target string, headers map[string]string, body interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "dab-downloader/"+toolVersion)
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := newHTTPClient(notifyTimeout).Do(req)
+	if err != nil {
+		if urlErr, ok := err.(*url.Error); ok {
+			urlErr.URL = maskNotificationURL(urlErr.URL)
+		}
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
+
+type telegramNotifier struct {
+	base   string
+	token  string
+	chatID string
+}
+
+func (t *telegramNotifier) Notify(ctx context.Context, n Notification) error {
+	return postJSON(ctx, fmt.Sprintf("%s/bot%s/sendMessage", t.base, t.token), nil, map[string]interface{}{
+		"chat_id":                  t.chatID,
+		"text":                     n.Message,
+		"disable_web_page_preview": true,
+	})
+}
+
+type discordNotifier struct {
+	url string
+}
+
+func (d *discordNotifier) Notify(ctx context.Context, n Notification) error {
+	content := n.Message
+	if runes := []rune(content); len(runes) > discordMessageLimit {
+		content = string(runes[:discordMessageLimit-1]) + "…"
+	}
+	return postJSON(ctx, d.url, nil, map[string]interface{}{"username": "dab-downloader", "content": content})
+}
+
+// webhookNotifier posts the Notification itself as JSON
+type webhookNotifier struct {
+	url     string
+	headers map[string]string
+}
+
+func (w *webhookNotifier) Notify(ctx context.Context, n Notification) error {
+	return postJSON(ctx, w.url, w.headers, n)
+}
+
+type gotifyNotifier struct {
+	url   string
+	token string
+}
+
+func (g *gotifyNotifier) Notify(ctx context.Context, n Notification) error {
+	title, message, _ := strings.Cut(n.Message, "\n")
+	priority := 5
+	if n.Failed > 0 {
+		priority = 8
+	}
+	return postJSON(ctx, g.url, map[string]string{"X-Gotify-Key": g.token}, map[string]interface{}{
+		"title":    title,
+		"message":  message,
+		"priority": priority,
+	})
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	defaultOutageWaitMinutes = 30
+	outagePollInitial        = 5 * time.Second
+	outagePollMax            = 2 * time.Minute
+	maxOutageResumes         = 3 // Times a single request is retried after the endpoint comes back
+)
+
+// SetOutageWait configures how long requests wait for an unreachable DAB endpoint to
+// come back before failing. Zero disables waiting.
+func (api *DabAPI) SetOutageWait(wait time.Duration) {
+	api.outageWait = wait
+}
+
+// isOutageStatus reports whether a status code from the endpoint means it is down
+func isOutageStatus(code int) bool {
+	return code == http.StatusBadGateway || code == http.StatusServiceUnavailable || code == http.StatusGatewayTimeout
+}
+
+// waitForEndpoint pauses until the DAB endpoint is reachable again. Only one goroutine
+// polls the endpoint, concurrent downloads wait for its result instead of failing.
+func (api *DabAPI) waitForEndpoint(ctx context.Context) error {
+	api.outageMu.Lock()
+	if done := api.outageDone; done != nil {
+		api.outageMu.Unlock()
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		api.outageMu.Lock()
+		err := api.outageErr
+		api.outageMu.Unlock()
+		return err
+	}
+	done := make(chan struct{})
+	api.outageDone = done
+	api.outageMu.Unlock()
+
+	err := api.pollEndpoint(ctx)
+
+	api.outageMu.Lock()
+	api.outageErr = err
+	api.outageDone = nil
+	api.outageMu.Unlock()
+	close(done)
+	return err
+}
+
+// pollEndpoint polls the endpoint with backoff until it responds or the outage wait expires
+func (api *DabAPI) pollEndpoint(ctx context.Context) error {
+	colorWarning.Printf("⏸️ DAB endpoint %s is unreachable, pausing downloads for up to %s...\n", api.endpoint, api.outageWait)
+	Audit(ctx, "outage", api.endpoint, "paused")
+
+	start := time.Now()
+	deadline := start.Add(api.outageWait)
+	delay := outagePollInitial
+	for {
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		if api.endpointReachable(ctx) {
+			downtime := time.Since(start).Round(time.Second)
+			colorSuccess.Printf("▶️ DAB endpoint is back after %s, resuming downloads\n", downtime)
+			Audit(ctx, "outage", api.endpoint, fmt.Sprintf("resumed after %s", downtime))
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			Audit(ctx, "outage", api.endpoint, "gave up")
+			return fmt.Errorf("DAB endpoint unreachable for %s", api.outageWait)
+		}
+		delay *= 2
+		if delay > outagePollMax {
+			delay = outagePollMax
+		}
+	}
+}
+
+// endpointReachable reports whether the endpoint answers without a gateway or availability error
+func (api *DabAPI) endpointReachable(ctx context.Context) bool {
+	req, err := http.NewRequestWithContext(ctx, "GET", api.endpoint, nil)
+	if err != nil {
+		return false
+	}
+	req.Header.Set("User-Agent", userAgent)
+	api.setEndpointHeaders(req)
+
+	resp, err := api.client.Do(req)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return !isOutageStatus(resp.StatusCode)
+}
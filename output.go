@@ -0,0 +1,82 @@
+package main
+
+import (
+	"io"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// plainReplacer maps decorative characters to plain ASCII equivalents
+var plainReplacer = strings.NewReplacer(
+	"•", "-",
+	"×", "x",
+	"→", "->",
+	"…", "...",
+)
+
+// enablePlainOutput switches all colored output to plain, screen-reader friendly text
+func enablePlainOutput() {
+	color.NoColor = true
+	color.Output = &plainWriter{w: os.Stdout}
+	color.Error = &plainWriter{w: os.Stderr}
+}
+
+// progressBarsEnabled reports whether interactive progress bars can be shown
+func progressBarsEnabled() bool {
+	return isTTY() && !plainOutput && !jsonOutput
+}
+
+// plainWriter strips emojis and box-drawing characters from everything written through it
+type plainWriter struct {
+	w io.Writer
+}
+
+func (p *plainWriter) Write(b []byte) (int, error) {
+	if _, err := io.WriteString(p.w, stripDecorations(string(b))); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// stripDecorations removes emojis and box-drawing characters from s, line by line
+func stripDecorations(s string) string {
+	s = plainReplacer.Replace(s)
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		var b strings.Builder
+		stripped := false
+		for _, r := range line {
+			if isDecorativeRune(r) {
+				stripped = true
+				continue
+			}
+			b.WriteRune(r)
+		}
+		if stripped {
+			// Drop the space that separated a leading emoji from the message
+			lines[i] = strings.TrimLeft(b.String(), " ")
+		} else {
+			lines[i] = line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// isDecorativeRune reports whether r is an emoji, pictograph or box-drawing character
+func isDecorativeRune(r rune) bool {
+	switch {
+	case r >= 0x1F000 && r <= 0x1FAFF: // Emoji and pictographs
+		return true
+	case r >= 0x2600 && r <= 0x27BF: // Miscellaneous symbols and dingbats
+		return true
+	case r >= 0x2B00 && r <= 0x2BFF: // Stars, arrows and other symbols
+		return true
+	case r >= 0x2500 && r <= 0x257F: // Box drawing
+		return true
+	case r == 0xFE0F || r == 0x200D: // Emoji variation selector and zero-width joiner
+		return true
+	}
+	return false
+}
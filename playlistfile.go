@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// PlaylistEntry is a downloaded track written to an M3U8 playlist
+type PlaylistEntry struct {
+	Path        string
+	Title       string
+	Artist      string
+	DurationSec int
+}
+
+// newPlaylistEntry returns the playlist entry of a track downloaded to path
+func newPlaylistEntry(track Track, path string) PlaylistEntry {
+	return PlaylistEntry{Path: path, Title: track.Title, Artist: track.Artist, DurationSec: track.Duration}
+}
+
+// WritePlaylistFile writes entries, in order, to <DownloadLocation>/<name>.m3u8 when
+// WriteM3U is enabled. Paths are relative to the playlist unless M3UAbsolutePaths is set.
+// It returns the path of the playlist, or "" when nothing was written.
+func WritePlaylistFile(config *Config, name string, entries []PlaylistEntry) (string, error) {
+	if !config.WriteM3U || len(entries) == 0 {
+		return "", nil
+	}
+	if name == "" {
+		name = "playlist"
+	}
+	playlistPath := filepath.Join(config.DownloadLocation, SanitizeFileName(name)+".m3u8")
+
+	var b strings.Builder
+	b.WriteString("#EXTM3U\n")
+	b.WriteString(fmt.Sprintf("#PLAYLIST:%s\n", name))
+	playlistDir, _ := filepath.Abs(filepath.Dir(playlistPath))
+	for _, entry := range entries {
+		path, err := filepath.Abs(entry.Path)
+		if err != nil {
+			path = entry.Path
+		}
+		if !config.M3UAbsolutePaths {
+			// Forward slashes keep relative playlists usable across platforms
+			if rel, err := filepath.Rel(playlistDir, path); err == nil {
+				path = filepath.ToSlash(rel)
+			}
+		}
+		duration := entry.DurationSec
+		if duration <= 0 {
+			duration = -1
+		}
+		b.WriteString(fmt.Sprintf("#EXTINF:%d,%s - %s\n%s\n", duration, entry.Artist, entry.Title, path))
+	}
+
+	if err := os.MkdirAll(filepath.Dir(playlistPath), 0755); err != nil {
+		return "", fmt.Errorf("failed to create playlist directory: %w", err)
+	}
+	if err := os.WriteFile(playlistPath, []byte(b.String()), 0644); err != nil {
+		return "", fmt.Errorf("failed to write playlist: %w", err)
+	}
+	return playlistPath, nil
+}
+
+// writePlaylistFile writes a playlist file and reports the result
+func writePlaylistFile(config *Config, name string, entries []PlaylistEntry) {
+	path, err := WritePlaylistFile(config, name, entries)
+	if err != nil {
+		colorError.Printf("❌ %v\n", err)
+	} else if path != "" {
+		colorSuccess.Printf("📝 Playlist with %d tracks written to %s\n", len(entries), path)
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+)
+
+// playlistPreview is what an --auto playlist import is about to download
+type playlistPreview struct {
+	matched  int   // Tracks found on DAB
+	existing int   // Matched tracks already downloaded, skipped
+	size     int64 // Estimated size of the remaining tracks
+}
+
+// previewPlaylistDownload works out which matched tracks are already on disk or in the
+// download history and estimates the size of the others. Albums are taken from the
+// prefetch, so it should be started first.
+func (api *DabAPI) previewPlaylistDownload(ctx context.Context, tracks []Track, config *Config) playlistPreview {
+	preview := playlistPreview{matched: len(tracks)}
+	var pending []Track
+	for _, track := range tracks {
+		if api.alreadyDownloaded(ctx, track, config) {
+			preview.existing++
+			continue
+		}
+		pending = append(pending, track)
+	}
+	_, _, preview.size = estimateDownload([]Album{{Tracks: pending}}, config)
+	return preview
+}
+
+// alreadyDownloaded reports whether DownloadSingleTrack would skip the track
+func (api *DabAPI) alreadyDownloaded(ctx context.Context, track Track, config *Config) bool {
+	if previouslyDownloaded(ctx, track, track.AlbumID, config) != nil {
+		return true
+	}
+	prefetched := api.prefetchedAlbum(ctx, track.AlbumID)
+	if prefetched == nil {
+		return false
+	}
+	album := prefetched.album
+	for _, albumTrack := range album.Tracks {
+		if idToString(albumTrack.ID) != idToString(track.ID) {
+			continue
+		}
+		trackPath := filepath.Join(api.outputLocation, albumFolder(config, album, albumTrack.Artist), trackFile(config, album, albumTrack, albumTrack.Artist))
+		return findLocalCopy(ctx, config, albumTrack, track.AlbumID, trackPath, config.Format).existing != ""
+	}
+	return false
+}
+
+// confirmPlaylistDownload prints the preview and asks whether to go ahead
+func confirmPlaylistDownload(preview playlistPreview, requested int) bool {
+	colorInfo.Printf("\n📋 %d of %d tracks matched, %d already downloaded\n", preview.matched, requested, preview.existing)
+	toDownload := preview.matched - preview.existing
+	if toDownload == 0 {
+		return true
+	}
+	colorInfo.Printf("📦 Estimated: %d tracks to download, ~%s\n", toDownload, FormatBytes(preview.size))
+	return Confirm("Proceed with download? (y/N)")
+}
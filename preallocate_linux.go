@@ -0,0 +1,18 @@
+//go:build linux
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// fallocKeepSize reserves blocks without changing the file size (FALLOC_FL_KEEP_SIZE), so
+// size checks and resuming from the .part file still see only the bytes written
+const fallocKeepSize = 0x01
+
+// preallocateFile reserves size bytes on disk for f, reducing fragmentation of large files.
+// Filesystems that don't support it are ignored.
+func preallocateFile(f *os.File, size int64) {
+	syscall.Fallocate(int(f.Fd()), fallocKeepSize, 0, size)
+}
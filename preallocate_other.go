@@ -0,0 +1,8 @@
+//go:build !linux
+
+package main
+
+import "os"
+
+// preallocateFile is only supported on Linux
+func preallocateFile(f *os.File, size int64) {}
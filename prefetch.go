@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+
+	"golang.org/x/sync/semaphore"
+)
+
+// albumPrefetch is an album and its cover loaded ahead of the track downloads that need them
+type albumPrefetch struct {
+	done     chan struct{} // Closed once album and cover are loaded
+	album    *Album
+	cover    []byte
+	albumErr error
+	coverErr error
+	uses     int // Downloads that haven't taken it yet, it is dropped after the last
+}
+
+// PrefetchAlbums loads the given albums and their covers in the background, at most
+// parallelism at a time and in the order given, so playlist downloads don't wait for
+// metadata one track at a time. Albums already prefetched are skipped. Every ID given is
+// one use, taken by takePrefetchedAlbum or given back by releasePrefetched.
+func (api *DabAPI) PrefetchAlbums(ctx context.Context, albumIDs []string, parallelism int) {
+	if parallelism < 1 {
+		parallelism = 1
+	}
+	sem := semaphore.NewWeighted(int64(parallelism))
+
+	api.prefetchMu.Lock()
+	if api.prefetched == nil {
+		api.prefetched = make(map[string]*albumPrefetch)
+	}
+	pending := make(map[string]*albumPrefetch)
+	var order []string
+	for _, id := range albumIDs {
+		if id == "" {
+			continue
+		}
+		if entry := api.prefetched[id]; entry != nil {
+			entry.uses++
+			continue
+		}
+		entry := &albumPrefetch{done: make(chan struct{}), uses: 1}
+		api.prefetched[id] = entry
+		pending[id] = entry
+		order = append(order, id)
+	}
+	api.prefetchMu.Unlock()
+
+	go func() {
+		defer handlePanic()
+		for _, id := range order {
+			entry := pending[id]
+			if err := sem.Acquire(ctx, 1); err != nil {
+				entry.albumErr = err
+				close(entry.done)
+				continue
+			}
+			go func(id string, entry *albumPrefetch) {
+				defer handlePanic()
+				defer sem.Release(1)
+				defer close(entry.done)
+				entry.album, entry.albumErr = api.GetAlbum(ctx, id)
+				if entry.albumErr == nil && entry.album.Cover != "" {
+					entry.cover, entry.coverErr = api.DownloadCover(ctx, entry.album.Cover)
+				}
+			}(id, entry)
+		}
+	}()
+}
+
+// prefetchedAlbum returns the prefetched album, waiting for it if it is still loading.
+// It returns nil when the album wasn't prefetched or loading it failed.
+func (api *DabAPI) prefetchedAlbum(ctx context.Context, albumID string) *albumPrefetch {
+	api.prefetchMu.Lock()
+	entry := api.prefetched[albumID]
+	api.prefetchMu.Unlock()
+	return waitPrefetch(ctx, entry)
+}
+
+// takePrefetchedAlbum is prefetchedAlbum for a download, which uses the entry up. Long
+// running processes would otherwise keep every album they ever prefetched.
+func (api *DabAPI) takePrefetchedAlbum(ctx context.Context, albumID string) *albumPrefetch {
+	api.prefetchMu.Lock()
+	entry := api.prefetched[albumID]
+	api.dropPrefetchUse(albumID)
+	api.prefetchMu.Unlock()
+	return waitPrefetch(ctx, entry)
+}
+
+// releasePrefetched gives back the uses of albums that won't be downloaded after all
+func (api *DabAPI) releasePrefetched(albumIDs []string) {
+	api.prefetchMu.Lock()
+	defer api.prefetchMu.Unlock()
+	for _, id := range albumIDs {
+		api.dropPrefetchUse(id)
+	}
+}
+
+// dropPrefetchUse counts down the uses of an album, the caller holds prefetchMu
+func (api *DabAPI) dropPrefetchUse(albumID string) {
+	entry := api.prefetched[albumID]
+	if entry == nil {
+		return
+	}
+	entry.uses--
+	if entry.uses <= 0 {
+		delete(api.prefetched, albumID)
+	}
+}
+
+// waitPrefetch waits for entry to load, nil when there is none or loading failed
+func waitPrefetch(ctx context.Context, entry *albumPrefetch) *albumPrefetch {
+	if entry == nil {
+		return nil
+	}
+	select {
+	case <-entry.done:
+	case <-ctx.Done():
+		return nil
+	}
+	if entry.albumErr != nil {
+		return nil
+	}
+	return entry
+}
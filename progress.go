@@ -0,0 +1,83 @@
+package main
+
+import (
+	"io"
+	"time"
+)
+
+const (
+	lineProgressStep     = 25               // Print a line every 25% when the size is known
+	lineProgressInterval = 10 * time.Second // Print a line this often when the size is unknown
+)
+
+// lineProgressReader reports download progress as periodic status lines.
+// It replaces progress bars when output is not a terminal (files, pipes, systemd journal)
+// or when --plain or --json is used, so logs stay readable.
+type lineProgressReader struct {
+	reader    io.Reader
+	label     string
+	total     int64
+	read      int64
+	nextStep  int64
+	lastPrint time.Time
+}
+
+// newLineProgressReader wraps r so that progress for label is printed as plain lines.
+// offset is what a resumed download already has of the total size.
+func newLineProgressReader(r io.Reader, label string, offset, total int64) *lineProgressReader {
+	l := &lineProgressReader{
+		reader:    r,
+		label:     label,
+		total:     total,
+		read:      offset,
+		nextStep:  lineProgressStep,
+		lastPrint: time.Now(),
+	}
+	if total > 0 {
+		for l.nextStep <= offset*100/total {
+			l.nextStep += lineProgressStep
+		}
+	}
+	return l
+}
+
+func (l *lineProgressReader) Read(p []byte) (int, error) {
+	n, err := l.reader.Read(p)
+
+	l.read += int64(n)
+
+	if l.total > 0 {
+		percent := l.read * 100 / l.total
+		if percent >= l.nextStep && percent < 100 {
+			l.emit(percent)
+			colorInfo.Printf("   %s: %d%% (%s / %s)\n", l.label, percent, FormatBytes(l.read), FormatBytes(l.total))
+			for l.nextStep <= percent {
+				l.nextStep += lineProgressStep
+			}
+		}
+	} else if time.Since(l.lastPrint) >= lineProgressInterval {
+		l.emit(-1)
+		colorInfo.Printf("   %s: %s downloaded\n", l.label, FormatBytes(l.read))
+		l.lastPrint = time.Now()
+	}
+
+	return n, err
+}
+
+// emit sends the progress as a JSON event, percent is -1 when the size is unknown
+func (l *lineProgressReader) emit(percent int64) {
+	fields := map[string]interface{}{"track": l.label, "bytes": l.read}
+	if percent >= 0 {
+		fields["total"] = l.total
+		fields["percent"] = percent
+	}
+	emitEvent("progress", fields)
+}
+
+// Close closes the wrapped reader if it implements io.Closer
+func (l *lineProgressReader) Close() error {
+	if closer, ok := l.reader.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
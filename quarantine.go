@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	quarantineDirName     = ".quarantine"
+	defaultQuarantineDays = 7
+)
+
+// quarantineDir returns the directory that removed files are moved into
+func quarantineDir(config *Config) string {
+	if config.QuarantineDir != "" {
+		return config.QuarantineDir
+	}
+	return filepath.Join(config.DownloadLocation, quarantineDirName)
+}
+
+// RemoveFile moves a file into the quarantine folder instead of deleting it.
+// When quarantine is disabled (QuarantineDays <= 0) or no config is available,
+// the file is deleted immediately. The audit log names the job or run of ctx.
+func RemoveFile(ctx context.Context, path string, config *Config) error {
+	if config == nil || config.QuarantineDays <= 0 {
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		Audit(ctx, "delete", path, "")
+		return nil
+	}
+
+	dir := quarantineDir(config)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create quarantine directory: %w", err)
+	}
+
+	// Prefix with a timestamp so files with the same name don't overwrite each other
+	dest := filepath.Join(dir, fmt.Sprintf("%s_%s", time.Now().Format("20060102-150405.000"), filepath.Base(path)))
+	if err := moveFile(path, dest, config); err != nil {
+		return fmt.Errorf("failed to move %s to quarantine: %w", path, err)
+	}
+	// Moving keeps the original modification time, reset it so expiry counts from now
+	now := time.Now()
+	os.Chtimes(dest, now, now)
+	Audit(ctx, "quarantine", path, dest)
+	return nil
+}
+
+// PurgeQuarantine permanently deletes quarantined files older than the configured expiry.
+// Returns the number of files that were deleted.
+func PurgeQuarantine(config *Config) (int, error) {
+	if config == nil || config.QuarantineDays <= 0 {
+		return 0, nil
+	}
+
+	dir := quarantineDir(config)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil // Nothing has been quarantined yet
+		}
+		return 0, fmt.Errorf("failed to read quarantine directory: %w", err)
+	}
+
+	cutoff := time.Now().AddDate(0, 0, -config.QuarantineDays)
+	purged := 0
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || info.ModTime().After(cutoff) {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(dir, entry.Name())); err == nil {
+			Audit(context.Background(), "purge", filepath.Join(dir, entry.Name()), "quarantine expired")
+			purged++
+		}
+	}
+	return purged, nil
+}
@@ -0,0 +1,890 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/cheggaaa/pb/v3"
+	"golang.org/x/sync/semaphore"
+)
+
+const (
+	QueuePending   = "pending"
+	QueueRunning   = "running"
+	QueueDone      = "done"
+	QueueFailed    = "failed"
+	QueueCancelled = "cancelled"
+
+	queuePollInterval = 5 * time.Second // How often a paused queue checks for 'queue resume'
+	queueLease        = 2 * time.Minute // How long a running item stays claimed without its run renewing it
+	queueLeaseRenew   = 30 * time.Second
+)
+
+// QueueItem is an album or track waiting in the download queue
+type QueueItem struct {
+	ID            int           `json:"id"`
+	Type          string        `json:"type"` // "album" or "track"
+	Value         string        `json:"value"`
+	Format        string        `json:"format,omitempty"` // Empty uses the format of 'queue run'
+	Bitrate       string        `json:"bitrate,omitempty"`
+	Status        string        `json:"status"`
+	Error         string        `json:"error,omitempty"`
+	CorrelationID string        `json:"correlation_id,omitempty"` // Shown in logs, events and reports of the download
+	Options       *QueueOptions `json:"options,omitempty"`
+	SharedWith    int           `json:"shared_with,omitempty"` // Earlier item downloading the same album or track, this one finishes with it
+	LeaseUntil    *time.Time    `json:"lease_until,omitempty"` // A running item whose lease ran out lost its run
+	AddedAt       time.Time     `json:"added_at"`
+	UpdatedAt     time.Time     `json:"updated_at"`
+}
+
+// active reports whether an item is waiting or downloading
+func (i *QueueItem) active() bool {
+	return i.Status == QueuePending || i.Status == QueueRunning
+}
+
+// interrupted reports whether an item was left running by a run that stopped renewing its
+// lease, because it was interrupted or crashed
+func (i *QueueItem) interrupted(now time.Time) bool {
+	return i.Status == QueueRunning && (i.LeaseUntil == nil || now.After(*i.LeaseUntil))
+}
+
+// sameDownload reports whether two items download the same album or track the same way
+func (i *QueueItem) sameDownload(other *QueueItem) bool {
+	return i.Type == other.Type && i.Value == other.Value && i.Format == other.Format && i.Options.value() == other.Options.value()
+}
+
+// QueueOptions are the command-line options an item is downloaded with besides format and
+// bitrate. They travel with the item, so items handed to the daemon or downloaded by a later
+// 'queue run' are downloaded the way the queuing command asked.
+type QueueOptions struct {
+	Location         string `json:"location,omitempty"` // --download-location, absolute
+	ReplayGain       bool   `json:"replaygain,omitempty"`
+	WriteNFO         bool   `json:"nfo,omitempty"`
+	KeepOriginal     bool   `json:"keep_original,omitempty"`
+	FindAlternatives bool   `json:"find_alternatives,omitempty"`
+	IgnoreHistory    bool   `json:"ignore_history,omitempty"`
+}
+
+// currentQueueOptions returns the options set on the command line, nil when none are
+func currentQueueOptions() *QueueOptions {
+	options := QueueOptions{
+		ReplayGain:       replayGain,
+		WriteNFO:         writeNFOFiles,
+		KeepOriginal:     keepFLAC,
+		FindAlternatives: findAlternatives,
+		IgnoreHistory:    ignoreHistory,
+	}
+	if downloadLocation != "" {
+		// The daemon may run in another working directory
+		options.Location = downloadLocation
+		if abs, err := filepath.Abs(downloadLocation); err == nil {
+			options.Location = abs
+		}
+	}
+	if options == (QueueOptions{}) {
+		return nil
+	}
+	return &options
+}
+
+// value returns the options, empty ones for nil
+func (o *QueueOptions) value() QueueOptions {
+	if o == nil {
+		return QueueOptions{}
+	}
+	return *o
+}
+
+// apply sets the options on the config an item is downloaded with
+func (o *QueueOptions) apply(config *Config) {
+	if o == nil {
+		return
+	}
+	if o.Location != "" {
+		config.DownloadLocation = o.Location
+	}
+	config.ReplayGain = config.ReplayGain || o.ReplayGain
+	config.WriteNFO = config.WriteNFO || o.WriteNFO
+	config.KeepOriginal = config.KeepOriginal || o.KeepOriginal
+	config.FindAlternativeEditions = config.FindAlternativeEditions || o.FindAlternatives
+}
+
+type queueOptionsKey struct{}
+
+// withQueueOptions returns a context downloading with the options of a queue item
+func withQueueOptions(ctx context.Context, options *QueueOptions) context.Context {
+	return context.WithValue(ctx, queueOptionsKey{}, options)
+}
+
+// queueOptionsFrom returns the options of the queue item of ctx, empty ones outside the queue
+func queueOptionsFrom(ctx context.Context) QueueOptions {
+	if options, ok := ctx.Value(queueOptionsKey{}).(*QueueOptions); ok && options != nil {
+		return *options
+	}
+	return QueueOptions{}
+}
+
+// downloadRoot is the folder downloads of ctx go to, the location of its queue item if set
+func (api *DabAPI) downloadRoot(ctx context.Context) string {
+	if location := queueOptionsFrom(ctx).Location; location != "" {
+		return location
+	}
+	return api.outputLocation
+}
+
+// QueueState is the content of the queue file
+type QueueState struct {
+	Paused bool         `json:"paused"`
+	NextID int          `json:"next_id"`
+	Items  []*QueueItem `json:"items"`
+}
+
+// DownloadQueue persists queued downloads to a JSON file next to the config. The file is
+// re-read for every change, so 'queue add' and 'queue pause' work while 'queue run' is busy.
+// Changes hold a lock on <file>.lock, so processes changing it at once don't lose items.
+type DownloadQueue struct {
+	path string
+	mu   sync.Mutex
+}
+
+// downloadQueue is the queue used by the queue commands
+var downloadQueue = &DownloadQueue{path: filepath.Join("config", "queue.json")}
+
+// State returns the queue as stored on disk
+func (q *DownloadQueue) State() (*QueueState, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.load()
+}
+
+// Add appends items to the queue and returns the items that were added. Items without a
+// correlation ID get one shared by the call, which makes them one job. An item already
+// waiting for another job is downloaded once: the new item is added with SharedWith set and
+// finishes with the earlier one, so both jobs report it. Items already waiting for the same
+// job are skipped.
+func (q *DownloadQueue) Add(items ...*QueueItem) ([]*QueueItem, error) {
+	var added []*QueueItem
+	jobID := newCorrelationID()
+	err := q.update(func(state *QueueState) error {
+		for _, item := range items {
+			if item.CorrelationID == "" {
+				item.CorrelationID = jobID
+			}
+			duplicate := false
+			item.SharedWith = 0
+			for _, existing := range state.Items {
+				if !existing.active() || !existing.sameDownload(item) {
+					continue
+				}
+				if existing.CorrelationID == item.CorrelationID {
+					duplicate = true
+					break
+				}
+				if existing.SharedWith == 0 {
+					item.SharedWith = existing.ID
+				}
+			}
+			if duplicate {
+				continue
+			}
+			state.NextID++
+			item.ID = state.NextID
+			item.Status = QueuePending
+			item.AddedAt = time.Now()
+			item.UpdatedAt = item.AddedAt
+			state.Items = append(state.Items, item)
+			added = append(added, item)
+		}
+		return nil
+	})
+	return added, err
+}
+
+// sharedQueueItems counts the items that share the download of an item queued earlier
+func sharedQueueItems(items []*QueueItem) int {
+	count := 0
+	for _, item := range items {
+		if item.SharedWith != 0 {
+			count++
+		}
+	}
+	return count
+}
+
+// SetPaused pauses or resumes the queue. A running 'queue run' finishes the items it
+// already started and waits until the queue is resumed.
+func (q *DownloadQueue) SetPaused(paused bool) error {
+	return q.update(func(state *QueueState) error {
+		state.Paused = paused
+		return nil
+	})
+}
+
+// Clear removes items from the queue, only finished ones when finishedOnly is set.
+// Items that are downloading are never removed. It returns the number of items removed.
+func (q *DownloadQueue) Clear(finishedOnly bool) (int, error) {
+	removed := 0
+	err := q.update(func(state *QueueState) error {
+		kept := state.Items[:0]
+		for _, item := range state.Items {
+			if item.Status == QueueRunning || (finishedOnly && item.Status == QueuePending) {
+				kept = append(kept, item)
+				continue
+			}
+			removed++
+		}
+		state.Items = kept
+		return nil
+	})
+	return removed, err
+}
+
+// Cancel stops an item from being downloaded. Pending items are marked cancelled right
+// away; for a running item running is set and the caller has to stop the download.
+func (q *DownloadQueue) Cancel(id int) (item *QueueItem, running bool, err error) {
+	err = q.update(func(state *QueueState) error {
+		for _, candidate := range state.Items {
+			if candidate.ID != id {
+				continue
+			}
+			item = candidate
+			switch candidate.Status {
+			case QueuePending:
+				candidate.Status = QueueCancelled
+				candidate.UpdatedAt = time.Now()
+				state.releaseShared(id)
+			case QueueRunning:
+				running = true
+			default:
+				return fmt.Errorf("item %d is already %s", id, candidate.Status)
+			}
+			return nil
+		}
+		return fmt.Errorf("no queue item with ID %d", id)
+	})
+	return item, running, err
+}
+
+// requeueInterrupted puts items left running by an interrupted 'queue run' back in line.
+// Items another run is still downloading keep their lease and are left alone.
+func (q *DownloadQueue) requeueInterrupted() (int, error) {
+	count := 0
+	err := q.update(func(state *QueueState) error {
+		now := time.Now()
+		for _, item := range state.Items {
+			if item.interrupted(now) {
+				item.Status = QueuePending
+				item.LeaseUntil = nil
+				count++
+			}
+		}
+		return nil
+	})
+	return count, err
+}
+
+// claim marks the first pending or interrupted item as running, leased to this run, and
+// returns it. It returns nil when nothing is pending, and paused is set when the queue is
+// paused.
+func (q *DownloadQueue) claim() (item *QueueItem, paused bool, err error) {
+	err = q.update(func(state *QueueState) error {
+		if state.Paused {
+			paused = true
+			return nil
+		}
+		now := time.Now()
+		for _, candidate := range state.Items {
+			if state.waiting(candidate) || candidate.interrupted(now) {
+				lease := now.Add(queueLease)
+				candidate.Status = QueueRunning
+				candidate.LeaseUntil = &lease
+				candidate.UpdatedAt = now
+				item = candidate
+				return nil
+			}
+		}
+		return nil
+	})
+	return item, paused, err
+}
+
+// renewLeases keeps the running items of a run claimed
+func (q *DownloadQueue) renewLeases(ids map[int]bool) error {
+	return q.update(func(state *QueueState) error {
+		lease := time.Now().Add(queueLease)
+		for _, item := range state.Items {
+			if ids[item.ID] && item.Status == QueueRunning {
+				item.LeaseUntil = &lease
+			}
+		}
+		return nil
+	})
+}
+
+// release ends the lease of an item left running by an interrupted run, so the next run
+// resumes it right away
+func (q *DownloadQueue) release(id int) error {
+	return q.update(func(state *QueueState) error {
+		for _, item := range state.Items {
+			if item.ID == id {
+				item.LeaseUntil = nil
+			}
+		}
+		return nil
+	})
+}
+
+// finish records the outcome of an item. cancelled marks it cancelled whatever the error.
+// The items sharing its download get the same outcome and are returned, unless it was
+// cancelled; then they download it themselves.
+func (q *DownloadQueue) finish(id int, downloadErr error, cancelled bool) (shared []*QueueItem, err error) {
+	err = q.update(func(state *QueueState) error {
+		var finished *QueueItem
+		for _, item := range state.Items {
+			if item.ID != id {
+				continue
+			}
+			item.Status = QueueDone
+			item.Error = ""
+			if cancelled {
+				item.Status = QueueCancelled
+			} else if downloadErr != nil {
+				item.Status = QueueFailed
+				item.Error = downloadErr.Error()
+			}
+			item.LeaseUntil = nil
+			item.UpdatedAt = time.Now()
+			finished = item
+		}
+		if finished == nil {
+			return nil
+		}
+		if cancelled {
+			state.releaseShared(id)
+			return nil
+		}
+		for _, item := range state.Items {
+			if item.SharedWith == id && item.Status == QueuePending {
+				item.Status, item.Error, item.UpdatedAt = finished.Status, finished.Error, finished.UpdatedAt
+				shared = append(shared, item)
+			}
+		}
+		return nil
+	})
+	return shared, err
+}
+
+// waiting reports whether an item is pending and downloads by itself, not with the item
+// it shares the download of
+func (s *QueueState) waiting(item *QueueItem) bool {
+	if item.Status != QueuePending {
+		return false
+	}
+	if item.SharedWith == 0 {
+		return true
+	}
+	for _, other := range s.Items {
+		if other.ID == item.SharedWith {
+			return !other.active()
+		}
+	}
+	return true
+}
+
+// releaseShared lets the items sharing the download of a cancelled item download it
+// without it: the first of them takes its place for the others
+func (s *QueueState) releaseShared(id int) {
+	next := 0
+	for _, item := range s.Items {
+		if item.SharedWith != id || item.Status != QueuePending {
+			continue
+		}
+		if next == 0 {
+			item.SharedWith = 0
+			next = item.ID
+			continue
+		}
+		item.SharedWith = next
+	}
+}
+
+// update loads the queue, applies fn and saves the result, holding the lock of the queue
+// file throughout
+func (q *DownloadQueue) update(fn func(state *QueueState) error) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	unlock, err := q.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	state, err := q.load()
+	if err != nil {
+		return err
+	}
+	if err := fn(state); err != nil {
+		return err
+	}
+	return q.save(state)
+}
+
+// lock waits for other processes to finish changing the queue and keeps them out until
+// the returned function is called
+func (q *DownloadQueue) lock() (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(q.path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create queue directory: %w", err)
+	}
+	f, err := os.OpenFile(q.path+".lock", os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open queue lock: %w", err)
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to lock queue: %w", err)
+	}
+	return func() {
+		unlockFile(f)
+		f.Close()
+	}, nil
+}
+
+func (q *DownloadQueue) load() (*QueueState, error) {
+	state := &QueueState{}
+	data, err := os.ReadFile(q.path)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read queue: %w", err)
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", q.path, err)
+	}
+	return state, nil
+}
+
+func (q *DownloadQueue) save(state *QueueState) error {
+	if err := os.MkdirAll(filepath.Dir(q.path), 0755); err != nil {
+		return fmt.Errorf("failed to create queue directory: %w", err)
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	// Write through a temporary file so an interrupted run never leaves a truncated queue
+	tmp := q.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write queue: %w", err)
+	}
+	return os.Rename(tmp, q.path)
+}
+
+// RunQueue downloads the pending queue items, concurrency at a time, until the queue is
+// empty. Items added while it runs are picked up too. While the queue is paused no new
+// items are started and RunQueue waits for 'queue resume'.
+func (api *DabAPI) RunQueue(ctx context.Context, concurrency int, config *Config, debug bool) error {
+	if concurrency < 1 {
+		concurrency = albumConcurrency(config)
+	}
+	if count, err := downloadQueue.requeueInterrupted(); err != nil {
+		return err
+	} else if count > 0 {
+		colorInfo.Printf("🔁 Resuming %d items interrupted by the last run\n", count)
+	}
+
+	// Tracks per album are reduced while several items download at once
+	itemConfig := *config
+	itemConfig.MaxConcurrentTracks = nestedTrackConcurrency(config, concurrency)
+
+	var pool *pb.Pool
+	if progressBarsEnabled() {
+		var err error
+		if pool, err = pb.StartPool(); err != nil {
+			colorError.Printf("❌ Failed to start progress bar pool: %v\n", err)
+			pool = nil
+		}
+	}
+	defer func() {
+		if pool != nil {
+			pool.Stop()
+		}
+	}()
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	done, failed := 0, 0
+	leased := make(map[int]bool) // Items of this run, whose leases are renewed
+	stopRenewing := make(chan struct{})
+	defer close(stopRenewing)
+	go func() {
+		ticker := time.NewTicker(queueLeaseRenew)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stopRenewing:
+				return
+			case <-ticker.C:
+				mu.Lock()
+				ids := make(map[int]bool, len(leased))
+				for id := range leased {
+					ids[id] = true
+				}
+				mu.Unlock()
+				if err := downloadQueue.renewLeases(ids); err != nil {
+					colorWarning.Printf("⚠️ Failed to update queue: %v\n", err)
+				}
+			}
+		}
+	}()
+	sem := semaphore.NewWeighted(int64(concurrency))
+	pausedShown := false
+	for {
+		if err := sem.Acquire(ctx, 1); err != nil {
+			wg.Wait()
+			return err
+		}
+		item, paused, err := downloadQueue.claim()
+		if err != nil {
+			sem.Release(1)
+			wg.Wait()
+			return err
+		}
+		if item == nil {
+			sem.Release(1)
+			if paused {
+				if !pausedShown {
+					colorWarning.Println("⏸️ Queue paused, waiting for 'queue resume' (Ctrl+C to stop)")
+					pausedShown = true
+				}
+				select {
+				case <-ctx.Done():
+					wg.Wait()
+					return ctx.Err()
+				case <-time.After(queuePollInterval):
+				}
+				continue
+			}
+			// Nothing pending: wait for the running items, new ones may be added meanwhile
+			wg.Wait()
+			if !downloadQueue.hasPending() {
+				break
+			}
+			continue
+		}
+		if pausedShown {
+			colorInfo.Println("▶️ Queue resumed")
+			pausedShown = false
+		}
+
+		mu.Lock()
+		leased[item.ID] = true
+		mu.Unlock()
+		wg.Add(1)
+		go func(item *QueueItem) {
+			defer handlePanic()
+			defer wg.Done()
+			defer sem.Release(1)
+			defer func() {
+				mu.Lock()
+				delete(leased, item.ID)
+				mu.Unlock()
+			}()
+
+			itemCopy := itemConfig
+			if item.Format != "" {
+				itemCopy.Format = item.Format
+			}
+			if item.Bitrate != "" {
+				itemCopy.Bitrate = item.Bitrate
+			}
+			item.Options.apply(&itemCopy)
+			colorInfo.Printf("📥 [queue #%d %s] %s %s\n", item.ID, item.CorrelationID, item.Type, item.Value)
+			itemCtx, run := runningQueueItems.start(withQueueOptions(withCorrelationID(ctx, item.CorrelationID), item.Options), item.ID)
+			defer runningQueueItems.stop(item.ID)
+			emitEvent("job_start", jobEventFields(itemCtx, map[string]interface{}{"type": item.Type, "value": item.Value}))
+			err := api.runQueueItem(itemCtx, item, &itemCopy, pool, debug)
+			if ctx.Err() != nil {
+				// Interrupted, leave the item running so the next run picks it up again
+				if err := downloadQueue.release(item.ID); err != nil {
+					colorWarning.Printf("⚠️ Failed to update queue: %v\n", err)
+				}
+				return
+			}
+			cancelled := run.cancelled()
+			shared, ferr := downloadQueue.finish(item.ID, err, cancelled)
+			if ferr != nil {
+				colorWarning.Printf("⚠️ Failed to update queue: %v\n", ferr)
+			}
+			emitJobComplete(itemCtx, item.ID, err, cancelled)
+			for _, other := range shared {
+				emitJobComplete(withCorrelationID(ctx, other.CorrelationID), other.ID, err, false)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if cancelled {
+				colorWarning.Printf("🛑 [queue #%d] cancelled\n", item.ID)
+			} else if err != nil {
+				failed += 1 + len(shared)
+				colorError.Printf("❌ [queue #%d] %v\n", item.ID, err)
+			} else {
+				done += 1 + len(shared)
+				colorSuccess.Printf("✅ [queue #%d] finished\n", item.ID)
+			}
+			for _, other := range shared {
+				colorInfo.Printf("🔗 [queue #%d] %s with #%d\n", other.ID, other.Status, item.ID)
+			}
+		}(item)
+	}
+
+	colorSuccess.Printf("✅ Queue finished: %d downloaded, %d failed\n", done, failed)
+	return nil
+}
+
+// hasPending reports whether any item is waiting to be downloaded
+func (q *DownloadQueue) hasPending() bool {
+	state, err := q.State()
+	if err != nil {
+		return false
+	}
+	now := time.Now()
+	for _, item := range state.Items {
+		if state.waiting(item) || item.interrupted(now) {
+			return true
+		}
+	}
+	return false
+}
+
+// emitJobComplete sends the final status of a queue item as a "job_complete" event
+func emitJobComplete(ctx context.Context, id int, err error, cancelled bool) {
+	fields := map[string]interface{}{"job_id": id, "status": QueueDone, "correlation_id": correlationIDFrom(ctx)}
+	if cancelled {
+		fields["status"] = QueueCancelled
+	} else if err != nil {
+		fields["status"] = QueueFailed
+		fields["message"] = err.Error()
+	}
+	emitEvent("job_complete", fields)
+}
+
+// runQueueItem downloads a single queue item
+func (api *DabAPI) runQueueItem(ctx context.Context, item *QueueItem, config *Config, pool *pb.Pool, debug bool) error {
+	switch item.Type {
+	case "album":
+		stats, err := api.DownloadAlbum(ctx, item.Value, config, debug, pool, nil)
+		if ctx.Err() == nil {
+			notified := stats
+			if err != nil {
+				notified = &DownloadStats{FailedCount: 1, FailedItems: []string{err.Error()}}
+			}
+			notifyTargets(config, EventAlbumComplete, "album "+item.Value, notified)
+		}
+		if err != nil {
+			return err
+		}
+		if stats.FailedCount > 0 {
+			return fmt.Errorf("%d tracks failed: %v", stats.FailedCount, stats.FailedItems)
+		}
+		return nil
+	case "track":
+		track, err := api.GetTrack(ctx, item.Value)
+		if err != nil {
+			return err
+		}
+		if run := queueItemRunFrom(ctx); run != nil {
+			run.start(track.Title, 1)
+		}
+		_, err = api.DownloadSingleTrack(ctx, *track, debug, config.Format, config.Bitrate, pool, config, nil)
+		return err
+	}
+	return fmt.Errorf("unknown queue item type '%s'", item.Type)
+}
+
+// QueueItemProgress is how far a running queue item got
+type QueueItemProgress struct {
+	Title       string    `json:"title,omitempty"` // Album or track title once it is known
+	TracksTotal int       `json:"tracks_total"`
+	TracksDone  int       `json:"tracks_done"` // Tracks downloaded, skipped ones aren't counted
+	Bytes       int64     `json:"bytes"`       // Audio bytes received so far
+	StartedAt   time.Time `json:"started_at"`
+}
+
+// queueItemRun is a queue item being downloaded by this process
+type queueItemRun struct {
+	id       int
+	mu       sync.Mutex
+	progress QueueItemProgress
+	cancel   context.CancelFunc
+	canceled bool
+}
+
+// Progress returns a copy of the progress that is safe to use while the download continues
+func (r *queueItemRun) Progress() QueueItemProgress {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.progress
+}
+
+func (r *queueItemRun) start(title string, tracks int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.progress.Title = title
+	r.progress.TracksTotal = tracks
+}
+
+func (r *queueItemRun) trackDone() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.progress.TracksDone++
+}
+
+func (r *queueItemRun) add(n int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.progress.Bytes += n
+}
+
+func (r *queueItemRun) cancelled() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.canceled
+}
+
+// queueItemRunKey is the context key of the queue item being downloaded
+type queueItemRunKey struct{}
+
+// queueItemRunFrom returns the queue item downloads made with ctx belong to, nil outside
+// of the queue
+func queueItemRunFrom(ctx context.Context) *queueItemRun {
+	run, _ := ctx.Value(queueItemRunKey{}).(*queueItemRun)
+	return run
+}
+
+// trackProgressInterval is how often the progress of a track in the queue is sent as an
+// event
+const trackProgressInterval = 500 * time.Millisecond
+
+// queueProgressReader counts the audio bytes of a queue item and sends the progress of
+// the track as "track_progress" events
+type queueProgressReader struct {
+	io.ReadCloser
+	run      *queueItemRun
+	trackID  string
+	title    string
+	read     int64 // Bytes of the file, including a resumed part
+	total    int64 // -1 when unknown
+	started  time.Time
+	received int64 // Bytes received by this reader, for the speed
+	lastSent time.Time
+}
+
+func newQueueProgressReader(body io.ReadCloser, run *queueItemRun, track Track, offset, total int64) *queueProgressReader {
+	return &queueProgressReader{ReadCloser: body, run: run, trackID: idToString(track.ID), title: track.Title, read: offset, total: total, started: time.Now()}
+}
+
+func (r *queueProgressReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if n > 0 {
+		r.run.add(int64(n))
+		r.read += int64(n)
+		r.received += int64(n)
+	}
+	switch {
+	case err == io.EOF:
+		r.emit("transferred")
+	case err != nil:
+		r.emit("failed")
+	case time.Since(r.lastSent) >= trackProgressInterval:
+		r.emit("downloading")
+	}
+	return n, err
+}
+
+func (r *queueProgressReader) emit(state string) {
+	r.lastSent = time.Now()
+	fields := map[string]interface{}{"job_id": r.run.id, "track_id": r.trackID, "title": r.title, "state": state, "bytes": r.read}
+	if r.total > 0 {
+		fields["total"] = r.total
+		fields["percent"] = r.read * 100 / r.total
+	}
+	if elapsed := time.Since(r.started).Seconds(); elapsed > 0 {
+		fields["bytes_per_second"] = int64(float64(r.received) / elapsed)
+	}
+	emitEvent("track_progress", fields)
+}
+
+// jobEventFields adds the ID of the queue item to the fields of an event sent while it
+// downloads
+func jobEventFields(ctx context.Context, fields map[string]interface{}) map[string]interface{} {
+	if run := queueItemRunFrom(ctx); run != nil {
+		fields["job_id"] = run.id
+	}
+	fields["correlation_id"] = correlationIDFrom(ctx)
+	return fields
+}
+
+// runningItems tracks the queue items this process is downloading, so they can be
+// reported and cancelled
+type runningItems struct {
+	mu    sync.Mutex
+	items map[int]*queueItemRun
+}
+
+// runningQueueItems are the items RunQueue is downloading right now
+var runningQueueItems = &runningItems{items: map[int]*queueItemRun{}}
+
+// start registers an item and returns the context to download it with
+func (r *runningItems) start(ctx context.Context, id int) (context.Context, *queueItemRun) {
+	ctx, cancel := context.WithCancel(ctx)
+	run := &queueItemRun{id: id, progress: QueueItemProgress{StartedAt: time.Now()}, cancel: cancel}
+	r.mu.Lock()
+	r.items[id] = run
+	r.mu.Unlock()
+	return context.WithValue(ctx, queueItemRunKey{}, run), run
+}
+
+func (r *runningItems) stop(id int) {
+	r.mu.Lock()
+	run := r.items[id]
+	delete(r.items, id)
+	r.mu.Unlock()
+	if run != nil {
+		run.cancel()
+	}
+}
+
+// Progress returns the progress of a running item, nil if this process isn't downloading it
+func (r *runningItems) Progress(id int) *QueueItemProgress {
+	r.mu.Lock()
+	run := r.items[id]
+	r.mu.Unlock()
+	if run == nil {
+		return nil
+	}
+	progress := run.Progress()
+	return &progress
+}
+
+// Cancel stops the download of a running item. It reports false if this process isn't
+// downloading it.
+func (r *runningItems) Cancel(id int) bool {
+	r.mu.Lock()
+	run := r.items[id]
+	r.mu.Unlock()
+	if run == nil {
+		return false
+	}
+	run.mu.Lock()
+	run.canceled = true
+	run.mu.Unlock()
+	run.cancel()
+	return true
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestQueue(t *testing.T) *DownloadQueue {
+	return &DownloadQueue{path: filepath.Join(t.TempDir(), "queue.json")}
+}
+
+func TestQueueAddSharesDownloads(t *testing.T) {
+	q := newTestQueue(t)
+	first, err := q.Add(&QueueItem{Type: "album", Value: "1"}, &QueueItem{Type: "album", Value: "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(first) != 1 {
+		t.Fatalf("added %d items of one job, want the duplicate skipped", len(first))
+	}
+
+	tests := []struct {
+		name       string
+		item       *QueueItem
+		added      bool
+		sharedWith int
+	}{
+		{"same album, other job", &QueueItem{Type: "album", Value: "1"}, true, first[0].ID},
+		{"same album, same job", &QueueItem{Type: "album", Value: "1", CorrelationID: first[0].CorrelationID}, false, 0},
+		{"other format", &QueueItem{Type: "album", Value: "1", Format: "mp3"}, true, 0},
+		{"other location", &QueueItem{Type: "album", Value: "1", Options: &QueueOptions{Location: "/other"}}, true, 0},
+		{"track with the same ID", &QueueItem{Type: "track", Value: "1"}, true, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			added, err := q.Add(tt.item)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (len(added) == 1) != tt.added {
+				t.Fatalf("added %d items, want added %v", len(added), tt.added)
+			}
+			if tt.added && added[0].SharedWith != tt.sharedWith {
+				t.Errorf("SharedWith = %d, want %d", added[0].SharedWith, tt.sharedWith)
+			}
+		})
+	}
+}
+
+func TestQueueFinishCreditsSharedItems(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		cancelled  bool
+		wantStatus string
+		wantShared int
+	}{
+		{"done", nil, false, QueueDone, 1},
+		{"failed", errors.New("stream unavailable"), false, QueueFailed, 1},
+		{"cancelled", nil, true, QueuePending, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := newTestQueue(t)
+			q.Add(&QueueItem{Type: "album", Value: "1"})
+			second, _ := q.Add(&QueueItem{Type: "album", Value: "1"})
+
+			item, _, _ := q.claim()
+			if item == nil || item.ID == second[0].ID {
+				t.Fatalf("claimed %+v, want the first item", item)
+			}
+			if next, _, _ := q.claim(); next != nil {
+				t.Fatalf("claimed shared item %d while the first one runs", next.ID)
+			}
+
+			shared, err := q.finish(item.ID, tt.err, tt.cancelled)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(shared) != tt.wantShared {
+				t.Fatalf("finish credited %d items, want %d", len(shared), tt.wantShared)
+			}
+			state, _ := q.State()
+			other := state.Items[1]
+			if other.Status != tt.wantStatus {
+				t.Errorf("shared item is %s, want %s", other.Status, tt.wantStatus)
+			}
+			if tt.cancelled {
+				if other.SharedWith != 0 {
+					t.Errorf("shared item still waits for cancelled item %d", other.SharedWith)
+				}
+				if next, _, _ := q.claim(); next == nil || next.ID != other.ID {
+					t.Errorf("claimed %+v after cancelling, want the shared item", next)
+				}
+			}
+		})
+	}
+}
+
+func TestQueueRequeueInterrupted(t *testing.T) {
+	past := time.Now().Add(-time.Second)
+	future := time.Now().Add(time.Minute)
+	tests := []struct {
+		name     string
+		status   string
+		lease    *time.Time
+		requeued bool
+	}{
+		{"lease of another run", QueueRunning, &future, false},
+		{"lease ran out", QueueRunning, &past, true},
+		{"no lease", QueueRunning, nil, true},
+		{"pending", QueuePending, nil, false},
+		{"done", QueueDone, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := newTestQueue(t)
+			q.Add(&QueueItem{Type: "album", Value: "1"})
+			q.update(func(state *QueueState) error {
+				state.Items[0].Status = tt.status
+				state.Items[0].LeaseUntil = tt.lease
+				return nil
+			})
+
+			count, err := q.requeueInterrupted()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (count == 1) != tt.requeued {
+				t.Errorf("requeued %d items, want requeued %v", count, tt.requeued)
+			}
+			// Another run's item can't be claimed either, an interrupted one can
+			item, _, _ := q.claim()
+			wantClaim := tt.requeued || tt.status == QueuePending
+			if (item != nil) != wantClaim {
+				t.Errorf("claimed %+v, want claimed %v", item, wantClaim)
+			}
+			if item != nil && (item.LeaseUntil == nil || !item.LeaseUntil.After(time.Now())) {
+				t.Errorf("claimed item has no lease: %v", item.LeaseUntil)
+			}
+		})
+	}
+}
+
+func TestQueueRenewAndReleaseLeases(t *testing.T) {
+	q := newTestQueue(t)
+	q.Add(&QueueItem{Type: "album", Value: "1"})
+	item, _, _ := q.claim()
+
+	past := time.Now().Add(-time.Second)
+	q.update(func(state *QueueState) error {
+		state.Items[0].LeaseUntil = &past
+		return nil
+	})
+	if err := q.renewLeases(map[int]bool{item.ID: true}); err != nil {
+		t.Fatal(err)
+	}
+	if count, _ := q.requeueInterrupted(); count != 0 {
+		t.Errorf("requeued an item with a renewed lease")
+	}
+
+	if err := q.release(item.ID); err != nil {
+		t.Fatal(err)
+	}
+	if count, _ := q.requeueInterrupted(); count != 1 {
+		t.Errorf("requeued %d items after releasing, want 1", count)
+	}
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	implausibleYearPenalty = 0.2  // Release years before recordings existed or in the future
+	queryYearBonus         = 0.1  // Release year named in the query
+	albumTypeWeight        = 0.05 // Preference of albums over EPs over singles
+)
+
+// albumTypeScores rank release types when the query matches several equally well
+var albumTypeScores = map[string]float64{
+	"album":       1,
+	"ep":          0.5,
+	"single":      0.25,
+	"compilation": 0,
+}
+
+var yearPattern = regexp.MustCompile(`\b(19|20)\d{2}\b`)
+
+// rankResults orders search results by how well they match the query. Unwanted versions
+// (karaoke, live, covers...) still come last unless the query asks for them; within those
+// groups results are sorted by normalized Levenshtein similarity to the query, adjusted by
+// release year sanity and album type. An artist listed several times is kept once, at its
+// best rank.
+func rankResults(query string, results *SearchResults) *SearchResults {
+	ranked := &SearchResults{
+		Artists: results.Artists,
+		Albums:  results.Albums,
+		Tracks:  results.Tracks,
+	}
+
+	order := rankOrder(len(ranked.Artists),
+		func(i int) int { return variantPenalty(query, ranked.Artists[i].Name) },
+		func(i int) float64 { return textSimilarity(query, ranked.Artists[i].Name) })
+	artists := make([]Artist, len(order))
+	for i, k := range order {
+		artists[i] = ranked.Artists[k]
+	}
+	ranked.Artists = dedupeArtists(artists)
+
+	order = rankOrder(len(ranked.Albums),
+		func(i int) int { return variantPenalty(query, ranked.Albums[i].Title+" "+ranked.Albums[i].Artist) },
+		func(i int) float64 {
+			album := ranked.Albums[i]
+			return matchScore(query, album.Artist, album.Title) +
+				yearScore(query, releaseYear(album.Year, album.ReleaseDate)) +
+				albumTypeWeight*albumTypeScores[strings.ToLower(album.Type)]
+		})
+	albums := make([]Album, len(order))
+	for i, k := range order {
+		albums[i] = ranked.Albums[k]
+	}
+	ranked.Albums = albums
+
+	order = rankOrder(len(ranked.Tracks),
+		func(i int) int {
+			track := ranked.Tracks[i]
+			return variantPenalty(query, track.Title+" "+track.Artist+" "+track.Album)
+		},
+		func(i int) float64 {
+			track := ranked.Tracks[i]
+			return matchScore(query, track.Artist, track.Title) + yearScore(query, releaseYear(track.Year, track.ReleaseDate))
+		})
+	tracks := make([]Track, len(order))
+	for i, k := range order {
+		tracks[i] = ranked.Tracks[k]
+	}
+	ranked.Tracks = tracks
+
+	return ranked
+}
+
+// rankOrder returns the indexes of n results sorted by variant penalty, then by descending
+// score. Ties keep the API order.
+func rankOrder(n int, penalty func(int) int, score func(int) float64) []int {
+	order := make([]int, n)
+	penalties := make([]int, n)
+	scores := make([]float64, n)
+	for i := range order {
+		order[i] = i
+		penalties[i] = penalty(i)
+		scores[i] = score(i)
+	}
+	sort.SliceStable(order, func(i, k int) bool {
+		a, b := order[i], order[k]
+		if penalties[a] != penalties[b] {
+			return penalties[a] < penalties[b]
+		}
+		return scores[a] > scores[b]
+	})
+	return order
+}
+
+// matchScore is the best similarity between the query and the artist and title of a
+// result, whichever order the query names them in
+func matchScore(query, artist, title string) float64 {
+	best := textSimilarity(query, title)
+	for _, candidate := range []string{artist + " " + title, title + " " + artist} {
+		if score := textSimilarity(query, candidate); score > best {
+			best = score
+		}
+	}
+	return best
+}
+
+// textSimilarity returns the normalized Levenshtein similarity of two strings, from 0 for
+// nothing in common to 1 for equal strings after normalization
+func textSimilarity(a, b string) float64 {
+	ra, rb := []rune(normalizeForMatch(a)), []rune(normalizeForMatch(b))
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	if longest == 0 {
+		return 0
+	}
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+// normalizeForMatch lower-cases s, drops punctuation and a leading "the", and collapses
+// whitespace, so "The Beatles - Help!" and "beatles help" compare equal
+func normalizeForMatch(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+		case r == '&':
+			b.WriteString(" and ")
+		default:
+			b.WriteRune(' ')
+		}
+	}
+	words := strings.Fields(b.String())
+	if len(words) > 1 && words[0] == "the" {
+		words = words[1:]
+	}
+	return strings.Join(words, " ")
+}
+
+// levenshtein returns the edit distance between a and b
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for k := range prev {
+		prev[k] = k
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for k := 1; k <= len(b); k++ {
+			cost := 1
+			if a[i-1] == b[k-1] {
+				cost = 0
+			}
+			curr[k] = min(prev[k]+1, curr[k-1]+1, prev[k-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+// releaseYear returns the year of a release from its year or release date, 0 if unknown
+func releaseYear(year, releaseDate string) int {
+	if year == "" && len(releaseDate) >= 4 {
+		year = releaseDate[:4]
+	}
+	y, err := strconv.Atoi(year)
+	if err != nil {
+		return 0
+	}
+	return y
+}
+
+// yearScore rewards the year named in the query and penalizes implausible release years
+func yearScore(query string, year int) float64 {
+	if year == 0 {
+		return 0
+	}
+	if year < 1900 || year > time.Now().Year()+1 {
+		return -implausibleYearPenalty
+	}
+	for _, match := range yearPattern.FindAllString(query, -1) {
+		if match == strconv.Itoa(year) {
+			return queryYearBonus
+		}
+	}
+	return 0
+}
+
+// dedupeArtists drops artists whose ID was already listed, keeping the first. Artists
+// without an ID are told apart by their normalized name. Different artists can share a
+// name, so same-name entries with different IDs are kept; see artistDisambiguation.
+func dedupeArtists(artists []Artist) []Artist {
+	seen := make(map[string]bool)
+	var result []Artist
+	for _, artist := range artists {
+		key := idToString(artist.ID)
+		if key == "" {
+			key = "name:" + normalizeForMatch(artist.Name)
+		}
+		if key != "name:" && seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, artist)
+	}
+	return result
+}
+
+// artistDisambiguation tells an artist apart from others of the same name in a list, by
+// country and ID. Artists with a unique name need none.
+func artistDisambiguation(artists []Artist, artist Artist) string {
+	name := normalizeForMatch(artist.Name)
+	for _, other := range artists {
+		if normalizeForMatch(other.Name) != name || idToString(other.ID) == idToString(artist.ID) {
+			continue
+		}
+		if artist.Country != "" {
+			return fmt.Sprintf(" (%s, ID %s)", artist.Country, idToString(artist.ID))
+		}
+		return fmt.Sprintf(" (ID %s)", idToString(artist.ID))
+	}
+	return ""
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDedupeArtists(t *testing.T) {
+	tests := []struct {
+		name    string
+		artists []Artist
+		want    []interface{}
+	}{
+		{"same ID", []Artist{{ID: "1", Name: "Abba"}, {ID: "1", Name: "ABBA"}}, []interface{}{"1"}},
+		{"numeric and string ID", []Artist{{ID: float64(7), Name: "Muse"}, {ID: "7", Name: "Muse"}}, []interface{}{float64(7)}},
+		{"same name, other artist", []Artist{{ID: "1", Name: "Nirvana"}, {ID: "2", Name: "Nirvana"}}, []interface{}{"1", "2"}},
+		{"no ID, same name", []Artist{{Name: "The Band"}, {Name: "the band"}}, []interface{}{nil}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []interface{}
+			for _, artist := range dedupeArtists(tt.artists) {
+				got = append(got, artist.ID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("dedupeArtists kept IDs %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestArtistDisambiguation(t *testing.T) {
+	artists := []Artist{{ID: "1", Name: "Nirvana", Country: "US"}, {ID: "2", Name: "Nirvana"}, {ID: "3", Name: "Muse"}}
+	tests := []struct {
+		artist Artist
+		want   string
+	}{
+		{artists[0], " (US, ID 1)"},
+		{artists[1], " (ID 2)"},
+		{artists[2], ""},
+	}
+	for _, tt := range tests {
+		if got := artistDisambiguation(artists, tt.artist); got != tt.want {
+			t.Errorf("artistDisambiguation(%s %v) = %q, want %q", tt.artist.Name, tt.artist.ID, got, tt.want)
+		}
+	}
+}
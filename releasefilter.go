@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Release date limits of artist downloads, set by --from-year, --to-year and
+// --released-after
+var (
+	fromYear      int
+	toYear        int
+	releasedAfter string
+)
+
+// releaseRange is the span of release dates an artist download is limited to. A zero
+// bound leaves that side open.
+type releaseRange struct {
+	from time.Time
+	to   time.Time // Exclusive
+}
+
+// parseReleaseRange builds the range from the release date flags
+func parseReleaseRange(fromYear, toYear int, releasedAfter string) (releaseRange, error) {
+	var r releaseRange
+	if fromYear < 0 || toYear < 0 {
+		return r, fmt.Errorf("years must be positive")
+	}
+	if fromYear > 0 {
+		r.from = time.Date(fromYear, time.January, 1, 0, 0, 0, 0, time.UTC)
+	}
+	if releasedAfter != "" {
+		after, err := time.Parse("2006-01-02", releasedAfter)
+		if err != nil {
+			return r, fmt.Errorf("invalid --released-after date '%s' (expected YYYY-MM-DD)", releasedAfter)
+		}
+		if after.After(r.from) {
+			r.from = after
+		}
+	}
+	if toYear > 0 {
+		r.to = time.Date(toYear+1, time.January, 1, 0, 0, 0, 0, time.UTC)
+	}
+	if !r.from.IsZero() && !r.to.IsZero() && !r.from.Before(r.to) {
+		return r, fmt.Errorf("the release date range is empty")
+	}
+	return r, nil
+}
+
+// active reports whether the range limits anything
+func (r releaseRange) active() bool {
+	return !r.from.IsZero() || !r.to.IsZero()
+}
+
+// contains reports whether a DAB release date ("2020-01-31" or just the year) is in the
+// range. Releases without a usable date are left out of a limited range.
+func (r releaseRange) contains(releaseDate string) bool {
+	if !r.active() {
+		return true
+	}
+	date, ok := parseReleaseDate(releaseDate)
+	if !ok {
+		return false
+	}
+	if !r.from.IsZero() && date.Before(r.from) {
+		return false
+	}
+	return r.to.IsZero() || date.Before(r.to)
+}
+
+// String describes the range for messages
+func (r releaseRange) String() string {
+	switch {
+	case !r.from.IsZero() && !r.to.IsZero():
+		return fmt.Sprintf("released from %s to %s", r.from.Format("2006-01-02"), r.to.AddDate(0, 0, -1).Format("2006-01-02"))
+	case !r.from.IsZero():
+		return fmt.Sprintf("released on or after %s", r.from.Format("2006-01-02"))
+	case !r.to.IsZero():
+		return fmt.Sprintf("released up to %s", r.to.AddDate(0, 0, -1).Format("2006-01-02"))
+	}
+	return "any release date"
+}
+
+// parseReleaseDate reads the date of a release. A year alone counts as January 1st.
+func parseReleaseDate(releaseDate string) (time.Time, bool) {
+	if len(releaseDate) >= 10 {
+		if date, err := time.Parse("2006-01-02", releaseDate[:10]); err == nil {
+			return date, true
+		}
+	}
+	if len(releaseDate) >= 4 {
+		if year, err := strconv.Atoi(releaseDate[:4]); err == nil && year > 0 {
+			return time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// filterByReleaseDate returns the items released within r
+func filterByReleaseDate(items []Album, r releaseRange) []Album {
+	if !r.active() {
+		return items
+	}
+	kept := []Album{}
+	for _, item := range items {
+		if r.contains(item.ReleaseDate) {
+			kept = append(kept, item)
+		}
+	}
+	return kept
+}
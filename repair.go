@@ -0,0 +1,451 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-flac/flacpicture"
+	"github.com/go-flac/flacvorbis"
+	"github.com/go-flac/go-flac"
+)
+
+// repairTags are the tags a track needs to be listed correctly by players
+var repairTags = []string{"TITLE", "ARTIST", "ALBUM", "ALBUMARTIST", "TRACKNUMBER", "DISCNUMBER", "DATE"}
+
+// repairAlbumTags can be copied from the other tracks of the album
+var repairAlbumTags = []string{"ALBUM", "ALBUMARTIST", "DATE"}
+
+// RepairFile is a downloaded track with the gaps found in its metadata
+type RepairFile struct {
+	Path        string
+	Entry       *HistoryEntry // Download history entry, nil if the history doesn't know the file
+	MissingArt  bool
+	MissingTags []string
+	tags        map[string][]string
+}
+
+// RepairAlbum groups the tracks of one album folder
+type RepairAlbum struct {
+	Dir           string
+	Files         []*RepairFile
+	MissingCovers []string // Cover files from album_art that don't exist
+	cover         []byte   // Cover embedded in one of the tracks, reused for the others
+}
+
+// NeedsRepair reports whether anything in the album is missing
+func (a *RepairAlbum) NeedsRepair() bool {
+	if len(a.MissingCovers) > 0 {
+		return true
+	}
+	for _, file := range a.Files {
+		if file.MissingArt || len(file.MissingTags) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// albumID returns the DAB album ID recorded for any track of the album
+func (a *RepairAlbum) albumID() string {
+	for _, file := range a.Files {
+		if file.Entry != nil && file.Entry.AlbumID != "" {
+			return file.Entry.AlbumID
+		}
+	}
+	return ""
+}
+
+// RepairFilesFromHistory returns the files of the download history that still exist
+func RepairFilesFromHistory() []string {
+	var paths []string
+	for _, entry := range downloadHistory.Search("") {
+		if strings.EqualFold(filepath.Ext(entry.Path), ".flac") && FileExists(entry.Path) {
+			paths = append(paths, entry.Path)
+		}
+	}
+	return paths
+}
+
+// RepairFilesInDir returns the FLAC files below root
+func RepairFilesInDir(root string) ([]string, error) {
+	var paths []string
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == quarantineDirName {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.EqualFold(filepath.Ext(path), ".flac") {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	return paths, err
+}
+
+// FindRepairs checks the given FLAC files for missing embedded art and tags, and their
+// folders for missing cover files when SaveAlbumArt is on. Files are grouped by folder.
+func FindRepairs(paths []string, config *Config) ([]*RepairAlbum, error) {
+	entries := make(map[string]*HistoryEntry)
+	history := downloadHistory.Search("")
+	for i := range history {
+		if abs, err := filepath.Abs(history[i].Path); err == nil {
+			entries[abs] = &history[i]
+		}
+	}
+
+	albums := make(map[string]*RepairAlbum)
+	for _, path := range paths {
+		dir := filepath.Dir(path)
+		album := albums[dir]
+		if album == nil {
+			album = &RepairAlbum{Dir: dir}
+			albums[dir] = album
+		}
+
+		tags, picture, err := readFlacMetadata(path)
+		if err != nil {
+			colorWarning.Printf("⚠️ Skipping %s: %v\n", path, err)
+			continue
+		}
+		file := &RepairFile{Path: path, tags: tags, MissingArt: picture == nil}
+		if abs, err := filepath.Abs(path); err == nil {
+			file.Entry = entries[abs]
+		}
+		for _, tag := range repairTags {
+			if len(tags[tag]) == 0 {
+				file.MissingTags = append(file.MissingTags, tag)
+			}
+		}
+		if picture != nil && album.cover == nil {
+			album.cover = picture
+		}
+		album.Files = append(album.Files, file)
+	}
+
+	var result []*RepairAlbum
+	for _, album := range albums {
+		if len(album.Files) == 0 {
+			continue
+		}
+		if config.SaveAlbumArt {
+			for _, name := range albumArtFilenames(config) {
+				// Disc folders share the cover of the album folder above them
+				if !FileExists(filepath.Join(album.Dir, name)) && !FileExists(filepath.Join(filepath.Dir(album.Dir), name)) {
+					album.MissingCovers = append(album.MissingCovers, name)
+				}
+			}
+		}
+		result = append(result, album)
+	}
+	sort.Slice(result, func(i, k int) bool { return result[i].Dir < result[k].Dir })
+	return result, nil
+}
+
+// albumArtFilenames returns the cover files saved next to every album
+func albumArtFilenames(config *Config) []string {
+	if config.AlbumArt != nil && len(config.AlbumArt.Filenames) > 0 {
+		return config.AlbumArt.Filenames
+	}
+	return []string{defaultAlbumArtFilename}
+}
+
+// readFlacMetadata returns the Vorbis comments of a FLAC file keyed by upper-case tag name
+// and the data of its first embedded picture, nil if it has none
+func readFlacMetadata(path string) (map[string][]string, []byte, error) {
+	tags, err := readVorbisTags(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	file, err := flac.ParseMetadata(f)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse FLAC: %w", err)
+	}
+	for _, block := range file.Meta {
+		if block.Type != flac.Picture {
+			continue
+		}
+		picture, err := flacpicture.ParseFromMetaDataBlock(*block)
+		if err == nil && len(picture.ImageData) > 0 {
+			return tags, picture.ImageData, nil
+		}
+	}
+	return tags, nil, nil
+}
+
+// RepairAlbum fills the gaps found by FindRepairs. Tags and covers come from the download
+// history, the other tracks and the cover files of the album first; DAB is only asked when
+// those don't have what's missing, and never when offline is set. Complete files are not
+// touched and existing tags are never changed. It returns the number of files written;
+// tracks that can't be written are reported and skipped.
+func (api *DabAPI) RepairAlbum(ctx context.Context, album *RepairAlbum, config *Config, offline, debug bool) (int, error) {
+	var dabAlbum *Album
+	dabFetched := false
+	fetchAlbum := func() *Album {
+		if dabFetched || offline {
+			return dabAlbum
+		}
+		dabFetched = true
+		albumID := album.albumID()
+		if albumID == "" {
+			return nil
+		}
+		fetched, err := api.GetAlbum(ctx, albumID)
+		if err != nil {
+			if debug {
+				fmt.Printf("DEBUG: Failed to fetch album %s for repair: %v\n", albumID, err)
+			}
+			return nil
+		}
+		dabAlbum = fetched
+		return dabAlbum
+	}
+
+	needsCover := len(album.MissingCovers) > 0
+	for _, file := range album.Files {
+		needsCover = needsCover || file.MissingArt
+	}
+	cover := album.cover
+	if cover == nil && needsCover {
+		cover = readCoverFile(album.Dir, config)
+	}
+	if cover == nil && needsCover {
+		if fetched := fetchAlbum(); fetched != nil && fetched.Cover != "" {
+			if data, err := api.DownloadCover(ctx, fetched.Cover); err == nil && len(data) > 0 {
+				cover = data
+			}
+		}
+	}
+
+	written := 0
+	for _, name := range album.MissingCovers {
+		if cover == nil {
+			break
+		}
+		if err := writeImageFile(filepath.Join(album.Dir, name), cover, 0); err != nil {
+			return written, err
+		}
+		written++
+	}
+
+	shared := sharedAlbumTags(album)
+	for _, file := range album.Files {
+		if !file.MissingArt && len(file.MissingTags) == 0 {
+			continue
+		}
+		values := localRepairValues(file, shared)
+		add := make(map[string]string)
+		complete := true
+		for _, tag := range file.MissingTags {
+			if values[tag] != "" {
+				add[tag] = values[tag]
+			} else {
+				complete = false
+			}
+		}
+		if !complete {
+			if fetched := fetchAlbum(); fetched != nil {
+				if track := findRepairTrack(fetched, file); track != nil {
+					dab := dabRepairValues(*track, fetched)
+					for _, tag := range file.MissingTags {
+						if add[tag] == "" && dab[tag] != "" {
+							add[tag] = dab[tag]
+						}
+					}
+				}
+			}
+		}
+
+		var picture []byte
+		if file.MissingArt {
+			picture = cover
+		}
+		if len(add) == 0 && picture == nil {
+			continue
+		}
+		if err := patchFlacMetadata(file.Path, add, picture); err != nil {
+			colorError.Printf("❌ Failed to repair %s: %v\n", file.Path, err)
+			continue
+		}
+		written++
+	}
+	return written, nil
+}
+
+// readCoverFile returns the first cover file found in dir or the folder above it
+func readCoverFile(dir string, config *Config) []byte {
+	names := append(albumArtFilenames(config), defaultAlbumArtFilename, "folder.jpg")
+	for _, d := range []string{dir, filepath.Dir(dir)} {
+		for _, name := range names {
+			if data, err := os.ReadFile(filepath.Join(d, name)); err == nil && len(data) > 0 {
+				return data
+			}
+		}
+	}
+	return nil
+}
+
+// sharedAlbumTags returns the album tags all tracks that have them agree on
+func sharedAlbumTags(album *RepairAlbum) map[string]string {
+	shared := make(map[string]string)
+	for _, tag := range repairAlbumTags {
+		value, conflict := "", false
+		for _, file := range album.Files {
+			if len(file.tags[tag]) == 0 {
+				continue
+			}
+			if value != "" && value != file.tags[tag][0] {
+				conflict = true
+				break
+			}
+			value = file.tags[tag][0]
+		}
+		if !conflict && value != "" {
+			shared[tag] = value
+		}
+	}
+	return shared
+}
+
+// localRepairValues returns the tag values known without asking DAB
+func localRepairValues(file *RepairFile, shared map[string]string) map[string]string {
+	values := make(map[string]string)
+	for tag, value := range shared {
+		values[tag] = value
+	}
+	if file.Entry != nil {
+		if file.Entry.Title != "" {
+			values["TITLE"] = file.Entry.Title
+		}
+		if file.Entry.Artist != "" {
+			values["ARTIST"] = file.Entry.Artist
+		}
+		if file.Entry.Album != "" && values["ALBUM"] == "" {
+			values["ALBUM"] = file.Entry.Album
+		}
+	}
+	return values
+}
+
+// findRepairTrack finds the album track of a file by history ID, ISRC or title
+func findRepairTrack(album *Album, file *RepairFile) *Track {
+	for i := range album.Tracks {
+		track := &album.Tracks[i]
+		if file.Entry != nil && file.Entry.TrackID == idToString(track.ID) {
+			return track
+		}
+	}
+	for i := range album.Tracks {
+		track := &album.Tracks[i]
+		if len(file.tags["ISRC"]) > 0 && strings.EqualFold(file.tags["ISRC"][0], track.ISRC) {
+			return track
+		}
+		if len(file.tags["TITLE"]) > 0 && strings.EqualFold(file.tags["TITLE"][0], track.Title) {
+			return track
+		}
+	}
+	return nil
+}
+
+// dabRepairValues returns the repair tags as AddMetadata writes them for a track
+func dabRepairValues(track Track, album *Album) map[string]string {
+	trackNumber, discNumber := track.TrackNumber, track.DiscNumber
+	if trackNumber == 0 {
+		trackNumber = 1
+	}
+	if discNumber == 0 {
+		discNumber = 1
+	}
+	date := getReleaseDate(track, album)
+	if date == "" {
+		date = track.Year
+	}
+	return map[string]string{
+		"TITLE":       track.Title,
+		"ARTIST":      track.Artist,
+		"ALBUM":       getAlbumTitle(track, album),
+		"ALBUMARTIST": getAlbumArtist(track, album),
+		"TRACKNUMBER": strconv.Itoa(trackNumber),
+		"DISCNUMBER":  strconv.Itoa(discNumber),
+		"DATE":        date,
+	}
+}
+
+// patchFlacMetadata adds tags and a front cover to a FLAC file, keeping everything it has
+func patchFlacMetadata(path string, tags map[string]string, cover []byte) error {
+	f, err := flac.ParseFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to parse FLAC file: %w", err)
+	}
+
+	if len(tags) > 0 {
+		index := -1
+		comment := flacvorbis.New()
+		for i, block := range f.Meta {
+			if block.Type == flac.VorbisComment {
+				if comment, err = flacvorbis.ParseFromMetaDataBlock(*block); err != nil {
+					return err
+				}
+				index = i
+				break
+			}
+		}
+		names := make([]string, 0, len(tags))
+		for name := range tags {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			addField(comment, name, tags[name])
+		}
+		block := comment.Marshal()
+		if index >= 0 {
+			f.Meta[index] = &block
+		} else {
+			f.Meta = append(f.Meta, &block)
+		}
+	}
+
+	if err := addCoverArt(f, cover); err != nil {
+		return err
+	}
+	return f.Save(path)
+}
+
+// PrintRepairs lists what is missing per album
+func PrintRepairs(albums []*RepairAlbum) {
+	for _, album := range albums {
+		if !album.NeedsRepair() {
+			continue
+		}
+		colorWarning.Printf("⚠️ %s\n", album.Dir)
+		if len(album.MissingCovers) > 0 {
+			fmt.Printf("   missing %s\n", strings.Join(album.MissingCovers, ", "))
+		}
+		for _, file := range album.Files {
+			var gaps []string
+			if file.MissingArt {
+				gaps = append(gaps, "embedded art")
+			}
+			gaps = append(gaps, file.MissingTags...)
+			if len(gaps) > 0 {
+				fmt.Printf("   %s: %s\n", filepath.Base(file.Path), strings.Join(gaps, ", "))
+			}
+		}
+	}
+}
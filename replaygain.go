@@ -0,0 +1,181 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-flac/flacvorbis"
+	"github.com/go-flac/go-flac"
+)
+
+// replayGainReference is the ReplayGain 2.0 target loudness in LUFS
+const replayGainReference = -18.0
+
+// Loudness is the EBU R128 measurement of a track or a whole album
+type Loudness struct {
+	Integrated float64 // Integrated loudness in LUFS
+	Peak       float64 // True peak as a linear sample value (1.0 is full scale)
+}
+
+// Gain returns the ReplayGain 2.0 gain in dB
+func (l Loudness) Gain() float64 {
+	return replayGainReference - l.Integrated
+}
+
+var (
+	ebur128Integrated = regexp.MustCompile(`I:\s+(-?[\d.]+|-inf) LUFS`)
+	ebur128Peak       = regexp.MustCompile(`Peak:\s+(-?[\d.]+|-inf) dBFS`)
+)
+
+// MeasureLoudness runs ffmpeg's ebur128 filter over the given files played back to back.
+// A single file gives the track loudness, all files of an album the album loudness.
+func MeasureLoudness(paths ...string) (*Loudness, error) {
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no files to measure")
+	}
+	args := []string{"-hide_banner", "-nostats"}
+	for _, path := range paths {
+		args = append(args, "-i", path)
+	}
+	filter := "ebur128=peak=true"
+	if len(paths) > 1 {
+		var inputs strings.Builder
+		for i := range paths {
+			inputs.WriteString(fmt.Sprintf("[%d:a]", i))
+		}
+		filter = fmt.Sprintf("%sconcat=n=%d:v=0:a=1,%s", inputs.String(), len(paths), filter)
+	}
+	args = append(args, "-filter_complex", filter, "-f", "null", "-")
+
+	output, err := exec.Command("ffmpeg", args...).CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("loudness scan failed: %w\nffmpeg output: %s", err, TruncateString(string(output), 500))
+	}
+
+	// The summary is printed last, after the per-frame log lines
+	summary := string(output)
+	if idx := strings.LastIndex(summary, "Summary:"); idx >= 0 {
+		summary = summary[idx:]
+	}
+	integrated := ebur128Integrated.FindStringSubmatch(summary)
+	peak := ebur128Peak.FindStringSubmatch(summary)
+	if integrated == nil || peak == nil {
+		return nil, fmt.Errorf("could not read loudness from ffmpeg output")
+	}
+	if integrated[1] == "-inf" {
+		return nil, fmt.Errorf("audio is silent")
+	}
+	lufs, err := strconv.ParseFloat(integrated[1], 64)
+	if err != nil {
+		return nil, err
+	}
+	loudness := &Loudness{Integrated: lufs}
+	if peak[1] != "-inf" {
+		dbfs, err := strconv.ParseFloat(peak[1], 64)
+		if err != nil {
+			return nil, err
+		}
+		loudness.Peak = math.Pow(10, dbfs/20)
+	}
+	return loudness, nil
+}
+
+// WriteReplayGainTags replaces the REPLAYGAIN_* tags of a FLAC file. album may be nil to
+// write only the track tags.
+func WriteReplayGainTags(path string, track, album *Loudness) error {
+	f, err := flac.ParseFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	var comment *flacvorbis.MetaDataBlockVorbisComment
+	idx := -1
+	for i, block := range f.Meta {
+		if block.Type == flac.VorbisComment {
+			comment, err = flacvorbis.ParseFromMetaDataBlock(*block)
+			if err != nil {
+				return fmt.Errorf("failed to parse tags of %s: %w", path, err)
+			}
+			idx = i
+			break
+		}
+	}
+	if comment == nil {
+		comment = flacvorbis.New()
+	}
+
+	// Drop earlier values, so a rescan doesn't leave two gains behind
+	kept := comment.Comments[:0]
+	for _, c := range comment.Comments {
+		if !strings.HasPrefix(strings.ToUpper(c), "REPLAYGAIN_") {
+			kept = append(kept, c)
+		}
+	}
+	comment.Comments = kept
+
+	addField(comment, "REPLAYGAIN_TRACK_GAIN", fmt.Sprintf("%+.2f dB", track.Gain()))
+	addField(comment, "REPLAYGAIN_TRACK_PEAK", fmt.Sprintf("%.6f", track.Peak))
+	if album != nil {
+		addField(comment, "REPLAYGAIN_ALBUM_GAIN", fmt.Sprintf("%+.2f dB", album.Gain()))
+		addField(comment, "REPLAYGAIN_ALBUM_PEAK", fmt.Sprintf("%.6f", album.Peak))
+	}
+
+	block := comment.Marshal()
+	if idx >= 0 {
+		f.Meta[idx] = &block
+	} else {
+		f.Meta = append(f.Meta, &block)
+	}
+	return f.Save(path)
+}
+
+// applyReplayGain scans FLAC files and writes their track gain. When isAlbum is set the
+// files are the tracks of one album and the album gain is written as well.
+func applyReplayGain(paths []string, isAlbum, debug bool) {
+	var flacs []string
+	for _, path := range paths {
+		if strings.EqualFold(filepath.Ext(path), ".flac") {
+			flacs = append(flacs, path)
+		}
+	}
+	if len(flacs) == 0 {
+		return
+	}
+	if !CheckFFmpeg() {
+		colorWarning.Println("⚠️ ReplayGain needs ffmpeg, which is not installed")
+		return
+	}
+
+	warn := func(path string, err error) {
+		colorWarning.Printf("⚠️ ReplayGain failed for %s: %v\n", filepath.Base(path), err)
+	}
+
+	var album *Loudness
+	if isAlbum {
+		var err error
+		album, err = MeasureLoudness(flacs...)
+		if err != nil {
+			// Tracks with different sample rates can't be concatenated; track gain still works
+			warn(flacs[0], fmt.Errorf("album scan: %w", err))
+		}
+	}
+	for _, path := range flacs {
+		track, err := MeasureLoudness(path)
+		if err != nil {
+			warn(path, err)
+			continue
+		}
+		if err := WriteReplayGainTags(path, track, album); err != nil {
+			warn(path, err)
+			continue
+		}
+		if debug {
+			fmt.Printf("DEBUG: ReplayGain %s: track %+.2f dB\n", path, track.Gain())
+		}
+	}
+}
@@ -0,0 +1,297 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	RequestPending  = "pending"
+	RequestApproved = "approved"
+	RequestRejected = "rejected"
+)
+
+// DownloadRequest is an artist or album a user of the request portal asked for. Nothing
+// is downloaded until an admin approves it, which queues its albums.
+type DownloadRequest struct {
+	ID        int       `json:"id"`
+	User      string    `json:"user"`
+	Type      string    `json:"type"`            // "artist" or "album"
+	Value     string    `json:"value"`           // DAB artist or album ID
+	Title     string    `json:"title,omitempty"` // Artist name or "Album - Artist" when it was requested
+	Note      string    `json:"note,omitempty"`  // Message from the user to the admin
+	Status    string    `json:"status"`
+	Reason    string    `json:"reason,omitempty"` // Why it was rejected
+	DecidedBy string    `json:"decided_by,omitempty"`
+	Jobs      []int     `json:"jobs,omitempty"` // Queue items created on approval
+	CreatedAt time.Time  `json:"created_at"`
+	DecidedAt *time.Time `json:"decided_at,omitempty"`
+}
+
+// RequestStore persists download requests to a JSON file next to the config
+type RequestStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// downloadRequests is the store of the request portal
+var downloadRequests = &RequestStore{path: filepath.Join("config", "requests.json")}
+
+// List returns the requests, newest first. A user only gets their own, an empty user and
+// status get all.
+func (s *RequestStore) List(user, status string) ([]*DownloadRequest, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	requests, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	kept := []*DownloadRequest{}
+	for _, request := range requests {
+		if (user == "" || request.User == user) && (status == "" || request.Status == status) {
+			kept = append(kept, request)
+		}
+	}
+	sort.Slice(kept, func(i, k int) bool { return kept[i].ID > kept[k].ID })
+	return kept, nil
+}
+
+// Get returns the request with the given ID
+func (s *RequestStore) Get(id int) (*DownloadRequest, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	requests, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	for _, request := range requests {
+		if request.ID == id {
+			return request, nil
+		}
+	}
+	return nil, fmt.Errorf("request %d not found", id)
+}
+
+// Add records a new pending request and assigns its ID. The same user asking again for
+// something still pending returns the existing request instead.
+func (s *RequestStore) Add(request *DownloadRequest) (*DownloadRequest, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	requests, err := s.load()
+	if err != nil {
+		return nil, false, err
+	}
+	request.ID = 1
+	for _, existing := range requests {
+		if existing.Status == RequestPending && existing.User == request.User && existing.Type == request.Type && existing.Value == request.Value {
+			return existing, false, nil
+		}
+		if existing.ID >= request.ID {
+			request.ID = existing.ID + 1
+		}
+	}
+	request.Status = RequestPending
+	request.CreatedAt = time.Now()
+	return request, true, s.save(append(requests, request))
+}
+
+// Update changes a request with fn and saves it
+func (s *RequestStore) Update(id int, fn func(request *DownloadRequest) error) (*DownloadRequest, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	requests, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	for _, request := range requests {
+		if request.ID == id {
+			if err := fn(request); err != nil {
+				return nil, err
+			}
+			return request, s.save(requests)
+		}
+	}
+	return nil, fmt.Errorf("request %d not found", id)
+}
+
+// Remove deletes a request
+func (s *RequestStore) Remove(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	requests, err := s.load()
+	if err != nil {
+		return err
+	}
+	for i, request := range requests {
+		if request.ID == id {
+			return s.save(append(requests[:i], requests[i+1:]...))
+		}
+	}
+	return fmt.Errorf("request %d not found", id)
+}
+
+func (s *RequestStore) load() ([]*DownloadRequest, error) {
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read requests: %w", err)
+	}
+	var requests []*DownloadRequest
+	if err := json.Unmarshal(data, &requests); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", s.path, err)
+	}
+	return requests, nil
+}
+
+func (s *RequestStore) save(requests []*DownloadRequest) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create requests directory: %w", err)
+	}
+	data, err := json.MarshalIndent(requests, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// SubmitRequest checks that the artist or album exists and records a pending request for
+// it. created is false when the user already has the same request pending.
+func (api *DabAPI) SubmitRequest(ctx context.Context, user, requestType, id, note string, config *Config) (request *DownloadRequest, created bool, err error) {
+	request = &DownloadRequest{User: user, Type: requestType, Value: id, Note: note}
+	switch requestType {
+	case "album":
+		album, err := api.GetAlbum(ctx, id)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to get album: %w", err)
+		}
+		request.Title = album.Title + " - " + album.Artist
+	case "artist":
+		artist, err := api.GetArtist(ctx, id, config, false)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to get artist info: %w", err)
+		}
+		request.Title = artist.Name
+	default:
+		return nil, false, fmt.Errorf("type must be \"artist\" or \"album\"")
+	}
+	request, created, err = downloadRequests.Add(request)
+	if err == nil && created {
+		emitEvent("request_submitted", map[string]interface{}{"request_id": request.ID, "user": user, "type": requestType, "value": id, "title": request.Title})
+	}
+	return request, created, err
+}
+
+// ApproveRequest queues the album of a pending request, or the releases of an artist
+// matching filter (albums, eps, singles or all) without excluded titles
+func (api *DabAPI) ApproveRequest(ctx context.Context, id int, filter, admin string, config *Config) (*DownloadRequest, error) {
+	request, err := downloadRequests.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if request.Status != RequestPending {
+		return nil, fmt.Errorf("request %d is already %s", id, request.Status)
+	}
+
+	albumIDs := []string{request.Value}
+	if request.Type == "artist" {
+		artist, err := api.GetArtist(ctx, request.Value, config, false)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get artist info: %w", err)
+		}
+		if filter == "" || filter == "all" {
+			filter = "albums,eps,singles"
+		}
+		albums, eps, singles, _ := api.categorizeAlbums(artist.Albums)
+		items, _ := excludeTitles(filterArtistItems(albums, eps, singles, filter))
+		if len(items) == 0 {
+			return nil, fmt.Errorf("no releases of %s match '%s'", artist.Name, filter)
+		}
+		albumIDs = albumIDs[:0]
+		for _, item := range items {
+			albumIDs = append(albumIDs, item.ID)
+		}
+	}
+
+	// The queue items share an ID so the whole request can be followed in the logs
+	correlationID := fmt.Sprintf("request-%d", request.ID)
+	items := make([]*QueueItem, 0, len(albumIDs))
+	for _, albumID := range albumIDs {
+		items = append(items, &QueueItem{Type: "album", Value: albumID, CorrelationID: correlationID})
+	}
+
+	// Approving first makes sure only one of several concurrent approvals queues the albums
+	request, err = downloadRequests.Update(id, func(request *DownloadRequest) error {
+		if request.Status != RequestPending {
+			return fmt.Errorf("request %d is already %s", id, request.Status)
+		}
+		now := time.Now()
+		request.Status = RequestApproved
+		request.DecidedBy = admin
+		request.DecidedAt = &now
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	added, err := downloadQueue.Add(items...)
+	if err != nil {
+		// Nothing was queued, so the request can be approved again
+		downloadRequests.Update(id, func(request *DownloadRequest) error {
+			request.Status = RequestPending
+			request.DecidedBy = ""
+			request.DecidedAt = nil
+			return nil
+		})
+		return nil, err
+	}
+	wakeDaemon()
+
+	request, err = downloadRequests.Update(id, func(request *DownloadRequest) error {
+		for _, item := range added {
+			request.Jobs = append(request.Jobs, item.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	emitEvent("request_approved", map[string]interface{}{"request_id": request.ID, "user": request.User, "jobs": request.Jobs, "correlation_id": correlationID})
+	return request, nil
+}
+
+// RejectRequest declines a pending request, with an optional reason shown to the user
+func RejectRequest(id int, reason, admin string) (*DownloadRequest, error) {
+	request, err := downloadRequests.Update(id, func(request *DownloadRequest) error {
+		if request.Status != RequestPending {
+			return fmt.Errorf("request %d is already %s", id, request.Status)
+		}
+		now := time.Now()
+		request.Status = RequestRejected
+		request.Reason = reason
+		request.DecidedBy = admin
+		request.DecidedAt = &now
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	emitEvent("request_rejected", map[string]interface{}{"request_id": request.ID, "user": request.User, "reason": reason})
+	return request, nil
+}
@@ -0,0 +1,359 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Schedule is a command the daemon runs on a cron schedule, stored in config.json
+type Schedule struct {
+	ID       int      `json:"id"`
+	Cron     string   `json:"cron"` // Five fields (minute hour day month weekday) or @daily, @hourly...
+	Args     []string `json:"args"` // The command and its arguments, e.g. ["batch", "playlist.txt", "--expand"]
+	Disabled bool     `json:"disabled,omitempty"`
+}
+
+// String is the command line of the schedule
+func (s Schedule) String() string {
+	quoted := make([]string, len(s.Args))
+	for i, arg := range s.Args {
+		if arg == "" || strings.ContainsAny(arg, " \t\"'") {
+			arg = strconv.Quote(arg)
+		}
+		quoted[i] = arg
+	}
+	return strings.Join(quoted, " ")
+}
+
+// unschedulableCommands can't run from a schedule, they don't finish or need a person
+var unschedulableCommands = []string{"daemon", "serve", "schedule", "tui", "help", "completion"}
+
+// validateSchedule checks the cron expression and that the arguments name a command
+func validateSchedule(s Schedule) error {
+	spec, err := parseCron(s.Cron)
+	if err != nil {
+		return err
+	}
+	if spec.Next(time.Now()).IsZero() {
+		return fmt.Errorf("'%s' never runs", s.Cron)
+	}
+	if len(s.Args) == 0 {
+		return fmt.Errorf("no command given")
+	}
+	cmd, _, err := rootCmd.Find(s.Args)
+	if err != nil || cmd == rootCmd {
+		return fmt.Errorf("unknown command '%s'", s.Args[0])
+	}
+	for _, name := range unschedulableCommands {
+		if cmd.Name() == name || s.Args[0] == name {
+			return fmt.Errorf("'%s' can't be scheduled", s.Args[0])
+		}
+	}
+	return nil
+}
+
+// scheduleConfigFile is where schedules are stored
+var scheduleConfigFile = filepath.Join("config", "config.json")
+
+// loadSchedules reads the schedules from the config file. Only the schedules are read, so
+// the daemon picks up 'schedule add' without a restart and without repeating config warnings.
+func loadSchedules() ([]Schedule, error) {
+	data, err := os.ReadFile(scheduleConfigFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var file struct {
+		Schedules []Schedule `json:"schedules"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to read schedules: %w", err)
+	}
+	return file.Schedules, nil
+}
+
+// updateSchedules changes the schedules in the config file. The file is loaded again so
+// flags given on this run are not saved.
+func updateSchedules(fn func(config *Config) error) error {
+	config := &Config{}
+	if err := LoadConfig(scheduleConfigFile, config); err != nil {
+		return err
+	}
+	if err := fn(config); err != nil {
+		return err
+	}
+	return SaveConfig(scheduleConfigFile, config)
+}
+
+// AddSchedule stores a new schedule and returns it with its ID
+func AddSchedule(cron string, args []string) (*Schedule, error) {
+	schedule := Schedule{Cron: strings.TrimSpace(cron), Args: args}
+	if err := validateSchedule(schedule); err != nil {
+		return nil, err
+	}
+	err := updateSchedules(func(config *Config) error {
+		for _, existing := range config.Schedules {
+			schedule.ID = max(schedule.ID, existing.ID)
+		}
+		schedule.ID++
+		config.Schedules = append(config.Schedules, schedule)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+// RemoveSchedule deletes the schedule with the given ID
+func RemoveSchedule(id int) error {
+	return updateSchedules(func(config *Config) error {
+		for i, schedule := range config.Schedules {
+			if schedule.ID == id {
+				config.Schedules = append(config.Schedules[:i], config.Schedules[i+1:]...)
+				return nil
+			}
+		}
+		return fmt.Errorf("no schedule with ID %d", id)
+	})
+}
+
+// RunScheduler starts due schedules until ctx is cancelled. It checks at the start of
+// every minute, and skips a run while the previous run of the same schedule is still busy.
+func RunScheduler(ctx context.Context) {
+	defer handlePanic()
+	if schedules, err := loadSchedules(); err == nil && len(schedules) > 0 {
+		colorInfo.Printf("⏰ %d schedules loaded, see 'schedule list'\n", len(schedules))
+	}
+
+	var mu sync.Mutex
+	running := map[int]bool{}
+	for {
+		now := time.Now()
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(now.Truncate(time.Minute).Add(time.Minute).Sub(now)):
+		}
+		minute := time.Now().Truncate(time.Minute)
+
+		schedules, err := loadSchedules()
+		if err != nil {
+			colorWarning.Printf("⚠️ %v\n", err)
+			continue
+		}
+		for _, schedule := range schedules {
+			if schedule.Disabled {
+				continue
+			}
+			spec, err := parseCron(schedule.Cron)
+			if err != nil || !spec.Matches(minute) {
+				continue
+			}
+			mu.Lock()
+			busy := running[schedule.ID]
+			running[schedule.ID] = true
+			mu.Unlock()
+			if busy {
+				colorWarning.Printf("⚠️ Schedule %d is still running, skipping this run\n", schedule.ID)
+				continue
+			}
+			go func(schedule Schedule) {
+				defer handlePanic()
+				RunSchedule(ctx, schedule)
+				mu.Lock()
+				delete(running, schedule.ID)
+				mu.Unlock()
+			}(schedule)
+		}
+	}
+}
+
+// RunSchedule runs the command of a schedule as a separate dab-downloader process, so it
+// gets its own flags, and waits for it to finish
+func RunSchedule(ctx context.Context, schedule Schedule) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	colorInfo.Printf("⏰ Running schedule %d: %s\n", schedule.ID, schedule)
+	emitEvent("schedule_start", map[string]interface{}{"schedule_id": schedule.ID, "args": schedule.Args})
+	start := time.Now()
+
+	// The command runs here, handing it to this daemon would only queue its downloads
+	cmd := exec.CommandContext(ctx, exe, append(append([]string{}, schedule.Args...), "--no-daemon")...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	err = cmd.Run()
+
+	fields := map[string]interface{}{"schedule_id": schedule.ID, "duration_ms": time.Since(start).Milliseconds()}
+	if err != nil {
+		fields["error"] = err.Error()
+		colorError.Printf("❌ Schedule %d failed after %s: %v\n", schedule.ID, time.Since(start).Round(time.Second), err)
+	} else {
+		colorSuccess.Printf("✅ Schedule %d finished in %s\n", schedule.ID, time.Since(start).Round(time.Second))
+	}
+	emitEvent("schedule_complete", fields)
+	return err
+}
+
+// cronSpec is a parsed cron expression, with the allowed values of each field
+type cronSpec struct {
+	minute, hour, day, month, weekday map[int]bool
+	anyDay, anyWeekday                bool
+}
+
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var (
+	cronMonthNames   = []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}
+	cronWeekdayNames = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+)
+
+// parseCron reads a standard five field cron expression. Fields take *, numbers, ranges
+// (1-5), steps (*/15, 0-30/10) and comma separated lists; months and weekdays also take
+// names like jan or mon, and Sunday is 0 or 7.
+func parseCron(expr string) (*cronSpec, error) {
+	if macro, ok := cronMacros[strings.ToLower(strings.TrimSpace(expr))]; ok {
+		expr = macro
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid cron expression '%s': expected 5 fields (minute hour day month weekday)", expr)
+	}
+	spec := &cronSpec{anyDay: fields[2] == "*", anyWeekday: fields[4] == "*"}
+	var err error
+	if spec.minute, err = parseCronField(fields[0], 0, 59, nil); err != nil {
+		return nil, fmt.Errorf("invalid minute: %w", err)
+	}
+	if spec.hour, err = parseCronField(fields[1], 0, 23, nil); err != nil {
+		return nil, fmt.Errorf("invalid hour: %w", err)
+	}
+	if spec.day, err = parseCronField(fields[2], 1, 31, nil); err != nil {
+		return nil, fmt.Errorf("invalid day of month: %w", err)
+	}
+	if spec.month, err = parseCronField(fields[3], 1, 12, cronMonthNames); err != nil {
+		return nil, fmt.Errorf("invalid month: %w", err)
+	}
+	if spec.weekday, err = parseCronField(fields[4], 0, 7, cronWeekdayNames); err != nil {
+		return nil, fmt.Errorf("invalid weekday: %w", err)
+	}
+	if spec.weekday[7] {
+		spec.weekday[0] = true
+	}
+	return spec, nil
+}
+
+// parseCronField returns the values from first to last a field allows. names are the
+// names of the values from first on.
+func parseCronField(field string, first, last int, names []string) (map[int]bool, error) {
+	value := func(s string) (int, error) {
+		for i, name := range names {
+			if strings.EqualFold(s, name) {
+				return first + i, nil
+			}
+		}
+		n, err := strconv.Atoi(s)
+		if err != nil || n < first || n > last {
+			return 0, fmt.Errorf("'%s' is not between %d and %d", s, first, last)
+		}
+		return n, nil
+	}
+
+	allowed := map[int]bool{}
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepPart); err != nil || step <= 0 {
+				return nil, fmt.Errorf("invalid step '%s'", stepPart)
+			}
+		}
+		low, high := first, last
+		if rangePart != "*" {
+			from, to, isRange := strings.Cut(rangePart, "-")
+			var err error
+			if low, err = value(from); err != nil {
+				return nil, err
+			}
+			high = low
+			if isRange {
+				if high, err = value(to); err != nil {
+					return nil, err
+				}
+			} else if hasStep {
+				high = last // 5/15 means from 5 on, every 15
+			}
+			if high < low {
+				return nil, fmt.Errorf("invalid range '%s'", rangePart)
+			}
+		}
+		for v := low; v <= high; v += step {
+			allowed[v] = true
+		}
+	}
+	return allowed, nil
+}
+
+// Matches reports whether the schedule runs in the minute of t
+func (c *cronSpec) Matches(t time.Time) bool {
+	return c.minute[t.Minute()] && c.hour[t.Hour()] && c.month[int(t.Month())] && c.dayMatches(t)
+}
+
+// dayMatches reports whether the schedule runs on the day of t. Like cron, a restricted
+// day of month and weekday match when either does.
+func (c *cronSpec) dayMatches(t time.Time) bool {
+	day, weekday := c.day[t.Day()], c.weekday[int(t.Weekday())]
+	switch {
+	case c.anyDay && c.anyWeekday:
+		return true
+	case c.anyDay:
+		return weekday
+	case c.anyWeekday:
+		return day
+	}
+	return day || weekday
+}
+
+// Next returns the first minute after t the schedule runs in, or the zero time when it
+// doesn't run in the next five years (e.g. on February 30th)
+func (c *cronSpec) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case !c.month[int(t.Month())]:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !c.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case !c.hour[t.Hour()]:
+			// Not t.Truncate(time.Hour), which works in UTC and misses the hour in zones
+			// with half-hour offsets
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case !c.minute[t.Minute()]:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
@@ -15,11 +15,24 @@ func handleSearch(ctx context.Context, api *DabAPI, query string, searchType str
 
 	totalResults := len(results.Artists) + len(results.Albums) + len(results.Tracks)
 	if totalResults == 0 {
+		emitEvent("results", map[string]interface{}{"query": query, "type": searchType, "artists": []Artist{}, "albums": []Album{}, "tracks": []Track{}})
 		colorWarning.Println("No results found.")
 		return nil, nil, nil
 	}
 
+	// Best matches first, unwanted versions (karaoke, live, covers...) only win when
+	// nothing else matches, and duplicate artists are listed once
+	results = rankResults(query, results)
+	totalResults = len(results.Artists) + len(results.Albums) + len(results.Tracks)
+	emitEvent("results", map[string]interface{}{"query": query, "type": searchType, "artists": results.Artists, "albums": results.Albums, "tracks": results.Tracks})
+	if jsonOutput && !auto {
+		// Scripts pick from the results themselves
+		return nil, nil, nil
+	}
+
 	if auto {
+		// Blocklisted results are never picked automatically
+		results = filterBlocked(results)
 		var selectedItems []interface{}
 		var itemTypes []string
 		if len(results.Artists) > 0 {
@@ -42,21 +55,21 @@ func handleSearch(ctx context.Context, api *DabAPI, query string, searchType str
 	if len(results.Artists) > 0 {
 		colorInfo.Println("\n--- Artists ---")
 		for _, artist := range results.Artists {
-			fmt.Printf("%d. %s\n", counter, artist.Name)
+			fmt.Printf("%d. %s%s%s\n", counter, artist.Name, artistDisambiguation(results.Artists, artist), blockedLabel("artist", artist.ID))
 			counter++
 		}
 	}
 	if len(results.Albums) > 0 {
 		colorInfo.Println("\n--- Albums ---")
 		for _, album := range results.Albums {
-			fmt.Printf("%d. %s - %s\n", counter, album.Title, album.Artist)
+			fmt.Printf("%d. %s - %s%s\n", counter, album.Title, album.Artist, blockedLabel("album", album.ID))
 			counter++
 		}
 	}
 	if len(results.Tracks) > 0 {
 		colorInfo.Println("\n--- Tracks ---")
 		for _, track := range results.Tracks {
-			fmt.Printf("%d. %s - %s (%s)\n", counter, track.Title, track.Artist, track.Album)
+			fmt.Printf("%d. %s - %s (%s)%s\n", counter, track.Title, track.Artist, track.Album, blockedLabel("track", track.ID))
 			counter++
 		}
 	}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// searchCacheEntry is a cached search response
+type searchCacheEntry struct {
+	Results  *SearchResults `json:"results"`
+	CachedAt time.Time      `json:"cached_at"`
+}
+
+// SearchCache maps search queries to their results for a limited time, so re-importing
+// the same playlist doesn't query DAB again for tracks that haven't changed
+type SearchCache struct {
+	path    string
+	ttl     time.Duration
+	entries map[string]searchCacheEntry
+	loaded  bool
+	mu      sync.Mutex
+}
+
+// NewSearchCache creates a cache backed by path whose entries expire after ttl
+func NewSearchCache(path string, ttl time.Duration) *SearchCache {
+	return &SearchCache{path: path, ttl: ttl, entries: make(map[string]searchCacheEntry)}
+}
+
+// SetSearchCache enables caching of search results. A nil cache disables it.
+func (api *DabAPI) SetSearchCache(cache *SearchCache) {
+	api.searchCache = cache
+}
+
+// searchCacheKey builds the cache key for a search, queries are matched case-insensitively
+func searchCacheKey(query, searchType string, limit int) string {
+	return fmt.Sprintf("%s|%d|%s", searchType, limit, strings.ToLower(strings.TrimSpace(query)))
+}
+
+// Get returns the cached results for key if they haven't expired
+func (c *SearchCache) Get(key string) (*SearchResults, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.load()
+	entry, ok := c.entries[key]
+	if !ok || time.Since(entry.CachedAt) > c.ttl {
+		return nil, false
+	}
+	return entry.Results, true
+}
+
+// Put stores results for key and writes the cache to disk
+func (c *SearchCache) Put(key string, results *SearchResults) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.load()
+	c.entries[key] = searchCacheEntry{Results: results, CachedAt: time.Now()}
+
+	// Drop expired entries so the file doesn't grow forever
+	for k, entry := range c.entries {
+		if time.Since(entry.CachedAt) > c.ttl {
+			delete(c.entries, k)
+		}
+	}
+
+	data, err := json.Marshal(c.entries)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(c.path, data, 0644)
+}
+
+// load reads the cache file once. A missing or corrupt file starts an empty cache.
+func (c *SearchCache) load() {
+	if c.loaded {
+		return
+	}
+	c.loaded = true
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		return
+	}
+	if err := json.Unmarshal(data, &c.entries); err != nil || c.entries == nil {
+		c.entries = make(map[string]searchCacheEntry)
+	}
+}
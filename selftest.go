@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/go-flac/flacvorbis"
+	"github.com/go-flac/go-flac"
+)
+
+const defaultSelfTestQuery = "Daft Punk"
+
+// RunSelfTest checks the whole pipeline on one short track: search, album fetch, download,
+// tagging, verification and conversion. Everything is written to a temporary directory
+// that is removed afterwards. Returns true when every step passed.
+func (api *DabAPI) RunSelfTest(ctx context.Context, config *Config, query string, debug bool) bool {
+	colorInfo.Println("🧪 Running self-test...")
+	passed := true
+	step := func(name string, err error) bool {
+		if err != nil {
+			colorError.Printf("   ❌ %s: %v\n", name, err)
+			passed = false
+			return false
+		}
+		colorSuccess.Printf("   ✅ %s\n", name)
+		return true
+	}
+
+	// Search for a known query and pick the shortest track to keep the download small
+	results, err := api.Search(ctx, query, "track", 10, debug)
+	if err == nil && len(results.Tracks) == 0 {
+		err = fmt.Errorf("no tracks found for '%s'", query)
+	}
+	if !step(fmt.Sprintf("Search '%s'", query), err) {
+		return false
+	}
+	track := results.Tracks[0]
+	for _, t := range results.Tracks {
+		if t.Duration > 0 && (track.Duration <= 0 || t.Duration < track.Duration) {
+			track = t
+		}
+	}
+
+	album, err := api.GetAlbum(ctx, track.AlbumID)
+	if !step(fmt.Sprintf("Fetch album %s", track.AlbumID), err) {
+		return false
+	}
+	for _, t := range album.Tracks {
+		if idToString(t.ID) == idToString(track.ID) {
+			track = t
+			break
+		}
+	}
+
+	tmpDir, err := os.MkdirTemp("", "dab-selftest-")
+	if !step("Create temporary directory", err) {
+		return false
+	}
+	defer func() {
+		step("Clean up", os.RemoveAll(tmpDir))
+	}()
+
+	// Use the real settings but delete straight away instead of quarantining
+	testConfig := *config
+	testConfig.QuarantineDays = 0
+	testConfig.VerifyDownloads = true
+
+	outputPath := filepath.Join(tmpDir, "selftest.flac")
+	_, _, err = api.DownloadTrack(ctx, track, album, outputPath, nil, nil, debug, "flac", "", &testConfig, NewWarningCollector(false))
+	if !step(fmt.Sprintf("Download, verify and tag '%s' (%s)", track.Title, formatTrackLength(track.Duration)), err) {
+		return false
+	}
+
+	step("Read back tags", checkTitleTag(outputPath, track.Title))
+
+	if !CheckFFmpeg() {
+		colorWarning.Println("   ⚠️ Conversion skipped: ffmpeg is not installed")
+	} else {
+		converted, err := ConvertTrack(outputPath, "mp3", "128", EncoderOptions{})
+		if err == nil && !FileExists(converted) {
+			err = fmt.Errorf("converted file not found")
+		}
+		step("Convert to MP3", err)
+	}
+
+	return passed
+}
+
+// checkTitleTag verifies that a FLAC file has the expected TITLE tag
+func checkTitleTag(path, title string) error {
+	f, err := flac.ParseFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to parse FLAC: %w", err)
+	}
+	for _, block := range f.Meta {
+		if block.Type != flac.VorbisComment {
+			continue
+		}
+		comment, err := flacvorbis.ParseFromMetaDataBlock(*block)
+		if err != nil {
+			return err
+		}
+		values, err := comment.Get(flacvorbis.FIELD_TITLE)
+		if err != nil {
+			return err
+		}
+		for _, value := range values {
+			if value == title {
+				return nil
+			}
+		}
+		return fmt.Errorf("TITLE tag is %v, expected '%s'", values, title)
+	}
+	return fmt.Errorf("no Vorbis comment block found")
+}
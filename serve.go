@@ -0,0 +1,660 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+const (
+	defaultServeAddress = "127.0.0.1:8765"
+	serveHistoryLimit   = 100 // History entries returned when no limit is given
+)
+
+// ServeJob is a queue item as returned by the REST API, with its progress while it runs
+type ServeJob struct {
+	*QueueItem
+	Progress *QueueItemProgress `json:"progress,omitempty"`
+}
+
+// serveJobRequest submits downloads to the REST API
+type serveJobRequest struct {
+	Type    string   `json:"type"`          // "album" or "track"
+	ID      string   `json:"id,omitempty"`  // A single album or track
+	IDs     []string `json:"ids,omitempty"` // Several albums or tracks
+	Format  string   `json:"format,omitempty"`
+	Bitrate string   `json:"bitrate,omitempty"`
+}
+
+// serveHTTPError is an error with the status code the REST API answers with
+type serveHTTPError struct {
+	status int
+	err    error
+}
+
+func (e *serveHTTPError) Error() string { return e.err.Error() }
+
+func httpError(status int, format string, args ...interface{}) error {
+	return &serveHTTPError{status: status, err: fmt.Errorf(format, args...)}
+}
+
+// ServeConfig configures the REST API of 'serve'. Flags override it.
+type ServeConfig struct {
+	Listen   string            `json:"listen,omitempty"`   // Address to listen on, default 127.0.0.1:8765
+	Token    string            `json:"token,omitempty"`    // Sent as "Authorization: Bearer <token>"
+	Username string            `json:"username,omitempty"` // Basic authentication, for browsers
+	Password string            `json:"password,omitempty"`
+	Users    map[string]string `json:"users,omitempty"`    // Request portal users by name with their password, they may only request downloads
+	Hosts    []string          `json:"hosts,omitempty"`    // Host names clients may use besides localhost and the listen address
+	TLSCert  string            `json:"tls_cert,omitempty"` // PEM certificate and key to serve HTTPS
+	TLSKey   string            `json:"tls_key,omitempty"`
+	NoAuth   bool              `json:"-"` // Allow other machines without authentication, set by --no-auth
+}
+
+// authEnabled reports whether requests have to authenticate
+func (c ServeConfig) authEnabled() bool {
+	return c.Token != "" || c.Username != "" || len(c.Users) > 0
+}
+
+// serveUser is who sent a request to the REST API
+type serveUser struct {
+	name  string
+	admin bool // Admins may use the whole API, portal users only their own download requests
+}
+
+// serveUserKey is the context key of the serveUser of a request
+type serveUserKey struct{}
+
+// serveUserFrom returns who sent a request
+func serveUserFrom(r *http.Request) serveUser {
+	user, _ := r.Context().Value(serveUserKey{}).(serveUser)
+	return user
+}
+
+// RunServe runs the daemon and serves its queue over a REST API. Downloads run in the
+// background: submitting a job returns its ID right away, and its status and progress are
+// polled with GET /api/jobs/{id}. When a token or user is configured, every request has to
+// send one of them.
+func (api *DabAPI) RunServe(ctx context.Context, config *Config, options ServeConfig, concurrency int, debug bool) error {
+	if connectDaemon() != nil {
+		return fmt.Errorf("a daemon is already running on %s, stop it before starting 'serve'", daemonSocketPath)
+	}
+	if options.Listen == "" {
+		options.Listen = defaultServeAddress
+	}
+	if (options.Username == "") != (options.Password == "") {
+		return fmt.Errorf("basic authentication needs both a username and a password")
+	}
+	if (options.TLSCert == "") != (options.TLSKey == "") {
+		return fmt.Errorf("HTTPS needs both a certificate and a key")
+	}
+	if !options.authEnabled() && !options.NoAuth {
+		if host, _, err := net.SplitHostPort(options.Listen); err != nil || !isLoopbackHost(host) {
+			return fmt.Errorf("refusing to serve %s without authentication, set a token or user (or pass --no-auth)", options.Listen)
+		}
+	}
+
+	server := &http.Server{ReadHeaderTimeout: 10 * time.Second}
+	scheme := "http"
+	if options.TLSCert != "" {
+		// Load the pair now so a wrong path fails before anything is served
+		cert, err := tls.LoadX509KeyPair(options.TLSCert, options.TLSKey)
+		if err != nil {
+			return fmt.Errorf("failed to load TLS certificate: %w", err)
+		}
+		server.TLSConfig = &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+		scheme = "https"
+	}
+	listener, err := net.Listen("tcp", options.Listen)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", options.Listen, err)
+	}
+	status := DaemonStatus{PID: os.Getpid(), StartedAt: time.Now(), Endpoint: config.APIURL}
+	server.Handler = api.serveHandler(status, options, config)
+	if server.TLSConfig != nil {
+		go server.ServeTLS(listener, "", "")
+	} else {
+		go server.Serve(listener)
+	}
+	defer server.Close()
+
+	colorSuccess.Printf("✅ REST API listening on %s://%s/api\n", scheme, listener.Addr())
+	if !options.authEnabled() {
+		colorWarning.Println("⚠️ The API accepts requests without authentication")
+		if host, _, err := net.SplitHostPort(options.Listen); err == nil && !isLoopbackHost(host) && len(options.Hosts) == 0 {
+			colorWarning.Println("⚠️ Only localhost and the listen address are accepted as host names, add others to the hosts of the serve config")
+		}
+	} else if server.TLSConfig == nil {
+		if host, _, err := net.SplitHostPort(options.Listen); err != nil || !isLoopbackHost(host) {
+			colorWarning.Println("⚠️ Credentials are sent unencrypted, consider --tls-cert and --tls-key")
+		}
+	}
+	return api.RunDaemon(ctx, config, concurrency, debug)
+}
+
+// serveHandler routes the REST API
+func (api *DabAPI) serveHandler(status DaemonStatus, options ServeConfig, config *Config) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/status", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeAPIResponse(w, nil, httpError(http.StatusMethodNotAllowed, "method %s not allowed", r.Method))
+			return
+		}
+		current := status
+		current.addThroughput()
+		state, err := downloadQueue.State()
+		if err == nil {
+			for _, item := range state.Items {
+				switch item.Status {
+				case QueuePending:
+					current.Pending++
+				case QueueRunning:
+					current.Running++
+				}
+			}
+		}
+		writeAPIResponse(w, current, err)
+	})
+	mux.HandleFunc("/api/jobs", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			jobs, err := listServeJobs(r.URL.Query().Get("status"))
+			writeAPIResponse(w, jobs, err)
+		case http.MethodPost:
+			jobs, err := submitServeJobs(r)
+			if err == nil {
+				w.Header().Set("Location", fmt.Sprintf("/api/jobs/%d", jobs[0].ID))
+				w.Header().Set("X-Correlation-ID", jobs[0].CorrelationID)
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusAccepted)
+			}
+			writeAPIResponse(w, jobs, err)
+		default:
+			writeAPIResponse(w, nil, httpError(http.StatusMethodNotAllowed, "method %s not allowed", r.Method))
+		}
+	})
+	mux.HandleFunc("/api/jobs/", func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/api/jobs/"))
+		if err != nil {
+			writeAPIResponse(w, nil, httpError(http.StatusNotFound, "invalid job ID"))
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			job, err := getServeJob(id)
+			writeAPIResponse(w, job, err)
+		case http.MethodDelete:
+			job, err := cancelServeJob(id)
+			writeAPIResponse(w, job, err)
+		default:
+			writeAPIResponse(w, nil, httpError(http.StatusMethodNotAllowed, "method %s not allowed", r.Method))
+		}
+	})
+	mux.Handle("/api/ws", websocket.Server{Handler: streamEvents, Handshake: func(config *websocket.Config, r *http.Request) error {
+		return checkWebSocketOrigin(r, tokenAuthenticated(r, options))
+	}})
+	mux.HandleFunc("/api/history", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeAPIResponse(w, nil, httpError(http.StatusMethodNotAllowed, "method %s not allowed", r.Method))
+			return
+		}
+		limit := serveHistoryLimit
+		if value := r.URL.Query().Get("limit"); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				writeAPIResponse(w, nil, httpError(http.StatusBadRequest, "invalid limit '%s'", value))
+				return
+			}
+			limit = n
+		}
+		entries := downloadHistory.Search(r.URL.Query().Get("q"))
+		if limit > 0 && len(entries) > limit {
+			entries = entries[:limit]
+		}
+		if entries == nil {
+			entries = []HistoryEntry{}
+		}
+		writeAPIResponse(w, entries, nil)
+	})
+
+	api.handleRequestPortal(mux, config)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := checkServeRequest(r, options); err != nil {
+			writeAPIResponse(w, nil, err)
+			return
+		}
+		user, ok := authenticate(r, options)
+		if !ok {
+			if options.Username != "" || len(options.Users) > 0 {
+				w.Header().Set("WWW-Authenticate", `Basic realm="dab-downloader"`)
+			}
+			writeAPIResponse(w, nil, httpError(http.StatusUnauthorized, "missing or wrong credentials"))
+			return
+		}
+		if !user.admin && r.URL.Path != "/api/requests" && !strings.HasPrefix(r.URL.Path, "/api/requests/") {
+			writeAPIResponse(w, nil, httpError(http.StatusForbidden, "portal users may only use /api/requests"))
+			return
+		}
+		mux.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), serveUserKey{}, user)))
+	})
+}
+
+// checkServeRequest refuses requests web pages can make on behalf of a browser: to a host
+// name that isn't ours, which DNS rebinding would send, from a page of another site, or
+// with a body that isn't JSON, which forms can send without asking the API first
+func checkServeRequest(r *http.Request, options ServeConfig) error {
+	// Hosts are checked whenever a page could reach the API without credentials
+	if !options.authEnabled() || len(options.Hosts) > 0 {
+		if !allowedServeHost(r.Host, options) {
+			return httpError(http.StatusForbidden, "host %s not allowed, add it to the hosts of the serve config", r.Host)
+		}
+	}
+	// WebSocket origins are checked by the handshake, browsers pass the token in the URL
+	if origin := r.Header.Get("Origin"); origin != "" && r.URL.Path != "/api/ws" {
+		if u, err := url.Parse(origin); err != nil || u.Host != r.Host {
+			return httpError(http.StatusForbidden, "origin %s not allowed", origin)
+		}
+	}
+	if r.ContentLength != 0 && (r.Method == http.MethodPost || r.Method == http.MethodPut || r.Method == http.MethodPatch) {
+		mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if err != nil || mediaType != "application/json" {
+			return httpError(http.StatusUnsupportedMediaType, "Content-Type must be application/json")
+		}
+	}
+	return nil
+}
+
+// allowedServeHost reports whether the Host header of a request names this server:
+// localhost, the host of the listen address or one of the configured hosts
+func allowedServeHost(hostHeader string, options ServeConfig) bool {
+	host, _, err := net.SplitHostPort(hostHeader)
+	if err != nil {
+		host = hostHeader
+	}
+	host = strings.ToLower(strings.Trim(host, "[]"))
+	if isLoopbackHost(host) {
+		return true
+	}
+	listen := options.Listen
+	if listen == "" {
+		listen = defaultServeAddress
+	}
+	if listenHost, _, err := net.SplitHostPort(listen); err == nil && listenHost != "" {
+		if ip := net.ParseIP(listenHost); (ip == nil || !ip.IsUnspecified()) && strings.EqualFold(listenHost, host) {
+			return true
+		}
+	}
+	for _, allowed := range options.Hosts {
+		if strings.EqualFold(allowed, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// authenticate checks the token or basic authentication of a request and returns who
+// sent it. Without authentication configured, everyone is an admin.
+func authenticate(r *http.Request, options ServeConfig) (serveUser, bool) {
+	if !options.authEnabled() {
+		return serveUser{name: "admin", admin: true}, true
+	}
+	if user, password, ok := r.BasicAuth(); ok {
+		if options.Username != "" && secureEqual(user, options.Username) {
+			return serveUser{name: user, admin: true}, secureEqual(password, options.Password)
+		}
+		if expected, found := options.Users[user]; found {
+			return serveUser{name: user}, secureEqual(password, expected)
+		}
+		return serveUser{}, false
+	}
+	if options.Token == "" {
+		return serveUser{}, false
+	}
+	given := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if r.URL.Path == "/api/ws" && r.Header.Get("Authorization") == "" {
+		// Browsers can't set headers on WebSocket connections
+		given = r.URL.Query().Get("token")
+	}
+	return serveUser{name: "admin", admin: true}, secureEqual(given, options.Token)
+}
+
+// secureEqual compares credentials in constant time
+func secureEqual(given, expected string) bool {
+	return subtle.ConstantTimeCompare([]byte(given), []byte(expected)) == 1
+}
+
+// listServeJobs returns the queue items, only those with the given status if it is set
+func listServeJobs(status string) ([]ServeJob, error) {
+	state, err := downloadQueue.State()
+	if err != nil {
+		return nil, err
+	}
+	jobs := []ServeJob{}
+	for _, item := range state.Items {
+		if status == "" || item.Status == status {
+			jobs = append(jobs, newServeJob(item))
+		}
+	}
+	return jobs, nil
+}
+
+// submitServeJobs queues the downloads of a POST /api/jobs request
+func submitServeJobs(r *http.Request) ([]ServeJob, error) {
+	var req serveJobRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, httpError(http.StatusBadRequest, "invalid request: %v", err)
+	}
+	if req.Type != "album" && req.Type != "track" {
+		return nil, httpError(http.StatusBadRequest, "type must be \"album\" or \"track\"")
+	}
+	if req.Format != "" {
+		if err := ValidateFormat(req.Format); err != nil {
+			return nil, httpError(http.StatusBadRequest, "%v", err)
+		}
+	}
+	ids := req.IDs
+	if req.ID != "" {
+		ids = append([]string{req.ID}, ids...)
+	}
+	if len(ids) == 0 {
+		return nil, httpError(http.StatusBadRequest, "no id given")
+	}
+
+	// A client may pass its own correlation ID to find the jobs in the logs and events
+	correlationID := r.Header.Get("X-Correlation-ID")
+	if correlationID != "" && !validCorrelationID.MatchString(correlationID) {
+		return nil, httpError(http.StatusBadRequest, "invalid X-Correlation-ID, use up to 64 letters, digits, '.', '_' or '-'")
+	}
+	items := make([]*QueueItem, 0, len(ids))
+	for _, id := range ids {
+		items = append(items, &QueueItem{Type: req.Type, Value: id, Format: req.Format, Bitrate: req.Bitrate, CorrelationID: correlationID})
+	}
+	added, err := downloadQueue.Add(items...)
+	if err != nil {
+		return nil, err
+	}
+	if len(added) == 0 {
+		return nil, httpError(http.StatusConflict, "already queued")
+	}
+	wakeDaemon()
+
+	jobs := make([]ServeJob, 0, len(added))
+	for _, item := range added {
+		jobs = append(jobs, newServeJob(item))
+		emitEvent("job_queued", map[string]interface{}{"job_id": item.ID, "type": item.Type, "value": item.Value, "correlation_id": item.CorrelationID})
+	}
+	return jobs, nil
+}
+
+// getServeJob returns a single queue item
+func getServeJob(id int) (*ServeJob, error) {
+	state, err := downloadQueue.State()
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range state.Items {
+		if item.ID == id {
+			job := newServeJob(item)
+			return &job, nil
+		}
+	}
+	return nil, httpError(http.StatusNotFound, "no job with ID %d", id)
+}
+
+// cancelServeJob removes a pending job from the queue or stops a running one. A running
+// job is marked cancelled once its download has stopped.
+func cancelServeJob(id int) (*ServeJob, error) {
+	item, running, err := downloadQueue.Cancel(id)
+	if err != nil {
+		if item == nil {
+			return nil, httpError(http.StatusNotFound, "%v", err)
+		}
+		return nil, httpError(http.StatusConflict, "%v", err)
+	}
+	if running && !runningQueueItems.Cancel(id) {
+		return nil, httpError(http.StatusConflict, "job %d is being downloaded by another process", id)
+	}
+	if !running {
+		emitJobComplete(withCorrelationID(context.Background(), item.CorrelationID), id, nil, true)
+	}
+	return getServeJob(id)
+}
+
+func newServeJob(item *QueueItem) ServeJob {
+	job := ServeJob{QueueItem: item}
+	if item.Status == QueueRunning {
+		job.Progress = runningQueueItems.Progress(item.ID)
+	}
+	return job
+}
+
+// streamEvents sends every event to a WebSocket client as a JSON text message until the
+// client disconnects
+func streamEvents(ws *websocket.Conn) {
+	events, unsubscribe := subscribeEvents()
+	defer unsubscribe()
+
+	// Nothing is expected from the client, reading only notices when it goes away
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		var discard string
+		for websocket.Message.Receive(ws, &discard) == nil {
+		}
+	}()
+
+	for {
+		select {
+		case event := <-events:
+			if err := websocket.JSON.Send(ws, event); err != nil {
+				return
+			}
+		case <-closed:
+			return
+		}
+	}
+}
+
+// tokenAuthenticated reports whether a request authenticated with the token. Browsers
+// resend cached basic credentials to any page, but a token has to be given explicitly.
+func tokenAuthenticated(r *http.Request, options ServeConfig) bool {
+	if options.Token == "" {
+		return false
+	}
+	_, _, basic := r.BasicAuth()
+	return !basic
+}
+
+// checkWebSocketOrigin refuses connections from web pages of other sites, which browsers
+// allow for WebSockets, unless the connection authenticated with the token
+func checkWebSocketOrigin(r *http.Request, tokenAuthenticated bool) error {
+	origin := r.Header.Get("Origin")
+	if origin == "" || tokenAuthenticated {
+		return nil
+	}
+	u, err := url.Parse(origin)
+	if err != nil || u.Host != r.Host {
+		return fmt.Errorf("origin %s not allowed", origin)
+	}
+	return nil
+}
+
+// writeAPIResponse writes value as JSON, or err with its status code
+func writeAPIResponse(w http.ResponseWriter, value interface{}, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	if err != nil {
+		status := http.StatusInternalServerError
+		if httpErr, ok := err.(*serveHTTPError); ok {
+			status = httpErr.status
+		}
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	json.NewEncoder(w).Encode(value)
+}
+
+// isLoopbackHost reports whether a listen host only accepts local connections
+func isLoopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// serveRequest is a download request as returned by the REST API, with how far the
+// downloads of an approved request got
+type serveRequest struct {
+	*DownloadRequest
+	Downloaded int `json:"downloaded,omitempty"` // Queue items of the request done
+	Failed     int `json:"failed,omitempty"`
+}
+
+// newServeRequests adds the state of their queue items to requests
+func newServeRequests(requests ...*DownloadRequest) []serveRequest {
+	status := make(map[int]string)
+	if state, err := downloadQueue.State(); err == nil {
+		for _, item := range state.Items {
+			status[item.ID] = item.Status
+		}
+	}
+	views := make([]serveRequest, 0, len(requests))
+	for _, request := range requests {
+		view := serveRequest{DownloadRequest: request}
+		for _, job := range request.Jobs {
+			switch status[job] {
+			case QueueDone:
+				view.Downloaded++
+			case QueueFailed:
+				view.Failed++
+			}
+		}
+		views = append(views, view)
+	}
+	return views
+}
+
+// handleRequestPortal routes the request portal: users submit artists and albums with
+// POST /api/requests and follow them, admins approve or reject them
+func (api *DabAPI) handleRequestPortal(mux *http.ServeMux, config *Config) {
+	mux.HandleFunc("/api/requests", func(w http.ResponseWriter, r *http.Request) {
+		user := serveUserFrom(r)
+		switch r.Method {
+		case http.MethodGet:
+			owner := user.name
+			if user.admin {
+				owner = r.URL.Query().Get("user")
+			}
+			requests, err := downloadRequests.List(owner, r.URL.Query().Get("status"))
+			if err != nil {
+				writeAPIResponse(w, nil, err)
+				return
+			}
+			writeAPIResponse(w, newServeRequests(requests...), nil)
+		case http.MethodPost:
+			var body struct {
+				Type string `json:"type"`
+				ID   string `json:"id"`
+				Note string `json:"note,omitempty"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				writeAPIResponse(w, nil, httpError(http.StatusBadRequest, "invalid request: %v", err))
+				return
+			}
+			if body.Type != "artist" && body.Type != "album" {
+				writeAPIResponse(w, nil, httpError(http.StatusBadRequest, "type must be \"artist\" or \"album\""))
+				return
+			}
+			if body.ID == "" {
+				writeAPIResponse(w, nil, httpError(http.StatusBadRequest, "no id given"))
+				return
+			}
+			request, created, err := api.SubmitRequest(r.Context(), user.name, body.Type, body.ID, TruncateString(body.Note, 500), config)
+			if err != nil {
+				writeAPIResponse(w, nil, httpError(http.StatusBadRequest, "%v", err))
+				return
+			}
+			if created {
+				w.Header().Set("Location", fmt.Sprintf("/api/requests/%d", request.ID))
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusCreated)
+			}
+			writeAPIResponse(w, newServeRequests(request)[0], nil)
+		default:
+			writeAPIResponse(w, nil, httpError(http.StatusMethodNotAllowed, "method %s not allowed", r.Method))
+		}
+	})
+	mux.HandleFunc("/api/requests/", func(w http.ResponseWriter, r *http.Request) {
+		user := serveUserFrom(r)
+		idPart, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/requests/"), "/")
+		id, err := strconv.Atoi(idPart)
+		if err != nil {
+			writeAPIResponse(w, nil, httpError(http.StatusNotFound, "invalid request ID"))
+			return
+		}
+		request, err := downloadRequests.Get(id)
+		if err != nil || (!user.admin && request.User != user.name) {
+			writeAPIResponse(w, nil, httpError(http.StatusNotFound, "no request with ID %d", id))
+			return
+		}
+
+		switch {
+		case action == "" && r.Method == http.MethodGet:
+			writeAPIResponse(w, newServeRequests(request)[0], nil)
+		case action == "" && r.Method == http.MethodDelete:
+			// Users may withdraw their requests until they are decided
+			if !user.admin && request.Status != RequestPending {
+				writeAPIResponse(w, nil, httpError(http.StatusConflict, "request %d is already %s", id, request.Status))
+				return
+			}
+			if err := downloadRequests.Remove(id); err != nil {
+				writeAPIResponse(w, nil, err)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		case (action == "approve" || action == "reject") && r.Method == http.MethodPost:
+			if !user.admin {
+				writeAPIResponse(w, nil, httpError(http.StatusForbidden, "only admins may %s requests", action))
+				return
+			}
+			var body struct {
+				Filter string `json:"filter,omitempty"` // Releases of an artist to queue, like --filter
+				Reason string `json:"reason,omitempty"`
+			}
+			if r.ContentLength != 0 {
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					writeAPIResponse(w, nil, httpError(http.StatusBadRequest, "invalid request: %v", err))
+					return
+				}
+			}
+			if action == "approve" {
+				request, err = api.ApproveRequest(r.Context(), id, body.Filter, user.name, config)
+			} else {
+				request, err = RejectRequest(id, body.Reason, user.name)
+			}
+			if err != nil {
+				writeAPIResponse(w, nil, httpError(http.StatusConflict, "%v", err))
+				return
+			}
+			writeAPIResponse(w, newServeRequests(request)[0], nil)
+		case action != "" && action != "approve" && action != "reject":
+			writeAPIResponse(w, nil, httpError(http.StatusNotFound, "unknown action '%s'", action))
+		default:
+			writeAPIResponse(w, nil, httpError(http.StatusMethodNotAllowed, "method %s not allowed", r.Method))
+		}
+	})
+}
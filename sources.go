@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+
+	"golang.org/x/sync/semaphore"
+)
+
+// SourceLimits caps the concurrent requests to each external service, so cover downloads
+// or MusicBrainz lookups can't take the connections audio streams need. 0 means unlimited.
+type SourceLimits struct {
+	DAB         int `json:"dab,omitempty"`         // DAB API calls (search, album, stream URL)
+	Streams     int `json:"streams,omitempty"`     // Audio downloads
+	Covers      int `json:"covers,omitempty"`      // Cover and artist images
+	MusicBrainz int `json:"musicbrainz,omitempty"` // MusicBrainz lookups
+}
+
+// sourceLimit is a concurrency cap for one service, nil means unlimited
+type sourceLimit struct {
+	sem *semaphore.Weighted
+}
+
+// Limits used by the download code, configured by ConfigureSourceLimits
+var (
+	dabLimit         *sourceLimit
+	streamLimit      *sourceLimit
+	coverLimit       *sourceLimit
+	musicBrainzLimit *sourceLimit
+)
+
+// ConfigureSourceLimits applies the per-service limits from the config
+func ConfigureSourceLimits(limits *SourceLimits) {
+	if limits == nil {
+		limits = &SourceLimits{}
+	}
+	dabLimit = newSourceLimit(limits.DAB)
+	streamLimit = newSourceLimit(limits.Streams)
+	coverLimit = newSourceLimit(limits.Covers)
+	musicBrainzLimit = newSourceLimit(limits.MusicBrainz)
+}
+
+func newSourceLimit(n int) *sourceLimit {
+	if n <= 0 {
+		return nil
+	}
+	return &sourceLimit{sem: semaphore.NewWeighted(int64(n))}
+}
+
+// acquire waits for a free slot. The returned function releases it and is never nil.
+func (l *sourceLimit) acquire(ctx context.Context) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+	if err := l.sem.Acquire(ctx, 1); err != nil {
+		return func() {}, err
+	}
+	return func() { l.sem.Release(1) }, nil
+}
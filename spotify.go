@@ -11,17 +11,21 @@ import (
 	"golang.org/x/oauth2/clientcredentials"
 )
 
-// SpotifyTrack represents a track from Spotify
+// SpotifyTrack represents a track from Spotify or another imported playlist
 type SpotifyTrack struct {
+	ID          string
+	Source      string // Service the track was imported from, empty for Spotify
 	Name        string
 	Artist      string
 	AlbumName   string
 	AlbumArtist string
+	AlbumType   string // "album", "single" or "compilation" where the source reports it
+	DurationSec int
 }
 
 // Authenticate authenticates the client with the spotify api
 func (s *SpotifyClient) Authenticate() error {
-	ctx := context.Background()
+	ctx := oauthContext(context.Background())
 	config := &clientcredentials.Config{
 		ClientID:     s.ID,
 		ClientSecret: s.Secret,
@@ -65,10 +69,13 @@ func (s *SpotifyClient) GetPlaylistTracks(playlistURL string) ([]SpotifyTrack, s
 			albumName := item.Track.Album.Name
 			albumArtist := item.Track.Album.Artists[0].Name
 			tracks = append(tracks, SpotifyTrack{
+				ID:          item.Track.ID.String(),
 				Name:        trackName,
 				Artist:      artistName,
 				AlbumName:   albumName,
 				AlbumArtist: albumArtist,
+				AlbumType:   item.Track.Album.AlbumType,
+				DurationSec: int(item.Track.Duration / 1000),
 			}) // Updated append
 		}
 
@@ -106,12 +113,32 @@ func (s *SpotifyClient) GetAlbumTracks(albumURL string) ([]SpotifyTrack, string,
 		trackName := track.Name
 		artistName := track.Artists[0].Name
 		tracks = append(tracks, SpotifyTrack{
+			ID:          track.ID.String(),
 			Name:        trackName,
 			Artist:      artistName,
 			AlbumName:   album.Name,
 			AlbumArtist: album.Artists[0].Name,
+			AlbumType:   album.AlbumType,
+			DurationSec: int(track.Duration / 1000),
 		})
 	}
 
 	return tracks, album.Name, nil
 }
+
+// GetArtistImage returns the URL of the largest image of the best matching Spotify artist
+func (s *SpotifyClient) GetArtistImage(name string) (string, error) {
+	results, err := s.client.Search(context.Background(), name, spotify.SearchTypeArtist, spotify.Limit(1))
+	if err != nil {
+		return "", err
+	}
+	if results.Artists == nil || len(results.Artists.Artists) == 0 {
+		return "", fmt.Errorf("no Spotify artist found for %s", name)
+	}
+	artist := results.Artists.Artists[0]
+	if !strings.EqualFold(artist.Name, name) || len(artist.Images) == 0 {
+		return "", fmt.Errorf("no Spotify image found for %s", name)
+	}
+	// Spotify lists images widest first
+	return artist.Images[0].URL, nil
+}
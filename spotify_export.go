@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/zmb3/spotify/v2"
+	spotifyauth "github.com/zmb3/spotify/v2/auth"
+)
+
+const (
+	spotifyPlaylistChunk    = 100 // Tracks per playlist change, the Spotify API maximum
+	spotifyExportSimilarity = 0.8 // Title similarity a search result needs to count as a match
+)
+
+// spotifyPlaylistScopes are the permissions needed to find, create and update playlists
+var spotifyPlaylistScopes = []string{
+	spotifyauth.ScopePlaylistReadPrivate,
+	spotifyauth.ScopePlaylistModifyPrivate,
+	spotifyauth.ScopePlaylistModifyPublic,
+}
+
+// ExportTrack is a downloaded track to look up on Spotify
+type ExportTrack struct {
+	Title  string
+	Artist string
+	ISRC   string
+}
+
+// ExportTracksFromFolder reads the tracks to export from the tags of the FLAC files below
+// root, in path order. Files without title or artist are skipped.
+func ExportTracksFromFolder(root string) ([]ExportTrack, error) {
+	var paths []string
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && d.Name() == quarantineDirName {
+			return filepath.SkipDir
+		}
+		if !d.IsDir() && strings.EqualFold(filepath.Ext(path), ".flac") {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	var tracks []ExportTrack
+	for _, path := range paths {
+		tags, err := readVorbisTags(path)
+		if err != nil || len(tags["TITLE"]) == 0 || len(tags["ARTIST"]) == 0 {
+			colorWarning.Printf("⚠️ Skipping %s: no title or artist tag\n", path)
+			continue
+		}
+		track := ExportTrack{Title: tags["TITLE"][0], Artist: tags["ARTIST"][0]}
+		if len(tags["ISRC"]) > 0 {
+			track.ISRC = tags["ISRC"][0]
+		}
+		tracks = append(tracks, track)
+	}
+	return tracks, nil
+}
+
+// ExportTracksFromNavidrome returns the tracks of a Navidrome playlist
+func ExportTracksFromNavidrome(client *NavidromeClient, playlistName string) ([]ExportTrack, error) {
+	playlistID, err := client.SearchPlaylist(playlistName)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := client.GetPlaylistTracks(playlistID)
+	if err != nil {
+		return nil, err
+	}
+	var tracks []ExportTrack
+	for _, entry := range entries {
+		tracks = append(tracks, ExportTrack{Title: entry.Title, Artist: entry.Artist})
+	}
+	return tracks, nil
+}
+
+// errNoSpotifyMatch is returned by FindSpotifyTrack when a search found nothing suitable,
+// unlike a failed search
+var errNoSpotifyMatch = errors.New("no Spotify match")
+
+// FindSpotifyTrack returns the Spotify track of a downloaded track, by ISRC when it is
+// known and by title and artist otherwise. Requires AuthenticateUser.
+func (s *SpotifyClient) FindSpotifyTrack(ctx context.Context, track ExportTrack) (spotify.ID, error) {
+	var queries []string
+	if track.ISRC != "" {
+		queries = append(queries, "isrc:"+track.ISRC)
+	}
+	queries = append(queries, fmt.Sprintf("track:%s artist:%s", track.Title, track.Artist))
+
+	for _, query := range queries {
+		results, err := s.client.Search(ctx, query, spotify.SearchTypeTrack, spotify.Limit(5))
+		if err != nil {
+			return "", err
+		}
+		if results.Tracks == nil {
+			continue
+		}
+		for _, candidate := range results.Tracks.Tracks {
+			if len(candidate.Artists) == 0 || !sameName(candidate.Artists[0].Name, track.Artist) {
+				continue
+			}
+			if textSimilarity(candidate.Name, track.Title) >= spotifyExportSimilarity {
+				return candidate.ID, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("%w for %s - %s", errNoSpotifyMatch, track.Title, track.Artist)
+}
+
+// SyncSpotifyPlaylist makes the user's playlist called name contain exactly trackIDs,
+// creating it when the user has no playlist of that name. It reports whether the playlist
+// was created. An existing playlist isn't emptied or shrunk to less than half its size
+// unless force is set. Requires AuthenticateUser with spotifyPlaylistScopes.
+func (s *SpotifyClient) SyncSpotifyPlaylist(ctx context.Context, name string, trackIDs []spotify.ID, public, force bool) (bool, error) {
+	user, err := s.client.CurrentUser(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	var playlistID spotify.ID
+	var existing int
+	page, err := s.client.CurrentUsersPlaylists(ctx, spotify.Limit(50))
+	for err == nil && playlistID == "" {
+		for _, playlist := range page.Playlists {
+			if playlist.Name == name && playlist.Owner.ID == user.ID {
+				playlistID = playlist.ID
+				existing = int(playlist.Tracks.Total)
+				break
+			}
+		}
+		if playlistID == "" {
+			err = s.client.NextPage(ctx, page)
+		}
+	}
+	if err != nil && err != spotify.ErrNoMorePages {
+		return false, err
+	}
+
+	if playlistID != "" && !force && existing > 0 && len(trackIDs) < (existing+1)/2 {
+		return false, fmt.Errorf("the playlist has %d tracks and would be cut to %d, pass --force to replace it anyway", existing, len(trackIDs))
+	}
+
+	created := false
+	if playlistID == "" {
+		playlist, err := s.client.CreatePlaylistForUser(ctx, user.ID, name, "Exported by dab-downloader", public, false)
+		if err != nil {
+			return false, err
+		}
+		playlistID = playlist.ID
+		created = true
+	}
+
+	// Replacing clears the playlist and sets the first chunk, the rest is appended
+	first := trackIDs
+	if len(first) > spotifyPlaylistChunk {
+		first = first[:spotifyPlaylistChunk]
+	}
+	if err := s.client.ReplacePlaylistTracks(ctx, playlistID, first...); err != nil {
+		return created, err
+	}
+	for start := len(first); start < len(trackIDs); start += spotifyPlaylistChunk {
+		end := start + spotifyPlaylistChunk
+		if end > len(trackIDs) {
+			end = len(trackIDs)
+		}
+		if _, err := s.client.AddTracksToPlaylist(ctx, playlistID, trackIDs[start:end]...); err != nil {
+			return created, err
+		}
+	}
+	return created, nil
+}
+
+// ExportToSpotify matches tracks on Spotify and writes the matches to the playlist called
+// name, in the order of tracks. Tracks without a match are listed and left out. A failed
+// search, e.g. after rate limiting, stops the export before the playlist is touched.
+func (s *SpotifyClient) ExportToSpotify(ctx context.Context, name string, tracks []ExportTrack, public, force bool) error {
+	var ids []spotify.ID
+	seen := make(map[spotify.ID]bool)
+	var unmatched []string
+	for _, track := range tracks {
+		id, err := s.FindSpotifyTrack(ctx, track)
+		if err != nil && !errors.Is(err, errNoSpotifyMatch) {
+			return fmt.Errorf("search for %s - %s failed, playlist '%s' left unchanged: %w", track.Title, track.Artist, name, err)
+		}
+		if err != nil {
+			unmatched = append(unmatched, fmt.Sprintf("%s - %s", track.Title, track.Artist))
+			continue
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	created, err := s.SyncSpotifyPlaylist(ctx, name, ids, public, force)
+	if err != nil {
+		return fmt.Errorf("failed to update Spotify playlist '%s': %w", name, err)
+	}
+	if created {
+		colorSuccess.Printf("✅ Created Spotify playlist '%s' with %d tracks\n", name, len(ids))
+	} else {
+		colorSuccess.Printf("✅ Updated Spotify playlist '%s' to %d tracks\n", name, len(ids))
+	}
+	if len(unmatched) > 0 {
+		colorWarning.Printf("⚠️ %d tracks have no match on Spotify:\n", len(unmatched))
+		for _, track := range unmatched {
+			colorWarning.Printf("   - %s\n", track)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/zmb3/spotify/v2"
+	spotifyauth "github.com/zmb3/spotify/v2/auth"
+	"golang.org/x/oauth2"
+)
+
+const (
+	defaultSpotifyRedirectURL = "http://127.0.0.1:8888/callback"
+	spotifyLoginTimeout       = 5 * time.Minute
+)
+
+// spotifyTokenPath holds the user's Spotify tokens. It is kept out of config.json and is
+// only readable by the owner, since the refresh token grants access to the account.
+var spotifyTokenPath = filepath.Join("config", "spotify_token.json")
+
+// spotifyLibraryScopes are the permissions needed to read liked songs and saved albums
+var spotifyLibraryScopes = []string{spotifyauth.ScopeUserLibraryRead}
+
+// spotifyUserToken is the stored token together with the scopes it was granted for
+type spotifyUserToken struct {
+	Token  *oauth2.Token `json:"token"`
+	Scopes []string      `json:"scopes"`
+}
+
+// covers reports whether the token was granted every scope in scopes
+func (t *spotifyUserToken) covers(scopes []string) bool {
+	granted := make(map[string]bool)
+	for _, scope := range t.Scopes {
+		granted[scope] = true
+	}
+	for _, scope := range scopes {
+		if !granted[scope] {
+			return false
+		}
+	}
+	return true
+}
+
+// AuthenticateUser authorizes the client to act for a Spotify user with the given scopes.
+// A stored refresh token is used when it covers the scopes; otherwise the user is asked to
+// log in through the browser with the authorization code flow and PKCE. redirectURL must be
+// registered for the app in the Spotify dashboard; empty uses http://127.0.0.1:8888/callback.
+func (s *SpotifyClient) AuthenticateUser(ctx context.Context, redirectURL string, scopes ...string) error {
+	if redirectURL == "" {
+		redirectURL = defaultSpotifyRedirectURL
+	}
+	ctx = oauthContext(ctx)
+	auth := spotifyauth.New(
+		spotifyauth.WithClientID(s.ID),
+		spotifyauth.WithClientSecret(s.Secret),
+		spotifyauth.WithRedirectURL(redirectURL),
+		spotifyauth.WithScopes(scopes...),
+	)
+
+	if stored, err := loadSpotifyToken(); err == nil && stored.covers(scopes) {
+		client := spotify.New(auth.Client(ctx, stored.Token))
+		// Refreshes the access token now, so a revoked login falls back to a new one
+		if token, err := client.Token(); err == nil {
+			s.client = client
+			if token.AccessToken != stored.Token.AccessToken {
+				if err := saveSpotifyToken(&spotifyUserToken{Token: token, Scopes: stored.Scopes}); err != nil {
+					colorWarning.Printf("⚠️ Failed to save Spotify login: %v\n", err)
+				}
+			}
+			return nil
+		}
+		colorWarning.Println("⚠️ Stored Spotify login is no longer valid, please log in again")
+	}
+
+	token, err := spotifyLogin(ctx, auth, redirectURL)
+	if err != nil {
+		return err
+	}
+	if err := saveSpotifyToken(&spotifyUserToken{Token: token, Scopes: scopes}); err != nil {
+		colorWarning.Printf("⚠️ Failed to save Spotify login: %v\n", err)
+	}
+	s.client = spotify.New(auth.Client(ctx, token))
+	return nil
+}
+
+// spotifyLogin runs the authorization code flow with PKCE: the user opens the login URL,
+// Spotify redirects to a local listener with the code, and the code is exchanged for tokens
+func spotifyLogin(ctx context.Context, auth *spotifyauth.Authenticator, redirectURL string) (*oauth2.Token, error) {
+	redirect, err := url.Parse(redirectURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Spotify redirect URL: %w", err)
+	}
+	verifier, err := randomURLString(64)
+	if err != nil {
+		return nil, err
+	}
+	state, err := randomURLString(16)
+	if err != nil {
+		return nil, err
+	}
+	challenge := sha256.Sum256([]byte(verifier))
+
+	listener, err := net.Listen("tcp", redirect.Host)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen for the Spotify login on %s: %w", redirect.Host, err)
+	}
+
+	type result struct {
+		token *oauth2.Token
+		err   error
+	}
+	results := make(chan result, 1)
+	mux := http.NewServeMux()
+	mux.HandleFunc(redirect.Path, func(w http.ResponseWriter, r *http.Request) {
+		token, err := auth.Token(oauthContext(r.Context()), state, r, oauth2.SetAuthURLParam("code_verifier", verifier))
+		if err != nil {
+			http.Error(w, "Spotify login failed: "+err.Error(), http.StatusBadRequest)
+		} else {
+			fmt.Fprintln(w, "Spotify login complete, you can close this window.")
+		}
+		select {
+		case results <- result{token, err}:
+		default:
+		}
+	})
+	server := &http.Server{Handler: mux}
+	go server.Serve(listener)
+	defer server.Close()
+
+	loginURL := auth.AuthURL(state,
+		oauth2.SetAuthURLParam("code_challenge_method", "S256"),
+		oauth2.SetAuthURLParam("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:])),
+	)
+	colorInfo.Println("🔑 Open this URL in your browser to log in to Spotify:")
+	fmt.Println(loginURL)
+
+	select {
+	case res := <-results:
+		if res.err != nil {
+			return nil, fmt.Errorf("Spotify login failed: %w", res.err)
+		}
+		colorSuccess.Println("✅ Logged in to Spotify")
+		return res.token, nil
+	case <-time.After(spotifyLoginTimeout):
+		return nil, fmt.Errorf("Spotify login timed out after %s", spotifyLoginTimeout)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// randomURLString returns n random bytes encoded for use in URLs
+func randomURLString(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func loadSpotifyToken() (*spotifyUserToken, error) {
+	data, err := os.ReadFile(spotifyTokenPath)
+	if err != nil {
+		return nil, err
+	}
+	var stored spotifyUserToken
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, err
+	}
+	if stored.Token == nil || stored.Token.RefreshToken == "" {
+		return nil, fmt.Errorf("no refresh token in %s", spotifyTokenPath)
+	}
+	return &stored, nil
+}
+
+func saveSpotifyToken(stored *spotifyUserToken) error {
+	data, err := json.MarshalIndent(stored, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(spotifyTokenPath), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(spotifyTokenPath, data, 0600); err != nil {
+		return err
+	}
+	// WriteFile keeps the mode of an existing file
+	return os.Chmod(spotifyTokenPath, 0600)
+}
+
+// GetLikedTracks returns the user's liked songs. Requires AuthenticateUser.
+func (s *SpotifyClient) GetLikedTracks(ctx context.Context) ([]SpotifyTrack, error) {
+	page, err := s.client.CurrentUsersTracks(ctx, spotify.Limit(50))
+	if err != nil {
+		return nil, err
+	}
+	var tracks []SpotifyTrack
+	for {
+		for _, item := range page.Tracks {
+			if len(item.Artists) == 0 || len(item.Album.Artists) == 0 {
+				continue
+			}
+			tracks = append(tracks, SpotifyTrack{
+				ID:          item.ID.String(),
+				Name:        item.Name,
+				Artist:      item.Artists[0].Name,
+				AlbumName:   item.Album.Name,
+				AlbumArtist: item.Album.Artists[0].Name,
+				DurationSec: int(item.Duration / 1000),
+			})
+		}
+		err = s.client.NextPage(ctx, page)
+		if err == spotify.ErrNoMorePages {
+			return tracks, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// GetSavedAlbums returns one entry per album saved in the user's library, enough to find
+// the albums on DAB. Requires AuthenticateUser.
+func (s *SpotifyClient) GetSavedAlbums(ctx context.Context) ([]SpotifyTrack, error) {
+	page, err := s.client.CurrentUsersAlbums(ctx, spotify.Limit(50))
+	if err != nil {
+		return nil, err
+	}
+	var albums []SpotifyTrack
+	for {
+		for _, item := range page.Albums {
+			if len(item.Artists) == 0 {
+				continue
+			}
+			albums = append(albums, SpotifyTrack{
+				ID:          item.ID.String(),
+				Name:        item.Name,
+				Artist:      item.Artists[0].Name,
+				AlbumName:   item.Name,
+				AlbumArtist: item.Artists[0].Name,
+			})
+		}
+		err = s.client.NextPage(ctx, page)
+		if err == spotify.ErrNoMorePages {
+			return albums, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// statusCheckTimeout bounds each service check, so one unreachable service doesn't hold
+// up the whole report
+const statusCheckTimeout = 10 * time.Second
+
+// ServiceStatus is the result of checking one service
+type ServiceStatus struct {
+	Name     string
+	Endpoint string
+	Detail   string // Version, login or queue information
+	Skipped  string // Why the service wasn't checked, e.g. not configured
+	Err      error
+	Latency  time.Duration
+}
+
+// CheckServices checks DAB, Spotify, Navidrome, MusicBrainz and the daemon at the same time
+func (api *DabAPI) CheckServices(ctx context.Context, config *Config) []ServiceStatus {
+	checks := []func(ctx context.Context) ServiceStatus{
+		func(ctx context.Context) ServiceStatus { return api.checkDAB(ctx, config) },
+		func(ctx context.Context) ServiceStatus { return checkSpotify(config) },
+		func(ctx context.Context) ServiceStatus { return checkNavidrome(config) },
+		func(ctx context.Context) ServiceStatus { return checkMusicBrainz(config) },
+		checkDaemon,
+	}
+	names := []string{"DAB", "Spotify", "Navidrome", "MusicBrainz", "Daemon"}
+
+	results := make([]ServiceStatus, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check func(ctx context.Context) ServiceStatus) {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, statusCheckTimeout)
+			defer cancel()
+
+			done := make(chan ServiceStatus, 1)
+			start := time.Now()
+			go func() { done <- check(checkCtx) }()
+			select {
+			case status := <-done:
+				if status.Skipped == "" {
+					status.Latency = time.Since(start)
+				}
+				results[i] = status
+			case <-checkCtx.Done():
+				// Some clients retry without a context, so a slow check is abandoned
+				results[i] = ServiceStatus{Name: names[i], Err: fmt.Errorf("no answer within %s", statusCheckTimeout)}
+			}
+		}(i, check)
+	}
+	wg.Wait()
+	return results
+}
+
+func (api *DabAPI) checkDAB(ctx context.Context, config *Config) ServiceStatus {
+	status := ServiceStatus{Name: "DAB", Endpoint: config.APIURL}
+	switch {
+	case config.APIToken != "":
+		status.Detail = "bearer token"
+	case config.APICookie != "":
+		status.Detail = "cookie"
+	}
+	// A search needs the same authentication as downloads, unlike the base URL. It is sent
+	// directly, as Search may answer from the cache or the daemon.
+	resp, err := api.Request(ctx, "api/search", true, []QueryParam{
+		{Name: "q", Value: defaultSelfTestQuery},
+		{Name: "type", Value: "artist"},
+		{Name: "limit", Value: "1"},
+	})
+	if err != nil {
+		status.Err = err
+		return status
+	}
+	resp.Body.Close()
+	return status
+}
+
+func checkSpotify(config *Config) ServiceStatus {
+	status := ServiceStatus{Name: "Spotify", Endpoint: "api.spotify.com"}
+	if config.SpotifyClientID == "" || config.SpotifyClientSecret == "" {
+		status.Skipped = "no client ID or secret configured"
+		return status
+	}
+	if err := NewSpotifyClient(config.SpotifyClientID, config.SpotifyClientSecret).Authenticate(); err != nil {
+		status.Err = err
+		return status
+	}
+	status.Detail = "app credentials valid, no user login"
+	if stored, err := loadSpotifyToken(); err == nil {
+		status.Detail = fmt.Sprintf("app credentials valid, user login stored (%d scopes)", len(stored.Scopes))
+	}
+	return status
+}
+
+func checkNavidrome(config *Config) ServiceStatus {
+	status := ServiceStatus{Name: "Navidrome", Endpoint: config.NavidromeURL}
+	if config.NavidromeURL == "" {
+		status.Skipped = "no URL configured"
+		return status
+	}
+	query := subsonicAuth(config.NavidromeUsername, config.NavidromePassword)
+	query.Set("v", "1.16.1")
+	query.Set("c", "dab-downloader")
+	query.Set("f", "json")
+	resp, err := newHTTPClient(statusCheckTimeout).Get(config.NavidromeURL + "/rest/ping.view?" + query.Encode())
+	if err != nil {
+		// The URL of the error holds the credentials
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		status.Err = err
+		return status
+	}
+	defer resp.Body.Close()
+
+	var ping struct {
+		SubsonicResponse struct {
+			Status        string `json:"status"`
+			Version       string `json:"version"`
+			Type          string `json:"type"`
+			ServerVersion string `json:"serverVersion"`
+			Error         struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		} `json:"subsonic-response"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&ping); err != nil {
+		status.Err = fmt.Errorf("not a Subsonic API (HTTP %d)", resp.StatusCode)
+		return status
+	}
+	r := ping.SubsonicResponse
+	if r.Status != "ok" {
+		status.Err = fmt.Errorf("login failed: %s", r.Error.Message)
+		return status
+	}
+	status.Detail = "API " + r.Version
+	if r.ServerVersion != "" {
+		status.Detail = fmt.Sprintf("%s %s, API %s", r.Type, r.ServerVersion, r.Version)
+	}
+	return status
+}
+
+func checkMusicBrainz(config *Config) ServiceStatus {
+	status := ServiceStatus{Name: "MusicBrainz", Endpoint: mbClient.baseURL}
+	if config.DisableMusicBrainz {
+		status.Skipped = "disabled"
+		return status
+	}
+	if config.MusicBrainzURL != "" {
+		status.Detail = "mirror"
+	}
+	_, status.Err = mbClient.get("genre/all?limit=1")
+	return status
+}
+
+func checkDaemon(ctx context.Context) ServiceStatus {
+	status := ServiceStatus{Name: "Daemon", Endpoint: daemonSocketPath}
+	daemon := connectDaemon()
+	if daemon == nil {
+		status.Skipped = "not running"
+		return status
+	}
+	info, err := daemon.Status(ctx)
+	if err != nil {
+		status.Err = err
+		return status
+	}
+	status.Detail = fmt.Sprintf("pid %d, up %s, %d queued, %d downloading, %s downloaded",
+		info.PID, time.Since(info.StartedAt).Round(time.Second), info.Pending, info.Running, FormatBytes(info.DownloadedBytes))
+	if len(info.Throughput) > 0 {
+		status.Detail += fmt.Sprintf(", avg %s/s, peak %s/s", FormatBytes(int64(info.AverageRate)), FormatBytes(int64(info.PeakRate)))
+		if !plainOutput {
+			status.Detail += " " + Sparkline(info.Throughput)
+		}
+	}
+	return status
+}
+
+// PrintServiceStatus lists the service checks, one line each
+func PrintServiceStatus(statuses []ServiceStatus) {
+	for _, s := range statuses {
+		switch {
+		case s.Skipped != "":
+			colorInfo.Printf("➖ %-12s %s\n", s.Name, s.Skipped)
+		case s.Err != nil:
+			colorError.Printf("❌ %-12s %s: %v\n", s.Name, s.Endpoint, s.Err)
+		default:
+			line := fmt.Sprintf("✅ %-12s %s (%d ms)", s.Name, s.Endpoint, s.Latency.Milliseconds())
+			if s.Detail != "" {
+				line += ", " + s.Detail
+			}
+			colorSuccess.Println(line)
+		}
+	}
+}
+
+// PrintLocalStatus shows the version, tools and the size of the library and local stores
+func PrintLocalStatus(config *Config) {
+	colorInfo.Printf("📦 dab-downloader %s (%s, %s/%s)\n", toolVersion, runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	if CheckFFmpeg() {
+		fmt.Println("   ffmpeg:       installed")
+	} else {
+		fmt.Println("   ffmpeg:       not installed (needed for conversion and ReplayGain)")
+	}
+
+	files, size := directorySize(config.DownloadLocation)
+	fmt.Printf("   Library:      %s, %d files, %s\n", config.DownloadLocation, files, FormatBytes(size))
+	fmt.Printf("   History:      %d tracks\n", len(downloadHistory.Search("")))
+	fmt.Printf("   Album lists:  %d albums\n", len(albumTracklists.IDs()))
+	if state, err := downloadQueue.State(); err == nil {
+		pending := 0
+		for _, item := range state.Items {
+			if item.Status == QueuePending || item.Status == QueueRunning {
+				pending++
+			}
+		}
+		fmt.Printf("   Queue:        %d items, %d not finished\n", len(state.Items), pending)
+	}
+	for _, store := range []struct{ name, path string }{
+		{"Search cache", filepath.Join("config", "search_cache.json")},
+		{"Match memory", filepath.Join("config", "matches.json")},
+	} {
+		if info, err := os.Stat(store.path); err == nil {
+			fmt.Printf("   %-13s %s\n", store.name+":", FormatBytes(info.Size()))
+		}
+	}
+}
+
+// directorySize returns the number of files below root and their total size. The
+// quarantine folder is left out.
+func directorySize(root string) (int, int64) {
+	files, size := 0, int64(0)
+	filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if d.Name() == quarantineDirName {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			files++
+			size += info.Size()
+		}
+		return nil
+	})
+	return files, size
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// summaryOnly hides all messages and prints a table of the downloaded items at the end,
+// set by --summary-only
+var summaryOnly bool
+
+// summaryRow is one downloaded album or track of the summary table
+type summaryRow struct {
+	name    string
+	result  string
+	size    int64
+	started time.Time
+	elapsed time.Duration
+}
+
+// runSummary collects the rows of the summary table from download events
+type runSummary struct {
+	mu     sync.Mutex
+	rows   []*summaryRow
+	albums map[string]*summaryRow // Albums being downloaded, by ID
+	out    io.Writer              // The real stdout
+	stop   func()
+	flush  chan struct{}
+	done   chan struct{}
+}
+
+// summary is set while --summary-only is in effect
+var summary *runSummary
+
+// enableSummaryOutput silences stdout and colored output except errors, which go to
+// stderr, and starts collecting the summary printed by printSummary
+func enableSummaryOutput() {
+	if summary != nil {
+		return
+	}
+	events, stop := subscribeEvents()
+	summary = &runSummary{albums: make(map[string]*summaryRow), out: os.Stdout, stop: stop, flush: make(chan struct{}), done: make(chan struct{})}
+	if devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0); err == nil {
+		os.Stdout = devNull
+	}
+	errorOutput = color.Error
+	color.Output = io.Discard
+	go summary.collect(events)
+}
+
+func (s *runSummary) collect(events <-chan map[string]interface{}) {
+	defer close(s.done)
+	for {
+		select {
+		case event := <-events:
+			s.add(event)
+		case <-s.flush:
+			// Nothing is sent anymore, take what is still buffered
+			for {
+				select {
+				case event := <-events:
+					s.add(event)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (s *runSummary) add(event map[string]interface{}) {
+	field := func(key string) string {
+		value, _ := event[key].(string)
+		return value
+	}
+	count := func(key string) int {
+		value, _ := event[key].(int)
+		return value
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch event["event"] {
+	case "album_start":
+		row := &summaryRow{name: field("artist") + " - " + field("title"), result: "downloading", started: time.Now()}
+		s.albums[field("album_id")] = row
+		s.rows = append(s.rows, row)
+	case "track_complete":
+		var size int64
+		if info, err := os.Stat(field("path")); err == nil {
+			size = info.Size()
+		}
+		if row, ok := s.albums[field("album_id")]; ok {
+			row.size += size
+			return
+		}
+		s.rows = append(s.rows, &summaryRow{name: field("artist") + " - " + field("title"), result: "downloaded", size: size})
+	case "album_complete":
+		row, ok := s.albums[field("album_id")]
+		if !ok {
+			return
+		}
+		delete(s.albums, field("album_id"))
+		row.elapsed = time.Since(row.started)
+		var parts []string
+		for _, key := range []string{"downloaded", "skipped", "failed", "unavailable"} {
+			if n := count(key); n > 0 {
+				parts = append(parts, fmt.Sprintf("%d %s", n, key))
+			}
+		}
+		row.result = strings.Join(parts, ", ")
+		if row.result == "" {
+			row.result = "nothing to do"
+		}
+	case "error":
+		s.rows = append(s.rows, &summaryRow{name: field("command") + " " + field("target"), result: "error: " + field("message")})
+	}
+}
+
+// printSummary prints the summary table of the run when --summary-only is used
+func printSummary() {
+	if summary == nil {
+		return
+	}
+	summary.stop()
+	close(summary.flush)
+	<-summary.done
+
+	summary.mu.Lock()
+	defer summary.mu.Unlock()
+	out := summary.out
+	if len(summary.rows) == 0 {
+		fmt.Fprintln(out, "Nothing was downloaded")
+		return
+	}
+	// The result goes last, so error messages don't need cutting
+	fmt.Fprintf(out, "%-45s %10s %8s  %s\n", "ITEM", "SIZE", "TIME", "RESULT")
+	var total int64
+	for _, row := range summary.rows {
+		size, elapsed := "-", "-"
+		if row.size > 0 {
+			size = FormatBytes(row.size)
+			total += row.size
+		}
+		if row.elapsed > 0 {
+			elapsed = row.elapsed.Round(time.Second).String()
+		}
+		fmt.Fprintf(out, "%-45s %10s %8s  %s\n", TruncateString(row.name, 45), size, elapsed, row.result)
+	}
+	fmt.Fprintf(out, "%d item(s), %s\n", len(summary.rows), FormatBytes(total))
+}
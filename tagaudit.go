@@ -0,0 +1,235 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/go-flac/flacvorbis"
+	"github.com/go-flac/go-flac"
+)
+
+// picardTags are the tags a full Picard run writes to every track of a release
+var picardTags = []string{
+	"TITLE", "ARTIST", "ARTISTS", "ARTISTSORT", "ALBUM", "ALBUMARTIST", "ALBUMARTISTSORT",
+	"DATE", "ORIGINALDATE", "TRACKNUMBER", "TOTALTRACKS", "DISCNUMBER", "TOTALDISCS",
+	"ISRC", "MEDIA", "LABEL", "CATALOGNUMBER", "BARCODE", "SCRIPT",
+	"RELEASETYPE", "RELEASESTATUS", "RELEASECOUNTRY",
+	"MUSICBRAINZ_TRACKID", "MUSICBRAINZ_ARTISTID", "MUSICBRAINZ_ALBUMID",
+	"MUSICBRAINZ_ALBUMARTISTID", "MUSICBRAINZ_RELEASEGROUPID", "MUSICBRAINZ_RELEASETRACKID",
+}
+
+// picardAlbumTags must have the same value on every track of an album
+var picardAlbumTags = []string{
+	"ALBUM", "ALBUMARTIST", "DATE", "ORIGINALDATE", "TOTALDISCS", "LABEL", "CATALOGNUMBER", "BARCODE",
+	"RELEASETYPE", "RELEASESTATUS", "RELEASECOUNTRY",
+	"MUSICBRAINZ_ALBUMID", "MUSICBRAINZ_ALBUMARTISTID", "MUSICBRAINZ_RELEASEGROUPID",
+}
+
+var (
+	mbidPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+	datePattern = regexp.MustCompile(`^\d{4}(-\d{2}(-\d{2})?)?$`)
+)
+
+// AlbumTagAudit is the audit result for the files of one album directory
+type AlbumTagAudit struct {
+	Dir          string
+	Files        int
+	Missing      map[string]int      // Tag -> number of files without it
+	Inconsistent map[string][]string // Album tag -> distinct values found
+	Invalid      []string            // "file: problem" descriptions
+}
+
+// NeedsPicard reports whether the album would change in a Picard run
+func (a *AlbumTagAudit) NeedsPicard() bool {
+	return len(a.Missing) > 0 || len(a.Inconsistent) > 0 || len(a.Invalid) > 0
+}
+
+// AuditTags compares the tags of every FLAC file under root with what Picard writes.
+// Files are grouped by directory, which is one album in the download layout.
+func AuditTags(root string) ([]*AlbumTagAudit, int, error) {
+	albums := make(map[string]*AlbumTagAudit)
+	values := make(map[string]map[string]map[string]bool) // dir -> tag -> values
+	skipped := 0
+
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == quarantineDirName {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		ext := strings.ToLower(filepath.Ext(path))
+		if ext != ".flac" {
+			if ext == ".mp3" || ext == ".ogg" || ext == ".opus" {
+				skipped++
+			}
+			return nil
+		}
+
+		dir := filepath.Dir(path)
+		album := albums[dir]
+		if album == nil {
+			album = &AlbumTagAudit{Dir: dir, Missing: make(map[string]int), Inconsistent: make(map[string][]string)}
+			albums[dir] = album
+			values[dir] = make(map[string]map[string]bool)
+		}
+		album.Files++
+
+		tags, err := readVorbisTags(path)
+		if err != nil {
+			album.Invalid = append(album.Invalid, fmt.Sprintf("%s: %v", filepath.Base(path), err))
+			return nil
+		}
+		for _, tag := range picardTags {
+			if len(tags[tag]) == 0 {
+				album.Missing[tag]++
+			}
+		}
+		for _, tag := range picardAlbumTags {
+			if len(tags[tag]) > 0 {
+				if values[dir][tag] == nil {
+					values[dir][tag] = make(map[string]bool)
+				}
+				values[dir][tag][strings.Join(tags[tag], "; ")] = true
+			}
+		}
+		album.Invalid = append(album.Invalid, checkTagValues(filepath.Base(path), tags)...)
+		return nil
+	})
+	if err != nil {
+		return nil, skipped, err
+	}
+
+	var result []*AlbumTagAudit
+	for dir, album := range albums {
+		for tag, distinct := range values[dir] {
+			if len(distinct) > 1 {
+				for value := range distinct {
+					album.Inconsistent[tag] = append(album.Inconsistent[tag], value)
+				}
+				sort.Strings(album.Inconsistent[tag])
+			}
+		}
+		result = append(result, album)
+	}
+	sort.Slice(result, func(i, k int) bool { return result[i].Dir < result[k].Dir })
+	return result, skipped, nil
+}
+
+// checkTagValues reports tags whose values Picard would write differently
+func checkTagValues(file string, tags map[string][]string) []string {
+	var problems []string
+	for tag, vals := range tags {
+		if strings.HasPrefix(tag, "MUSICBRAINZ_") && strings.HasSuffix(tag, "ID") {
+			for _, v := range vals {
+				if !mbidPattern.MatchString(v) {
+					problems = append(problems, fmt.Sprintf("%s: %s is not a MusicBrainz ID (%s)", file, tag, v))
+				}
+			}
+		}
+	}
+	for _, tag := range []string{"DATE", "ORIGINALDATE"} {
+		for _, v := range tags[tag] {
+			if !datePattern.MatchString(v) {
+				problems = append(problems, fmt.Sprintf("%s: %s '%s' is not YYYY-MM-DD", file, tag, v))
+			}
+		}
+	}
+	// Single-valued tags written more than once confuse players and Picard alike
+	for _, tag := range []string{"TITLE", "ALBUM", "TRACKNUMBER", "DISCNUMBER", "DATE", "MUSICBRAINZ_TRACKID", "MUSICBRAINZ_ALBUMID"} {
+		if len(tags[tag]) > 1 {
+			problems = append(problems, fmt.Sprintf("%s: %s appears %d times", file, tag, len(tags[tag])))
+		}
+	}
+	sort.Strings(problems)
+	return problems
+}
+
+// readVorbisTags returns the Vorbis comments of a FLAC file keyed by upper-case tag name
+func readVorbisTags(path string) (map[string][]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	file, err := flac.ParseMetadata(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse FLAC: %w", err)
+	}
+	tags := make(map[string][]string)
+	for _, block := range file.Meta {
+		if block.Type != flac.VorbisComment {
+			continue
+		}
+		comment, err := flacvorbis.ParseFromMetaDataBlock(*block)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range comment.Comments {
+			if key, value, ok := strings.Cut(c, "="); ok {
+				key = strings.ToUpper(key)
+				tags[key] = append(tags[key], value)
+			}
+		}
+	}
+	return tags, nil
+}
+
+// PrintTagAudit prints the audit per album and a verdict on whether Picard is still needed
+func PrintTagAudit(albums []*AlbumTagAudit, skipped int, verbose bool) {
+	needPicard := 0
+	for _, album := range albums {
+		if !album.NeedsPicard() {
+			if verbose {
+				colorSuccess.Printf("✅ %s (%d files)\n", album.Dir, album.Files)
+			}
+			continue
+		}
+		needPicard++
+		colorWarning.Printf("⚠️ %s (%d files)\n", album.Dir, album.Files)
+
+		var missing []string
+		for tag := range album.Missing {
+			missing = append(missing, tag)
+		}
+		sort.Strings(missing)
+		for _, tag := range missing {
+			count := album.Missing[tag]
+			if count == album.Files {
+				fmt.Printf("   missing      %s\n", tag)
+			} else {
+				fmt.Printf("   missing      %s (%d/%d files)\n", tag, count, album.Files)
+			}
+		}
+
+		var inconsistent []string
+		for tag := range album.Inconsistent {
+			inconsistent = append(inconsistent, tag)
+		}
+		sort.Strings(inconsistent)
+		for _, tag := range inconsistent {
+			fmt.Printf("   inconsistent %s: %s\n", tag, strings.Join(album.Inconsistent[tag], " | "))
+		}
+		for _, problem := range album.Invalid {
+			fmt.Printf("   invalid      %s\n", problem)
+		}
+	}
+
+	fmt.Println()
+	if skipped > 0 {
+		colorInfo.Printf("ℹ️ Skipped %d non-FLAC files, only FLAC tags can be audited.\n", skipped)
+	}
+	if needPicard == 0 {
+		colorSuccess.Printf("✅ All %d albums match a full Picard tagging run, no Picard pass needed.\n", len(albums))
+		return
+	}
+	colorWarning.Printf("🏷️ %d of %d albums differ from a full Picard tagging run. Run them through Picard to complete their tags.\n", needPicard, len(albums))
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	throttleSpike    = 3                // 429 responses within throttleWindow that halve the downloads
+	throttleWindow   = 30 * time.Second // Period 429 responses are counted over
+	throttleRecovery = time.Minute      // Time without a 429 before one more download is allowed
+)
+
+// DownloadThrottle caps the tracks downloading at once across all albums. The cap starts
+// at the configured total and is halved when DAB answers with bursts of 429 responses,
+// then raised by one per quiet minute until it is back at the configured total.
+type DownloadThrottle struct {
+	mu         sync.Mutex
+	max        int // Configured total, 0 disables the throttle
+	limit      int // Current cap
+	active     int
+	hits       []time.Time // Recent 429 responses
+	lastHit    time.Time
+	lastChange time.Time
+	changed    chan struct{} // Closed when a slot frees up or the cap changes
+}
+
+// downloadThrottle is shared by every download of the process
+var downloadThrottle = &DownloadThrottle{changed: make(chan struct{})}
+
+// Configure sets the total number of tracks that may download at once
+func (t *DownloadThrottle) Configure(max int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.max, t.limit = max, max
+	t.notify()
+}
+
+// Acquire waits until another track may download. The returned function releases the
+// slot and is never nil.
+func (t *DownloadThrottle) Acquire(ctx context.Context) (func(), error) {
+	for {
+		t.mu.Lock()
+		t.recover()
+		if t.max <= 0 || t.active < t.limit {
+			t.active++
+			t.mu.Unlock()
+			return t.release, nil
+		}
+		changed := t.changed
+		t.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return func() {}, ctx.Err()
+		case <-changed:
+		case <-time.After(throttleRecovery):
+		}
+	}
+}
+
+func (t *DownloadThrottle) release() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.active--
+	t.notify()
+}
+
+// RecordRateLimit notes a 429 response and halves the cap when they pile up
+func (t *DownloadThrottle) RecordRateLimit() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	t.lastHit = now
+	recent := t.hits[:0]
+	for _, hit := range t.hits {
+		if now.Sub(hit) < throttleWindow {
+			recent = append(recent, hit)
+		}
+	}
+	t.hits = append(recent, now)
+
+	// One reduction per window, so a single burst doesn't drop straight to one download
+	if t.max <= 0 || len(t.hits) < throttleSpike || t.limit <= 1 || now.Sub(t.lastChange) < throttleWindow {
+		return
+	}
+	t.limit = max(1, t.limit/2)
+	t.lastChange = now
+	t.hits = nil
+	colorWarning.Printf("🐢 DAB is rate limiting, downloading %d tracks at once for now\n", t.limit)
+}
+
+// recover raises the cap by one after a quiet period. Called with mu held.
+func (t *DownloadThrottle) recover() {
+	if t.limit >= t.max || time.Since(t.lastHit) < throttleRecovery || time.Since(t.lastChange) < throttleRecovery {
+		return
+	}
+	t.limit++
+	t.lastChange = time.Now()
+	if t.limit == t.max {
+		colorInfo.Printf("🐇 Rate limiting stopped, back to %d tracks at once\n", t.limit)
+	}
+	t.notify()
+}
+
+// notify wakes the waiting downloads. Called with mu held.
+func (t *DownloadThrottle) notify() {
+	close(t.changed)
+	t.changed = make(chan struct{})
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	throughputBucket  = 5 * time.Second
+	throughputSamples = 60 // 5 minutes of history
+)
+
+// sparkBlocks are the characters used to draw a sparkline, from lowest to highest
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// throughputSample holds the bytes downloaded during one bucket
+type throughputSample struct {
+	start time.Time
+	bytes int64
+}
+
+// ThroughputTracker keeps a rolling window of download throughput samples
+type ThroughputTracker struct {
+	bucket  time.Duration
+	max     int
+	samples []throughputSample
+	total   int64 // Bytes recorded since the tracker was created
+	mu      sync.Mutex
+}
+
+// downloadThroughput tracks the aggregate throughput of all audio downloads in this session
+var downloadThroughput = NewThroughputTracker(throughputBucket, throughputSamples)
+
+// NewThroughputTracker creates a tracker with the given bucket size and window length
+func NewThroughputTracker(bucket time.Duration, max int) *ThroughputTracker {
+	return &ThroughputTracker{
+		bucket: bucket,
+		max:    max,
+	}
+}
+
+// Add records n downloaded bytes at the current time
+func (t *ThroughputTracker) Add(n int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.total += n
+	now := time.Now().Truncate(t.bucket)
+	if len(t.samples) > 0 {
+		last := t.samples[len(t.samples)-1].start
+		if last.Equal(now) {
+			t.samples[len(t.samples)-1].bytes += n
+			return
+		}
+		// Fill idle buckets so gaps show up in the graph
+		for ts := last.Add(t.bucket); ts.Before(now); ts = ts.Add(t.bucket) {
+			t.samples = append(t.samples, throughputSample{start: ts})
+		}
+	}
+	t.samples = append(t.samples, throughputSample{start: now, bytes: n})
+
+	if len(t.samples) > t.max {
+		t.samples = t.samples[len(t.samples)-t.max:]
+	}
+}
+
+// Rates returns the throughput of each bucket in bytes per second, oldest first
+func (t *ThroughputTracker) Rates() []float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	rates := make([]float64, len(t.samples))
+	for i, sample := range t.samples {
+		rates[i] = float64(sample.bytes) / t.bucket.Seconds()
+	}
+	return rates
+}
+
+// Total returns the number of bytes recorded since the tracker was created
+func (t *ThroughputTracker) Total() int64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.total
+}
+
+// Summary returns the average and peak throughput in bytes per second over the window
+func (t *ThroughputTracker) Summary() (avg, peak float64) {
+	rates := t.Rates()
+	if len(rates) == 0 {
+		return 0, 0
+	}
+	var total float64
+	for _, rate := range rates {
+		total += rate
+		if rate > peak {
+			peak = rate
+		}
+	}
+	return total / float64(len(rates)), peak
+}
+
+// Sparkline renders values as a single line graph of block characters
+func Sparkline(values []float64) string {
+	var peak float64
+	for _, v := range values {
+		if v > peak {
+			peak = v
+		}
+	}
+
+	var b strings.Builder
+	for _, v := range values {
+		idx := 0
+		if peak > 0 {
+			idx = int(v / peak * float64(len(sparkBlocks)-1))
+		}
+		b.WriteRune(sparkBlocks[idx])
+	}
+	return b.String()
+}
+
+// printThroughputGraph prints the recent download throughput as a sparkline with average and peak
+func printThroughputGraph() {
+	rates := downloadThroughput.Rates()
+	if len(rates) == 0 {
+		return
+	}
+	avg, peak := downloadThroughput.Summary()
+	if plainOutput {
+		colorInfo.Printf("Throughput: average %s/s, peak %s/s\n", FormatBytes(int64(avg)), FormatBytes(int64(peak)))
+		return
+	}
+	colorInfo.Printf("📈 Throughput: %s  avg %s/s, peak %s/s\n", Sparkline(rates), FormatBytes(int64(avg)), FormatBytes(int64(peak)))
+}
+
+// throughputReader records every read into a ThroughputTracker
+type throughputReader struct {
+	io.ReadCloser
+	tracker *ThroughputTracker
+}
+
+func (r *throughputReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if n > 0 {
+		r.tracker.Add(int64(n))
+	}
+	return n, err
+}
+
+// TransferStats describes how one track was downloaded
+type TransferStats struct {
+	Bytes    int64         // Bytes received in this run, excluding a resumed part
+	Duration time.Duration // Time from the first request to the complete file, retries included
+	Host     string        // Host the audio was streamed from
+}
+
+// BytesPerSecond returns the average transfer speed
+func (t *TransferStats) BytesPerSecond() float64 {
+	if t == nil || t.Duration <= 0 {
+		return 0
+	}
+	return float64(t.Bytes) / t.Duration.Seconds()
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// albumTrackSelection picks tracks of the album command by position, e.g. "1,3,5-8", set
+// by --tracks
+var albumTrackSelection string
+
+// trackSelectionKey is the context key of the tracks an album download is limited to
+type trackSelectionKey struct{}
+
+// withTrackSelection limits album downloads made with ctx to the tracks at the given
+// positions, counted from 1
+func withTrackSelection(ctx context.Context, positions []int) context.Context {
+	selected := make(map[int]bool, len(positions))
+	for _, position := range positions {
+		selected[position] = true
+	}
+	return context.WithValue(ctx, trackSelectionKey{}, selected)
+}
+
+// trackSelectionFrom returns the positions album downloads made with ctx are limited to,
+// nil for all tracks
+func trackSelectionFrom(ctx context.Context) map[int]bool {
+	selected, _ := ctx.Value(trackSelectionKey{}).(map[int]bool)
+	return selected
+}
+
+// parseTrackSelection reads a selection like "1,3,5-8" for an album of total tracks
+func parseTrackSelection(input string, total int) ([]int, error) {
+	positions, err := ParseSelectionInput(input, total)
+	if err != nil {
+		return nil, err
+	}
+	if len(positions) == 0 {
+		return nil, fmt.Errorf("no tracks selected, the album has %d", total)
+	}
+	return positions, nil
+}
+
+// promptTrackSelection lists the tracks of an album and asks which to download. It
+// returns nil when all tracks are wanted.
+func promptTrackSelection(album *Album) ([]int, error) {
+	colorInfo.Printf("\n💿 %s by %s\n", album.Title, album.Artist)
+	for i, track := range album.Tracks {
+		artist := ""
+		if track.Artist != "" && !strings.EqualFold(track.Artist, album.Artist) {
+			artist = " - " + track.Artist
+		}
+		fmt.Printf("%3d. %s%s (%d:%02d)\n", i+1, track.Title, artist, track.Duration/60, track.Duration%60)
+	}
+	for {
+		input := strings.TrimSpace(GetUserInput("Tracks to download (e.g. 1,3,5-8, Enter for all)", ""))
+		if input == "" || strings.EqualFold(input, "all") {
+			return nil, nil
+		}
+		positions, err := parseTrackSelection(input, len(album.Tracks))
+		if err == nil {
+			return positions, nil
+		}
+		colorError.Printf("❌ %v\n", err)
+	}
+}
+
+// selectAlbumTracks returns ctx limited to the tracks chosen with --tracks, or picked at a
+// prompt when the flag isn't given and the terminal is interactive. ctx is returned as is
+// when the whole album is wanted.
+func selectAlbumTracks(ctx context.Context, api *DabAPI, albumID string) (context.Context, error) {
+	if albumTrackSelection == "" && (!isTTY() || jsonOutput || metadataOnly) {
+		return ctx, nil
+	}
+	album, err := api.GetAlbum(ctx, albumID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get album info: %w", err)
+	}
+	var positions []int
+	if albumTrackSelection != "" {
+		positions, err = parseTrackSelection(albumTrackSelection, len(album.Tracks))
+	} else {
+		positions, err = promptTrackSelection(album)
+	}
+	if err != nil || positions == nil {
+		return ctx, err
+	}
+	colorInfo.Printf("🎯 Downloading %d of %d tracks\n", len(positions), len(album.Tracks))
+	return withTrackSelection(ctx, positions), nil
+}
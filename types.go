@@ -11,21 +11,36 @@ const (
 	requestTimeout    = 10 * time.Minute
 	userAgent         = "DAB-Downloader/2.0"
 	defaultMaxRetries = 3
+	estimatedFLACKbps   = 1000 // Typical 16-bit/44.1kHz FLAC bitrate, used for size estimates
+	averageTrackSeconds = 240  // Assumed track length when the API doesn't report a duration
+	defaultDurationToleranceSec = 10 // Auto matches further off than this are flagged for review
 )
 
 // Configuration structure
 type Config struct {
+	ConfigVersion       int `json:"config_version"` // Structure version of config.json, upgraded automatically
 	APIURL              string
+	APIToken            string `json:"api_token,omitempty"` // Bearer token for DAB instances that require authentication
+	APICookie           string `json:"api_cookie,omitempty"` // Cookie sent to DAB instances that use cookie authentication
+	APIHeaders          map[string]string `json:"api_headers,omitempty"` // Extra headers for the DAB endpoint, e.g. CF-Access tokens or User-Agent
 	DownloadLocation    string
 	Parallelism         int
+	MaxConcurrentAlbums int `json:"MaxConcurrentAlbums,omitempty"` // Albums downloaded at once by artist and watch downloads, 0 uses Parallelism
+	MaxConcurrentTracks int `json:"MaxConcurrentTracks,omitempty"` // Tracks of one album downloaded at once, 0 uses Parallelism
+	MaxTotalDownloads   int `json:"MaxTotalDownloads,omitempty"`   // Tracks downloading at the same time across albums, 0 uses the larger of Parallelism and MaxConcurrentTracks
+	SourceLimits        *SourceLimits `json:"source_limits,omitempty"` // Concurrent requests per service (DAB, streams, covers, MusicBrainz)
 	SpotifyClientID     string
 	SpotifyClientSecret string
+	SpotifyRedirectURL  string `json:"SpotifyRedirectURL,omitempty"` // Redirect URI registered for the app, defaults to http://127.0.0.1:8888/callback
 	NavidromeURL        string
 	NavidromeUsername   string
 	NavidromePassword   string
 	Format              string
 	Bitrate             string
 	SaveAlbumArt        bool
+	AlbumArt            *AlbumArtOptions `json:"album_art,omitempty"` // Cover file names and extra sizes saved with SaveAlbumArt
+	SaveArtistArt       bool              `json:"SaveArtistArt,omitempty"` // Save an artist image in the artist folder for discography downloads
+	ArtistArt           *ArtistArtOptions `json:"artist_art,omitempty"`    // Artist image file names and sources
 	DisableUpdateCheck  bool `json:"DisableUpdateCheck"`
 	IsDockerContainer   bool `json:"-"` // Not saved to config.json
 	UpdateRepo          string `json:"UpdateRepo"`
@@ -33,14 +48,68 @@ type Config struct {
 	VerifyDownloads     bool `json:"VerifyDownloads"` // Enable/disable download verification
 	MaxRetryAttempts    int  `json:"MaxRetryAttempts"` // Configurable retry attempts
 	WarningBehavior     string `json:"WarningBehavior"` // "immediate", "summary", or "silent"
+	QuarantineDir       string `json:"QuarantineDir,omitempty"` // Defaults to <DownloadLocation>/.quarantine
+	QuarantineDays      int    `json:"QuarantineDays"` // Days to keep removed files, 0 deletes immediately
+	NetworkSafeWrites   bool   `json:"NetworkSafeWrites"` // fsync and copy instead of rename, for SMB/NFS download locations
+	MaxBandwidth        string `json:"MaxBandwidth,omitempty"` // Combined download speed cap in bytes per second, e.g. "5M", empty for no cap
+	CopyBufferKB        int    `json:"CopyBufferKB,omitempty"` // Write buffer for downloads in KB, 0 picks 256 KB or 1 MB by file size
+	PreallocateFiles    bool   `json:"PreallocateFiles,omitempty"` // Reserve disk space for each download up front (Linux only)
+	Language            string `json:"Language,omitempty"` // Message language (en, es, de), empty uses the system locale
+	ColorTheme          string            `json:"ColorTheme,omitempty"` // "default", "high-contrast" or "none"
+	Colors              map[string]string `json:"Colors,omitempty"`     // Per-role overrides, e.g. {"warning": "hi-yellow,bold"}
+	AuditLogPath        string `json:"AuditLogPath,omitempty"` // Append-only log of download/delete/rename actions, empty disables
+	CACertFile          string `json:"CACertFile,omitempty"` // PEM bundle trusted in addition to the system CAs (self-signed DAB/Navidrome)
+	InsecureSkipVerify  bool   `json:"InsecureSkipVerify,omitempty"` // Disable TLS verification entirely (not recommended)
+	IPVersion           string `json:"IPVersion,omitempty"` // "4" or "6" to force a protocol, empty for automatic
+	DNSServer           string `json:"DNSServer,omitempty"` // DNS server used instead of the system resolver, e.g. "1.1.1.1:53"
+	HostOverrides       map[string]string `json:"HostOverrides,omitempty"` // Static host name to IP mappings, like /etc/hosts
+	ProxyURL            string `json:"ProxyURL,omitempty"` // HTTP(S) or SOCKS5 proxy, e.g. "socks5://127.0.0.1:1080", empty uses HTTPS_PROXY
+	StreamProxyURL      string `json:"StreamProxyURL,omitempty"` // Proxy for audio streams only, "direct" for none, empty uses ProxyURL
+	NoProxy             string `json:"NoProxy,omitempty"` // Comma-separated hosts, domains and CIDRs reached directly, like NO_PROXY
+	OutageWaitMinutes   int    `json:"OutageWaitMinutes"` // Minutes to wait for an unreachable DAB endpoint before failing, 0 disables
+	SMTP                *SMTPConfig `json:"smtp,omitempty"` // Mail server for download summary emails
+	FeedPath            string `json:"FeedPath,omitempty"` // RSS file listing recently downloaded albums, empty disables
+	FeedLink            string `json:"FeedLink,omitempty"` // Link used for the feed and its items, e.g. your Navidrome URL
+	FeedItems           int    `json:"FeedItems,omitempty"` // Number of albums kept in the feed (default 50)
+	SearchCacheHours    int    `json:"SearchCacheHours,omitempty"` // Hours to cache search results for repeated imports, 0 disables
+	Blocklist           *Blocklist `json:"blocklist,omitempty"` // DAB IDs never picked by --auto or batch matching
+	DurationToleranceSec int   `json:"DurationToleranceSec"` // Max seconds an auto match may differ from the source duration, 0 disables
+	MusicBrainzURL      string  `json:"MusicBrainzURL,omitempty"` // Self-hosted MusicBrainz mirror, e.g. "http://mirror:5000/ws/2/"
+	MusicBrainzRate     float64 `json:"MusicBrainzRate,omitempty"` // Requests per second, above 1 only with a mirror
+	MusicBrainzNoContact bool   `json:"MusicBrainzNoContact,omitempty"` // Leave the contact email out of the user agent
+	DisableMusicBrainz  bool    `json:"DisableMusicBrainz,omitempty"` // Skip MusicBrainz lookups and keep only DAB-provided tags
+	WriteM3U            bool   `json:"WriteM3U,omitempty"` // Write an .m3u8 playlist after Spotify, Deezer and batch downloads
+	M3UAbsolutePaths    bool   `json:"M3UAbsolutePaths,omitempty"` // Use absolute instead of relative paths in playlists
+	ReplayGain          bool   `json:"ReplayGain,omitempty"` // Scan downloaded FLACs with ffmpeg and write ReplayGain tags
+	FindAlternativeEditions bool `json:"FindAlternativeEditions,omitempty"` // Download unavailable album tracks from other editions of the album
+	ReleasePreferences  *ReleasePreferences `json:"release_preferences,omitempty"` // How to choose between editions of an album on MusicBrainz
+	OutputTargets       []OutputTarget `json:"OutputTargets,omitempty"` // SFTP, WebDAV or S3 destinations new downloads are uploaded to
+	DeleteAfterUpload   bool           `json:"DeleteAfterUpload,omitempty"` // Remove local files once every output target has them
+	Serve               *ServeConfig   `json:"serve,omitempty"` // Address, authentication and TLS of the 'serve' REST API
+	Notifications       []NotificationTarget `json:"notifications,omitempty"` // Telegram, Discord, webhook or Gotify targets told about finished downloads
+	NoLocalConversion   bool           `json:"NoLocalConversion,omitempty"` // Download lossy formats from DAB even when the FLAC is already on disk
+	Schedules           []Schedule     `json:"schedules,omitempty"` // Commands the daemon and 'serve' run on a cron schedule
+	WriteNFO            bool           `json:"WriteNFO,omitempty"` // Write Kodi/Jellyfin album.nfo and artist.nfo files and artist images
+	NoChecksumManifest  bool           `json:"NoChecksumManifest,omitempty"` // Don't write checksums.sha256 to album folders after downloading
+	NoDuplicateDetection bool          `json:"NoDuplicateDetection,omitempty"` // Don't look for tracks already in the album folder under another name or format
+	SkipCrossAlbumDuplicates bool      `json:"SkipCrossAlbumDuplicates,omitempty"` // Skip a recording already downloaded with another album
+	Encoders            map[string]EncoderOptions `json:"encoders,omitempty"` // Per-format VBR quality, container and extra ffmpeg arguments
+	CompilationExpansion string         `json:"CompilationExpansion,omitempty"` // What playlist --expand downloads for compilation tracks: "compilation" (default) or "original"
+	KeepOriginal        bool           `json:"KeepOriginal,omitempty"` // Keep the FLAC when converting, converted copies go to ConvertedLocation
+	ExcludeTitles       []string       `json:"ExcludeTitles,omitempty"` // Regular expressions of album titles skipped by artist, batch and watch downloads
+	WatchMaxAgeDays     int            `json:"WatchMaxAgeDays,omitempty"` // Watched artists' releases older than this are ignored, unless the artist sets its own
+	WatchExclude        []string       `json:"WatchExclude,omitempty"` // Title words of releases ignored for every watched artist, e.g. "live", "remix", "demo"
+	ConvertedLocation   string         `json:"ConvertedLocation,omitempty"` // Root of converted copies with KeepOriginal, "{format}" is replaced; defaults to DownloadLocation-<format>
 }
 
 // NamingOptions defines the configurable naming masks
 type NamingOptions struct {
+	Enabled          bool   `json:"enabled,omitempty"` // Apply the folder and file masks; configs written before they were applied leave it off
 	AlbumFolderMask  string `json:"album_folder_mask"`
 	EpFolderMask     string `json:"ep_folder_mask"`
 	SingleFolderMask string `json:"single_folder_mask"`
 	FileMask         string `json:"file_mask"`
+	DiscFolderMask   string `json:"disc_folder_mask,omitempty"` // Subfolder for each disc of multi-disc albums, e.g. "CD{disc_number}"
 }
 
 // VersionInfo represents the structure of our version.json file
@@ -149,6 +218,9 @@ type DownloadStats struct {
 	SkippedCount int
 	FailedCount  int
 	FailedItems  []string
+	UnavailableCount int      // Tracks DAB has no stream for, e.g. region-locked
+	UnavailableItems []string
+	CorrelationID    string // Job the stats belong to, for summaries
 }
 
 // trackError holds information about a failed track download
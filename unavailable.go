@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/cheggaaa/pb/v3"
+)
+
+// maxAlternativeEditions is the number of other editions tried for an unavailable track
+const maxAlternativeEditions = 3
+
+// ErrTrackUnavailable is returned for tracks DAB lists but has no stream for, usually
+// because they are not licensed in the region of the DAB instance
+var ErrTrackUnavailable = errors.New("track is not available for streaming")
+
+// ErrAlbumUnavailable is returned for albums DAB still lists without any tracks
+var ErrAlbumUnavailable = errors.New("album is no longer available")
+
+// editionSuffix matches edition notes at the end of album titles, e.g. "(Deluxe Edition)",
+// "[Remastered 2011]" or " - Expanded Edition"
+var editionSuffix = regexp.MustCompile(`(?i)\s*(\([^)]*\)|\[[^\]]*\]|\s-\s[^-]*\b(edition|version|remaster(ed)?)\b.*)\s*$`)
+
+// isTrackUnavailable reports whether err means the track can't be streamed at all, as
+// opposed to a network or server problem that may go away on the next try. 401 and 403 are
+// credential problems, which another edition wouldn't fix.
+func isTrackUnavailable(err error) bool {
+	if errors.Is(err, ErrTrackUnavailable) {
+		return true
+	}
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		switch httpErr.StatusCode {
+		case http.StatusNotFound, http.StatusGone, http.StatusUnavailableForLegalReasons:
+			return true
+		}
+	}
+	return false
+}
+
+// isAlbumUnavailable reports whether err means the album can't be downloaded from its ID
+// anymore, because DAB removed it or lists it without tracks
+func isAlbumUnavailable(err error) bool {
+	return errors.Is(err, ErrAlbumUnavailable) || isTrackUnavailable(err)
+}
+
+// baseAlbumTitle strips edition notes from an album title, so editions of one release compare equal
+func baseAlbumTitle(title string) string {
+	for {
+		stripped := editionSuffix.ReplaceAllString(title, "")
+		if stripped == title || stripped == "" {
+			return title
+		}
+		title = stripped
+	}
+}
+
+// FindAlternativeAlbums returns other DAB editions of an album that is unavailable: the
+// release with the same UPC first, then albums by the same artist with the same title
+// apart from edition notes. The album is identified by what DAB still returns for it, or
+// else by the stored track list or the download history.
+func (api *DabAPI) FindAlternativeAlbums(ctx context.Context, albumID string, debug bool) ([]Album, error) {
+	var title, artist, upc string
+	if album, err := api.GetAlbum(ctx, albumID); err == nil {
+		title, artist, upc = album.Title, album.Artist, album.UPC
+	} else if recorded := albumTracklists.Get(albumID); recorded != nil {
+		title, artist = recorded.Title, recorded.Artist
+	} else {
+		for _, entry := range downloadHistory.Search("") {
+			if entry.AlbumID == albumID && entry.Album != "" {
+				title, artist = entry.Album, entry.Artist
+				break
+			}
+		}
+	}
+	if title == "" {
+		return nil, fmt.Errorf("nothing is known about album %s to search for other editions", albumID)
+	}
+
+	var alternatives []Album
+	seen := map[string]bool{albumID: true}
+	if upc != "" {
+		if album, err := api.FindAlbumByUPC(ctx, upc, debug); err == nil && !seen[album.ID] {
+			seen[album.ID] = true
+			alternatives = append(alternatives, *album)
+		}
+	}
+
+	results, err := api.Search(ctx, baseAlbumTitle(title)+" "+artist, "album", identifierSearchLimit, debug)
+	if err != nil {
+		if len(alternatives) > 0 {
+			return alternatives, nil
+		}
+		return nil, err
+	}
+	for _, album := range rankResults(title+" "+artist, results).Albums {
+		if seen[album.ID] || isBlocked("album", album.ID) ||
+			!sameName(album.Artist, artist) || !sameName(baseAlbumTitle(album.Title), baseAlbumTitle(title)) {
+			continue
+		}
+		seen[album.ID] = true
+		alternatives = append(alternatives, album)
+	}
+	return alternatives, nil
+}
+
+// chooseAlternativeAlbum offers the other editions of an unavailable album. With --auto or
+// FindAlternativeEditions the first one is taken, otherwise the user picks one. Without a
+// terminal to ask on, e.g. in the queue or a scheduled run, the album is skipped. It returns
+// nil when there is none or the user skips.
+func chooseAlternativeAlbum(ctx context.Context, api *DabAPI, albumID string, config *Config) *Album {
+	alternatives, err := api.FindAlternativeAlbums(ctx, albumID, debug)
+	if err != nil || len(alternatives) == 0 {
+		if debug && err != nil {
+			fmt.Printf("DEBUG: No alternative editions of album %s: %v\n", albumID, err)
+		}
+		return nil
+	}
+
+	colorInfo.Printf("🔀 Album %s is unavailable, other editions on DAB:\n", albumID)
+	for i, album := range alternatives {
+		fmt.Printf("%d. %s - %s (%s)\n", i+1, album.Title, album.Artist, album.ID)
+	}
+	if auto || config.FindAlternativeEditions {
+		return &alternatives[0]
+	}
+	if !isTTY() || jsonOutput || queueItemRunFrom(ctx) != nil {
+		colorWarning.Println("⚠️ Not downloading another edition without a terminal to ask on, pass --auto or set FindAlternativeEditions to take the first one")
+		return nil
+	}
+	selection := GetUserInput("Enter the number of the edition to download (or press Enter to skip)", "")
+	index, err := strconv.Atoi(strings.TrimSpace(selection))
+	if err != nil || index < 1 || index > len(alternatives) {
+		return nil
+	}
+	return &alternatives[index-1]
+}
+
+// downloadAlternativeEdition looks for the same recording on other DAB albums, e.g. the
+// deluxe or regional edition of the album, and downloads the first one that streams. The
+// file keeps the metadata and path of the original track, so the album stays consistent.
+func (api *DabAPI) downloadAlternativeEdition(ctx context.Context, track Track, album *Album, trackPath string, coverData []byte, bar *pb.ProgressBar, debug bool, config *Config, warningCollector *WarningCollector) (string, *TransferStats, error) {
+	results, err := api.Search(ctx, track.Title+" "+track.Artist, "track", 10, debug)
+	if err != nil {
+		return "", nil, err
+	}
+
+	tried := 0
+	for _, candidate := range results.Tracks {
+		if tried >= maxAlternativeEditions {
+			break
+		}
+		if idToString(candidate.ID) == idToString(track.ID) || candidate.AlbumID == album.ID ||
+			!sameName(candidate.Title, track.Title) || !sameName(candidate.Artist, track.Artist) ||
+			durationMismatch(track.Duration, candidate.Duration) || isBlocked("track", candidate.ID) {
+			continue
+		}
+		tried++
+
+		alternative := track
+		alternative.ID = candidate.ID
+		path, transfer, err := api.DownloadTrack(ctx, alternative, album, trackPath, coverData, bar, debug, config.Format, config.Bitrate, config, warningCollector)
+		if err == nil {
+			colorInfo.Printf("🔀 %s was unavailable, downloaded it from %s instead\n", track.Title, candidate.Album)
+			return path, transfer, nil
+		}
+		if debug {
+			fmt.Printf("DEBUG: Alternative edition %s of %s failed: %v\n", idToString(candidate.ID), track.Title, err)
+		}
+	}
+	return "", nil, fmt.Errorf("no other edition of %s could be downloaded", track.Title)
+}
@@ -9,6 +9,7 @@ import (
 	"os/exec"
 	"runtime"
 	"strings"
+	"time"
 
 	version "github.com/hashicorp/go-version" // Added this import
 )
@@ -28,7 +29,7 @@ func CheckForUpdates(config *Config, currentVersion string) {
 		repoURL = config.UpdateRepo
 	}
 	rawURL := fmt.Sprintf("https://raw.githubusercontent.com/%s/main/version/version.json", repoURL)
-	resp, err := http.Get(rawURL)
+	resp, err := newHTTPClient(30 * time.Second).Get(rawURL)
 	if err != nil {
 		colorError.Printf("Error checking for updates: %v\n", err)
 		return
@@ -51,6 +52,11 @@ func CheckForUpdates(config *Config, currentVersion string) {
 
 	if isNewerVersion(latestVersion, currentVersion) {
 		colorError.Printf("🚨 You are using an outdated version (%s) of dab-downloader! A new version (%s) is available.\n", currentVersion, latestVersion)
+		if assumeYes {
+			// Nobody may be there to use a browser, so only point to the guide
+			colorInfo.Println("See the 'Update Guide' in the README: https://github.com/PrathxmOp/dab-downloader/#update-guide")
+			return
+		}
 		colorPrompt.Print("Would you like to update now? (Y/n): ")
 		reader := bufio.NewReader(os.Stdin)
 		input, _ := reader.ReadString('\n')
@@ -0,0 +1,379 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const uploadTimeout = 30 * time.Minute
+
+// OutputTarget is a remote destination downloads are copied to after they finish
+type OutputTarget struct {
+	Name         string `json:"name"`
+	Type         string `json:"type"`                    // "sftp", "webdav" or "s3"
+	URL          string `json:"url"`                     // sftp://user@host:22/music, https://dav.example.com/music or the S3 endpoint
+	Username     string `json:"username,omitempty"`      // WebDAV user
+	Password     string `json:"password,omitempty"`      // WebDAV password
+	IdentityFile string `json:"identity_file,omitempty"` // SSH key for SFTP, empty uses the ssh defaults
+	Bucket       string `json:"bucket,omitempty"`        // S3 bucket
+	Region       string `json:"region,omitempty"`        // S3 region, default us-east-1
+	AccessKey    string `json:"access_key,omitempty"`
+	SecretKey    string `json:"secret_key,omitempty"`
+	Prefix       string `json:"prefix,omitempty"` // S3 key prefix, e.g. "music/"
+}
+
+// label names the target in messages
+func (t OutputTarget) label() string {
+	if t.Name != "" {
+		return t.Name
+	}
+	return t.Type
+}
+
+// Uploader copies a local file to a path relative to the root of a target
+type Uploader interface {
+	Upload(ctx context.Context, localPath, remotePath string) error
+}
+
+// newUploader returns the uploader for a target
+func newUploader(target OutputTarget) (Uploader, error) {
+	switch strings.ToLower(target.Type) {
+	case "sftp":
+		return newSFTPUploader(target)
+	case "webdav":
+		base, err := url.Parse(target.URL)
+		if err != nil || base.Host == "" {
+			return nil, fmt.Errorf("invalid WebDAV URL '%s'", target.URL)
+		}
+		return &webdavUploader{base: base, username: target.Username, password: target.Password}, nil
+	case "s3":
+		endpoint, err := url.Parse(target.URL)
+		if err != nil || endpoint.Host == "" {
+			return nil, fmt.Errorf("invalid S3 endpoint '%s'", target.URL)
+		}
+		if target.Bucket == "" || target.AccessKey == "" || target.SecretKey == "" {
+			return nil, fmt.Errorf("S3 target needs bucket, access_key and secret_key")
+		}
+		return &s3Uploader{endpoint: endpoint, target: target}, nil
+	default:
+		return nil, fmt.Errorf("unknown output target type '%s' (use sftp, webdav or s3)", target.Type)
+	}
+}
+
+// uploadToTargets copies files below root to every output target, keeping their path
+// relative to root. With DeleteAfterUpload the local copies of files that reached every
+// target are removed, along with folders left empty. Failures only produce warnings.
+func uploadToTargets(ctx context.Context, config *Config, root string, paths []string) {
+	if len(config.OutputTargets) == 0 || len(paths) == 0 {
+		return
+	}
+
+	uploaded := make(map[string]int)
+	for _, target := range config.OutputTargets {
+		uploader, err := newUploader(target)
+		if err != nil {
+			colorWarning.Printf("⚠️ Skipping output target %s: %v\n", target.label(), err)
+			continue
+		}
+		count := 0
+		for _, localPath := range paths {
+			rel, err := filepath.Rel(root, localPath)
+			if err != nil || strings.HasPrefix(rel, "..") {
+				rel = filepath.Base(localPath)
+			}
+			uploadCtx, cancel := context.WithTimeout(ctx, uploadTimeout)
+			err = uploader.Upload(uploadCtx, localPath, filepath.ToSlash(rel))
+			cancel()
+			if err != nil {
+				colorWarning.Printf("⚠️ Failed to upload %s to %s: %v\n", rel, target.label(), err)
+				continue
+			}
+			uploaded[localPath]++
+			count++
+		}
+		colorSuccess.Printf("📤 Uploaded %d of %d files to %s\n", count, len(paths), target.label())
+	}
+
+	if !config.DeleteAfterUpload {
+		return
+	}
+	root = filepath.Clean(root)
+	dirs := make(map[string]bool)
+	for _, localPath := range paths {
+		if uploaded[localPath] != len(config.OutputTargets) {
+			continue
+		}
+		if err := RemoveFile(ctx, localPath, config); err != nil {
+			colorWarning.Printf("⚠️ Failed to remove %s after upload: %v\n", localPath, err)
+			continue
+		}
+		dirs[filepath.Dir(localPath)] = true
+	}
+	for dir := range dirs {
+		// Removes the folder and its parents up to root as long as they are empty
+		for dir != root && strings.HasPrefix(dir, root) && os.Remove(dir) == nil {
+			Audit(ctx, "delete", dir, "")
+			dir = filepath.Dir(dir)
+		}
+	}
+}
+
+// albumArtFiles returns the cover and artwork images, the NFO file and the checksum
+// manifest saved in an album folder
+func albumArtFiles(albumDir string) []string {
+	entries, err := os.ReadDir(albumDir)
+	if err != nil {
+		return nil
+	}
+	var files []string
+	for _, entry := range entries {
+		switch strings.ToLower(filepath.Ext(entry.Name())) {
+		case ".jpg", ".jpeg", ".png", ".webp", ".nfo", ".sha256":
+			if !entry.IsDir() {
+				files = append(files, filepath.Join(albumDir, entry.Name()))
+			}
+		}
+	}
+	return files
+}
+
+// sftpUploader uploads with the system sftp client in batch mode, so authentication has
+// to work without a prompt (an SSH key or agent)
+type sftpUploader struct {
+	destination  string
+	port         string
+	basePath     string
+	identityFile string
+}
+
+func newSFTPUploader(target OutputTarget) (*sftpUploader, error) {
+	u, err := url.Parse(target.URL)
+	if err != nil || u.Scheme != "sftp" || u.Hostname() == "" {
+		return nil, fmt.Errorf("invalid SFTP URL '%s', expected sftp://user@host/path", target.URL)
+	}
+	if _, err := exec.LookPath("sftp"); err != nil {
+		return nil, fmt.Errorf("the sftp command is not installed")
+	}
+	destination := u.Hostname()
+	if u.User != nil && u.User.Username() != "" {
+		destination = u.User.Username() + "@" + destination
+	}
+	return &sftpUploader{destination: destination, port: u.Port(), basePath: u.Path, identityFile: target.IdentityFile}, nil
+}
+
+func (s *sftpUploader) Upload(ctx context.Context, localPath, remotePath string) error {
+	// Without a path in the URL, files go below the home directory
+	remote := path.Join(s.basePath, remotePath)
+
+	var batch strings.Builder
+	dir := ""
+	for _, part := range strings.Split(path.Dir(remote), "/") {
+		if part == "" {
+			dir = "/"
+			continue
+		}
+		dir = path.Join(dir, part)
+		// A leading "-" ignores the error when the folder exists
+		fmt.Fprintf(&batch, "-mkdir %s\n", sftpQuote(dir))
+	}
+	fmt.Fprintf(&batch, "put %s %s\n", sftpQuote(localPath), sftpQuote(remote))
+
+	args := []string{"-b", "-", "-o", "BatchMode=yes"}
+	if s.port != "" {
+		args = append(args, "-P", s.port)
+	}
+	if s.identityFile != "" {
+		args = append(args, "-i", s.identityFile)
+	}
+	args = append(args, s.destination)
+
+	cmd := exec.CommandContext(ctx, "sftp", args...)
+	cmd.Stdin = strings.NewReader(batch.String())
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("sftp failed: %v: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// sftpQuote quotes a path for an sftp batch file
+func sftpQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// webdavUploader uploads with HTTP PUT, creating missing folders with MKCOL
+type webdavUploader struct {
+	base     *url.URL
+	username string
+	password string
+}
+
+func (w *webdavUploader) Upload(ctx context.Context, localPath, remotePath string) error {
+	client := newHTTPClient(uploadTimeout)
+	basePath := strings.TrimSuffix(w.base.Path, "/")
+
+	dir := basePath
+	parts := strings.Split(remotePath, "/")
+	for _, part := range parts[:len(parts)-1] {
+		dir += "/" + part
+		resp, err := w.do(ctx, client, "MKCOL", dir+"/", nil, 0)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		// 405 means the folder exists already
+		if resp.StatusCode >= 300 && resp.StatusCode != http.StatusMethodNotAllowed {
+			return &HTTPError{StatusCode: resp.StatusCode, Status: resp.Status, Message: "failed to create " + dir}
+		}
+	}
+
+	f, err := os.Open(localPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	resp, err := w.do(ctx, client, http.MethodPut, basePath+"/"+remotePath, f, info.Size())
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return &HTTPError{StatusCode: resp.StatusCode, Status: resp.Status, Message: "upload failed"}
+	}
+	return nil
+}
+
+func (w *webdavUploader) do(ctx context.Context, client *http.Client, method, remotePath string, body io.Reader, size int64) (*http.Response, error) {
+	target := *w.base
+	target.Path = remotePath
+	target.RawPath = ""
+	req, err := http.NewRequestWithContext(ctx, method, target.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.ContentLength = size
+	}
+	if w.username != "" {
+		req.SetBasicAuth(w.username, w.password)
+	}
+	return client.Do(req)
+}
+
+// s3Uploader uploads to S3-compatible storage (AWS, MinIO, Backblaze B2, Cloudflare R2...)
+// with path-style requests signed with AWS Signature Version 4
+type s3Uploader struct {
+	endpoint *url.URL
+	target   OutputTarget
+}
+
+func (s *s3Uploader) Upload(ctx context.Context, localPath, remotePath string) error {
+	f, err := os.Open(localPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	// The payload hash is part of the signature
+	hash := sha256.New()
+	size, err := io.Copy(hash, f)
+	if err != nil {
+		return err
+	}
+	payloadHash := hex.EncodeToString(hash.Sum(nil))
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	key := strings.TrimPrefix(path.Join(s.target.Prefix, remotePath), "/")
+	canonicalPath := strings.TrimSuffix(s.endpoint.Path, "/") + "/" + s3Escape(s.target.Bucket) + "/" + s3Escape(key)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.endpoint.Scheme+"://"+s.endpoint.Host+canonicalPath, f)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	s.sign(req, canonicalPath, payloadHash, time.Now().UTC())
+
+	resp, err := newHTTPClient(uploadTimeout).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return &HTTPError{StatusCode: resp.StatusCode, Status: resp.Status, Message: strings.TrimSpace(string(message))}
+	}
+	return nil
+}
+
+// sign adds the Signature Version 4 headers to an S3 request
+func (s *s3Uploader) sign(req *http.Request, canonicalPath, payloadHash string, now time.Time) {
+	region := s.target.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	req.Header.Set("x-amz-date", amzDate)
+	req.Header.Set("x-amz-content-sha256", payloadHash)
+
+	const signedHeaders = "host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalPath,
+		"",
+		"host:" + req.URL.Host,
+		"x-amz-content-sha256:" + payloadHash,
+		"x-amz-date:" + amzDate,
+		"",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	scope := day + "/" + region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	signingKey := []byte("AWS4" + s.target.SecretKey)
+	for _, part := range []string{day, region, "s3", "aws4_request"} {
+		signingKey = hmacSHA256(signingKey, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.target.AccessKey, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// s3Escape URI-encodes an object key as Signature Version 4 expects, keeping the slashes
+func s3Escape(key string) string {
+	var b strings.Builder
+	for _, c := range []byte(key) {
+		switch {
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c >= '0' && c <= '9',
+			c == '-', c == '_', c == '.', c == '~', c == '/':
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
@@ -68,20 +68,28 @@ func CreateDirIfNotExists(dir string) error {
 	return nil
 }
 
-// GetTrackFilename generates a filename for a track
-func GetTrackFilename(trackNumber int, title string) string {
-	if trackNumber == 0 {
-		return fmt.Sprintf("%s.flac", SanitizeFileName(title))
-	}
-	return fmt.Sprintf("%02d - %s.flac", trackNumber, SanitizeFileName(title))
-}
-
-// LoadConfig loads configuration from a JSON file
+// LoadConfig loads configuration from a JSON file, upgrading older files first. Unknown
+// keys are reported with their position, and values of the wrong type fail with theirs.
 func LoadConfig(filePath string, config *Config) error {
 	data, err := os.ReadFile(filePath)
 	if err != nil {
 		return fmt.Errorf("failed to read config file: %w", err)
 	}
+	defaults := *config
+	if data, err = MigrateConfig(filePath, data, &defaults); err != nil {
+		colorWarning.Printf("⚠️ %v\n", err)
+	}
+	warnings, errs := ValidateConfig(data)
+	for _, issue := range warnings {
+		colorWarning.Printf("⚠️ %s, %s\n", filePath, issue)
+	}
+	if len(errs) > 0 {
+		messages := make([]string, len(errs))
+		for i, issue := range errs {
+			messages[i] = issue.String()
+		}
+		return fmt.Errorf("invalid config: %s", strings.Join(messages, "; "))
+	}
 	if err := json.Unmarshal(data, config); err != nil {
 		return fmt.Errorf("failed to unmarshal config: %w", err)
 	}
@@ -144,6 +152,15 @@ func GetYesNoInput(prompt string, defaultValue string) bool {
 	}
 }
 
+// Confirm asks a yes/no question that defaults to no. With --yes it is answered yes
+// without asking.
+func Confirm(prompt string) bool {
+	if assumeYes {
+		return true
+	}
+	return GetYesNoInput(prompt, "n")
+}
+
 // ParseSelectionInput parses a string like "1-7, 10, 12-15" into a slice of unique integers.
 func ParseSelectionInput(input string, max int) ([]int, error) {
 	selected := make(map[int]bool)
@@ -243,4 +260,17 @@ func VerifyFileIntegrity(filePath string, expectedSize int64, debug bool) error
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+// FormatBytes formats a byte count as a human-readable size (e.g. "12.3 MB")
+func FormatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
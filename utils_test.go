@@ -0,0 +1,42 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSelectionInput(t *testing.T) {
+	tests := []struct {
+		input   string
+		max     int
+		want    []int
+		wantErr bool
+	}{
+		{"1,3,5", 10, []int{1, 3, 5}, false},
+		{"1-3, 7", 10, []int{1, 2, 3, 7}, false},
+		{"3-1", 10, []int{1, 2, 3}, false},
+		{"2,2,1-2", 10, []int{2, 1}, false},
+		{"8-12", 10, []int{8, 9, 10}, false},
+		{"0, 11", 10, nil, false},
+		{" , ", 10, nil, false},
+		{"a", 10, nil, true},
+		{"1-b", 10, nil, true},
+		{"1-2-3", 10, nil, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseSelectionInput(tt.input, tt.max)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseSelectionInput(%q) error = %v, want error %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if tt.wantErr {
+			continue
+		}
+		if len(got) == 0 && len(tt.want) == 0 {
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseSelectionInput(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// Verbosity levels, set by -v, -vv and -vvv
+const (
+	LevelNormal = iota
+	LevelInfo   // -v: a line for every HTTP request of every service
+	LevelDebug  // -vv: internal details, what --debug showed
+	LevelTrace  // -vvv: also the raw API responses
+)
+
+const traceBodyLimit = 64 * 1024 // Bytes of a response body printed at trace level
+
+// verbosity is the verbosity level, counted from -v flags
+var verbosity int
+
+func init() {
+	cobra.OnInitialize(applyVerbosity)
+}
+
+// applyVerbosity reconciles -v with the deprecated --debug, which is the debug level.
+// debug stays the switch for debug output everywhere.
+func applyVerbosity() {
+	if debug {
+		verbosity = max(verbosity, LevelDebug)
+	}
+	debug = verbosity >= LevelDebug
+}
+
+// tracef prints a line at trace level
+func tracef(format string, args ...interface{}) {
+	if verbosity >= LevelTrace {
+		fmt.Printf("TRACE - "+format+"\n", args...)
+	}
+}
+
+// loggingTransport prints every request at info level, and text responses at trace level
+type loggingTransport struct {
+	next http.RoundTripper
+}
+
+func (t *loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	elapsed := time.Since(start).Round(time.Millisecond)
+	if err != nil {
+		colorInfo.Printf("🌐 [%s] %s %s failed after %s: %v\n", correlationIDFrom(req.Context()), req.Method, redactURL(req.URL), elapsed, err)
+		return resp, err
+	}
+	colorInfo.Printf("🌐 [%s] %s %s → %d in %s\n", correlationIDFrom(req.Context()), req.Method, redactURL(req.URL), resp.StatusCode, elapsed)
+	if verbosity >= LevelTrace && isTextContent(resp.Header.Get("Content-Type")) {
+		// Print the start of the body without holding up or buffering the rest
+		head, _ := io.ReadAll(io.LimitReader(resp.Body, traceBodyLimit))
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(head), resp.Body), resp.Body}
+		more := ""
+		if len(head) == traceBodyLimit {
+			more = " …"
+		}
+		tracef("Response body of %s: %s%s", redactURL(req.URL), redactBody(head), more)
+	}
+	return resp, nil
+}
+
+// isTextContent reports whether a content type is worth printing, audio and images aren't
+func isTextContent(contentType string) bool {
+	contentType = strings.ToLower(contentType)
+	return strings.HasPrefix(contentType, "text/") || strings.Contains(contentType, "json") || strings.Contains(contentType, "xml")
+}
+
+var (
+	secretQueryKeys = []string{"token", "key", "secret", "password", "auth", "signature", "sig", "credential"}
+	// Subsonic credentials: the password, or the salted token with its salt, which can be
+	// replayed to log in. Matched exactly, as other keys contain these letters.
+	subsonicAuthKeys = []string{"p", "t", "s"}
+	telegramBotPath  = regexp.MustCompile(`/bot[^/]+`)
+	discordHookPath  = regexp.MustCompile(`/api/webhooks/.*`)
+	secretJSONFields = regexp.MustCompile(`("[^"]*(?i:token|secret|password)[^"]*"\s*:\s*)"[^"]*"`)
+)
+
+// redactBody hides the tokens, secrets and passwords of a JSON response body, such as the
+// access and refresh tokens of OAuth token responses
+func redactBody(body []byte) []byte {
+	return secretJSONFields.ReplaceAll(body, []byte(`$1"***"`))
+}
+
+// redactURL hides passwords, tokens and signatures in a URL before it is printed
+func redactURL(u *url.URL) string {
+	clean := *u
+	query := clean.Query()
+	for key := range query {
+		if slices.Contains(subsonicAuthKeys, key) {
+			query.Set(key, "***")
+			continue
+		}
+		for _, secret := range secretQueryKeys {
+			if strings.Contains(strings.ToLower(key), secret) {
+				query.Set(key, "***")
+				break
+			}
+		}
+	}
+	clean.RawQuery = query.Encode()
+	if strings.HasPrefix(clean.Path, "/bot") {
+		clean.Path = telegramBotPath.ReplaceAllString(clean.Path, "/bot***")
+		clean.RawPath = ""
+	}
+	if strings.Contains(clean.Path, "/api/webhooks/") {
+		clean.Path = discordHookPath.ReplaceAllString(clean.Path, "/api/webhooks/***")
+		clean.RawPath = ""
+	}
+	// The masks are escaped like any other value, show them as they were set
+	return strings.ReplaceAll(clean.Redacted(), "%2A%2A%2A", "***")
+}
@@ -0,0 +1,368 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// LibraryAlbum is an album folder checked by verify-library
+type LibraryAlbum struct {
+	Dir      string
+	Title    string
+	Artist   string
+	Files    int             // FLAC files in the folder
+	Expected int             // Tracks the album should have, 0 if unknown
+	Source   string          // Where Expected comes from: "DAB", "MusicBrainz" or "tags"
+	DabAlbum *Album          // The album on DAB, nil if it wasn't found
+	Missing  []Track         // Tracks of the DAB album that are not in the folder
+	Gaps     []TrackPosition // Track numbers missing between the tracks in the folder
+
+	albumID     string // DAB album ID from the download history
+	upc         string
+	mbid        string
+	totalTracks int             // TOTALTRACKS tag
+	totalDiscs  int             // TOTALDISCS tag
+	present     map[string]bool // "<disc>-<track>" of the files in the folder
+	titles      map[string]bool // Normalized titles of the files in the folder
+	lastTrack   map[int]int     // Highest track number on each disc
+}
+
+// TrackPosition is the disc and track number of a track
+type TrackPosition struct {
+	Disc  int
+	Track int
+}
+
+func (p TrackPosition) String() string {
+	return fmt.Sprintf("%d-%02d", p.Disc, p.Track)
+}
+
+// Complete reports whether the folder has every track the album is known to have
+func (a *LibraryAlbum) Complete() bool {
+	if a.DabAlbum != nil {
+		return len(a.Missing) == 0
+	}
+	return a.Expected == 0 || a.Files >= a.Expected
+}
+
+// ScanLibrary groups FLAC files into album folders and reads what identifies each album.
+// Disc subfolders (DiscFolderMask) count as part of their album folder.
+func ScanLibrary(paths []string, config *Config) []*LibraryAlbum {
+	albumIDs := make(map[string]string)
+	for _, entry := range downloadHistory.Search("") {
+		if abs, err := filepath.Abs(entry.Path); err == nil && entry.AlbumID != "" {
+			albumIDs[abs] = entry.AlbumID
+		}
+	}
+
+	albums := make(map[string]*LibraryAlbum)
+	for _, path := range paths {
+		tags, err := readVorbisTags(path)
+		if err != nil {
+			colorWarning.Printf("⚠️ Skipping %s: %v\n", path, err)
+			continue
+		}
+		first := func(name string) string {
+			if len(tags[name]) > 0 {
+				return strings.TrimSpace(tags[name][0])
+			}
+			return ""
+		}
+		disc, track := tagNumber(first("DISCNUMBER")), tagNumber(first("TRACKNUMBER"))
+		if disc == 0 {
+			disc = 1
+		}
+
+		dir := filepath.Dir(path)
+		if config.NamingMasks.DiscFolderMask != "" && tagNumber(first("TOTALDISCS")) > 1 {
+			dir = filepath.Dir(dir)
+		}
+		album := albums[dir]
+		if album == nil {
+			album = &LibraryAlbum{Dir: dir, present: make(map[string]bool), titles: make(map[string]bool), lastTrack: make(map[int]int)}
+			albums[dir] = album
+		}
+		album.Files++
+		if album.Title == "" {
+			album.Title = first("ALBUM")
+		}
+		if album.Artist == "" {
+			album.Artist = first("ALBUMARTIST")
+			if album.Artist == "" {
+				album.Artist = first("ARTIST")
+			}
+		}
+		if album.upc == "" {
+			album.upc = first("UPC")
+		}
+		if album.mbid == "" {
+			album.mbid = first("MUSICBRAINZ_ALBUMID")
+		}
+		if total := tagNumber(first("TOTALTRACKS")); total > album.totalTracks {
+			album.totalTracks = total
+		}
+		if total := tagNumber(first("TOTALDISCS")); total > album.totalDiscs {
+			album.totalDiscs = total
+		}
+		if abs, err := filepath.Abs(path); err == nil && album.albumID == "" {
+			album.albumID = albumIDs[abs]
+		}
+		if track > 0 {
+			album.present[fmt.Sprintf("%d-%d", disc, track)] = true
+			album.lastTrack[disc] = max(album.lastTrack[disc], track)
+		}
+		album.titles[normalizeForMatch(first("TITLE"))] = true
+	}
+
+	var result []*LibraryAlbum
+	for _, album := range albums {
+		album.findGaps()
+		result = append(result, album)
+	}
+	sort.Slice(result, func(i, k int) bool { return result[i].Dir < result[k].Dir })
+	return result
+}
+
+// findGaps lists the track numbers missing on each disc, from 1 up to the highest track
+// in the folder. Single-disc albums also count up to the TOTALTRACKS tag; on multi-disc
+// albums it may be the total of all discs, so missing tracks at the end of a disc are
+// only found by comparing with DAB.
+func (a *LibraryAlbum) findGaps() {
+	a.Gaps = nil
+	discs := make([]int, 0, len(a.lastTrack))
+	for disc := range a.lastTrack {
+		discs = append(discs, disc)
+	}
+	sort.Ints(discs)
+	for _, disc := range discs {
+		last := a.lastTrack[disc]
+		if len(discs) == 1 && disc == 1 && a.totalDiscs <= 1 {
+			last = max(last, a.totalTracks)
+		}
+		for track := 1; track <= last; track++ {
+			if !a.present[fmt.Sprintf("%d-%d", disc, track)] {
+				a.Gaps = append(a.Gaps, TrackPosition{Disc: disc, Track: track})
+			}
+		}
+	}
+}
+
+// tagNumber parses track and disc tags, which may be written as "3" or "3/12"
+func tagNumber(value string) int {
+	if i := strings.Index(value, "/"); i >= 0 {
+		value = value[:i]
+	}
+	n, _ := strconv.Atoi(strings.TrimSpace(value))
+	return n
+}
+
+// VerifyLibraryAlbum finds how many tracks an album folder should have. The DAB album is
+// looked up by the download history, the UPC tag, or an exact title and artist search, and
+// its tracks are compared with the folder by disc and track number or title. Albums not on
+// DAB fall back to the MusicBrainz release and then to the TOTALTRACKS tag. Offline only
+// the tag is used.
+func (api *DabAPI) VerifyLibraryAlbum(ctx context.Context, album *LibraryAlbum, offline, debug bool) {
+	if !offline {
+		album.DabAlbum = api.findLibraryAlbum(ctx, album, debug)
+	}
+	if album.DabAlbum != nil {
+		album.Expected = len(album.DabAlbum.Tracks)
+		album.Source = "DAB"
+		album.Missing = nil
+		for idx, track := range album.DabAlbum.Tracks {
+			if track.TrackNumber == 0 {
+				track.TrackNumber = idx + 1
+			}
+			disc := track.DiscNumber
+			if disc == 0 {
+				disc = 1
+			}
+			if album.present[fmt.Sprintf("%d-%d", disc, track.TrackNumber)] || album.titles[normalizeForMatch(track.Title)] {
+				continue
+			}
+			album.Missing = append(album.Missing, track)
+		}
+		return
+	}
+
+	if !offline && musicBrainzEnabled && album.mbid != "" {
+		release, err := mbClient.GetReleaseMetadata(album.mbid)
+		if err == nil {
+			total := 0
+			for _, medium := range release.Media {
+				total += len(medium.Tracks)
+			}
+			if total > 0 {
+				album.Expected, album.Source = total, "MusicBrainz"
+				return
+			}
+		} else if debug {
+			fmt.Printf("DEBUG: MusicBrainz release %s: %v\n", album.mbid, err)
+		}
+	}
+	if album.totalTracks > 0 {
+		album.Expected, album.Source = album.totalTracks, "tags"
+	}
+}
+
+// FindGapTracks looks up the DAB album of a folder with gaps and sets Missing to the DAB
+// tracks at the missing positions, so only those are fetched. Offline nothing is looked up.
+func (api *DabAPI) FindGapTracks(ctx context.Context, album *LibraryAlbum, offline, debug bool) {
+	album.Missing = nil
+	if offline || len(album.Gaps) == 0 {
+		return
+	}
+	album.DabAlbum = api.findLibraryAlbum(ctx, album, debug)
+	if album.DabAlbum == nil {
+		return
+	}
+	gaps := make(map[TrackPosition]bool, len(album.Gaps))
+	for _, gap := range album.Gaps {
+		gaps[gap] = true
+	}
+	for idx, track := range album.DabAlbum.Tracks {
+		if track.TrackNumber == 0 {
+			track.TrackNumber = idx + 1
+		}
+		if gaps[TrackPosition{Disc: max(track.DiscNumber, 1), Track: track.TrackNumber}] {
+			album.Missing = append(album.Missing, track)
+		}
+	}
+}
+
+// PrintLibraryGaps lists the missing track numbers of an album folder, with their titles
+// when the album was found on DAB
+func PrintLibraryGaps(album *LibraryAlbum) {
+	name := album.Dir
+	if album.Title != "" {
+		name = fmt.Sprintf("%s - %s (%s)", album.Artist, album.Title, album.Dir)
+	}
+	if len(album.Gaps) == 0 {
+		colorSuccess.Printf("✅ %s: no gaps in %d tracks\n", name, album.Files)
+		return
+	}
+	colorWarning.Printf("⚠️ %s: %d missing track numbers\n", name, len(album.Gaps))
+	titles := make(map[TrackPosition]string, len(album.Missing))
+	for _, track := range album.Missing {
+		titles[TrackPosition{Disc: max(track.DiscNumber, 1), Track: track.TrackNumber}] = track.Title
+	}
+	for _, gap := range album.Gaps {
+		if title, ok := titles[gap]; ok {
+			fmt.Printf("   missing: %s - %s (on DAB)\n", gap, title)
+		} else {
+			fmt.Printf("   missing: %s\n", gap)
+		}
+	}
+}
+
+// findLibraryAlbum returns the DAB album of a folder, nil if it can't be identified
+func (api *DabAPI) findLibraryAlbum(ctx context.Context, album *LibraryAlbum, debug bool) *Album {
+	if album.albumID != "" {
+		if found, err := api.GetAlbum(ctx, album.albumID); err == nil && len(found.Tracks) > 0 {
+			return found
+		} else if debug && err != nil {
+			fmt.Printf("DEBUG: Failed to fetch album %s: %v\n", album.albumID, err)
+		}
+	}
+	if album.upc != "" {
+		if found, err := api.FindAlbumByUPC(ctx, album.upc, debug); err == nil {
+			// Search results only carry a summary of the album
+			if full, err := api.GetAlbum(ctx, found.ID); err == nil && len(full.Tracks) > 0 {
+				return full
+			}
+		}
+	}
+	if album.Title == "" || album.Artist == "" {
+		return nil
+	}
+	results, err := api.Search(ctx, album.Title+" "+album.Artist, "album", identifierSearchLimit, debug)
+	if err != nil {
+		if debug {
+			fmt.Printf("DEBUG: Search for %s failed: %v\n", album.Title, err)
+		}
+		return nil
+	}
+	for _, candidate := range results.Albums {
+		// Editions differ in track count, so only the exact title counts
+		if !sameName(candidate.Title, album.Title) || !sameName(candidate.Artist, album.Artist) {
+			continue
+		}
+		if full, err := api.GetAlbum(ctx, candidate.ID); err == nil && len(full.Tracks) > 0 {
+			return full
+		}
+	}
+	return nil
+}
+
+// FetchMissingTracks downloads the missing tracks of a DAB album into its existing folder
+// and returns the number downloaded
+func (api *DabAPI) FetchMissingTracks(ctx context.Context, album *LibraryAlbum, config *Config, debug bool) (int, error) {
+	dab := album.DabAlbum
+	if dab == nil || len(album.Missing) == 0 {
+		return 0, nil
+	}
+	var coverData []byte
+	if dab.Cover != "" {
+		coverData, _ = api.DownloadCover(ctx, dab.Cover)
+	}
+	_, coverData = api.prepareCover(ctx, dab, coverData, config, debug)
+	warningCollector := NewWarningCollector(config.WarningBehavior != "silent")
+
+	var newFiles []string
+	var lastErr error
+	for _, track := range album.Missing {
+		trackPath := filepath.Join(album.Dir, trackFile(config, dab, track, dab.Artist))
+		if FileExists(trackPath) {
+			continue
+		}
+		finalPath, transfer, err := api.DownloadTrack(ctx, track, dab, trackPath, coverData, nil, debug, config.Format, config.Bitrate, config, warningCollector)
+		if err != nil {
+			colorError.Printf("❌ %s: %v\n", track.Title, err)
+			lastErr = err
+			continue
+		}
+		recordDownload(track, dab, finalPath, config.Format, transfer)
+		newFiles = append(newFiles, finalPath)
+	}
+	if config.WarningBehavior == "summary" {
+		warningCollector.PrintSummary()
+	}
+	if len(newFiles) > 0 {
+		if config.ReplayGain {
+			applyReplayGain(newFiles, false, debug)
+			updateHistoryChecksums(newFiles)
+		}
+		recordTracklist(dab)
+		if !config.NoChecksumManifest {
+			if err := UpdateChecksumManifest(album.Dir, newFiles); err != nil {
+				colorWarning.Printf("⚠️ Failed to write %s: %v\n", checksumManifestName, err)
+			}
+		}
+		uploadToTargets(ctx, config, api.outputLocation, newFiles)
+	}
+	return len(newFiles), lastErr
+}
+
+// PrintLibraryAlbum lists an album folder with its track count and missing tracks
+func PrintLibraryAlbum(album *LibraryAlbum) {
+	name := album.Dir
+	if album.Title != "" {
+		name = fmt.Sprintf("%s - %s (%s)", album.Artist, album.Title, album.Dir)
+	}
+	if album.Complete() {
+		if album.Expected > 0 {
+			colorSuccess.Printf("✅ %s: %d/%d tracks\n", name, album.Files, album.Expected)
+		} else {
+			colorInfo.Printf("❔ %s: %d tracks, expected count unknown\n", name, album.Files)
+		}
+		return
+	}
+	colorWarning.Printf("⚠️ %s: %d/%d tracks (%s)\n", name, album.Files, album.Expected, album.Source)
+	for _, track := range album.Missing {
+		fmt.Printf("   missing: %d-%02d - %s\n", max(track.DiscNumber, 1), track.TrackNumber, track.Title)
+	}
+}
@@ -28,8 +28,9 @@ type Warning struct {
 
 // WarningCollector collects warnings during download operations
 type WarningCollector struct {
-	warnings []Warning
-	enabled  bool
+	warnings      []Warning
+	enabled       bool
+	correlationID string // Job the warnings belong to, shown in the summary
 }
 
 // NewWarningCollector creates a new warning collector
@@ -135,7 +136,11 @@ func (wc *WarningCollector) PrintSummary() {
 		return
 	}
 
-	colorWarning.Printf("\n⚠️  Warning Summary (%d warnings):\n", len(wc.warnings))
+	header := T("warnings.header", len(wc.warnings))
+	if wc.correlationID != "" {
+		header += " [" + wc.correlationID + "]"
+	}
+	colorWarning.Println("\n" + header)
 	colorWarning.Println(strings.Repeat("─", 50))
 
 	grouped := wc.GetWarningsByType()
@@ -191,18 +196,18 @@ func (wc *WarningCollector) printWarningTypeSection(warningType WarningType, war
 func (wc *WarningCollector) getWarningTypeTitle(warningType WarningType) string {
 	switch warningType {
 	case MusicBrainzTrackWarning:
-		return "MusicBrainz Track Lookup Failures"
+		return T("warnings.mb_track")
 	case MusicBrainzReleaseWarning:
-		return "MusicBrainz Release Lookup Failures"
+		return T("warnings.mb_release")
 	case CoverArtDownloadWarning:
-		return "Cover Art Download Failures"
+		return T("warnings.cover_dl")
 	case CoverArtMetadataWarning:
-		return "Cover Art Metadata Failures"
+		return T("warnings.cover_meta")
 	case AlbumFetchWarning:
-		return "Album Information Fetch Failures"
+		return T("warnings.album_fetch")
 	case TrackSkippedWarning:
-		return "Tracks Skipped (Already Exist)"
+		return T("warnings.skipped")
 	default:
-		return "Other Warnings"
+		return T("warnings.other")
 	}
 }
\ No newline at end of file
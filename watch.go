@@ -0,0 +1,289 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultWatchInterval = 6 * time.Hour
+
+// WatchedArtist is an artist whose new releases are downloaded automatically
+type WatchedArtist struct {
+	ID            string    `json:"id"`
+	Name          string    `json:"name"`
+	Filter        string    `json:"filter"`                   // Item types to download, like --filter of the artist command
+	Known         []string  `json:"known,omitempty"`          // Album IDs already seen, never downloaded again by watch
+	ReleasedAfter string    `json:"released_after,omitempty"` // Releases before this date (YYYY-MM-DD) are ignored
+	MaxAgeDays    int       `json:"max_age_days,omitempty"`   // Releases older than this are ignored, 0 uses WatchMaxAgeDays
+	Exclude       []string  `json:"exclude,omitempty"`        // Title words of releases to ignore, e.g. "live", "remix", "demo"
+	AddedAt       time.Time `json:"added_at"`
+	LastChecked   time.Time `json:"last_checked,omitempty"`
+}
+
+// Watchlist persists the watched artists to a JSON file next to the config
+type Watchlist struct {
+	path string
+	mu   sync.Mutex
+}
+
+// watchlist is the watchlist used by the watch commands
+var watchlist = &Watchlist{path: filepath.Join("config", "watchlist.json")}
+
+// List returns the watched artists
+func (w *Watchlist) List() ([]*WatchedArtist, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.load()
+}
+
+// Add starts watching an artist, replacing an existing entry with the same ID
+func (w *Watchlist) Add(artist *WatchedArtist) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	artists, err := w.load()
+	if err != nil {
+		return err
+	}
+	for i, existing := range artists {
+		if existing.ID == artist.ID {
+			artists[i] = artist
+			return w.save(artists)
+		}
+	}
+	return w.save(append(artists, artist))
+}
+
+// Remove stops watching an artist
+func (w *Watchlist) Remove(artistID string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	artists, err := w.load()
+	if err != nil {
+		return err
+	}
+	for i, existing := range artists {
+		if existing.ID == artistID {
+			return w.save(append(artists[:i], artists[i+1:]...))
+		}
+	}
+	return fmt.Errorf("artist %s is not on the watchlist", artistID)
+}
+
+// Update saves the state of a watched artist
+func (w *Watchlist) Update(artist *WatchedArtist) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	artists, err := w.load()
+	if err != nil {
+		return err
+	}
+	for i, existing := range artists {
+		if existing.ID == artist.ID {
+			artists[i] = artist
+			return w.save(artists)
+		}
+	}
+	// Removed while it was being checked
+	return nil
+}
+
+func (w *Watchlist) load() ([]*WatchedArtist, error) {
+	data, err := os.ReadFile(w.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read watchlist: %w", err)
+	}
+	var artists []*WatchedArtist
+	if err := json.Unmarshal(data, &artists); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", w.path, err)
+	}
+	return artists, nil
+}
+
+func (w *Watchlist) save(artists []*WatchedArtist) error {
+	if err := os.MkdirAll(filepath.Dir(w.path), 0755); err != nil {
+		return fmt.Errorf("failed to create watchlist directory: %w", err)
+	}
+	data, err := json.MarshalIndent(artists, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(w.path, data, 0644)
+}
+
+// WatchArtist adds an artist to the watchlist with the filters of watched. Unless backfill
+// is set, the current discography is marked as known so only releases that appear later
+// are downloaded.
+func (api *DabAPI) WatchArtist(ctx context.Context, artistID string, watched *WatchedArtist, backfill bool, config *Config, debug bool) (*WatchedArtist, error) {
+	artist, err := api.GetArtist(ctx, artistID, config, debug)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get artist info: %w", err)
+	}
+	watched.ID, watched.Name, watched.AddedAt = artistID, artist.Name, time.Now()
+	if !backfill {
+		albums, eps, singles, _ := api.categorizeAlbums(artist.Albums)
+		for _, album := range append(append(albums, eps...), singles...) {
+			watched.Known = append(watched.Known, album.ID)
+		}
+	}
+	return watched, watchlist.Add(watched)
+}
+
+// CheckWatchedArtist downloads the releases of an artist that are neither known nor in
+// the download history. It returns the number of albums downloaded.
+func (api *DabAPI) CheckWatchedArtist(ctx context.Context, watched *WatchedArtist, config *Config, debug bool) (int, error) {
+	artist, err := api.GetArtist(ctx, watched.ID, config, debug)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get artist info: %w", err)
+	}
+	known := make(map[string]bool, len(watched.Known))
+	for _, id := range watched.Known {
+		known[id] = true
+	}
+
+	albums, eps, singles, _ := api.categorizeAlbums(artist.Albums)
+	filter := watched.Filter
+	if filter == "" || filter == "all" {
+		filter = "albums,eps,singles"
+	}
+
+	downloaded := 0
+	var firstErr error
+	ignored := 0
+	for _, album := range filterArtistItems(albums, eps, singles, filter) {
+		if known[album.ID] || isExcludedTitle(album.Title) {
+			continue
+		}
+		// Not marked as known, so changing the filters takes effect on the next check
+		if !watched.wanted(album, config) {
+			ignored++
+			continue
+		}
+		if !ignoreHistory && downloadHistory.HasAlbum(album.ID) {
+			watched.Known = append(watched.Known, album.ID)
+			continue
+		}
+
+		colorInfo.Printf("🆕 New release by %s: %s\n", artist.Name, album.Title)
+		stats, err := api.DownloadAlbum(ctx, album.ID, config, debug, nil, nil)
+		if err == nil && stats.FailedCount > 0 {
+			err = fmt.Errorf("%d tracks failed", stats.FailedCount)
+		}
+		if err != nil {
+			// Not marked as known, so the next check tries again
+			colorError.Println(T("album.failed_name", album.Title, err))
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		colorSuccess.Println(T("album.completed_name", album.Title))
+		watched.Known = append(watched.Known, album.ID)
+		downloaded++
+	}
+
+	if debug && ignored > 0 {
+		fmt.Printf("DEBUG: Ignored %d releases of %s by release date or title\n", ignored, artist.Name)
+	}
+
+	watched.Name = artist.Name
+	watched.LastChecked = time.Now()
+	if err := watchlist.Update(watched); err != nil {
+		return downloaded, err
+	}
+	return downloaded, firstErr
+}
+
+// wanted reports whether a release passes the release date and title filters of the
+// watched artist and the watch defaults of the config. Releases without a date are kept,
+// as new releases sometimes get theirs later.
+func (w *WatchedArtist) wanted(album Album, config *Config) bool {
+	if containsWord(album.Title, w.Exclude) || containsWord(album.Title, config.WatchExclude) {
+		return false
+	}
+	date, ok := parseReleaseDate(album.ReleaseDate)
+	return !ok || !date.Before(w.releasedAfter(config))
+}
+
+// releasedAfter returns the earliest release date downloaded for the artist, zero when
+// any date is
+func (w *WatchedArtist) releasedAfter(config *Config) time.Time {
+	var after time.Time
+	if w.ReleasedAfter != "" {
+		after, _ = time.Parse("2006-01-02", w.ReleasedAfter) // Checked by watch add
+	}
+	maxAge := w.MaxAgeDays
+	if maxAge == 0 {
+		maxAge = config.WatchMaxAgeDays
+	}
+	if maxAge > 0 {
+		if cutoff := time.Now().AddDate(0, 0, -maxAge); cutoff.After(after) {
+			after = cutoff
+		}
+	}
+	return after
+}
+
+// describeFilters lists the release filters of a watched artist for 'watch list'
+func (w *WatchedArtist) describeFilters() string {
+	var filters []string
+	if w.ReleasedAfter != "" {
+		filters = append(filters, "released on or after "+w.ReleasedAfter)
+	}
+	if w.MaxAgeDays > 0 {
+		filters = append(filters, fmt.Sprintf("at most %d days old", w.MaxAgeDays))
+	}
+	if len(w.Exclude) > 0 {
+		filters = append(filters, "without "+strings.Join(w.Exclude, ", ")+" in the title")
+	}
+	return strings.Join(filters, "; ")
+}
+
+// RunWatch checks every watched artist, then repeats after interval. With once set it
+// returns after the first pass, for running from cron or a systemd timer.
+func (api *DabAPI) RunWatch(ctx context.Context, interval time.Duration, once bool, config *Config, debug bool) error {
+	if interval <= 0 {
+		interval = defaultWatchInterval
+	}
+	for {
+		artists, err := watchlist.List()
+		if err != nil {
+			return err
+		}
+		if len(artists) == 0 {
+			return fmt.Errorf("the watchlist is empty, add artists with 'watch add <artist_id>'")
+		}
+
+		colorInfo.Printf("👀 Checking %d watched artists for new releases...\n", len(artists))
+		total := 0
+		for _, watched := range artists {
+			count, err := api.CheckWatchedArtist(ctx, watched, config, debug)
+			if err != nil {
+				colorError.Printf("❌ %s: %v\n", watched.Name, err)
+			}
+			total += count
+		}
+		colorSuccess.Printf("✅ Watch check finished, %d new releases downloaded.\n", total)
+
+		if once {
+			return nil
+		}
+		colorInfo.Printf("🕒 Next check at %s\n", FormatDate(time.Now().Add(interval)))
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}